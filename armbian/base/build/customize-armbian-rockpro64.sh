@@ -376,6 +376,10 @@ printtoconsole=1
 # rpc
 rpcconnect=127.0.0.1
 
+# zmq notifications, used by the middleware
+zmqpubhashblock=tcp://127.0.0.1:28332
+zmqpubrawtx=tcp://127.0.0.1:28333
+
 # performance
 dbcache=2000
 maxmempool=50
@@ -404,6 +408,8 @@ Type=simple
 Restart=always
 RestartSec=60
 TimeoutSec=300
+# flushing the chainstate on stop can take long, a killed bitcoind has to reindex
+TimeoutStopSec=30min
 PrivateTmp=true
 ProtectSystem=full
 NoNewPrivileges=true
@@ -419,6 +425,8 @@ BIN_DEPS_TAG="v0.0.1-alpha"
 LIGHTNING_VERSION="0.7.0"
 
 apt install -y libsodium-dev
+# sqlite3 takes consistent copies of the lightningd database for the backups of the middleware
+apt install -y --no-install-recommends sqlite3
 
 ## either compile c-lightning from source (default), or use prebuilt binary
 if [ "${BASE_BUILD_LIGHTNINGD}" == "true" ]; then
@@ -571,9 +579,15 @@ if [ -f /tmp/overlay/bin/base-middleware ]; then
 
   mkdir -p /etc/base-middleware/
   cat << EOF > /etc/base-middleware/base-middleware.conf
-BITCOIN_RPCUSER=__cookie__
+BITCOIN_NETWORK=testnet
+BITCOIN_RPCCOOKIE=/mnt/ssd/bitcoin/.bitcoin/.cookie
 BITCOIN_RPCPORT=18332
 LIGHTNING_RPCPATH=/mnt/ssd/bitcoin/.lightning-testnet/lightning-rpc
+BITCOIN_ZMQ_HASHBLOCK=tcp://127.0.0.1:28332
+BITCOIN_ZMQ_RAWTX=tcp://127.0.0.1:28333
+MIDDLEWARE_DATADIR=/mnt/ssd/system/middleware
+MIDDLEWARE_LISTEN=:8845
+MIDDLEWARE_METRICS_LISTEN=127.0.0.1:8847
 EOF
 
   cat << 'EOF' > /etc/systemd/system/base-middleware.service
@@ -583,10 +597,10 @@ Wants=bitcoind.service lightningd.service electrs.service
 After=lightningd.service
 
 [Service]
-Type=simple
-EnvironmentFile=/etc/base-middleware/base-middleware.conf
-EnvironmentFile=/mnt/ssd/bitcoin/.bitcoin/.cookie.env
-ExecStart=/usr/local/sbin/base-middleware -rpcuser=${BITCOIN_RPCUSER} -rpcpassword=${RPCPASSWORD} -rpcport=${BITCOIN_RPCPORT} -lightning-rpc-path=${LIGHTNING_RPCPATH} -datadir=/mnt/ssd/system/middleware
+Type=notify
+ExecStart=/usr/local/sbin/base-middleware -config=/etc/base-middleware/base-middleware.conf
+ExecReload=/bin/kill -HUP $MAINPID
+WatchdogSec=5min
 Restart=always
 RestartSec=10
 
@@ -638,6 +652,10 @@ scrape_configs:
   - job_name: lightningd
     static_configs:
     - targets: ['127.0.0.1:9900']    
+  - job_name: middleware
+    scrape_interval: 1m
+    static_configs:
+    - targets: ['127.0.0.1:8847']
 EOF
 
 cat << 'EOF' > /etc/systemd/system/prometheus.service
@@ -19,7 +19,7 @@ possible commands:
   disable   any 'enable' argument
 
   set       <bitcoin_network|hostname|root_pw|wifi_ssid|wifi_pw>
-            bitcoin_network     <mainnet|testnet>
+            bitcoin_network     <mainnet|testnet|regtest|signet>
             other arguments     string
 
   get       any 'enable' or 'set' argument, or
@@ -148,7 +148,7 @@ case "${COMMAND}" in
                         sed -i '/CONFIGURED FOR/Ic\echo "Configured for Bitcoin MAINNET"; echo' /etc/update-motd.d/20-shift
                         sed -i "/ALIAS LCLI=/Ic\alias lcli='lightning-cli --lightning-dir=/mnt/ssd/bitcoin/.lightning'" /home/base/.bashrc-custom
                         sed -i '/HIDDENSERVICEPORT 18333/Ic\HiddenServicePort 8333 127.0.0.1:8333' /etc/tor/torrc
-                        sed -i '/TESTNET=/Ic\#testnet=1' /etc/bitcoin/bitcoin.conf
+                        sed -i -E '/^#?(testnet|regtest|signet)=/c\#testnet=1' /etc/bitcoin/bitcoin.conf
                         sed -i '/NETWORK=/Ic\network=bitcoin' /etc/lightningd/lightningd.conf
                         sed -i '/BITCOIN-RPCPORT=/Ic\bitcoin-rpcport=8332' /etc/lightningd/lightningd.conf
                         sed -i '/LIGHTNING-DIR=/Ic\lightning-dir=/mnt/ssd/bitcoin/.lightning' /etc/lightningd/lightningd.conf
@@ -156,6 +156,7 @@ case "${COMMAND}" in
                         sed -i '/RPCPORT=/Ic\RPCPORT=8332' /etc/electrs/electrs.conf
                         sed -i '/BITCOIN_RPCPORT=/Ic\BITCOIN_RPCPORT=8332' /etc/base-middleware/base-middleware.conf || true
                         sed -i '/LIGHTNING_RPCPATH=/Ic\LIGHTNING_RPCPATH=/mnt/ssd/bitcoin/.lightning/lightning-rpc' /etc/base-middleware/base-middleware.conf || true
+                        sed -i '/BITCOIN_NETWORK=/Ic\BITCOIN_NETWORK=mainnet' /etc/base-middleware/base-middleware.conf || true
                         echo "BITCOIN_NETWORK=mainnet" > "${SYSCONFIG_PATH}/${SETTING}"
                         ;;
 
@@ -163,7 +164,7 @@ case "${COMMAND}" in
                         sed -i '/CONFIGURED FOR/Ic\echo "Configured for Bitcoin TESTNET"; echo' /etc/update-motd.d/20-shift
                         sed -i "/ALIAS LCLI=/Ic\alias lcli='lightning-cli --lightning-dir=/mnt/ssd/bitcoin/.lightning-testnet'" /home/base/.bashrc-custom
                         sed -i '/HIDDENSERVICEPORT 8333/Ic\HiddenServicePort 18333 127.0.0.1:18333' /etc/tor/torrc
-                        sed -i '/TESTNET=/Ic\testnet=1' /etc/bitcoin/bitcoin.conf
+                        sed -i -E '/^#?(testnet|regtest|signet)=/c\testnet=1' /etc/bitcoin/bitcoin.conf
                         sed -i '/NETWORK=/Ic\network=testnet' /etc/lightningd/lightningd.conf
                         sed -i '/LIGHTNING-DIR=/Ic\lightning-dir=/mnt/ssd/bitcoin/.lightning-testnet' /etc/lightningd/lightningd.conf
                         sed -i '/BITCOIN-RPCPORT=/Ic\bitcoin-rpcport=18332' /etc/lightningd/lightningd.conf
@@ -171,11 +172,44 @@ case "${COMMAND}" in
                         sed -i '/RPCPORT=/Ic\RPCPORT=18332' /etc/electrs/electrs.conf
                         sed -i '/BITCOIN_RPCPORT=/Ic\BITCOIN_RPCPORT=18332' /etc/base-middleware/base-middleware.conf || true
                         sed -i '/LIGHTNING_RPCPATH=/Ic\LIGHTNING_RPCPATH=/mnt/ssd/bitcoin/.lightning-testnet/lightning-rpc' /etc/base-middleware/base-middleware.conf || true
+                        sed -i '/BITCOIN_NETWORK=/Ic\BITCOIN_NETWORK=testnet' /etc/base-middleware/base-middleware.conf || true
                         echo "BITCOIN_NETWORK=testnet" > "${SYSCONFIG_PATH}/${SETTING}"
                         ;;
 
+                    regtest)
+                        # development networks, the Tor hidden service of the bitcoin p2p port is left as is
+                        sed -i '/CONFIGURED FOR/Ic\echo "Configured for Bitcoin REGTEST"; echo' /etc/update-motd.d/20-shift
+                        sed -i "/ALIAS LCLI=/Ic\alias lcli='lightning-cli --lightning-dir=/mnt/ssd/bitcoin/.lightning-regtest'" /home/base/.bashrc-custom
+                        sed -i -E '/^#?(testnet|regtest|signet)=/c\regtest=1' /etc/bitcoin/bitcoin.conf
+                        sed -i '/NETWORK=/Ic\network=regtest' /etc/lightningd/lightningd.conf
+                        sed -i '/LIGHTNING-DIR=/Ic\lightning-dir=/mnt/ssd/bitcoin/.lightning-regtest' /etc/lightningd/lightningd.conf
+                        sed -i '/BITCOIN-RPCPORT=/Ic\bitcoin-rpcport=18443' /etc/lightningd/lightningd.conf
+                        sed -i '/NETWORK=/Ic\NETWORK=regtest' /etc/electrs/electrs.conf
+                        sed -i '/RPCPORT=/Ic\RPCPORT=18443' /etc/electrs/electrs.conf
+                        sed -i '/BITCOIN_RPCPORT=/Ic\BITCOIN_RPCPORT=18443' /etc/base-middleware/base-middleware.conf || true
+                        sed -i '/LIGHTNING_RPCPATH=/Ic\LIGHTNING_RPCPATH=/mnt/ssd/bitcoin/.lightning-regtest/lightning-rpc' /etc/base-middleware/base-middleware.conf || true
+                        sed -i '/BITCOIN_NETWORK=/Ic\BITCOIN_NETWORK=regtest' /etc/base-middleware/base-middleware.conf || true
+                        echo "BITCOIN_NETWORK=regtest" > "${SYSCONFIG_PATH}/${SETTING}"
+                        ;;
+
+                    signet)
+                        # development networks, the Tor hidden service of the bitcoin p2p port is left as is
+                        sed -i '/CONFIGURED FOR/Ic\echo "Configured for Bitcoin SIGNET"; echo' /etc/update-motd.d/20-shift
+                        sed -i "/ALIAS LCLI=/Ic\alias lcli='lightning-cli --lightning-dir=/mnt/ssd/bitcoin/.lightning-signet'" /home/base/.bashrc-custom
+                        sed -i -E '/^#?(testnet|regtest|signet)=/c\signet=1' /etc/bitcoin/bitcoin.conf
+                        sed -i '/NETWORK=/Ic\network=signet' /etc/lightningd/lightningd.conf
+                        sed -i '/LIGHTNING-DIR=/Ic\lightning-dir=/mnt/ssd/bitcoin/.lightning-signet' /etc/lightningd/lightningd.conf
+                        sed -i '/BITCOIN-RPCPORT=/Ic\bitcoin-rpcport=38332' /etc/lightningd/lightningd.conf
+                        sed -i '/NETWORK=/Ic\NETWORK=signet' /etc/electrs/electrs.conf
+                        sed -i '/RPCPORT=/Ic\RPCPORT=38332' /etc/electrs/electrs.conf
+                        sed -i '/BITCOIN_RPCPORT=/Ic\BITCOIN_RPCPORT=38332' /etc/base-middleware/base-middleware.conf || true
+                        sed -i '/LIGHTNING_RPCPATH=/Ic\LIGHTNING_RPCPATH=/mnt/ssd/bitcoin/.lightning-signet/lightning-rpc' /etc/base-middleware/base-middleware.conf || true
+                        sed -i '/BITCOIN_NETWORK=/Ic\BITCOIN_NETWORK=signet' /etc/base-middleware/base-middleware.conf || true
+                        echo "BITCOIN_NETWORK=signet" > "${SYSCONFIG_PATH}/${SETTING}"
+                        ;;
+
                     *)
-                        echo "Invalid argument: ${SETTING} can only be set to 'mainnet' or 'testnet'."
+                        echo "Invalid argument: ${SETTING} can only be set to 'mainnet', 'testnet', 'regtest' or 'signet'."
                         exit 1
                 esac
                 echo "System configuration ${SETTING} will be enabled on next boot."
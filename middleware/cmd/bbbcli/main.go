@@ -0,0 +1,770 @@
+// Package main provides bbbcli, a command line client for the BitBox Base middleware api.
+// It speaks the same noise encrypted protobuf protocol as the bitbox-wallet-app, so that a Base can be scripted without
+// the app and without root ssh access.
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/digitalbitbox/bitbox-base/middleware/src/client"
+	basemessages "github.com/digitalbitbox/bitbox-base/middleware/src/messages"
+)
+
+const usage = `Usage: bbbcli [flags] <command> [<args>]
+
+Commands:
+  pair                       pair with the BitBox Base, comparing the pairing code shown on the Base
+  status                     show the last known state of the Base services
+  sysenv                     show the system environment
+  services                   show the state of the Base services
+  health                     show the health of the middleware backends with their last error
+  config get <key>           read a setting, see bbb-config.sh
+  config set <key> <value>   change a setting, toggles take true or false
+  logs [-f] [-n lines] [-p priority] [-since duration] <unit>
+                             show the logs of a service, tor or bbbsupervisor, -f keeps following new lines
+  update <file>              upload an update file to the Base
+  support-bundle [-o file]   download a tar.gz archive with redacted diagnostics of the Base, to attach to a
+                             support request, by default named as suggested by the Base
+  lightning-backup [-drive] [-o file]
+                             download an encrypted backup of the lightning node, or write it to the backup
+                             drive of the Base with -drive (owners only)
+  lightning-restore [-drive] <file>
+                             restore the lightning node from a backup file, or from a backup on the backup
+                             drive of the Base with -drive, refused if the node has channels (owners only)
+  config-backup [-drive] [-o file]
+                             download a signed, encrypted backup of the configuration, the pairings and the
+                             onion addresses of the Base, or write it to its backup drive with -drive
+                             (owners only)
+  config-restore [-drive] -signer <pubkey> <file>
+                             restore the configuration of the Base from a backup file, or from a backup on its
+                             backup drive with -drive, if it was signed by the signer printed by config-backup,
+                             the Base then closes all connections (owners only)
+  factory-reset [-blockchain] [-lightning] [-yes]
+                             wipe the pairings, onion addresses, settings and wifi credentials of the Base, and
+                             with -blockchain and -lightning its blockchain and lightning node, then reboot it,
+                             after showing the warnings and asking for confirmation unless -yes is given
+                             (owners only)
+  reboot [-at time|-in duration|-cancel|-scheduled]
+                             stop the services in order and reboot the Base, or schedule a maintenance reboot
+                             at an RFC 3339 time or after a duration, cancel or show it (owners only)
+  shutdown                   stop the services in order and shut the Base down (owners only)
+  clients                    list the paired clients and their roles (owners only)
+  role <pubkey> <role>       change the role of a paired client, given its hex pubkey, to owner, operator or
+                             read-only (owners only)
+  loglevel [<level>]         show or change the log level of the middleware: debug, info, warning or error
+                             (owners only)
+  audit [-offset n] [-n entries]
+                             show the audit log of administrative actions, the last entries by default
+                             (owners only)
+
+The passphrase of backups is read from the BBB_BACKUP_PASSPHRASE environment variable, or asked for.
+
+Flags:
+`
+
+// defaultConfigDir returns the directory where bbbcli stores its noise keypair and the pinned key of the Base.
+func defaultConfigDir() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "bbbcli")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".bbbcli"
+	}
+	return filepath.Join(home, ".config", "bbbcli")
+}
+
+func main() {
+	address := flag.String("address", "ws://127.0.0.1:8845/ws", "Address of the middleware: ws://host:port/ws, wss://host:port/ws, tcp://host:port or unix:///path")
+	configDir := flag.String("config-dir", defaultConfigDir(), "Directory where the client keypair and the pinned key of the Base are stored")
+	jsonOutput := flag.Bool("json", false, "Print the results as JSON")
+	timeout := flag.Duration("timeout", 30*time.Second, "Timeout for connecting and for single requests")
+	tlsCA := flag.String("tls-ca", "", "Path of a PEM certificate to trust for wss:// addresses, e.g. the self-signed certificate of the Base")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var tlsConfig *tls.Config
+	if *tlsCA != "" {
+		certificate, err := ioutil.ReadFile(*tlsCA)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: "+err.Error())
+			os.Exit(1)
+		}
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(certificate) {
+			fmt.Fprintln(os.Stderr, "Error: no PEM certificate found in "+*tlsCA)
+			os.Exit(1)
+		}
+		tlsConfig = &tls.Config{RootCAs: roots}
+	}
+
+	cli := &cli{
+		config: client.Config{
+			Address:   *address,
+			DataDir:   *configDir,
+			TLSConfig: tlsConfig,
+		},
+		jsonOutput: *jsonOutput,
+		timeout:    *timeout,
+	}
+	if err := cli.run(flag.Arg(0), flag.Args()[1:]); err != nil {
+		if err == client.ErrPairingRequired {
+			err = errors.New("not paired with this BitBox Base, run bbbcli pair first")
+		}
+		fmt.Fprintln(os.Stderr, "Error: "+err.Error())
+		os.Exit(1)
+	}
+}
+
+type cli struct {
+	config     client.Config
+	jsonOutput bool
+	timeout    time.Duration
+}
+
+// run connects to the Base and executes a single command.
+func (cli *cli) run(command string, args []string) error {
+	// Interrupting bbbcli, e.g. while following logs, cancels the command.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		cancel()
+	}()
+
+	switch command {
+	case "pair":
+		return cli.pair(ctx)
+	case "status", "sysenv", "services", "health", "config", "logs", "update", "clients", "role", "audit", "loglevel",
+		"support-bundle", "lightning-backup", "lightning-restore",
+		"config-backup", "config-restore", "factory-reset", "reboot", "shutdown":
+	default:
+		return errors.New("unknown command " + command)
+	}
+
+	dialCtx, dialCancel := context.WithTimeout(ctx, cli.timeout)
+	baseClient, err := client.Dial(dialCtx, cli.config)
+	dialCancel()
+	if err != nil {
+		return err
+	}
+	defer baseClient.Close()
+
+	switch command {
+	case "status":
+		requestCtx, requestCancel := context.WithTimeout(ctx, cli.timeout)
+		defer requestCancel()
+		state, version, err := baseClient.State(requestCtx)
+		if err != nil {
+			return err
+		}
+		if state.GetBitcoind().GetUnreachable() {
+			return cli.print(map[string]interface{}{"version": version, "state": state}, fmt.Sprintf(
+				"state version:       %d\nbitcoind:            unreachable\nelectrs blocks:      %d\nlightning alias:     %s\nnetwork:             %s",
+				version,
+				state.GetElectrs().GetBlocks(),
+				state.GetLightning().GetAlias(),
+				state.GetSystem().GetNetwork()))
+		}
+		return cli.print(map[string]interface{}{"version": version, "state": state}, fmt.Sprintf(
+			"state version:       %d\nbitcoind blocks:     %d\nbitcoind difficulty: %g\nelectrs blocks:      %d\nlightning alias:     %s\nnetwork:             %s",
+			version,
+			state.GetBitcoind().GetBlocks(),
+			state.GetBitcoind().GetDifficulty(),
+			state.GetElectrs().GetBlocks(),
+			state.GetLightning().GetAlias(),
+			state.GetSystem().GetNetwork()))
+	case "sysenv":
+		requestCtx, requestCancel := context.WithTimeout(ctx, cli.timeout)
+		defer requestCancel()
+		env, err := baseClient.SystemEnv(requestCtx)
+		if err != nil {
+			return err
+		}
+		return cli.print(env, fmt.Sprintf("network:          %s\nelectrs rpc port: %s", env.Network, env.ElectrsRPCPort))
+	case "services":
+		requestCtx, requestCancel := context.WithTimeout(ctx, cli.timeout)
+		defer requestCancel()
+		services, err := baseClient.Services(requestCtx)
+		if err != nil {
+			return err
+		}
+		lines := make([]string, len(services))
+		for i, service := range services {
+			lines[i] = fmt.Sprintf("%-26s %s", service.Name+":", service.ActiveState)
+		}
+		return cli.print(services, strings.Join(lines, "\n"))
+	case "health":
+		requestCtx, requestCancel := context.WithTimeout(ctx, cli.timeout)
+		defer requestCancel()
+		health, err := baseClient.Health(requestCtx)
+		if err != nil {
+			return err
+		}
+		lines := []string{"status:     " + health.Status}
+		for _, backend := range health.Backends {
+			line := fmt.Sprintf("%-11s %-9s", backend.Name+":", backend.Status)
+			if backend.LastSuccess != 0 {
+				line += " last success " + time.Unix(backend.LastSuccess, 0).Format(time.RFC3339)
+			}
+			if backend.Status != "ok" && backend.LastError != "" {
+				line += " last error " + time.Unix(backend.LastErrorTime, 0).Format(time.RFC3339) + ": " + backend.LastError
+			}
+			lines = append(lines, line)
+		}
+		return cli.print(health, strings.Join(lines, "\n"))
+	case "config":
+		return cli.configCommand(ctx, baseClient, args)
+	case "logs":
+		return cli.logs(ctx, baseClient, args)
+	case "clients", "role":
+		return cli.clients(ctx, baseClient, command, args)
+	case "audit":
+		return cli.audit(ctx, baseClient, args)
+	case "support-bundle":
+		return cli.supportBundle(ctx, baseClient, args)
+	case "lightning-backup":
+		return cli.lightningBackup(ctx, baseClient, args)
+	case "lightning-restore":
+		return cli.lightningRestore(ctx, baseClient, args)
+	case "config-backup":
+		return cli.configBackup(ctx, baseClient, args)
+	case "config-restore":
+		return cli.configRestore(ctx, baseClient, args)
+	case "factory-reset":
+		return cli.factoryReset(ctx, baseClient, args)
+	case "reboot", "shutdown":
+		return cli.power(ctx, baseClient, command, args)
+	case "loglevel":
+		if len(args) > 1 {
+			return errors.New("usage: bbbcli loglevel [debug|info|warning|error]")
+		}
+		ctx, cancel := context.WithTimeout(ctx, cli.timeout)
+		defer cancel()
+		level, err := baseClient.LogLevel(ctx, strings.Join(args, ""))
+		if err != nil {
+			return err
+		}
+		return cli.print(map[string]string{"level": level}, level)
+	default: // update
+		if len(args) != 1 {
+			return errors.New("usage: bbbcli update <file>")
+		}
+		// Uploading large files can take a while, so the request is only limited by interrupting it.
+		path, err := baseClient.Update(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		return cli.print(map[string]string{"path": path}, "update staged at "+path)
+	}
+}
+
+// supportBundle downloads a support bundle into the current directory, or the file given with -o. The file is only
+// created once the bundle is complete and verified.
+func (cli *cli) supportBundle(ctx context.Context, baseClient *client.Client, args []string) error {
+	flags := flag.NewFlagSet("support-bundle", flag.ContinueOnError)
+	output := flags.String("o", "", "File to write the bundle to")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New("usage: bbbcli support-bundle [-o file]")
+	}
+	// Collecting the logs takes a while, so the request is only limited by interrupting it.
+	path, err := saveAttachment(*output, ".tar.gz", "bitbox-base-support.tar.gz", func(writer io.Writer) (string, error) {
+		return baseClient.SupportBundle(ctx, writer)
+	})
+	if err != nil {
+		return err
+	}
+	return cli.print(map[string]string{"path": path}, "support bundle written to "+path)
+}
+
+// saveAttachment downloads an attachment into a temporary file and moves it to output once download succeeds, which
+// verifies the attachment and returns the filename chosen by the Base. If output is empty, the base name of that
+// filename is used in the current directory, or fallback if it does not end with suffix.
+func saveAttachment(output, suffix, fallback string, download func(writer io.Writer) (string, error)) (string, error) {
+	file, err := ioutil.TempFile(filepath.Dir(output), ".bbbcli-download")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = os.Remove(file.Name())
+	}()
+	filename, err := download(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+	path := output
+	if path == "" {
+		path = filepath.Base(filename)
+		if !strings.HasSuffix(path, suffix) {
+			path = fallback
+		}
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// backupPassphrase returns the passphrase of backups from the BBB_BACKUP_PASSPHRASE environment variable, or asks
+// the user for it.
+func backupPassphrase() (string, error) {
+	if passphrase := os.Getenv("BBB_BACKUP_PASSPHRASE"); passphrase != "" {
+		return passphrase, nil
+	}
+	fmt.Fprint(os.Stderr, "Backup passphrase: ")
+	passphrase, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(passphrase, "\r\n"), nil
+}
+
+// lightningBackup downloads an encrypted backup of the lightning node into the current directory, or the file given
+// with -o, or makes the Base write it to its backup drive. A downloaded file is only created once the backup is
+// complete and verified.
+func (cli *cli) lightningBackup(ctx context.Context, baseClient *client.Client, args []string) error {
+	flags := flag.NewFlagSet("lightning-backup", flag.ContinueOnError)
+	drive := flags.Bool("drive", false, "Write the backup to the backup drive of the Base")
+	output := flags.String("o", "", "File to write the backup to")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 || (*drive && *output != "") {
+		return errors.New("usage: bbbcli lightning-backup [-drive] [-o file]")
+	}
+	passphrase, err := backupPassphrase()
+	if err != nil {
+		return err
+	}
+	if *drive {
+		backup, err := baseClient.LightningBackupToDrive(ctx, passphrase)
+		if err != nil {
+			return err
+		}
+		return cli.print(backup, "backup of node "+backup.NodeId+" written to "+backup.Path+" on the BitBox Base")
+	}
+	var backup *basemessages.BaseLightningBackupOut
+	// Copying the database takes a while, so the request is only limited by interrupting it.
+	path, err := saveAttachment(*output, ".backup", "lightning.backup", func(writer io.Writer) (string, error) {
+		backup, err = baseClient.LightningBackup(ctx, passphrase, writer)
+		if err != nil {
+			return "", err
+		}
+		return backup.Filename, nil
+	})
+	if err != nil {
+		return err
+	}
+	return cli.print(map[string]string{"path": path, "nodeId": backup.NodeId},
+		"backup of node "+backup.NodeId+" written to "+path)
+}
+
+// lightningRestore restores the lightning node from a backup file, or from a backup on the backup drive of the Base.
+func (cli *cli) lightningRestore(ctx context.Context, baseClient *client.Client, args []string) error {
+	flags := flag.NewFlagSet("lightning-restore", flag.ContinueOnError)
+	drive := flags.Bool("drive", false, "Restore the backup with this filename from the backup drive of the Base")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: bbbcli lightning-restore [-drive] <file>")
+	}
+	passphrase, err := backupPassphrase()
+	if err != nil {
+		return err
+	}
+	// Uploading the backup and restarting lightningd can take a while, so the request is only limited by
+	// interrupting it.
+	var restored *basemessages.BaseLightningRestoreOut
+	if *drive {
+		restored, err = baseClient.LightningRestoreFromDrive(ctx, passphrase, flags.Arg(0))
+	} else {
+		restored, err = baseClient.LightningRestore(ctx, passphrase, flags.Arg(0))
+	}
+	if err != nil {
+		return err
+	}
+	return cli.print(restored, "restored node "+restored.NodeId+" on "+restored.Network+" from the backup of "+
+		time.Unix(restored.Created, 0).Format(time.RFC3339))
+}
+
+// configBackup downloads a signed and encrypted backup of the configuration into the current directory, or the file
+// given with -o, or makes the Base write it to its backup drive. A downloaded file is only created once the backup is
+// complete and verified.
+func (cli *cli) configBackup(ctx context.Context, baseClient *client.Client, args []string) error {
+	flags := flag.NewFlagSet("config-backup", flag.ContinueOnError)
+	drive := flags.Bool("drive", false, "Write the backup to the backup drive of the Base")
+	output := flags.String("o", "", "File to write the backup to")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 || (*drive && *output != "") {
+		return errors.New("usage: bbbcli config-backup [-drive] [-o file]")
+	}
+	passphrase, err := backupPassphrase()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, cli.timeout)
+	defer cancel()
+	if *drive {
+		backup, err := baseClient.ConfigBackupToDrive(ctx, passphrase)
+		if err != nil {
+			return err
+		}
+		return cli.print(backup, "configuration backup signed by "+backup.Signer+" written to "+backup.Path+
+			" on the BitBox Base")
+	}
+	var backup *basemessages.BaseConfigBackupOut
+	path, err := saveAttachment(*output, ".backup", "config.backup", func(writer io.Writer) (string, error) {
+		backup, err = baseClient.ConfigBackup(ctx, passphrase, writer)
+		if err != nil {
+			return "", err
+		}
+		return backup.Filename, nil
+	})
+	if err != nil {
+		return err
+	}
+	return cli.print(map[string]string{"path": path, "signer": backup.Signer},
+		"configuration backup signed by "+backup.Signer+" written to "+path)
+}
+
+// configRestore restores the configuration of the Base from a backup file, or from a backup on its backup drive.
+func (cli *cli) configRestore(ctx context.Context, baseClient *client.Client, args []string) error {
+	flags := flag.NewFlagSet("config-restore", flag.ContinueOnError)
+	drive := flags.Bool("drive", false, "Restore the backup with this filename from the backup drive of the Base")
+	signer := flags.String("signer", "", "Signer of the backup, as printed by config-backup")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || *signer == "" {
+		return errors.New("usage: bbbcli config-restore [-drive] -signer <pubkey> <file>")
+	}
+	passphrase, err := backupPassphrase()
+	if err != nil {
+		return err
+	}
+	// Applying the settings and restarting tor can take a while, so the request is only limited by interrupting it.
+	var restored *basemessages.BaseConfigRestoreOut
+	if *drive {
+		restored, err = baseClient.ConfigRestoreFromDrive(ctx, passphrase, *signer, flags.Arg(0))
+	} else {
+		restored, err = baseClient.ConfigRestore(ctx, passphrase, *signer, flags.Arg(0))
+	}
+	if err != nil {
+		return err
+	}
+	return cli.print(restored, "restored the "+restored.Network+" configuration from the backup of "+
+		time.Unix(restored.Created, 0).Format(time.RFC3339)+", the Base closed the connection")
+}
+
+// factoryReset resets the Base to its factory state. The warnings of the Base are shown and the user has to type
+// "reset" to confirm, unless -yes is given.
+func (cli *cli) factoryReset(ctx context.Context, baseClient *client.Client, args []string) error {
+	flags := flag.NewFlagSet("factory-reset", flag.ContinueOnError)
+	blockchain := flags.Bool("blockchain", false, "Wipe the blockchain as well")
+	lightning := flags.Bool("lightning", false, "Wipe the lightning node as well, including its funds")
+	yes := flags.Bool("yes", false, "Do not ask for confirmation")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New("usage: bbbcli factory-reset [-blockchain] [-lightning] [-yes]")
+	}
+	requestCtx, requestCancel := context.WithTimeout(ctx, cli.timeout)
+	confirmation, err := baseClient.RequestFactoryReset(requestCtx, *blockchain, *lightning)
+	requestCancel()
+	if err != nil {
+		return err
+	}
+	if !*yes {
+		fmt.Fprintln(os.Stderr, "Resetting the BitBox Base to its factory state:")
+		for _, warning := range confirmation.Warnings {
+			fmt.Fprintln(os.Stderr, "  - "+warning)
+		}
+		fmt.Fprint(os.Stderr, "Type reset to confirm: ")
+		answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(answer) != "reset" {
+			return errors.New("factory reset aborted")
+		}
+	}
+	// Stopping the services and wiping the SSD can take a while, so the request is only limited by interrupting it.
+	if err := baseClient.ConfirmFactoryReset(ctx, confirmation.Token, *blockchain, *lightning); err != nil {
+		return err
+	}
+	return cli.print(map[string]bool{"resetting": true}, "the BitBox Base was reset and reboots, pair again afterwards")
+}
+
+// power reboots or shuts down the Base, printing every step. Reboots can be scheduled instead.
+func (cli *cli) power(ctx context.Context, baseClient *client.Client, command string, args []string) error {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	at := flags.String("at", "", "Schedule a maintenance reboot at this RFC 3339 time")
+	in := flags.Duration("in", 0, "Schedule a maintenance reboot after this duration")
+	cancelSchedule := flags.Bool("cancel", false, "Cancel the scheduled reboot")
+	scheduled := flags.Bool("scheduled", false, "Show the scheduled reboot")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	options := 0
+	for _, set := range []bool{*at != "", *in != 0, *cancelSchedule, *scheduled} {
+		if set {
+			options++
+		}
+	}
+	if flags.NArg() != 0 || options > 1 || (command == "shutdown" && options > 0) {
+		return errors.New("usage: bbbcli reboot [-at time|-in duration|-cancel|-scheduled] or bbbcli shutdown")
+	}
+	if options == 0 {
+		// Stopping bitcoind flushes its chainstate, which can take minutes, so the request is only limited by
+		// interrupting it.
+		err := baseClient.Power(ctx, command, func(step string) {
+			fmt.Fprintln(os.Stderr, step+"...")
+		})
+		if err != nil {
+			return err
+		}
+		return cli.print(map[string]string{"action": command}, "the BitBox Base is powering down: "+command)
+	}
+
+	requestCtx, requestCancel := context.WithTimeout(ctx, cli.timeout)
+	defer requestCancel()
+	var rebootAt time.Time
+	var err error
+	switch {
+	case *at != "":
+		parsed, parseErr := time.Parse(time.RFC3339, *at)
+		if parseErr != nil {
+			return parseErr
+		}
+		rebootAt, err = baseClient.ScheduleReboot(requestCtx, parsed)
+	case *in != 0:
+		rebootAt, err = baseClient.ScheduleReboot(requestCtx, time.Now().Add(*in))
+	case *cancelSchedule:
+		err = baseClient.CancelReboot(requestCtx)
+	default:
+		rebootAt, err = baseClient.ScheduledReboot(requestCtx)
+	}
+	if err != nil {
+		return err
+	}
+	if rebootAt.IsZero() {
+		return cli.print(map[string]string{"scheduledReboot": ""}, "no reboot scheduled")
+	}
+	formatted := rebootAt.Format(time.RFC3339)
+	return cli.print(map[string]string{"scheduledReboot": formatted}, "reboot scheduled at "+formatted)
+}
+
+// pair connects to the Base and asks the user to compare the pairing code, if the Base is not paired yet.
+func (cli *cli) pair(ctx context.Context) error {
+	config := cli.config
+	config.ConfirmPairing = func(pairingCode string) error {
+		fmt.Println("Pairing code:")
+		fmt.Println(pairingCode)
+		fmt.Print("Does the code match the one shown on the BitBox Base? [y/N] ")
+		answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			return err
+		}
+		if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+			return errors.New("pairing aborted")
+		}
+		return nil
+	}
+	// The user needs time to compare the code, so the handshake is not limited by the timeout.
+	baseClient, err := client.Dial(ctx, config)
+	if err != nil {
+		return err
+	}
+	baseClient.Close()
+	return cli.print(map[string]bool{"paired": true}, "paired with the BitBox Base")
+}
+
+func (cli *cli) configCommand(ctx context.Context, baseClient *client.Client, args []string) error {
+	ctx, cancel := context.WithTimeout(ctx, cli.timeout)
+	defer cancel()
+	switch {
+	case len(args) == 2 && args[0] == "get":
+		value, err := baseClient.ConfigGet(ctx, args[1])
+		if err != nil {
+			return err
+		}
+		return cli.print(map[string]string{"key": args[1], "value": value}, value)
+	case len(args) == 3 && args[0] == "set":
+		output, err := baseClient.ConfigSet(ctx, args[1], args[2])
+		if err != nil {
+			return err
+		}
+		return cli.print(map[string]string{"key": args[1], "output": output}, output)
+	default:
+		return errors.New("usage: bbbcli config get <key> | bbbcli config set <key> <value>")
+	}
+}
+
+// clients lists the paired clients, after changing the role of one of them for the role command.
+func (cli *cli) clients(ctx context.Context, baseClient *client.Client, command string, args []string) error {
+	ctx, cancel := context.WithTimeout(ctx, cli.timeout)
+	defer cancel()
+	var clients []*basemessages.BasePairedClient
+	var err error
+	switch {
+	case command == "clients" && len(args) == 0:
+		clients, err = baseClient.PairedClients(ctx)
+	case command == "role" && len(args) == 2:
+		pubkey, decodeErr := hex.DecodeString(args[0])
+		if decodeErr != nil {
+			return errors.New("invalid pubkey " + args[0] + ", expected hex")
+		}
+		clients, err = baseClient.SetClientRole(ctx, pubkey, args[1])
+	case command == "clients":
+		return errors.New("usage: bbbcli clients")
+	default:
+		return errors.New("usage: bbbcli role <pubkey> <owner|operator|read-only>")
+	}
+	if err != nil {
+		return err
+	}
+	lines := make([]string, len(clients))
+	for i, pairedClient := range clients {
+		lines[i] = fmt.Sprintf("%x %s", pairedClient.Pubkey, pairedClient.Role)
+		if pairedClient.Self {
+			lines[i] += " (this client)"
+		}
+	}
+	return cli.print(clients, strings.Join(lines, "\n"))
+}
+
+// audit shows entries of the audit log. Without an offset, the last entries are shown.
+func (cli *cli) audit(ctx context.Context, baseClient *client.Client, args []string) error {
+	flags := flag.NewFlagSet("audit", flag.ContinueOnError)
+	offset := flags.Int64("offset", -1, "Sequence number of the first entry to show, the last entries by default")
+	entries := flags.Uint("n", 20, "Number of entries to show")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New("usage: bbbcli audit [-offset n] [-n entries]")
+	}
+	ctx, cancel := context.WithTimeout(ctx, cli.timeout)
+	defer cancel()
+	start := uint64(0)
+	if *offset >= 0 {
+		start = uint64(*offset)
+	} else {
+		// Request the total number of entries first.
+		auditLog, err := baseClient.AuditLog(ctx, 0, 1)
+		if err != nil {
+			return err
+		}
+		if auditLog.Total > uint64(*entries) {
+			start = auditLog.Total - uint64(*entries)
+		}
+	}
+	auditLog, err := baseClient.AuditLog(ctx, start, uint32(*entries))
+	if err != nil {
+		return err
+	}
+	lines := []string{}
+	if auditLog.IntegrityError != "" {
+		lines = append(lines, "WARNING: the audit log was tampered with: "+auditLog.IntegrityError)
+	}
+	for _, entry := range auditLog.Entries {
+		fingerprint := entry.Client
+		if fingerprint == "" {
+			fingerprint = "-"
+		}
+		lines = append(lines, fmt.Sprintf("%d %s %s %s %s", entry.Sequence,
+			time.Unix(entry.Time, 0).Format(time.RFC3339), fingerprint, entry.Action, entry.Details))
+	}
+	return cli.print(auditLog, strings.Join(lines, "\n"))
+}
+
+func (cli *cli) logs(ctx context.Context, baseClient *client.Client, args []string) error {
+	flags := flag.NewFlagSet("logs", flag.ContinueOnError)
+	follow := flags.Bool("f", false, "Keep following new log lines")
+	lines := flags.Int("n", 50, "Number of past log lines to show")
+	priority := flags.String("p", "", "Only show lines up to this priority, e.g. err or warning")
+	since := flags.Duration("since", 0, "Only show lines of this period, e.g. 1h")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: bbbcli logs [-f] [-n lines] [-p priority] [-since duration] <unit>")
+	}
+	request := &basemessages.BaseLogsIn{
+		Unit:     flags.Arg(0),
+		Lines:    int32(*lines),
+		Follow:   *follow,
+		Priority: *priority,
+	}
+	if *since > 0 {
+		request.Since = time.Now().Add(-*since).Unix()
+	}
+	if !*follow {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cli.timeout)
+		defer cancel()
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	err := baseClient.LogEntries(ctx, request, func(entry *basemessages.BaseLogEntry) {
+		entryTime := time.Unix(0, entry.Time*int64(time.Microsecond))
+		if cli.jsonOutput {
+			_ = encoder.Encode(map[string]interface{}{
+				"time":     entryTime.Format(time.RFC3339Nano),
+				"unit":     entry.Unit,
+				"priority": entry.Priority,
+				"line":     entry.Message,
+			})
+			return
+		}
+		fmt.Println(entryTime.Format(time.RFC3339) + " " + entry.Message)
+	})
+	if *follow && err == context.Canceled {
+		return nil
+	}
+	return err
+}
+
+// print prints the result either as JSON or as the given human readable text.
+func (cli *cli) print(result interface{}, text string) error {
+	if !cli.jsonOutput {
+		fmt.Println(text)
+		return nil
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
@@ -3,40 +3,251 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
+	"fmt"
+	"io"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	middleware "github.com/digitalbitbox/bitbox-base/middleware/src"
 	"github.com/digitalbitbox/bitbox-base/middleware/src/handlers"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/logging"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/simulation"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/system"
 )
 
+// shutdownTimeout limits how long the middleware waits for requests in progress when shutting down.
+const shutdownTimeout = 20 * time.Second
+
 func main() {
-	bitcoinRPCUser := flag.String("rpcuser", "rpcuser", "Bitcoin rpc user name")
-	bitcoinRPCPassword := flag.String("rpcpassword", "rpcpassword", "Bitcoin rpc password")
-	bitcoinRPCPort := flag.String("rpcport", "18332", "Bitcoin rpc port, localhost is assumed as an address")
-	lightningRPCPath := flag.String("lightning-rpc-path", "/home/bitcoin/.lightning/lightning-rpc", "Path to the lightning rpc unix socket")
-	electrsRPCPort := flag.String("electrsport", "51002", "Electrs rpc port")
-	dataDir := flag.String("datadir", ".base", "Directory where middleware persistent data like noise keys is stored")
-	network := flag.String("network", "testnet", "Indicate wether running bitcoin on testnet or mainnet")
+	configFile := flag.String("config", system.DefaultConfigFile, "Path of the config file. Settings are taken from the defaults, the config file, the environment and the flags, in increasing priority")
+	dumpConfig := flag.Bool("dump-config", false, "Print the effective configuration and exit")
+	simulate := flag.Bool("simulate", false, "Simulate bitcoind, lightningd, electrs and the system instead of talking to the services of the Base, e.g. to develop apps on a laptop")
+	simulateScript := flag.String("simulate-script", "", "Path of the script driving the simulation, run once. By default, a built-in script with new blocks, transactions and channels is repeated")
+	system.RegisterFlags(flag.CommandLine)
 	flag.Parse()
 
+	// Until the configured level is known, and for packages without a logger of their own, lines of the standard log
+	// package are logged at the info level.
+	logger := logging.NewDefault(logging.LevelInfo)
+	log.SetFlags(0)
+	log.SetOutput(logger.Writer(logging.LevelInfo))
+
+	environment, err := system.LoadEnvironment(logger, *configFile, os.LookupEnv, flag.CommandLine)
+	if err != nil {
+		fatal(logger, "Invalid configuration", "error", err)
+	}
+	if *dumpConfig {
+		fmt.Print(environment.Dump())
+		return
+	}
+
 	logBeforeExit := func() {
 		// Recover from all panics and log error before panicking again.
 		if r := recover(); r != nil {
 			// r is of type interface{}, just print its value
-			log.Printf("%v, error detected, shutting down.", r)
+			logger.Error("Panic, shutting down", "panic", r)
 			panic(r)
 		}
 	}
 	defer logBeforeExit()
-	middleware := middleware.NewMiddleware(*bitcoinRPCUser, *bitcoinRPCPassword, *bitcoinRPCPort, *lightningRPCPath, *electrsRPCPort, *network)
-	log.Println("--------------- Started middleware --------------")
+	middleware := newMiddleware(logger, environment, *simulate, *simulateScript)
+	logger = middleware.Logger()
+	log.SetOutput(logger.Writer(logging.LevelInfo))
+	defer logger.HandleSignals()()
+	logger.Info("Started middleware", "network", environment.Network, "logLevel", logger.Level())
+
+	handlers := handlers.NewHandlers(middleware, environment.DataDir)
+
+	// The noise api over stream connections.
+	streamListeners := []net.Listener{}
+	if environment.TCPAddress != "" {
+		listener, err := net.Listen("tcp", environment.TCPAddress)
+		if err != nil {
+			fatal(logger, "Failed to listen on TCP address", "address", environment.TCPAddress, "error", err)
+		}
+		streamListeners = append(streamListeners, listener)
+		logger.Info("Serving noise api over TCP", "address", environment.TCPAddress)
+		go func() {
+			logger.Info("Stopped serving noise api over TCP", "error", handlers.Serve(listener))
+		}()
+	}
+	if environment.UnixSocket != "" {
+		listener, err := listen("unix:" + environment.UnixSocket)
+		if err != nil {
+			fatal(logger, "Failed to listen on Unix socket", "path", environment.UnixSocket, "error", err)
+		}
+		streamListeners = append(streamListeners, listener)
+		logger.Info("Serving noise api over Unix socket", "path", environment.UnixSocket)
+		go func() {
+			logger.Info("Stopped serving noise api over Unix socket", "error", handlers.Serve(listener))
+		}()
+	}
+
+	// The http api and the websocket, on every configured address.
+	server := &http.Server{Handler: handlers.Router}
+	serverDone := make(chan struct{})
+	var serving sync.WaitGroup
+	for _, address := range environment.ListenAddresses() {
+		listener, err := listen(address)
+		if err != nil {
+			fatal(logger, "Failed to listen", "address", address, "error", err)
+		}
+		// TLS is not needed on Unix sockets, those are only reachable from the Base itself.
+		useTLS := environment.TLSCert != "" && !strings.HasPrefix(address, "unix:")
+		logger.Info("Serving http api", "address", address, "tls", useTLS)
+		serving.Add(1)
+		go func(address string) {
+			defer serving.Done()
+			var err error
+			if useTLS {
+				err = server.ServeTLS(listener, environment.TLSCert, environment.TLSKey)
+			} else {
+				err = server.Serve(listener)
+			}
+			if err != http.ErrServerClosed {
+				fatal(logger, "Failed to serve http api", "address", address, "error", err)
+			}
+		}(address)
+	}
+	go func() {
+		serving.Wait()
+		close(serverDone)
+	}()
+
+	// The metrics are not authenticated, so they are served on a listener of their own that is only reachable from the
+	// Base.
+	metricsServer := &http.Server{Handler: handlers.MetricsHandler}
+	if environment.MetricsListen != "" {
+		listener, err := listen(environment.MetricsListen)
+		if err != nil {
+			fatal(logger, "Failed to listen for metrics", "address", environment.MetricsListen, "error", err)
+		}
+		logger.Info("Serving metrics", "address", environment.MetricsListen)
+		go func() {
+			if err := metricsServer.Serve(listener); err != http.ErrServerClosed {
+				logger.Error("Failed to serve metrics", "address", environment.MetricsListen, "error", err)
+			}
+		}()
+	}
+
+	// Settings that are safe to change at runtime are reloaded on SIGHUP, e.g. after bbb-config.sh edited the config file.
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			reloaded, err := system.LoadEnvironment(logger, *configFile, os.LookupEnv, flag.CommandLine)
+			if err != nil {
+				logger.Error("Failed to reload the configuration, keeping the current one", "error", err)
+				continue
+			}
+			middleware.Reload(reloaded)
+		}
+	}()
+
+	// systemd restarts the middleware if it stops sending watchdog notifications, which are driven by the health checks.
+	if err := system.Notify("READY=1"); err != nil {
+		logger.Warning("Failed to notify systemd", "error", err)
+	}
+	if interval := system.WatchdogInterval(); interval > 0 {
+		go watchdog(logger, middleware, interval)
+	}
+
+	// On shutdown, stop accepting connections, let the requests in progress finish and tell the connected clients that
+	// the middleware is going away, so that they reconnect once it is back.
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case sig := <-shutdown:
+		logger.Info("Draining client connections", "signal", sig)
+	case <-serverDone:
+		return
+	}
+	_ = system.Notify("STOPPING=1")
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	for _, listener := range streamListeners {
+		_ = listener.Close()
+	}
+	// Shutdown does not wait for hijacked connections like the websockets, those are drained by the handlers.
+	if err := server.Shutdown(ctx); err != nil {
+		logger.Warning("Failed to shut down the http server gracefully", "error", err)
+	}
+	_ = metricsServer.Close()
+	handlers.Shutdown(ctx)
+	logger.Info("Stopped middleware")
+}
+
+// fatal logs an error and exits.
+func fatal(logger *logging.Logger, message string, keyvals ...interface{}) {
+	logger.Error(message, keyvals...)
+	os.Exit(1)
+}
+
+// newMiddleware returns the middleware for the services of the Base or, if simulate is true, for a simulation driven
+// by the script at scriptPath, or by the default script if scriptPath is empty.
+func newMiddleware(logger *logging.Logger, environment system.Environment, simulate bool, scriptPath string) *middleware.Middleware {
+	if !simulate {
+		return middleware.NewMiddleware(environment)
+	}
+	script := io.Reader(strings.NewReader(simulation.DefaultScript()))
+	if scriptPath != "" {
+		file, err := os.Open(scriptPath)
+		if err != nil {
+			fatal(logger, "Failed to open the simulation script", "error", err)
+		}
+		defer file.Close()
+		script = file
+	}
+	steps, err := simulation.ParseScript(script)
+	if err != nil {
+		fatal(logger, "Invalid simulation script", "error", err)
+	}
+	simulated := simulation.New(environment.Network)
+	go simulated.Run(steps, scriptPath == "", nil)
+	logger.Info("Simulating the services of the Base")
+	return middleware.NewMiddlewareWithBackends(environment, simulated.Backends())
+}
 
-	handlers := handlers.NewHandlers(middleware, *dataDir)
-	log.Println("Binding middleware api to port 8845")
+// watchdog notifies the systemd watchdog twice per interval, as long as the middleware is alive.
+func watchdog(logger *logging.Logger, middleware *middleware.Middleware, interval time.Duration) {
+	for range time.Tick(interval / 2) {
+		if !middleware.Alive() {
+			logger.Error("Health checks are not running anymore, skipping the watchdog notification")
+			continue
+		}
+		if err := system.Notify("WATCHDOG=1"); err != nil {
+			logger.Warning("Failed to notify the systemd watchdog", "error", err)
+		}
+	}
+}
 
-	if err := http.ListenAndServe(":8845", handlers.Router); err != nil {
-		log.Println(err.Error() + " Failed to listen for HTTP")
+// listen opens a listener on a TCP address like :8845 or 127.0.0.1:8845, or on a Unix socket given as unix:/path. A
+// stale socket left over by a previous run is removed, any other file at the path is left alone and reported as an
+// error.
+func listen(address string) (net.Listener, error) {
+	if strings.HasPrefix(address, "unix:") {
+		path := strings.TrimPrefix(address, "unix:")
+		if info, err := os.Lstat(path); err == nil {
+			if info.Mode()&os.ModeSocket == 0 {
+				return nil, errors.New(path + " exists and is not a socket")
+			}
+			if err := os.Remove(path); err != nil {
+				return nil, err
+			}
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+		return net.Listen("unix", path)
 	}
+	return net.Listen("tcp", address)
 }
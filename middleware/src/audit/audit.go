@@ -0,0 +1,221 @@
+// Package audit keeps an append-only log of security relevant actions, like pairings and changed settings, so that
+// users and support can reconstruct who changed what. Every entry carries the hash of the previous one, so that
+// modified or removed entries are detected by Verify.
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Filename is the name of the audit log in the data directory of the middleware.
+const Filename = "audit.log"
+
+// The actions recorded in the audit log.
+const (
+	ActionMiddlewareStarted = "middleware-started"
+	ActionPairing           = "pairing"
+	ActionPairingRevoked    = "pairing-revoked"
+	ActionRoleChanged       = "role-changed"
+	ActionAuthFailed        = "auth-failed"
+	ActionConfigChanged     = "config-changed"
+	ActionNetworkSwitched   = "network-switched"
+	ActionUpdateStaged      = "update-staged"
+	ActionBackupCreated     = "backup-created"
+	ActionBackupRestored    = "backup-restored"
+	ActionFactoryReset      = "factory-reset"
+	ActionPower             = "power"
+)
+
+// Entry is an entry of the audit log.
+type Entry struct {
+	// Sequence numbers start at zero and increase by one with every entry.
+	Sequence uint64 `json:"sequence"`
+	// Time is the unix timestamp in seconds.
+	Time int64 `json:"time"`
+	// Client is the fingerprint of the client that caused the action, empty for actions of the Base itself or of
+	// clients whose pubkey is not known.
+	Client   string `json:"client"`
+	Action   string `json:"action"`
+	Details  string `json:"details"`
+	PrevHash string `json:"prevHash"`
+	Hash     string `json:"hash"`
+}
+
+// hash returns the hash of the entry, covering all fields but the hash itself.
+func (entry Entry) hash() string {
+	entry.Hash = ""
+	encoded, err := json.Marshal(entry)
+	if err != nil {
+		// Marshalling a struct of strings and numbers can not fail.
+		panic(err)
+	}
+	hash := sha256.Sum256(encoded)
+	return hex.EncodeToString(hash[:])
+}
+
+// Fingerprint returns the fingerprint of a client pubkey as it is recorded in the audit log, the first 8 bytes of its
+// sha256 hash in hex.
+func Fingerprint(pubkey []byte) string {
+	if len(pubkey) == 0 {
+		return ""
+	}
+	hash := sha256.Sum256(pubkey)
+	return hex.EncodeToString(hash[:8])
+}
+
+// Log is the audit log in a file. It is safe for concurrent use.
+type Log struct {
+	path string
+	mu   sync.Mutex
+	// loaded is true once the last entry was read from the file.
+	loaded bool
+	last   *Entry
+}
+
+// New returns the audit log stored in dir. The file is created with the first entry.
+func New(dir string) *Log {
+	return &Log{path: filepath.Join(dir, Filename)}
+}
+
+// read returns all entries of the log.
+func (log *Log) read() ([]Entry, error) {
+	file, err := os.Open(log.path)
+	if os.IsNotExist(err) {
+		return []Entry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	entries := []Entry{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 4096), 1<<20)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, errors.New("audit log entry " + strconv.Itoa(len(entries)) + " is corrupt: " + err.Error())
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// Append adds an entry for an action. client is the pubkey of the client that caused it, nil if there is none.
+func (log *Log) Append(client []byte, action, details string) error {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	if !log.loaded {
+		entries, err := log.read()
+		if err != nil {
+			return err
+		}
+		if len(entries) > 0 {
+			log.last = &entries[len(entries)-1]
+		}
+		log.loaded = true
+	}
+	entry := Entry{
+		Time:    time.Now().Unix(),
+		Client:  Fingerprint(client),
+		Action:  action,
+		Details: details,
+	}
+	if log.last != nil {
+		entry.Sequence = log.last.Sequence + 1
+		entry.PrevHash = log.last.Hash
+	}
+	entry.Hash = entry.hash()
+	encoded, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(log.path), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(log.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(encoded, '\n')); err != nil {
+		_ = file.Close()
+		return err
+	}
+	// The entry must survive a power loss right after a change.
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	log.last = &entry
+	return nil
+}
+
+// Entries returns up to limit entries, starting with the entry with the sequence number offset, and the total number of
+// entries.
+func (log *Log) Entries(offset uint64, limit int) ([]Entry, uint64, error) {
+	log.mu.Lock()
+	entries, err := log.read()
+	log.mu.Unlock()
+	if err != nil {
+		return nil, 0, err
+	}
+	total := uint64(len(entries))
+	if offset >= total {
+		return []Entry{}, total, nil
+	}
+	end := offset + uint64(limit)
+	if end > total {
+		end = total
+	}
+	return entries[offset:end], total, nil
+}
+
+// Reset removes all entries, e.g. for a factory reset. The next entry starts a new chain.
+func (log *Log) Reset() error {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	if err := os.Remove(log.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	log.loaded = true
+	log.last = nil
+	return nil
+}
+
+// Verify checks the hash chain of the log. It returns an error naming the first entry that was modified, or that
+// follows a removed entry.
+func (log *Log) Verify() error {
+	log.mu.Lock()
+	entries, err := log.read()
+	log.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	prevHash := ""
+	for i, entry := range entries {
+		position := strconv.Itoa(i)
+		if entry.Sequence != uint64(i) {
+			return errors.New("audit log entry " + position + " has the sequence number " +
+				strconv.FormatUint(entry.Sequence, 10))
+		}
+		if entry.PrevHash != prevHash {
+			return errors.New("audit log entry " + position + " does not follow the previous entry")
+		}
+		if entry.Hash != entry.hash() {
+			return errors.New("audit log entry " + position + " was modified")
+		}
+		prevHash = entry.Hash
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package audit_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-base/middleware/src/audit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bbb-audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	log := audit.New(dir)
+	require.NoError(t, log.Verify())
+	entries, total, err := log.Entries(0, 10)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+	require.Equal(t, uint64(0), total)
+
+	pubkey := []byte("client pubkey")
+	require.NoError(t, log.Append(nil, audit.ActionMiddlewareStarted, ""))
+	require.NoError(t, log.Append(pubkey, audit.ActionConfigChanged, "hostname=base"))
+	// Appending continues the chain of an existing log.
+	log = audit.New(dir)
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, log.Append(pubkey, audit.ActionAuthFailed, "permission denied"))
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, log.Verify())
+
+	entries, total, err = log.Entries(1, 2)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), total)
+	require.Len(t, entries, 2)
+	require.Equal(t, uint64(1), entries[0].Sequence)
+	require.Equal(t, audit.Fingerprint(pubkey), entries[0].Client)
+	require.Len(t, entries[0].Client, 16)
+	require.Equal(t, "hostname=base", entries[0].Details)
+	require.Equal(t, entries[0].Hash, entries[1].PrevHash)
+	entries, _, err = log.Entries(4, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	entries, _, err = log.Entries(5, 10)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	// Modified and removed entries are detected.
+	path := filepath.Join(dir, audit.Filename)
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	modified := strings.Replace(string(content), "hostname=base", "hostname=evil", 1)
+	require.NoError(t, ioutil.WriteFile(path, []byte(modified), 0600))
+	require.EqualError(t, log.Verify(), "audit log entry 1 was modified")
+	lines := strings.SplitAfter(string(content), "\n")
+	require.NoError(t, ioutil.WriteFile(path, []byte(lines[0]+strings.Join(lines[2:], "")), 0600))
+	require.EqualError(t, log.Verify(), "audit log entry 1 has the sequence number 2")
+
+	// Resetting starts a new chain.
+	log = audit.New(dir)
+	require.NoError(t, log.Reset())
+	require.NoError(t, log.Append(nil, audit.ActionFactoryReset, ""))
+	require.NoError(t, log.Verify())
+	entries, total, err = log.Entries(0, 10)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), total)
+	require.Equal(t, uint64(0), entries[0].Sequence)
+	require.Equal(t, audit.ActionFactoryReset, entries[0].Action)
+}
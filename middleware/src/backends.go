@@ -0,0 +1,350 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/backup"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/system"
+	lightning "github.com/fiatjaf/lightningd-gjson-rpc"
+)
+
+// BitcoinBackend is the bitcoin node of the Base.
+type BitcoinBackend interface {
+	// ChainInfo returns the state of the chain and the mempool. Unreachable is not set.
+	ChainInfo() (BitcoindState, error)
+	// MempoolTransactions returns the number of transactions in the mempool.
+	MempoolTransactions() (int64, error)
+}
+
+// LightningBackend is the lightning node of the Base.
+type LightningBackend interface {
+	// Info returns the alias and the number of active channels of the node.
+	Info() (LightningState, error)
+	// Node returns the id of the running node.
+	Node() (LightningNode, error)
+	// Files returns a consistent copy of the database and the hsm_secret of the node.
+	Files() (database, hsmSecret []byte, err error)
+	// Restore stops the node, puts the database and the hsm_secret in place and starts the node again.
+	Restore(database, hsmSecret []byte) error
+}
+
+// LightningNode identifies the lightning node of the Base.
+type LightningNode struct {
+	ID string
+	// Channels is the number of channels, including pending and inactive ones.
+	Channels int64
+}
+
+// ElectrsBackend is the electrum server of the Base.
+type ElectrsBackend interface {
+	// Blocks returns the block height the electrum server has indexed.
+	Blocks() (int64, error)
+}
+
+// SystemBackend is the system the middleware runs on, with the systemd services and the settings of the Base.
+type SystemBackend interface {
+	ServicesStatus() []system.ServiceStatus
+	ConfigGet(key string) (string, error)
+	ConfigSet(key, value string) (string, error)
+	// Journal sends the journal entries of a unit, see system.Journal.
+	Journal(query system.JournalQuery, stop <-chan struct{}, onEntry func(entry system.JournalEntry)) error
+	// Disks returns the usage of the root file system and the SSD.
+	Disks() []system.DiskUsage
+	// Thermal returns the current temperature and fan speed.
+	Thermal() (system.ThermalSample, error)
+	// BackupDrive returns the directory of the mounted backup drive.
+	BackupDrive() (string, error)
+	// ConfigFiles returns the system files that configuration backups hold, see system.ConfigFiles.
+	ConfigFiles() ([]backup.File, error)
+	// RestoreConfigFiles puts the system files of a configuration backup in place and restarts tor, so that it serves
+	// the hidden services with the restored keys.
+	RestoreConfigFiles(files []backup.File) error
+	// FactoryReset stops the services returned by system.ResetServices and wipes the configuration of the Base and
+	// the data on the SSD selected by options, see system.FactoryReset.
+	FactoryReset(options system.ResetOptions) error
+	// StopService stops a service returned by system.Services and waits until it stopped.
+	StopService(name string) error
+	// StartService starts a service returned by system.Services and waits until it started.
+	StartService(name string) error
+	// Power reboots or shuts down the Base.
+	Power(action system.PowerAction) error
+}
+
+// Backends are the services the middleware reports on and controls.
+type Backends struct {
+	Bitcoin   BitcoinBackend
+	Lightning LightningBackend
+	Electrs   ElectrsBackend
+	System    SystemBackend
+}
+
+// ReachableError is returned by a backend that was reached, but answered with an error, e.g. while it is still
+// starting up. The backend is reported degraded instead of down then.
+type ReachableError struct {
+	Err error
+}
+
+func (err *ReachableError) Error() string {
+	return err.Err.Error()
+}
+
+// isReachable returns true if err was returned by a backend that was reached.
+func isReachable(err error) bool {
+	switch err.(type) {
+	case *ReachableError, *btcjson.RPCError:
+		return true
+	default:
+		return false
+	}
+}
+
+// newBackends returns the backends of the Base, talking to bitcoind, lightningd, electrs and systemd with the current
+// settings of the middleware.
+func newBackends(middleware *Middleware) Backends {
+	environment := middleware.getEnvironment()
+	return Backends{
+		Bitcoin: &bitcoindBackend{
+			client: newBitcoindClient(
+				middleware.logger,
+				"127.0.0.1:"+environment.BitcoinRPCPort,
+				environment.BitcoinRPCUser,
+				environment.BitcoinRPCPassword,
+				environment.BitcoinRPCCookie),
+			environment: middleware.getEnvironment,
+		},
+		Lightning: &lightningdBackend{environment: middleware.getEnvironment},
+		Electrs:   &electrsBackend{environment: middleware.getEnvironment},
+		System:    systemBackend{},
+	}
+}
+
+// bitcoindBackend talks to bitcoind over rpc.
+type bitcoindBackend struct {
+	client      *bitcoindClient
+	environment func() system.Environment
+}
+
+// call applies the current rpc settings, e.g. after a reload, and calls bitcoind.
+func (bitcoind *bitcoindBackend) call(call func(client *rpcclient.Client) error) error {
+	environment := bitcoind.environment()
+	bitcoind.client.configure(
+		"127.0.0.1:"+environment.BitcoinRPCPort,
+		environment.BitcoinRPCUser,
+		environment.BitcoinRPCPassword,
+		environment.BitcoinRPCCookie)
+	return bitcoind.client.call(call)
+}
+
+// ChainInfo gets the blockcount, difficulty, best block hash and the number of mempool transactions.
+func (bitcoind *bitcoindBackend) ChainInfo() (BitcoindState, error) {
+	var state BitcoindState
+	err := bitcoind.call(func(client *rpcclient.Client) error {
+		blockCount, err := client.GetBlockCount()
+		if err != nil {
+			return err
+		}
+		blockChainInfo, err := client.GetBlockChainInfo()
+		if err != nil {
+			return err
+		}
+		mempoolTransactions, err := mempoolRPC(client)
+		if err != nil {
+			return err
+		}
+		state = BitcoindState{
+			Blocks:              blockCount,
+			Difficulty:          blockChainInfo.Difficulty,
+			BestBlockHash:       blockChainInfo.BestBlockHash,
+			MempoolTransactions: mempoolTransactions,
+		}
+		return nil
+	})
+	// After a timeout, the call may still set the state.
+	if err != nil {
+		return BitcoindState{}, err
+	}
+	return state, nil
+}
+
+// MempoolTransactions gets the number of transactions in the mempool.
+func (bitcoind *bitcoindBackend) MempoolTransactions() (int64, error) {
+	var mempoolTransactions int64
+	err := bitcoind.call(func(client *rpcclient.Client) error {
+		var err error
+		mempoolTransactions, err = mempoolRPC(client)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return mempoolTransactions, nil
+}
+
+// mempoolRPC gets the number of transactions in the mempool of bitcoind.
+func mempoolRPC(client *rpcclient.Client) (int64, error) {
+	result, err := client.RawRequest("getmempoolinfo", nil)
+	if err != nil {
+		return 0, err
+	}
+	var mempoolInfo struct {
+		Size int64 `json:"size"`
+	}
+	if err := json.Unmarshal(result, &mempoolInfo); err != nil {
+		return 0, err
+	}
+	return mempoolInfo.Size, nil
+}
+
+// lightningdBackend talks to c-lightning over its rpc unix socket.
+type lightningdBackend struct {
+	environment func() system.Environment
+}
+
+// Info gets the alias and the number of active channels with getinfo.
+func (lightningd *lightningdBackend) Info() (LightningState, error) {
+	ln := &lightning.Client{
+		Path: lightningd.environment().LightningRPCPath,
+	}
+	nodeinfo, err := ln.Call("getinfo")
+	if err != nil {
+		return LightningState{}, err
+	}
+	return LightningState{
+		Alias:    nodeinfo.Get("alias").String(),
+		Channels: nodeinfo.Get("num_active_channels").Int(),
+	}, nil
+}
+
+// Node gets the id and the number of channels with getinfo.
+func (lightningd *lightningdBackend) Node() (LightningNode, error) {
+	ln := &lightning.Client{
+		Path: lightningd.environment().LightningRPCPath,
+	}
+	nodeinfo, err := ln.Call("getinfo")
+	if err != nil {
+		return LightningNode{}, err
+	}
+	return LightningNode{
+		ID: nodeinfo.Get("id").String(),
+		Channels: nodeinfo.Get("num_pending_channels").Int() + nodeinfo.Get("num_active_channels").Int() +
+			nodeinfo.Get("num_inactive_channels").Int(),
+	}, nil
+}
+
+// dir returns the lightning directory, which holds the rpc socket.
+func (lightningd *lightningdBackend) dir() string {
+	return filepath.Dir(lightningd.environment().LightningRPCPath)
+}
+
+func (lightningd *lightningdBackend) Files() ([]byte, []byte, error) {
+	return system.LightningFiles(lightningd.dir())
+}
+
+// Restore stops lightningd with systemd while the files are replaced. It is started again even if replacing the
+// files failed.
+func (lightningd *lightningdBackend) Restore(database, hsmSecret []byte) error {
+	if err := system.StopService("lightningd"); err != nil {
+		return err
+	}
+	restoreErr := system.RestoreLightningFiles(lightningd.dir(), database, hsmSecret)
+	if err := system.StartService("lightningd"); err != nil && restoreErr == nil {
+		return err
+	}
+	return restoreErr
+}
+
+// electrsBackend talks to electrs with the electrum protocol.
+type electrsBackend struct {
+	environment func() system.Environment
+}
+
+// Blocks gets the block height of electrs.
+func (electrs *electrsBackend) Blocks() (int64, error) {
+	conn, err := net.DialTimeout("tcp", "127.0.0.1:"+electrs.environment().ElectrsRPCPort, 2*time.Second)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	_, err = conn.Write([]byte(`{"jsonrpc":"2.0","id":0,"method":"blockchain.headers.subscribe","params":[]}` + "\n"))
+	if err != nil {
+		return 0, err
+	}
+	var response struct {
+		Result struct {
+			Height int64 `json:"height"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(conn).Decode(&response); err != nil {
+		return 0, &ReachableError{Err: err}
+	}
+	return response.Result.Height, nil
+}
+
+// systemBackend manages the Base with systemd, journald and bbb-config.sh.
+type systemBackend struct{}
+
+func (systemBackend) ServicesStatus() []system.ServiceStatus {
+	return system.ServicesStatus()
+}
+
+func (systemBackend) ConfigGet(key string) (string, error) {
+	return system.ConfigGet(key)
+}
+
+func (systemBackend) ConfigSet(key, value string) (string, error) {
+	return system.ConfigSet(key, value)
+}
+
+func (systemBackend) Journal(query system.JournalQuery, stop <-chan struct{}, onEntry func(entry system.JournalEntry)) error {
+	return system.Journal(query, stop, onEntry)
+}
+
+func (systemBackend) Disks() []system.DiskUsage {
+	return system.Disks()
+}
+
+func (systemBackend) Thermal() (system.ThermalSample, error) {
+	return system.Thermal()
+}
+
+func (systemBackend) BackupDrive() (string, error) {
+	return system.BackupDrive()
+}
+
+func (systemBackend) ConfigFiles() ([]backup.File, error) {
+	return system.ConfigFiles("/")
+}
+
+func (systemBackend) RestoreConfigFiles(files []backup.File) error {
+	if err := system.RestoreConfigFiles("/", files); err != nil {
+		return err
+	}
+	return system.RestartTor()
+}
+
+func (systemBackend) FactoryReset(options system.ResetOptions) error {
+	for _, service := range system.ResetServices(options) {
+		if err := system.StopService(service); err != nil {
+			return err
+		}
+	}
+	return system.FactoryReset("/", options)
+}
+
+func (systemBackend) StopService(name string) error {
+	return system.StopService(name)
+}
+
+func (systemBackend) StartService(name string) error {
+	return system.StartService(name)
+}
+
+func (systemBackend) Power(action system.PowerAction) error {
+	return system.Power(action)
+}
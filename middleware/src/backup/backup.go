@@ -0,0 +1,321 @@
+// Package backup implements the encrypted backup archives of the Base. An archive is a tar.gz file with a manifest and
+// the backed up files, encrypted and authenticated with chacha20poly1305 under a key derived from a user passphrase
+// with scrypt, so that it can be stored anywhere, e.g. in the app or on a second drive.
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+// The kinds of backups.
+const (
+	// KindLightning backups hold the database and the hsm_secret of c-lightning.
+	KindLightning = "lightning"
+	// KindConfig backups hold the configuration of the Base, see the middleware for the files.
+	KindConfig = "config"
+)
+
+const (
+	// magic starts every backup archive.
+	magic         = "BBBACKUP"
+	formatVersion = 1
+	// scryptLogN is the cost of the key derivation, N=2^15 with r=8 needs 32 MiB of memory.
+	scryptLogN = 15
+	saltSize   = 16
+	headerSize = len(magic) + 2 + saltSize + chacha20poly1305.NonceSize
+	// minPassphraseLength is the minimum number of characters of a passphrase.
+	minPassphraseLength = 8
+	// manifestFilename is the name of the manifest in the archive.
+	manifestFilename = "manifest.json"
+	// signatureFilename is the name of the signature of the manifest in signed archives.
+	signatureFilename = "manifest.sig"
+	// maxArchiveSize limits the size of a decrypted archive.
+	maxArchiveSize = 256 << 20
+)
+
+// ErrDecrypt is returned by Open if the passphrase is wrong or the backup was modified.
+var ErrDecrypt = errors.New("wrong passphrase or corrupted backup")
+
+// Extension is the file extension of backup archives.
+const Extension = ".backup"
+
+// Archive is an encrypted backup with the name it is stored under.
+type Archive struct {
+	Filename string
+	Data     []byte
+	Manifest Manifest
+}
+
+// Filename returns the name a backup is stored under, like lightning-testnet-20190620-120000.backup.
+func Filename(manifest Manifest) string {
+	return manifest.Kind + "-" + manifest.Network + "-" +
+		time.Unix(manifest.Created, 0).UTC().Format("20060102-150405") + Extension
+}
+
+// FileInfo describes a file of a backup in the manifest.
+type FileInfo struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	Sha256 string `json:"sha256"`
+}
+
+// Manifest describes a backup.
+type Manifest struct {
+	Kind string `json:"kind"`
+	// Created is the unix timestamp of the backup in seconds.
+	Created int64 `json:"created"`
+	// Network is the bitcoin network the Base ran on.
+	Network string `json:"network"`
+	// NodeID is the id of the lightning node, only set for lightning backups.
+	NodeID string `json:"nodeId,omitempty"`
+	// Signer is the compressed secp256k1 pubkey in hex that signed the manifest, only set for signed backups. As the
+	// manifest holds the hashes of the files, the signature covers them too.
+	Signer string `json:"signer,omitempty"`
+	// Files is filled in by Seal.
+	Files []FileInfo `json:"files"`
+}
+
+// File is a file of a backup.
+type File struct {
+	Name string
+	Data []byte
+	// Mode holds the permissions of the file, 0600 if not set.
+	Mode os.FileMode
+}
+
+// ValidatePassphrase returns an error if the passphrase is too short to protect a backup.
+func ValidatePassphrase(passphrase string) error {
+	if len([]rune(passphrase)) < minPassphraseLength {
+		return errors.New("the passphrase needs at least " + strconv.Itoa(minPassphraseLength) + " characters")
+	}
+	return nil
+}
+
+// deriveKey derives the encryption key from the passphrase.
+func deriveKey(passphrase string, salt []byte, logN byte) ([]byte, error) {
+	if logN < 10 || logN > 20 {
+		return nil, errors.New("unsupported backup key derivation cost")
+	}
+	return scrypt.Key([]byte(passphrase), salt, 1<<logN, 8, 1, chacha20poly1305.KeySize)
+}
+
+// SigningKey derives the key that signs backups from a secret of the Base.
+func SigningKey(secret []byte) *btcec.PrivateKey {
+	key, _ := btcec.PrivKeyFromBytes(btcec.S256(), hkdfSHA256(secret, nil, []byte("backup signing key"), 32))
+	return key
+}
+
+// Seal returns the encrypted archive of the files, described by the manifest. The files are added to the manifest.
+func Seal(passphrase string, manifest Manifest, files []File) ([]byte, error) {
+	return seal(passphrase, manifest, files, nil)
+}
+
+// SealSigned is like Seal, but the manifest is signed with the key, whose pubkey becomes the Signer of the manifest.
+func SealSigned(passphrase string, manifest Manifest, files []File, key *btcec.PrivateKey) ([]byte, error) {
+	return seal(passphrase, manifest, files, key)
+}
+
+func seal(passphrase string, manifest Manifest, files []File, key *btcec.PrivateKey) ([]byte, error) {
+	if err := ValidatePassphrase(passphrase); err != nil {
+		return nil, err
+	}
+	manifest.Signer = ""
+	if key != nil {
+		manifest.Signer = hex.EncodeToString(key.PubKey().SerializeCompressed())
+	}
+	manifest.Files = []FileInfo{}
+	for _, file := range files {
+		hash := sha256.Sum256(file.Data)
+		manifest.Files = append(manifest.Files, FileInfo{
+			Name:   file.Name,
+			Size:   int64(len(file.Data)),
+			Sha256: hex.EncodeToString(hash[:]),
+		})
+	}
+	encodedManifest, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	entries := []File{{Name: manifestFilename, Data: encodedManifest}}
+	if key != nil {
+		hash := sha256.Sum256(encodedManifest)
+		signature, err := key.Sign(hash[:])
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, File{Name: signatureFilename, Data: signature.Serialize()})
+	}
+	var archive bytes.Buffer
+	gzipWriter := gzip.NewWriter(&archive)
+	tarWriter := tar.NewWriter(gzipWriter)
+	modTime := time.Unix(manifest.Created, 0)
+	for _, file := range append(entries, files...) {
+		mode := file.Mode.Perm()
+		if mode == 0 {
+			mode = 0600
+		}
+		header := &tar.Header{
+			Name:     file.Name,
+			Typeflag: tar.TypeReg,
+			Mode:     int64(mode),
+			Size:     int64(len(file.Data)),
+			ModTime:  modTime,
+		}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := tarWriter.Write(file.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err := tarWriter.Close(); err != nil {
+		return nil, err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, headerSize)
+	copy(header, magic)
+	header[len(magic)] = formatVersion
+	header[len(magic)+1] = scryptLogN
+	if _, err := rand.Read(header[len(magic)+2:]); err != nil {
+		return nil, err
+	}
+	salt := header[len(magic)+2 : len(magic)+2+saltSize]
+	nonce := header[len(magic)+2+saltSize:]
+	encryptionKey, err := deriveKey(passphrase, salt, scryptLogN)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.New(encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	// The header is authenticated along with the archive.
+	return aead.Seal(header, nonce, archive.Bytes(), header), nil
+}
+
+// Open decrypts a backup and returns its manifest and files. The files are checked against the manifest.
+func Open(passphrase string, sealed []byte) (Manifest, []File, error) {
+	if len(sealed) < headerSize || string(sealed[:len(magic)]) != magic {
+		return Manifest{}, nil, errors.New("not a BitBox Base backup")
+	}
+	if sealed[len(magic)] != formatVersion {
+		return Manifest{}, nil, errors.New("unsupported backup version " + strconv.Itoa(int(sealed[len(magic)])))
+	}
+	header := sealed[:headerSize]
+	salt := header[len(magic)+2 : len(magic)+2+saltSize]
+	nonce := header[len(magic)+2+saltSize:]
+	key, err := deriveKey(passphrase, salt, header[len(magic)+1])
+	if err != nil {
+		return Manifest{}, nil, err
+	}
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return Manifest{}, nil, err
+	}
+	archive, err := aead.Open(nil, nonce, sealed[headerSize:], header)
+	if err != nil {
+		return Manifest{}, nil, ErrDecrypt
+	}
+
+	gzipReader, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return Manifest{}, nil, err
+	}
+	tarReader := tar.NewReader(io.LimitReader(gzipReader, maxArchiveSize))
+	var manifest Manifest
+	var encodedManifest, signature []byte
+	files := []File{}
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Manifest{}, nil, err
+		}
+		data, err := ioutil.ReadAll(tarReader)
+		if err != nil {
+			return Manifest{}, nil, err
+		}
+		switch header.Name {
+		case manifestFilename:
+			if err := json.Unmarshal(data, &manifest); err != nil {
+				return Manifest{}, nil, errors.New("invalid backup manifest")
+			}
+			encodedManifest = data
+		case signatureFilename:
+			signature = data
+		default:
+			files = append(files, File{Name: header.Name, Data: data, Mode: header.FileInfo().Mode().Perm()})
+		}
+	}
+	if manifest.Kind == "" {
+		return Manifest{}, nil, errors.New("the backup has no manifest")
+	}
+	if manifest.Signer != "" {
+		if err := verifySignature(manifest.Signer, encodedManifest, signature); err != nil {
+			return Manifest{}, nil, err
+		}
+	}
+	if len(files) != len(manifest.Files) {
+		return Manifest{}, nil, errors.New("the backup does not contain the files listed in its manifest")
+	}
+	for i, file := range files {
+		hash := sha256.Sum256(file.Data)
+		if file.Name != manifest.Files[i].Name || hex.EncodeToString(hash[:]) != manifest.Files[i].Sha256 {
+			return Manifest{}, nil, errors.New("backup file " + file.Name + " does not match the manifest")
+		}
+	}
+	return manifest, files, nil
+}
+
+// verifySignature returns an error if the signature of the manifest was not made by signer.
+func verifySignature(signer string, encodedManifest, signature []byte) error {
+	invalid := errors.New("the signature of the backup is invalid")
+	encodedPubkey, err := hex.DecodeString(signer)
+	if err != nil {
+		return invalid
+	}
+	pubkey, err := btcec.ParsePubKey(encodedPubkey, btcec.S256())
+	if err != nil {
+		return invalid
+	}
+	parsedSignature, err := btcec.ParseDERSignature(signature, btcec.S256())
+	if err != nil {
+		return invalid
+	}
+	hash := sha256.Sum256(encodedManifest)
+	if !parsedSignature.Verify(hash[:], pubkey) {
+		return invalid
+	}
+	return nil
+}
+
+// Find returns the data of the file with the given name, or nil if the backup does not contain it.
+func Find(files []File, name string) []byte {
+	for _, file := range files {
+		if file.Name == name {
+			return file.Data
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package backup_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-base/middleware/src/backup"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealOpen(t *testing.T) {
+	manifest := backup.Manifest{Kind: backup.KindLightning, Created: 1560000000, Network: "testnet", NodeID: "02ab"}
+	files := []backup.File{
+		{Name: backup.LightningDatabase, Data: []byte("SQLite format 3"), Mode: 0600},
+		{Name: backup.LightningHSMSecret, Data: bytes.Repeat([]byte{7}, 32), Mode: 0400},
+	}
+	_, err := backup.Seal("short", manifest, files)
+	require.Error(t, err)
+	sealed, err := backup.Seal("correct horse", manifest, files)
+	require.NoError(t, err)
+	require.NotContains(t, string(sealed), "SQLite")
+
+	opened, openedFiles, err := backup.Open("correct horse", sealed)
+	require.NoError(t, err)
+	require.Equal(t, files, openedFiles)
+	require.Equal(t, "02ab", opened.NodeID)
+	require.Len(t, opened.Files, 2)
+	require.Equal(t, int64(15), opened.Files[0].Size)
+	require.Equal(t, []byte("SQLite format 3"), backup.Find(openedFiles, backup.LightningDatabase))
+	require.Nil(t, backup.Find(openedFiles, "missing"))
+
+	_, _, err = backup.Open("wrong horse", sealed)
+	require.Equal(t, backup.ErrDecrypt, err)
+	// The header is authenticated as well.
+	for _, position := range []int{9, 20, len(sealed) - 1} {
+		tampered := append([]byte{}, sealed...)
+		tampered[position] ^= 1
+		_, _, err = backup.Open("correct horse", tampered)
+		require.Equal(t, backup.ErrDecrypt, err, position)
+	}
+	_, _, err = backup.Open("correct horse", []byte("not a backup"))
+	require.Error(t, err)
+}
+
+func TestSealSigned(t *testing.T) {
+	manifest := backup.Manifest{Kind: backup.KindConfig, Created: 1560000000, Network: "testnet"}
+	files := []backup.File{{Name: "settings.json", Data: []byte(`{"hostname":"bitbox-base"}`), Mode: 0600}}
+	key := backup.SigningKey([]byte("noise static key"))
+	require.Equal(t, key.Serialize(), backup.SigningKey([]byte("noise static key")).Serialize())
+	sealed, err := backup.SealSigned("correct horse", manifest, files, key)
+	require.NoError(t, err)
+	opened, openedFiles, err := backup.Open("correct horse", sealed)
+	require.NoError(t, err)
+	require.Equal(t, files, openedFiles)
+	require.Equal(t, hex.EncodeToString(key.PubKey().SerializeCompressed()), opened.Signer)
+
+	// Unsigned backups have no signer, even if the manifest claims one.
+	manifest.Signer = opened.Signer
+	sealed, err = backup.Seal("correct horse", manifest, files)
+	require.NoError(t, err)
+	opened, _, err = backup.Open("correct horse", sealed)
+	require.NoError(t, err)
+	require.Empty(t, opened.Signer)
+}
+
+func TestLightningNodeID(t *testing.T) {
+	// Test case 1 of RFC 5869.
+	okm := backup.HKDFSHA256(
+		bytes.Repeat([]byte{0x0b}, 22),
+		[]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12},
+		[]byte{0xf0, 0xf1, 0xf2, 0xf3, 0xf4, 0xf5, 0xf6, 0xf7, 0xf8, 0xf9},
+		42)
+	require.Equal(t,
+		"3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865",
+		hex.EncodeToString(okm))
+
+	nodeID, err := backup.LightningNodeID(bytes.Repeat([]byte{1}, 32))
+	require.NoError(t, err)
+	require.Len(t, nodeID, 66)
+	otherID, err := backup.LightningNodeID(bytes.Repeat([]byte{2}, 32))
+	require.NoError(t, err)
+	require.NotEqual(t, nodeID, otherID)
+	_, err = backup.LightningNodeID([]byte{1})
+	require.Error(t, err)
+}
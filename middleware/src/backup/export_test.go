@@ -0,0 +1,6 @@
+package backup
+
+// HKDFSHA256 exposes the key derivation of the lightning node key to the tests.
+func HKDFSHA256(secret, salt, info []byte, size int) []byte {
+	return hkdfSHA256(secret, salt, info, size)
+}
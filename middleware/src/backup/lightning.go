@@ -0,0 +1,61 @@
+package backup
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+)
+
+// The files of a lightning backup, named like in the lightning directory of c-lightning.
+const (
+	LightningDatabase  = "lightningd.sqlite3"
+	LightningHSMSecret = "hsm_secret"
+)
+
+// hsmSecretSize is the size of the hsm_secret of c-lightning.
+const hsmSecretSize = 32
+
+// hkdfSHA256 derives size bytes from secret, salt and info with HKDF-SHA256, see RFC 5869.
+func hkdfSHA256(secret, salt, info []byte, size int) []byte {
+	extract := hmac.New(sha256.New, salt)
+	_, _ = extract.Write(secret)
+	pseudoRandomKey := extract.Sum(nil)
+	output := []byte{}
+	previous := []byte{}
+	for counter := byte(1); len(output) < size; counter++ {
+		expand := hmac.New(sha256.New, pseudoRandomKey)
+		_, _ = expand.Write(previous)
+		_, _ = expand.Write(info)
+		_, _ = expand.Write([]byte{counter})
+		previous = expand.Sum(nil)
+		output = append(output, previous...)
+	}
+	return output[:size]
+}
+
+// LightningNodeID returns the node id c-lightning derives from the hsm_secret. Like the hsmd of c-lightning, the node
+// key is derived with HKDF-SHA256 and the info "nodeid", using a little endian 32 bit counter as the salt that is
+// increased until the key is valid.
+func LightningNodeID(hsmSecret []byte) (string, error) {
+	if len(hsmSecret) != hsmSecretSize {
+		return "", errors.New("invalid hsm_secret")
+	}
+	order := btcec.S256().N
+	salt := make([]byte, 4)
+	for counter := uint32(0); counter < 1000; counter++ {
+		binary.LittleEndian.PutUint32(salt, counter)
+		key := hkdfSHA256(hsmSecret, salt, []byte("nodeid"), 32)
+		scalar := new(big.Int).SetBytes(key)
+		if scalar.Sign() == 0 || scalar.Cmp(order) >= 0 {
+			continue
+		}
+		_, publicKey := btcec.PrivKeyFromBytes(btcec.S256(), key)
+		return hex.EncodeToString(publicKey.SerializeCompressed()), nil
+	}
+	return "", errors.New("invalid hsm_secret")
+}
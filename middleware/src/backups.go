@@ -0,0 +1,290 @@
+package middleware
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/digitalbitbox/bitbox-base/middleware/src/backup"
+	noisemanager "github.com/digitalbitbox/bitbox-base/middleware/src/noise"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/system"
+)
+
+// LightningBackup returns an encrypted backup of the lightning node, with a consistent copy of its database and its
+// hsm_secret. Backups and restores are serialized.
+func (middleware *Middleware) LightningBackup(passphrase string) (backup.Archive, error) {
+	if err := backup.ValidatePassphrase(passphrase); err != nil {
+		return backup.Archive{}, err
+	}
+	middleware.backupMu.Lock()
+	defer middleware.backupMu.Unlock()
+	database, hsmSecret, err := middleware.backends.Lightning.Files()
+	if err != nil {
+		return backup.Archive{}, err
+	}
+	// The node id is derived from the hsm_secret, so that nodes that do not run can be backed up as well.
+	nodeID, err := backup.LightningNodeID(hsmSecret)
+	if err != nil {
+		return backup.Archive{}, err
+	}
+	manifest := backup.Manifest{
+		Kind:    backup.KindLightning,
+		Created: time.Now().Unix(),
+		Network: string(middleware.getEnvironment().Network),
+		NodeID:  nodeID,
+	}
+	data, err := backup.Seal(passphrase, manifest, []backup.File{
+		{Name: backup.LightningDatabase, Data: database},
+		{Name: backup.LightningHSMSecret, Data: hsmSecret},
+	})
+	if err != nil {
+		return backup.Archive{}, err
+	}
+	middleware.logger.Info("Created a lightning backup", "node", nodeID)
+	return backup.Archive{Filename: backup.Filename(manifest), Data: data, Manifest: manifest}, nil
+}
+
+// LightningRestore restores the lightning node from an encrypted backup. Before the files are put in place, it checks
+// that the backup was made on the network the Base runs on and that its hsm_secret belongs to the node id it was made
+// of. A running node with channels is never replaced, as its funds would be lost, and restoring an older state of it
+// would publish revoked channel states.
+func (middleware *Middleware) LightningRestore(passphrase string, data []byte) (backup.Manifest, error) {
+	manifest, files, err := backup.Open(passphrase, data)
+	if err != nil {
+		return backup.Manifest{}, err
+	}
+	if manifest.Kind != backup.KindLightning {
+		return backup.Manifest{}, errors.New("not a lightning backup, but a " + manifest.Kind + " backup")
+	}
+	network := string(middleware.getEnvironment().Network)
+	if manifest.Network != network {
+		return backup.Manifest{}, errors.New("the backup is of a " + manifest.Network + " node, but the Base runs on " + network)
+	}
+	database := backup.Find(files, backup.LightningDatabase)
+	hsmSecret := backup.Find(files, backup.LightningHSMSecret)
+	if database == nil || hsmSecret == nil {
+		return backup.Manifest{}, errors.New("the backup is incomplete")
+	}
+	nodeID, err := backup.LightningNodeID(hsmSecret)
+	if err != nil {
+		return backup.Manifest{}, err
+	}
+	if nodeID != manifest.NodeID {
+		return backup.Manifest{}, errors.New("the hsm_secret of the backup does not belong to node " + manifest.NodeID)
+	}
+
+	middleware.backupMu.Lock()
+	defer middleware.backupMu.Unlock()
+	node, err := middleware.backends.Lightning.Node()
+	if err != nil {
+		return backup.Manifest{}, errors.New("lightningd must be running to check the node before restoring: " + err.Error())
+	}
+	if node.Channels > 0 {
+		return backup.Manifest{}, errors.New("the Base runs node " + node.ID + " with " +
+			strconv.FormatInt(node.Channels, 10) + " channels, which would be lost, close them first")
+	}
+	if err := middleware.backends.Lightning.Restore(database, hsmSecret); err != nil {
+		return backup.Manifest{}, err
+	}
+	middleware.logger.Info("Restored the lightning node from a backup", "node", nodeID, "previousNode", node.ID)
+	return manifest, nil
+}
+
+// The files of a configuration backup besides the system files, see SystemBackend.ConfigFiles.
+const (
+	// configKeystoreFile holds the noise keystore and the paired clients of the middleware.
+	configKeystoreFile = "middleware/base.json"
+	// configSettingsFile holds the settings of bbb-config.sh as a JSON object.
+	configSettingsFile = "settings.json"
+)
+
+// ConfigBackup returns a signed and encrypted backup of the configuration of the Base: the settings, the given noise
+// keystore with the paired clients, the keys of the tor hidden services and the network settings. It is signed with a
+// key derived from the noise static key in the keystore, so that restoring it can check that it was made by the Base
+// whose keys it holds.
+func (middleware *Middleware) ConfigBackup(passphrase string, keystore []byte) (backup.Archive, error) {
+	if err := backup.ValidatePassphrase(passphrase); err != nil {
+		return backup.Archive{}, err
+	}
+	staticKey, err := noisemanager.KeystorePrivateKey(keystore)
+	if err != nil {
+		return backup.Archive{}, err
+	}
+	middleware.backupMu.Lock()
+	defer middleware.backupMu.Unlock()
+	settings := make(map[string]string)
+	for _, key := range system.ConfigKeys() {
+		output, err := middleware.backends.System.ConfigGet(key)
+		if err != nil {
+			// Settings that were never stored keep their defaults.
+			continue
+		}
+		value := system.ConfigValue(key, output)
+		if system.ValidateConfigSet(key, value) != nil {
+			// Neither are settings that could not be applied again.
+			continue
+		}
+		settings[key] = value
+	}
+	encodedSettings, err := json.Marshal(settings)
+	if err != nil {
+		return backup.Archive{}, err
+	}
+	systemFiles, err := middleware.backends.System.ConfigFiles()
+	if err != nil {
+		return backup.Archive{}, err
+	}
+	files := []backup.File{
+		{Name: configSettingsFile, Data: encodedSettings},
+		{Name: configKeystoreFile, Data: keystore},
+	}
+	for _, file := range systemFiles {
+		// Network settings that would be refused when restoring are left out as well.
+		if err := system.ValidateConfigFile(file); err != nil {
+			middleware.logger.Warning("Left a file out of the configuration backup", "file", file.Name, "error", err)
+			continue
+		}
+		files = append(files, file)
+	}
+	manifest := backup.Manifest{
+		Kind:    backup.KindConfig,
+		Created: time.Now().Unix(),
+		Network: string(middleware.getEnvironment().Network),
+	}
+	signingKey := backup.SigningKey(staticKey)
+	data, err := backup.SealSigned(passphrase, manifest, files, signingKey)
+	if err != nil {
+		return backup.Archive{}, err
+	}
+	manifest.Signer = hex.EncodeToString(signingKey.PubKey().SerializeCompressed())
+	middleware.logger.Info("Created a configuration backup", "files", len(files))
+	return backup.Archive{Filename: backup.Filename(manifest), Data: data, Manifest: manifest}, nil
+}
+
+// ConfigRestore restores the configuration of the Base from a backup made by ConfigBackup. The whole backup is
+// validated before anything is changed: it must be signed by signer, the pubkey that the owner was shown when the
+// backup was made, which must belong to the keystore it holds, and all settings and files must be known. As anyone
+// with the passphrase can sign an archive with the keys they put into it, only the signer confirmed by the owner
+// proves where the backup comes from. Then the keystore is passed to restoreKeystore, the system files are put in
+// place and the settings are applied with bbb-config.sh. A different bitcoin network takes effect with the next boot.
+func (middleware *Middleware) ConfigRestore(passphrase string, signer string, data []byte,
+	restoreKeystore func(keystore []byte) error) (backup.Manifest, error) {
+	if signer == "" {
+		return backup.Manifest{}, errors.New("the signer of the backup, shown when it was made, must be confirmed")
+	}
+	manifest, files, err := backup.Open(passphrase, data)
+	if err != nil {
+		return backup.Manifest{}, err
+	}
+	if manifest.Kind != backup.KindConfig {
+		return backup.Manifest{}, errors.New("not a configuration backup, but a " + manifest.Kind + " backup")
+	}
+	if manifest.Signer == "" {
+		return backup.Manifest{}, errors.New("the configuration backup is not signed")
+	}
+	if !strings.EqualFold(manifest.Signer, signer) {
+		return backup.Manifest{}, errors.New("the backup was signed by " + manifest.Signer + ", not by the confirmed signer")
+	}
+	keystore := backup.Find(files, configKeystoreFile)
+	encodedSettings := backup.Find(files, configSettingsFile)
+	if keystore == nil || encodedSettings == nil {
+		return backup.Manifest{}, errors.New("the backup is incomplete")
+	}
+	staticKey, err := noisemanager.KeystorePrivateKey(keystore)
+	if err != nil {
+		return backup.Manifest{}, err
+	}
+	keystoreSigner := hex.EncodeToString(backup.SigningKey(staticKey).PubKey().SerializeCompressed())
+	if keystoreSigner != manifest.Signer {
+		return backup.Manifest{}, errors.New("the backup was not signed by the Base whose keys it holds")
+	}
+	var settings map[string]string
+	if err := json.Unmarshal(encodedSettings, &settings); err != nil {
+		return backup.Manifest{}, errors.New("invalid settings in the backup")
+	}
+	for key, value := range settings {
+		if err := system.ValidateConfigSet(key, value); err != nil {
+			return backup.Manifest{}, err
+		}
+	}
+	systemFiles := []backup.File{}
+	for _, file := range files {
+		if file.Name == configKeystoreFile || file.Name == configSettingsFile {
+			continue
+		}
+		if err := system.ValidateConfigFile(file); err != nil {
+			return backup.Manifest{}, err
+		}
+		systemFiles = append(systemFiles, file)
+	}
+
+	middleware.backupMu.Lock()
+	defer middleware.backupMu.Unlock()
+	if err := restoreKeystore(keystore); err != nil {
+		return backup.Manifest{}, err
+	}
+	if err := middleware.backends.System.RestoreConfigFiles(systemFiles); err != nil {
+		return backup.Manifest{}, err
+	}
+	// All settings are applied, even if one fails, and they are applied in a fixed order.
+	var failed []string
+	for _, key := range system.ConfigKeys() {
+		value, ok := settings[key]
+		if !ok {
+			continue
+		}
+		if _, err := middleware.backends.System.ConfigSet(key, value); err != nil {
+			middleware.logger.Warning("Failed to apply a restored setting", "key", key, "error", err)
+			failed = append(failed, key)
+		}
+	}
+	if len(failed) > 0 {
+		return backup.Manifest{}, errors.New("the configuration was restored, but applying the settings " +
+			strings.Join(failed, ", ") + " failed")
+	}
+	middleware.logger.Info("Restored the configuration from a backup", "created", manifest.Created)
+	return manifest, nil
+}
+
+// backupDrivePath returns the path of a backup on the backup drive.
+func (middleware *Middleware) backupDrivePath(filename string) (string, error) {
+	if filename != filepath.Base(filename) || !strings.HasSuffix(filename, backup.Extension) {
+		return "", errors.New("invalid backup filename " + filename)
+	}
+	dir, err := middleware.backends.System.BackupDrive()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, filename), nil
+}
+
+// SaveBackup writes a backup to the backup drive and returns its path.
+func (middleware *Middleware) SaveBackup(archive backup.Archive) (string, error) {
+	path, err := middleware.backupDrivePath(archive.Filename)
+	if err != nil {
+		return "", err
+	}
+	err = system.WriteFile(path, func(writer io.Writer) error {
+		_, err := writer.Write(archive.Data)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// ReadBackup reads a backup from the backup drive.
+func (middleware *Middleware) ReadBackup(filename string) ([]byte, error) {
+	path, err := middleware.backupDrivePath(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(path)
+}
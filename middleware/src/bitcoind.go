@@ -0,0 +1,219 @@
+package middleware
+
+import (
+	"errors"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/logging"
+)
+
+const (
+	bitcoindMinBackoff = time.Second
+	bitcoindMaxBackoff = time.Minute
+	// bitcoindTimeout limits how long a call waits for bitcoind.
+	bitcoindTimeout = 30 * time.Second
+)
+
+// errBitcoindUnreachable is returned while waiting to reconnect to bitcoind after a failure.
+var errBitcoindUnreachable = errors.New("bitcoind unreachable")
+
+// bitcoindClient is a long-lived bitcoind rpc client. It authenticates with the cookie file of bitcoind if one is
+// configured, reloads the cookie when bitcoind rejects it, e.g. after a restart, and backs off exponentially while
+// bitcoind is unreachable. It is safe for concurrent use.
+type bitcoindClient struct {
+	host       string
+	user       string
+	password   string
+	cookiePath string
+	timeout    time.Duration
+	logger     *logging.Logger
+
+	mu     sync.Mutex
+	client *rpcclient.Client
+	// credentials are the user and password the client was created with.
+	credentials string
+	failures    uint
+	nextAttempt time.Time
+}
+
+// newBitcoindClient returns a client for the bitcoind rpc at host. If cookiePath is not empty, the client authenticates
+// with the cookie file at that path instead of the user and password.
+func newBitcoindClient(logger *logging.Logger, host, user, password, cookiePath string) *bitcoindClient {
+	return &bitcoindClient{
+		logger:     logger,
+		host:       host,
+		user:       user,
+		password:   password,
+		cookiePath: cookiePath,
+		timeout:    bitcoindTimeout,
+	}
+}
+
+// configure changes the connection settings. The next call connects with the new settings right away.
+func (bitcoind *bitcoindClient) configure(host, user, password, cookiePath string) {
+	bitcoind.mu.Lock()
+	defer bitcoind.mu.Unlock()
+	if host == bitcoind.host && user == bitcoind.user && password == bitcoind.password && cookiePath == bitcoind.cookiePath {
+		return
+	}
+	bitcoind.host = host
+	bitcoind.user = user
+	bitcoind.password = password
+	bitcoind.cookiePath = cookiePath
+	bitcoind.failures = 0
+	bitcoind.nextAttempt = time.Time{}
+	bitcoind.reset()
+}
+
+// readCredentials returns the user and password to authenticate with, read from the cookie file if configured.
+func (bitcoind *bitcoindClient) readCredentials() (string, string, error) {
+	if bitcoind.cookiePath == "" {
+		return bitcoind.user, bitcoind.password, nil
+	}
+	cookie, err := ioutil.ReadFile(bitcoind.cookiePath)
+	if err != nil {
+		return "", "", err
+	}
+	credentials := strings.SplitN(strings.TrimSpace(string(cookie)), ":", 2)
+	if len(credentials) != 2 {
+		return "", "", errors.New("invalid bitcoind cookie file " + bitcoind.cookiePath)
+	}
+	return credentials[0], credentials[1], nil
+}
+
+// connect creates the rpc client, unless it exists already and the credentials did not change. It must be called
+// with mu held.
+func (bitcoind *bitcoindClient) connect() error {
+	user, password, err := bitcoind.readCredentials()
+	if err != nil {
+		return err
+	}
+	if bitcoind.client != nil && bitcoind.credentials == user+":"+password {
+		return nil
+	}
+	bitcoind.reset()
+	client, err := rpcclient.New(&rpcclient.ConnConfig{
+		HTTPPostMode: true,
+		DisableTLS:   true,
+		Host:         bitcoind.host,
+		User:         user,
+		Pass:         password,
+	}, nil)
+	if err != nil {
+		return err
+	}
+	bitcoind.client = client
+	bitcoind.credentials = user + ":" + password
+	return nil
+}
+
+// reset shuts the rpc client down, so that the next call creates a new one. It must be called with mu held.
+func (bitcoind *bitcoindClient) reset() {
+	if bitcoind.client != nil {
+		bitcoind.client.Shutdown()
+		bitcoind.client = nil
+	}
+}
+
+// isUnauthorized returns true if bitcoind rejected the credentials. rpcclient reports http errors in post mode only
+// with their status code in the message.
+func isUnauthorized(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "status code: 401,")
+}
+
+// current returns the rpc client to call, connecting if needed, and whether the cookie is reloaded on authentication
+// failures. It fails with errBitcoindUnreachable until the backoff delay passed.
+func (bitcoind *bitcoindClient) current() (*rpcclient.Client, bool, error) {
+	bitcoind.mu.Lock()
+	defer bitcoind.mu.Unlock()
+	if time.Now().Before(bitcoind.nextAttempt) {
+		return nil, false, errBitcoindUnreachable
+	}
+	if err := bitcoind.connect(); err != nil {
+		return nil, false, err
+	}
+	return bitcoind.client, bitcoind.cookiePath != "", nil
+}
+
+// reconnect replaces the rpc client with one using the reloaded cookie, unless another call replaced it already.
+func (bitcoind *bitcoindClient) reconnect(client *rpcclient.Client) (*rpcclient.Client, error) {
+	bitcoind.mu.Lock()
+	defer bitcoind.mu.Unlock()
+	if bitcoind.client == client {
+		bitcoind.reset()
+	}
+	if err := bitcoind.connect(); err != nil {
+		return nil, err
+	}
+	return bitcoind.client, nil
+}
+
+// run runs the rpc call with the client, giving up after the timeout. rpcclient has no timeouts, so a call to a hanging
+// bitcoind keeps running in the background until the client is shut down and bitcoind closes the connection.
+func (bitcoind *bitcoindClient) run(client *rpcclient.Client, rpc func(client *rpcclient.Client) error) error {
+	bitcoind.mu.Lock()
+	timeout := bitcoind.timeout
+	bitcoind.mu.Unlock()
+	result := make(chan error, 1)
+	go func() {
+		result <- rpc(client)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-result:
+		return err
+	case <-timer.C:
+		return errors.New("bitcoind did not answer within " + timeout.String())
+	}
+}
+
+// failed records the result of a call with the client. Errors returned by bitcoind itself, like during warmup, do not
+// count as failures. Otherwise the client is reset and further calls fail with errBitcoindUnreachable until the
+// backoff delay passed, unless the client was replaced in the meantime.
+func (bitcoind *bitcoindClient) failed(client *rpcclient.Client, err error) {
+	bitcoind.mu.Lock()
+	defer bitcoind.mu.Unlock()
+	if _, ok := err.(*btcjson.RPCError); err == nil || ok {
+		bitcoind.failures = 0
+		return
+	}
+	if client != nil && client != bitcoind.client {
+		return
+	}
+	bitcoind.reset()
+	backoff := bitcoindMinBackoff << bitcoind.failures
+	if backoff > bitcoindMaxBackoff || backoff <= 0 {
+		backoff = bitcoindMaxBackoff
+	} else {
+		bitcoind.failures++
+	}
+	bitcoind.nextAttempt = time.Now().Add(backoff)
+}
+
+// call runs the rpc call with the client. The lock is only held to get or replace the client, so that a hanging
+// bitcoind only delays the calls until their timeout. If bitcoind rejects the credentials, the cookie is reloaded and
+// the call is retried once.
+func (bitcoind *bitcoindClient) call(rpc func(client *rpcclient.Client) error) error {
+	client, reloadCookie, err := bitcoind.current()
+	if err == errBitcoindUnreachable {
+		return err
+	}
+	if err == nil {
+		err = bitcoind.run(client, rpc)
+		if isUnauthorized(err) && reloadCookie {
+			// bitcoind writes a new cookie when it restarts.
+			bitcoind.logger.Info("bitcoind rejected the cookie, reloading it", "error", err)
+			if client, err = bitcoind.reconnect(client); err == nil {
+				err = bitcoind.run(client, rpc)
+			}
+		}
+	}
+	bitcoind.failed(client, err)
+	return err
+}
@@ -0,0 +1,628 @@
+// Package client implements a Go client for the noise encrypted protobuf api of the BitBox Base middleware. It takes
+// care of connecting over websockets, TCP or Unix sockets, the noise handshake including pairing, the chunked framing of
+// messages and reconnecting with the fast IK handshake.
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/digitalbitbox/bitbox-base/middleware/src/framing"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/logging"
+	basemessages "github.com/digitalbitbox/bitbox-base/middleware/src/messages"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/transport"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// maxMessageSize is the size limit for incoming messages from the BitBox Base.
+	maxMessageSize = 1 << 20
+	// eventsBufferSize is the number of events that are buffered before new events are dropped.
+	eventsBufferSize  = 32
+	minReconnectDelay = time.Second
+	maxReconnectDelay = time.Minute
+	resyncTimeout     = 30 * time.Second
+)
+
+// ErrClosed is returned by requests on a closed client, or if the connection was lost while waiting for a response.
+var ErrClosed = errors.New("the connection to the BitBox Base is closed")
+
+// Error is an error response of the BitBox Base to a request.
+type Error struct {
+	Message string
+}
+
+func (err *Error) Error() string {
+	return err.Message
+}
+
+// Config configures a client.
+type Config struct {
+	// Address of the BitBox Base middleware. Supported are websocket urls like ws://127.0.0.1:8845/ws, tcp://host:port
+	// and unix:///path/to/socket.
+	Address string
+	// DataDir is the directory where the static noise keypair of the client and the pinned static pubkey of the BitBox
+	// Base are stored.
+	DataDir string
+	// ConfirmPairing is called with the pairing code if the BitBox Base is not paired with this client yet, or if it
+	// presents a new static key. The user has to compare the code with the one shown by the BitBox Base. Returning an
+	// error aborts the connection. If nil, connecting fails with ErrPairingRequired in these cases.
+	ConfirmPairing func(pairingCode string) error
+	// Reconnect makes the client reconnect with exponential backoff if the connection is lost.
+	Reconnect bool
+	// TLSConfig is used for wss:// addresses. If nil, the system roots are trusted. The BitBox Base uses a self-signed
+	// certificate, which can be trusted with RootCAs.
+	TLSConfig *tls.Config
+	// Logger logs connection problems and dropped messages. If nil, logging.NewDefault(logging.LevelInfo) is used.
+	Logger *logging.Logger
+}
+
+// connection is an established noise session on top of a transport connection.
+type connection struct {
+	conn    transport.Conn
+	session *session
+	writer  *framing.Writer
+	// writeMu serializes writes, as the framing writer and the noise send cipher must not be used concurrently.
+	writeMu sync.Mutex
+}
+
+// responseKind is what a request expects in response.
+type responseKind int
+
+const (
+	// responseSingle requests are answered with a single message.
+	responseSingle responseKind = iota
+	// responseStream requests receive all matching messages until they are removed.
+	responseStream
+	// responseAttachment requests are answered with a single message followed by an attachment.
+	responseAttachment
+)
+
+// pendingRequest waits for the first incoming message that matches, or for an error response to the request. Stream
+// requests receive all matching messages until they are removed.
+type pendingRequest struct {
+	// id is the RequestId of the request, which the BitBox Base sends back with every response.
+	id uint64
+	// field is the field number of the request in the BitBoxBaseIn oneof, used to match error responses.
+	field    int32
+	match    func(*basemessages.BitBoxBaseOut) bool
+	stream   bool
+	response chan *basemessages.BitBoxBaseOut
+	// attachment receives the attachment that follows the response, for requests that expect one. It is closed if the
+	// connection is lost before. The reading loop waits until done is closed before reading the next message.
+	attachment chan io.Reader
+	// done is closed when the caller stops waiting for responses.
+	done chan struct{}
+}
+
+// matches returns true if the incoming message is a response to the request. Responses carry the id of their request,
+// messages without one, like the state sent on a resync or responses of older versions of the BitBox Base, are matched
+// by their type.
+func (request *pendingRequest) matches(outgoing *basemessages.BitBoxBaseOut) bool {
+	if outgoing.RequestId != 0 {
+		return outgoing.RequestId == request.id
+	}
+	if baseError := outgoing.GetBaseErrorOut(); baseError != nil {
+		return baseError.RequestField == request.field
+	}
+	return request.match(outgoing)
+}
+
+// Client is a connection to the BitBox Base middleware. It is safe for concurrent use.
+type Client struct {
+	config   Config
+	keystore *keystore
+	logger   *logging.Logger
+
+	mu         sync.Mutex
+	connection *connection
+	// lastRequestID is the RequestId of the last request sent.
+	lastRequestID uint64
+	// connected is closed once a connection is established, and replaced when the connection is lost.
+	connected chan struct{}
+	pending   []*pendingRequest
+
+	// state is the last known state of the base, merged from the snapshot and the patches sent by the base.
+	state *basemessages.BaseState
+	// stateVersion is the version of state. Patches only apply to the version they are based on.
+	stateVersion uint64
+	// resyncing is true while a resync of the state is requested.
+	resyncing bool
+	// stateReceived is closed once the first snapshot was received.
+	stateReceived chan struct{}
+
+	events    chan *basemessages.BitBoxBaseOut
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// Dial connects to the BitBox Base middleware and performs the noise handshake. The context only limits the initial
+// connection attempt.
+func Dial(ctx context.Context, config Config) (*Client, error) {
+	logger := config.Logger
+	if logger == nil {
+		logger = logging.NewDefault(logging.LevelInfo)
+	}
+	client := &Client{
+		logger:        logger,
+		config:        config,
+		keystore:      newKeystore(config.DataDir),
+		connected:     make(chan struct{}),
+		stateReceived: make(chan struct{}),
+		events:        make(chan *basemessages.BitBoxBaseOut, eventsBufferSize),
+		closed:        make(chan struct{}),
+	}
+	connection, err := client.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	client.setConnection(connection)
+	return client, nil
+}
+
+// dial opens the transport connection to the configured address.
+func dial(ctx context.Context, address string, tlsConfig *tls.Config) (transport.Conn, error) {
+	parsed, err := url.Parse(address)
+	if err != nil {
+		return nil, errors.New("invalid BitBox Base address " + address)
+	}
+	switch parsed.Scheme {
+	case "ws", "wss":
+		dialer := *websocket.DefaultDialer
+		dialer.TLSClientConfig = tlsConfig
+		ws, _, err := dialer.DialContext(ctx, address, nil)
+		if err != nil {
+			return nil, err
+		}
+		return transport.NewWebsocketConn(ws), nil
+	case "tcp":
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", parsed.Host)
+		if err != nil {
+			return nil, err
+		}
+		return transport.NewStreamConn(conn), nil
+	case "unix":
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "unix", parsed.Path)
+		if err != nil {
+			return nil, err
+		}
+		return transport.NewStreamConn(conn), nil
+	default:
+		return nil, errors.New("unsupported BitBox Base address scheme " + parsed.Scheme)
+	}
+}
+
+// connect dials the BitBox Base and performs the noise handshake. The connection is closed if ctx is done before the
+// handshake completes.
+func (client *Client) connect(ctx context.Context) (*connection, error) {
+	conn, err := dial(ctx, client.config.Address, client.config.TLSConfig)
+	if err != nil {
+		return nil, err
+	}
+	go keepalive(conn)
+	handshakeDone := make(chan struct{})
+	defer close(handshakeDone)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-handshakeDone:
+		}
+	}()
+	session, err := client.handshake(conn)
+	if err != nil {
+		_ = conn.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	connection := &connection{conn: conn, session: session}
+	connection.writer = framing.NewWriter(func(chunk []byte) error {
+		return conn.WriteMessage(session.sendCipher.Encrypt(nil, nil, chunk))
+	})
+	return connection, nil
+}
+
+// keepalive pings the BitBox Base until the connection is closed, so that the base does not drop the connection while
+// the client is idle, e.g. while the user compares the pairing code.
+func keepalive(conn transport.Conn) {
+	ticker := time.NewTicker(transport.PingInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := conn.Ping(); err != nil {
+			return
+		}
+	}
+}
+
+// setConnection makes connection the active connection and starts reading from it. The connection is closed instead if
+// the client was closed in the meantime.
+func (client *Client) setConnection(connection *connection) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	select {
+	case <-client.closed:
+		_ = connection.conn.Close()
+		return
+	default:
+	}
+	client.connection = connection
+	close(client.connected)
+	go client.readLoop(connection)
+}
+
+// readLoop dispatches incoming messages to pending requests and the events channel until the connection fails.
+func (client *Client) readLoop(connection *connection) {
+	reader := framing.NewReader(func() ([]byte, error) {
+		msg, err := connection.conn.ReadMessage()
+		if err != nil {
+			return nil, err
+		}
+		return connection.session.receiveCipher.Decrypt(nil, nil, msg)
+	})
+	var err error
+	for {
+		var message *framing.Message
+		message, err = reader.Next()
+		if err != nil {
+			break
+		}
+		if message.IsAttachment() {
+			client.logger.Warning("Received an attachment that no request is waiting for, discarding it")
+			continue
+		}
+		var data []byte
+		data, err = message.ReadAll(maxMessageSize)
+		if err != nil {
+			client.logger.Warning("Connection could not read incoming message", "error", err)
+			break
+		}
+		outgoing := &basemessages.BitBoxBaseOut{}
+		if err := proto.Unmarshal(data, outgoing); err != nil {
+			client.logger.Warning("Protobuf unmarshal of incoming packet failed", "error", err)
+			continue
+		}
+		if request := client.dispatch(outgoing); request != nil {
+			if err = receiveAttachment(reader, request); err != nil {
+				break
+			}
+		}
+	}
+	_ = connection.conn.Close()
+	client.connectionLost(err)
+}
+
+// dispatch hands the message to the pending request it answers, or to the events channel otherwise. Messages without
+// a request id go to the oldest matching request. It returns the request if it expects an attachment to follow the
+// message.
+func (client *Client) dispatch(outgoing *basemessages.BitBoxBaseOut) *pendingRequest {
+	client.mu.Lock()
+	if stateOut := outgoing.GetBaseStateOut(); stateOut != nil {
+		client.mergeState(stateOut)
+	}
+	for i, request := range client.pending {
+		if request.matches(outgoing) {
+			if !request.stream {
+				client.pending = append(client.pending[:i], client.pending[i+1:]...)
+			}
+			client.mu.Unlock()
+			// Stream requests that are not drained hold the reading loop, which applies backpressure to the base.
+			select {
+			case request.response <- outgoing:
+			case <-request.done:
+			}
+			if request.attachment == nil || outgoing.GetBaseErrorOut() != nil {
+				return nil
+			}
+			return request
+		}
+	}
+	client.mu.Unlock()
+	select {
+	case client.events <- outgoing:
+	default:
+		client.logger.Warning("Events channel is full, dropping event")
+	}
+	return nil
+}
+
+// receiveAttachment reads the attachment following the response to request and hands it to the request. It returns
+// once the caller is done with the attachment, the rest of it is discarded by the next read.
+func receiveAttachment(reader *framing.Reader, request *pendingRequest) error {
+	message, err := reader.Next()
+	if err != nil {
+		close(request.attachment)
+		return err
+	}
+	if !message.IsAttachment() {
+		close(request.attachment)
+		return errors.New("expected an attachment")
+	}
+	select {
+	case request.attachment <- message:
+	case <-request.done:
+		return nil
+	}
+	<-request.done
+	return nil
+}
+
+// mergeState applies a state message to the last known state. A patch that is not based on the version of the last
+// known state is dropped and a resync from that version is requested. It must be called with mu held.
+func (client *Client) mergeState(stateOut *basemessages.BaseStateOut) {
+	if stateOut.Snapshot {
+		client.state = proto.Clone(stateOut.GetState()).(*basemessages.BaseState)
+		client.stateVersion = stateOut.Version
+		select {
+		case <-client.stateReceived:
+		default:
+			close(client.stateReceived)
+		}
+		return
+	}
+	if client.state == nil {
+		return
+	}
+	if stateOut.BaseVersion != client.stateVersion {
+		if client.resyncing {
+			return
+		}
+		client.resyncing = true
+		client.logger.Info("State patch does not apply, resyncing", "patchVersion", stateOut.BaseVersion, "stateVersion", client.stateVersion)
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), resyncTimeout)
+			defer cancel()
+			if err := client.ResyncState(ctx); err != nil {
+				client.logger.Warning("Failed to resync the state", "error", err)
+			}
+			client.mu.Lock()
+			client.resyncing = false
+			client.mu.Unlock()
+		}()
+		return
+	}
+	for _, path := range stateOut.Paths {
+		if err := applyPath(client.state, stateOut.GetState(), path); err != nil {
+			client.logger.Warning("Failed to apply the state patch", "error", err)
+		}
+	}
+	client.stateVersion = stateOut.Version
+}
+
+// applyPath copies the field at path, like "bitcoind.blocks", from patch to state. The path segments are the json names
+// of the protobuf fields.
+func applyPath(state, patch proto.Message, path string) error {
+	target := reflect.ValueOf(state).Elem()
+	source := reflect.ValueOf(patch).Elem()
+	for _, name := range strings.Split(path, ".") {
+		index := -1
+		for i := 0; i < target.NumField(); i++ {
+			for _, option := range strings.Split(target.Type().Field(i).Tag.Get("protobuf"), ",") {
+				if option == "json="+name {
+					index = i
+				}
+			}
+		}
+		if index == -1 {
+			return errors.New("unknown state path " + path)
+		}
+		target = target.Field(index)
+		source = source.Field(index)
+		if target.Kind() != reflect.Ptr {
+			continue
+		}
+		// Descend into the message, the patch only carries the messages containing changed fields.
+		if source.IsNil() {
+			source = reflect.New(source.Type().Elem())
+		}
+		if target.IsNil() {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+		target = target.Elem()
+		source = source.Elem()
+	}
+	if target.Kind() == reflect.Struct {
+		return errors.New("state path " + path + " is not a field")
+	}
+	target.Set(source)
+	return nil
+}
+
+// connectionLost fails all pending requests and reconnects, if configured. The client does not reconnect if the base
+// closed the connection because the pairing was revoked.
+func (client *Client) connectionLost(err error) {
+	client.mu.Lock()
+	for _, request := range client.pending {
+		close(request.response)
+	}
+	client.pending = nil
+	client.connection = nil
+	client.connected = make(chan struct{})
+	client.mu.Unlock()
+
+	if closeErr, ok := err.(*transport.CloseError); ok && closeErr.Code == transport.CloseRevoked {
+		client.logger.Info("Connection revoked, not reconnecting", "error", closeErr)
+		client.Close()
+		return
+	}
+	if !client.config.Reconnect {
+		client.Close()
+		return
+	}
+	go client.reconnect()
+}
+
+// reconnect tries to connect again with exponential backoff until it succeeds or the client is closed.
+func (client *Client) reconnect() {
+	delay := minReconnectDelay
+	for {
+		select {
+		case <-client.closed:
+			return
+		case <-time.After(delay):
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			select {
+			case <-client.closed:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		connection, err := client.connect(ctx)
+		cancel()
+		if err == nil {
+			client.setConnection(connection)
+			return
+		}
+		if err == ErrPairingRequired {
+			client.logger.Error("Pairing required, giving up reconnecting", "error", err)
+			client.Close()
+			return
+		}
+		client.logger.Warning("Reconnecting to the BitBox Base failed", "error", err)
+		delay *= 2
+		if delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
+
+// Events returns the channel of messages that were not a response to a request, like the periodic
+// BaseMiddlewareInfoOut. Events are dropped if the channel is not drained.
+func (client *Client) Events() <-chan *basemessages.BitBoxBaseOut {
+	return client.events
+}
+
+// Request sends the request and waits for the first incoming message for which match returns true. If the base
+// responds with an error, it is returned as an *Error. If the client is reconnecting, Request waits for the new
+// connection until ctx is done.
+func (client *Client) Request(ctx context.Context, request *basemessages.BitBoxBaseIn, match func(*basemessages.BitBoxBaseOut) bool) (*basemessages.BitBoxBaseOut, error) {
+	pending, err := client.send(ctx, request, nil, match, responseSingle)
+	if err != nil {
+		return nil, err
+	}
+	defer client.removePending(pending)
+	return pending.next(ctx)
+}
+
+// send registers a pending request and sends the request, followed by the attachment if it is not nil.
+func (client *Client) send(ctx context.Context, request *basemessages.BitBoxBaseIn, attachment io.Reader, match func(*basemessages.BitBoxBaseOut) bool, kind responseKind) (*pendingRequest, error) {
+	client.mu.Lock()
+	client.lastRequestID++
+	id := client.lastRequestID
+	client.mu.Unlock()
+	data, err := proto.Marshal(request)
+	if err != nil {
+		return nil, errors.New("protobuf marshal of the request failed")
+	}
+	data = basemessages.AppendRequestID(data, id)
+	pending := &pendingRequest{
+		id:       id,
+		field:    basemessages.RPCField(request),
+		match:    match,
+		stream:   kind == responseStream,
+		response: make(chan *basemessages.BitBoxBaseOut, 1),
+		done:     make(chan struct{}),
+	}
+	if kind == responseAttachment {
+		pending.attachment = make(chan io.Reader)
+	}
+	connection, err := client.waitConnected(ctx, pending)
+	if err != nil {
+		return nil, err
+	}
+	connection.writeMu.Lock()
+	err = connection.writer.WriteMessage(data)
+	if err == nil && attachment != nil {
+		err = connection.writer.WriteAttachment(attachment)
+	}
+	connection.writeMu.Unlock()
+	if err != nil {
+		client.removePending(pending)
+		_ = connection.conn.Close()
+		return nil, err
+	}
+	return pending, nil
+}
+
+// next waits for the next response to the request.
+func (request *pendingRequest) next(ctx context.Context) (*basemessages.BitBoxBaseOut, error) {
+	select {
+	case response, ok := <-request.response:
+		if !ok {
+			return nil, ErrClosed
+		}
+		if baseError := response.GetBaseErrorOut(); baseError != nil {
+			return nil, &Error{Message: baseError.Message}
+		}
+		return response, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// waitConnected waits for an established connection and registers the pending request on it.
+func (client *Client) waitConnected(ctx context.Context, pending *pendingRequest) (*connection, error) {
+	for {
+		client.mu.Lock()
+		connection := client.connection
+		connected := client.connected
+		if connection != nil {
+			client.pending = append(client.pending, pending)
+			client.mu.Unlock()
+			return connection, nil
+		}
+		client.mu.Unlock()
+		select {
+		case <-connected:
+		case <-client.closed:
+			return nil, ErrClosed
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (client *Client) removePending(pending *pendingRequest) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	select {
+	case <-pending.done:
+		return
+	default:
+		close(pending.done)
+	}
+	for i, request := range client.pending {
+		if request == pending {
+			client.pending = append(client.pending[:i], client.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// Close closes the connection and stops reconnecting. Pending requests fail with ErrClosed.
+func (client *Client) Close() {
+	client.closeOnce.Do(func() {
+		client.mu.Lock()
+		close(client.closed)
+		connection := client.connection
+		client.mu.Unlock()
+		if connection != nil {
+			_ = connection.conn.Close()
+		}
+	})
+}
@@ -0,0 +1,623 @@
+package client_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	middleware "github.com/digitalbitbox/bitbox-base/middleware/src"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/client"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/handlers"
+	basemessages "github.com/digitalbitbox/bitbox-base/middleware/src/messages"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/simulation"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/system"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testEnvironment returns the environment the tests run the middleware with.
+func testEnvironment() system.Environment {
+	return system.Environment{
+		Network:            "testnet",
+		ElectrsRPCPort:     "18442",
+		BitcoinRPCUser:     "user",
+		BitcoinRPCPassword: "password",
+		BitcoinRPCPort:     "8332",
+		LightningRPCPath:   "/home/bitcoin/.lightning",
+		DataDir:            ".base",
+	}
+}
+
+// testMiddleware returns a middleware running against simulated backends.
+func testMiddleware() *middleware.Middleware {
+	return middleware.NewMiddlewareWithBackends(testEnvironment(), simulation.New(system.NetworkTestnet).Backends())
+}
+
+// serve starts a middleware serving the noise api over TCP and returns its address.
+func serve(t *testing.T, dataDir string) string {
+	middlewareInstance := testMiddleware()
+	handlers := handlers.NewHandlers(middlewareInstance, dataDir)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = handlers.Serve(listener)
+	}()
+	return "tcp://" + listener.Addr().String()
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "bbb-client")
+	require.NoError(t, err)
+	return dir
+}
+
+func TestClient(t *testing.T) {
+	serverDir := tempDir(t)
+	defer os.RemoveAll(serverDir)
+	clientDir := tempDir(t)
+	defer os.RemoveAll(clientDir)
+	address := serve(t, serverDir)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Without a pairing callback, connecting to an unpaired base fails.
+	_, err := client.Dial(ctx, client.Config{Address: address, DataDir: clientDir})
+	require.Equal(t, client.ErrPairingRequired, err)
+
+	// Rejecting the pairing code aborts the connection.
+	errRejected := errors.New("rejected")
+	_, err = client.Dial(ctx, client.Config{
+		Address:        address,
+		DataDir:        clientDir,
+		ConfirmPairing: func(string) error { return errRejected },
+	})
+	require.Equal(t, errRejected, err)
+
+	var pairingCode string
+	baseClient, err := client.Dial(ctx, client.Config{
+		Address: address,
+		DataDir: clientDir,
+		ConfirmPairing: func(code string) error {
+			pairingCode = code
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, pairingCode, 23)
+	systemEnv, err := baseClient.SystemEnv(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "testnet", systemEnv.GetNetwork())
+	baseClient.Close()
+	_, err = baseClient.SystemEnv(ctx)
+	require.Equal(t, client.ErrClosed, err)
+
+	// A paired client reconnects with the IK handshake without asking for the pairing again.
+	baseClient, err = client.Dial(ctx, client.Config{Address: address, DataDir: clientDir})
+	require.NoError(t, err)
+	systemEnv, err = baseClient.SystemEnv(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "18442", systemEnv.GetElectrsRPCPort())
+	// The base sends a snapshot of its state right after connecting.
+	state, version, err := baseClient.State(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "testnet", state.GetSystem().GetNetwork())
+	// Resyncing from the current version keeps the state.
+	require.NoError(t, baseClient.ResyncState(ctx))
+	state, resyncedVersion, err := baseClient.State(ctx)
+	require.NoError(t, err)
+	require.True(t, resyncedVersion >= version)
+	require.Equal(t, "testnet", state.GetSystem().GetNetwork())
+	baseClient.Close()
+
+	// A base with a new static key requires a new pairing.
+	newServerDir := tempDir(t)
+	defer os.RemoveAll(newServerDir)
+	newAddress := serve(t, newServerDir)
+	_, err = client.Dial(ctx, client.Config{Address: newAddress, DataDir: clientDir})
+	require.Equal(t, client.ErrPairingRequired, err)
+	pairingCode = ""
+	baseClient, err = client.Dial(ctx, client.Config{
+		Address: newAddress,
+		DataDir: clientDir,
+		ConfirmPairing: func(code string) error {
+			pairingCode = code
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, pairingCode)
+	baseClient.Close()
+}
+
+func TestRequests(t *testing.T) {
+	serverDir := tempDir(t)
+	defer os.RemoveAll(serverDir)
+	clientDir := tempDir(t)
+	defer os.RemoveAll(clientDir)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	baseClient, err := client.Dial(ctx, client.Config{
+		Address:        serve(t, serverDir),
+		DataDir:        clientDir,
+		ConfirmPairing: func(string) error { return nil },
+	})
+	require.NoError(t, err)
+	defer baseClient.Close()
+
+	// Errors of the base are matched to the failed request.
+	_, err = baseClient.ConfigGet(ctx, "root_pw")
+	require.IsType(t, &client.Error{}, err)
+	_, err = baseClient.ConfigSet(ctx, "tor_ssh", "maybe")
+	require.Equal(t, &client.Error{Message: "setting tor_ssh can only be set to true or false"}, err)
+	err = baseClient.Logs(ctx, "sshd", 10, false, func(string) {})
+	require.IsType(t, &client.Error{}, err)
+	err = baseClient.LogEntries(ctx, &basemessages.BaseLogsIn{Unit: "bitcoind", Lines: 10, Priority: "loud"},
+		func(*basemessages.BaseLogEntry) {})
+	require.IsType(t, &client.Error{}, err)
+	entries := 0
+	err = baseClient.LogEntries(ctx, &basemessages.BaseLogsIn{Unit: "bitcoind", Lines: 10, Priority: "err"},
+		func(*basemessages.BaseLogEntry) { entries++ })
+	require.NoError(t, err)
+	require.Equal(t, 0, entries)
+
+	// Updates are streamed as an attachment and staged in the data dir of the base.
+	update := make([]byte, 3*65536+17)
+	_, err = rand.Read(update)
+	require.NoError(t, err)
+	updateFile := filepath.Join(clientDir, "update.bin")
+	require.NoError(t, ioutil.WriteFile(updateFile, update, 0600))
+	path, err := baseClient.Update(ctx, updateFile)
+	require.NoError(t, err)
+	staged, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, update, staged)
+
+	// Support bundles are streamed back as an attachment.
+	var bundle bytes.Buffer
+	filename, err := baseClient.SupportBundle(ctx, &bundle)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(filename, "bitbox-base-support-"))
+	gzipReader, err := gzip.NewReader(&bundle)
+	require.NoError(t, err)
+	header, err := tar.NewReader(gzipReader).Next()
+	require.NoError(t, err)
+	require.Equal(t, "version.json", header.Name)
+
+	// Lightning backups are streamed back as an attachment and uploaded again to restore them.
+	var lightningBackup bytes.Buffer
+	backup, err := baseClient.LightningBackup(ctx, "correct horse battery", &lightningBackup)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(backup.GetFilename(), "lightning-testnet-"))
+	require.Equal(t, int64(lightningBackup.Len()), backup.GetSize())
+	backupFile := filepath.Join(clientDir, backup.GetFilename())
+	require.NoError(t, ioutil.WriteFile(backupFile, lightningBackup.Bytes(), 0600))
+	_, err = baseClient.LightningRestore(ctx, "wrong passphrase", backupFile)
+	require.Equal(t, &client.Error{Message: "wrong passphrase or corrupted backup"}, err)
+	restored, err := baseClient.LightningRestore(ctx, "correct horse battery", backupFile)
+	require.NoError(t, err)
+	require.Equal(t, backup.GetNodeId(), restored.GetNodeId())
+	require.Equal(t, "testnet", restored.GetNetwork())
+	_, err = baseClient.LightningBackupToDrive(ctx, "correct horse battery")
+	require.Equal(t, &client.Error{Message: "no backup drive is mounted"}, err)
+	_, err = baseClient.LightningRestoreFromDrive(ctx, "correct horse battery", backup.GetFilename())
+	require.Equal(t, &client.Error{Message: "no backup drive is mounted"}, err)
+
+	// The connection keeps working after the attachments.
+	systemEnv, err := baseClient.SystemEnv(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "testnet", systemEnv.GetNetwork())
+
+	health, err := baseClient.Health(ctx)
+	require.NoError(t, err)
+	require.Len(t, health.GetBackends(), 3)
+	require.Equal(t, "bitcoind", health.GetBackends()[0].GetName())
+}
+
+func TestConcurrentRequests(t *testing.T) {
+	serverDir := tempDir(t)
+	defer os.RemoveAll(serverDir)
+	clientDir := tempDir(t)
+	defer os.RemoveAll(clientDir)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	baseClient, err := client.Dial(ctx, client.Config{
+		Address:        serve(t, serverDir),
+		DataDir:        clientDir,
+		ConfirmPairing: func(string) error { return nil },
+	})
+	require.NoError(t, err)
+	defer baseClient.Close()
+	_, err = baseClient.ConfigSet(ctx, "hostname", "mybase")
+	require.NoError(t, err)
+
+	// Responses and errors of concurrent requests of the same rpc reach the request they answer.
+	errs := make(chan error, 80)
+	var requests sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		requests.Add(4)
+		go func() {
+			defer requests.Done()
+			value, err := baseClient.ConfigGet(ctx, "hostname")
+			if err == nil && value != "mybase" {
+				err = errors.New("unexpected hostname " + value)
+			}
+			errs <- err
+		}()
+		go func() {
+			defer requests.Done()
+			_, err := baseClient.ConfigGet(ctx, "root_pw")
+			if _, ok := err.(*client.Error); !ok {
+				err = fmt.Errorf("expected an error response for root_pw, got %v", err)
+			} else {
+				err = nil
+			}
+			errs <- err
+		}()
+		go func() {
+			defer requests.Done()
+			_, err := baseClient.ConfigSet(ctx, "hostname", "mybase")
+			errs <- err
+		}()
+		go func() {
+			defer requests.Done()
+			_, err := baseClient.ConfigSet(ctx, "tor_ssh", "maybe")
+			if !reflect.DeepEqual(err, &client.Error{Message: "setting tor_ssh can only be set to true or false"}) {
+				err = fmt.Errorf("expected an error response for tor_ssh, got %v", err)
+			} else {
+				err = nil
+			}
+			errs <- err
+		}()
+	}
+	requests.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+}
+
+func TestConfigBackup(t *testing.T) {
+	serverDir := tempDir(t)
+	defer os.RemoveAll(serverDir)
+	clientDir := tempDir(t)
+	defer os.RemoveAll(clientDir)
+	address := serve(t, serverDir)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	baseClient, err := client.Dial(ctx, client.Config{
+		Address:        address,
+		DataDir:        clientDir,
+		ConfirmPairing: func(string) error { return nil },
+	})
+	require.NoError(t, err)
+	defer baseClient.Close()
+
+	var configBackup bytes.Buffer
+	backup, err := baseClient.ConfigBackup(ctx, "correct horse battery", &configBackup)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(backup.GetFilename(), "config-testnet-"))
+	require.Len(t, backup.GetSigner(), 66)
+	backupFile := filepath.Join(clientDir, backup.GetFilename())
+	require.NoError(t, ioutil.WriteFile(backupFile, configBackup.Bytes(), 0600))
+	_, err = baseClient.ConfigRestore(ctx, "wrong passphrase", backup.GetSigner(), backupFile)
+	require.Equal(t, &client.Error{Message: "wrong passphrase or corrupted backup"}, err)
+	_, err = baseClient.ConfigRestore(ctx, "correct horse battery", strings.Repeat("0", 66), backupFile)
+	require.Equal(t, &client.Error{
+		Message: "the backup was signed by " + backup.GetSigner() + ", not by the confirmed signer",
+	}, err)
+
+	// After restoring, the base closes the connection, the restored pairing lets the client connect again.
+	restored, err := baseClient.ConfigRestore(ctx, "correct horse battery", backup.GetSigner(), backupFile)
+	require.NoError(t, err)
+	require.Equal(t, backup.GetSigner(), restored.GetSigner())
+	_, err = baseClient.SystemEnv(ctx)
+	require.Equal(t, client.ErrClosed, err)
+	baseClient, err = client.Dial(ctx, client.Config{Address: address, DataDir: clientDir})
+	require.NoError(t, err)
+	defer baseClient.Close()
+	systemEnv, err := baseClient.SystemEnv(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "testnet", systemEnv.GetNetwork())
+}
+
+func TestFactoryReset(t *testing.T) {
+	serverDir := tempDir(t)
+	defer os.RemoveAll(serverDir)
+	ownerDir := tempDir(t)
+	defer os.RemoveAll(ownerDir)
+	address := serve(t, serverDir)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	dial := func(dataDir string) *client.Client {
+		baseClient, err := client.Dial(ctx, client.Config{
+			Address:        address,
+			DataDir:        dataDir,
+			ConfirmPairing: func(string) error { return nil },
+		})
+		require.NoError(t, err)
+		return baseClient
+	}
+	owner := dial(ownerDir)
+	defer owner.Close()
+	tabletDir := tempDir(t)
+	defer os.RemoveAll(tabletDir)
+	tablet := dial(tabletDir)
+	defer tablet.Close()
+	_, err := tablet.RequestFactoryReset(ctx, false, false)
+	require.Equal(t, &client.Error{Message: "permission denied, BaseFactoryResetIn requires the owner role"}, err)
+
+	confirmation, err := owner.RequestFactoryReset(ctx, false, true)
+	require.NoError(t, err)
+	require.Len(t, confirmation.GetToken(), 32)
+	require.True(t, confirmation.GetExpires() > time.Now().Unix())
+	require.Len(t, confirmation.GetWarnings(), 2)
+	require.Contains(t, confirmation.GetWarnings()[1], "funds are lost without a lightning backup")
+	// The token only confirms the requested options, and a wrong attempt cancels it.
+	err = owner.ConfirmFactoryReset(ctx, confirmation.GetToken(), true, true)
+	require.Equal(t, &client.Error{
+		Message: "the factory reset was requested with other options, request a new confirmation token"}, err)
+	err = owner.ConfirmFactoryReset(ctx, confirmation.GetToken(), false, true)
+	require.Equal(t, &client.Error{Message: "invalid or expired confirmation token, request a new one"}, err)
+
+	confirmation, err = owner.RequestFactoryReset(ctx, false, false)
+	require.NoError(t, err)
+	require.Len(t, confirmation.GetWarnings(), 1)
+	require.NoError(t, owner.ConfirmFactoryReset(ctx, confirmation.GetToken(), false, false))
+	_, err = owner.SystemEnv(ctx)
+	require.Equal(t, client.ErrClosed, err)
+
+	// The Base has a new keypair, and the audit log starts over with the reset.
+	_, err = client.Dial(ctx, client.Config{Address: address, DataDir: ownerDir})
+	require.Equal(t, client.ErrPairingRequired, err)
+	newOwnerDir := tempDir(t)
+	defer os.RemoveAll(newOwnerDir)
+	newOwner := dial(newOwnerDir)
+	defer newOwner.Close()
+	auditLog, err := newOwner.AuditLog(ctx, 0, 0)
+	require.NoError(t, err)
+	require.Empty(t, auditLog.IntegrityError)
+	require.Equal(t, "factory-reset", auditLog.Entries[0].Action)
+	require.Equal(t, "blockchain false, lightning false", auditLog.Entries[0].Details)
+	// The previous owner failed to connect.
+	require.Equal(t, "auth-failed", auditLog.Entries[1].Action)
+	require.Equal(t, "pairing", auditLog.Entries[2].Action)
+	require.Equal(t, "role owner", auditLog.Entries[2].Details)
+}
+
+func TestPower(t *testing.T) {
+	serverDir := tempDir(t)
+	defer os.RemoveAll(serverDir)
+	simulated := simulation.New(system.NetworkTestnet)
+	baseHandlers := handlers.NewHandlers(
+		middleware.NewMiddlewareWithBackends(testEnvironment(), simulated.Backends()), serverDir)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = baseHandlers.Serve(listener)
+	}()
+	ownerDir := tempDir(t)
+	defer os.RemoveAll(ownerDir)
+	tabletDir := tempDir(t)
+	defer os.RemoveAll(tabletDir)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	dial := func(dataDir string) *client.Client {
+		baseClient, err := client.Dial(ctx, client.Config{
+			Address:        "tcp://" + listener.Addr().String(),
+			DataDir:        dataDir,
+			ConfirmPairing: func(string) error { return nil },
+		})
+		require.NoError(t, err)
+		return baseClient
+	}
+	owner := dial(ownerDir)
+	defer owner.Close()
+	tablet := dial(tabletDir)
+	defer tablet.Close()
+	err = tablet.Power(ctx, "shutdown", func(string) {})
+	require.Equal(t, &client.Error{Message: "permission denied, BasePowerIn requires the owner role"}, err)
+
+	// Maintenance reboots are scheduled, replaced and cancelled.
+	scheduled, err := owner.ScheduledReboot(ctx)
+	require.NoError(t, err)
+	require.True(t, scheduled.IsZero())
+	at := time.Now().Add(time.Hour).Truncate(time.Second)
+	scheduled, err = owner.ScheduleReboot(ctx, at)
+	require.NoError(t, err)
+	require.True(t, at.Equal(scheduled))
+	_, err = owner.ScheduleReboot(ctx, time.Now().Add(-time.Minute))
+	require.Equal(t, &client.Error{Message: "reboots can only be scheduled within the next 30 days"}, err)
+	require.NoError(t, owner.CancelReboot(ctx))
+	scheduled, err = owner.ScheduledReboot(ctx)
+	require.NoError(t, err)
+	require.True(t, scheduled.IsZero())
+	_, err = owner.ScheduleReboot(ctx, time.Now().Add(2*time.Second))
+	require.NoError(t, err)
+	for len(simulated.PowerActions()) == 0 {
+		select {
+		case <-ctx.Done():
+			t.Fatal("the scheduled reboot did not happen")
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	require.Equal(t, []system.PowerAction{system.PowerReboot}, simulated.PowerActions())
+	_, err = tablet.SystemEnv(ctx)
+	require.Equal(t, client.ErrClosed, err)
+
+	// Shutting down reports every step, lightningd is stopped before bitcoind.
+	owner = dial(ownerDir)
+	defer owner.Close()
+	steps := []string{}
+	require.NoError(t, owner.Power(ctx, "shutdown", func(step string) {
+		steps = append(steps, step)
+	}))
+	require.Equal(t, []string{"stopping lightningd", "stopping electrs", "stopping bitcoind", "shutdown"}, steps)
+	_, err = owner.SystemEnv(ctx)
+	require.Equal(t, client.ErrClosed, err)
+	require.Equal(t, []system.PowerAction{system.PowerReboot, system.PowerShutdown}, simulated.PowerActions())
+}
+
+func TestRoles(t *testing.T) {
+	serverDir := tempDir(t)
+	defer os.RemoveAll(serverDir)
+	address := serve(t, serverDir)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	dial := func() *client.Client {
+		clientDir := tempDir(t)
+		defer os.RemoveAll(clientDir)
+		baseClient, err := client.Dial(ctx, client.Config{
+			Address:        address,
+			DataDir:        clientDir,
+			ConfirmPairing: func(string) error { return nil },
+		})
+		require.NoError(t, err)
+		return baseClient
+	}
+
+	// The first paired client owns the Base, later ones can only view its status.
+	owner := dial()
+	defer owner.Close()
+	tablet := dial()
+	defer tablet.Close()
+	_, err := tablet.SystemEnv(ctx)
+	require.NoError(t, err)
+	_, err = tablet.ConfigGet(ctx, "hostname")
+	require.Equal(t, &client.Error{Message: "permission denied, BaseConfigGetIn requires the operator role"}, err)
+	_, err = tablet.PairedClients(ctx)
+	require.IsType(t, &client.Error{}, err)
+	updateFile := filepath.Join(serverDir, "update.bin")
+	require.NoError(t, ioutil.WriteFile(updateFile, []byte("update"), 0600))
+	_, err = tablet.Update(ctx, updateFile)
+	require.Equal(t, &client.Error{Message: "permission denied, BaseUpdateIn requires the owner role"}, err)
+	// The error response is not followed by an attachment.
+	_, err = tablet.SupportBundle(ctx, ioutil.Discard)
+	require.Equal(t, &client.Error{Message: "permission denied, BaseSupportBundleIn requires the operator role"}, err)
+
+	clients, err := owner.PairedClients(ctx)
+	require.NoError(t, err)
+	require.Len(t, clients, 2)
+	require.True(t, clients[0].GetSelf())
+	require.Equal(t, "owner", clients[0].GetRole())
+	require.Equal(t, "read-only", clients[1].GetRole())
+
+	// Role changes apply to open connections right away.
+	clients, err = owner.SetClientRole(ctx, clients[1].GetPubkey(), "operator")
+	require.NoError(t, err)
+	require.Equal(t, "operator", clients[1].GetRole())
+	_, err = tablet.ConfigGet(ctx, "hostname")
+	require.NotEqual(t, &client.Error{Message: "permission denied, BaseConfigGetIn requires the operator role"}, err)
+	_, err = owner.SetClientRole(ctx, clients[0].GetPubkey(), "read-only")
+	require.Equal(t, &client.Error{Message: "the last owner can not be demoted"}, err)
+	_, err = owner.SetClientRole(ctx, clients[1].GetPubkey(), "kid")
+	require.IsType(t, &client.Error{}, err)
+}
+
+func TestAuditLog(t *testing.T) {
+	serverDir := tempDir(t)
+	defer os.RemoveAll(serverDir)
+	address := serve(t, serverDir)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	dial := func() *client.Client {
+		clientDir := tempDir(t)
+		defer os.RemoveAll(clientDir)
+		baseClient, err := client.Dial(ctx, client.Config{
+			Address:        address,
+			DataDir:        clientDir,
+			ConfirmPairing: func(string) error { return nil },
+		})
+		require.NoError(t, err)
+		return baseClient
+	}
+	owner := dial()
+	defer owner.Close()
+	tablet := dial()
+	defer tablet.Close()
+	_, err := tablet.ConfigSet(ctx, "hostname", "evil")
+	require.IsType(t, &client.Error{}, err)
+	_, err = tablet.AuditLog(ctx, 0, 0)
+	require.Equal(t, &client.Error{Message: "permission denied, BaseAuditLogIn requires the owner role"}, err)
+	_, err = owner.ConfigSet(ctx, "hostname", "base")
+	require.NoError(t, err)
+	_, err = owner.ConfigSet(ctx, "bitcoin_network", "mainnet")
+	require.NoError(t, err)
+
+	auditLog, err := owner.AuditLog(ctx, 0, 0)
+	require.NoError(t, err)
+	require.Empty(t, auditLog.IntegrityError)
+	actions := []string{}
+	for _, entry := range auditLog.Entries {
+		actions = append(actions, entry.Action+" "+entry.Details)
+	}
+	require.Equal(t, []string{
+		"middleware-started ",
+		"pairing role owner",
+		"pairing role read-only",
+		"auth-failed permission denied for BaseConfigSetIn",
+		"auth-failed permission denied for BaseAuditLogIn",
+		"config-changed hostname=base",
+		"network-switched bitcoin_network=mainnet",
+	}, actions)
+	require.Equal(t, uint64(len(actions)), auditLog.Total)
+	require.Empty(t, auditLog.Entries[0].Client)
+	require.Equal(t, auditLog.Entries[1].Client, auditLog.Entries[5].Client)
+	require.NotEqual(t, auditLog.Entries[1].Client, auditLog.Entries[2].Client)
+
+	// The log is paginated by sequence number.
+	auditLog, err = owner.AuditLog(ctx, 5, 1)
+	require.NoError(t, err)
+	require.Len(t, auditLog.Entries, 1)
+	require.Equal(t, uint64(5), auditLog.Entries[0].Sequence)
+	_, err = owner.AuditLog(ctx, 0, 1001)
+	require.Equal(t, &client.Error{Message: "invalid number of audit log entries"}, err)
+}
+
+func TestLogLevel(t *testing.T) {
+	serverDir := tempDir(t)
+	defer os.RemoveAll(serverDir)
+	clientDir := tempDir(t)
+	defer os.RemoveAll(clientDir)
+	address := serve(t, serverDir)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	baseClient, err := client.Dial(ctx, client.Config{
+		Address:        address,
+		DataDir:        clientDir,
+		ConfirmPairing: func(string) error { return nil },
+	})
+	require.NoError(t, err)
+	defer baseClient.Close()
+
+	level, err := baseClient.LogLevel(ctx, "")
+	require.NoError(t, err)
+	require.Equal(t, "info", level)
+	level, err = baseClient.LogLevel(ctx, "Debug")
+	require.NoError(t, err)
+	require.Equal(t, "debug", level)
+	_, err = baseClient.LogLevel(ctx, "verbose")
+	require.IsType(t, &client.Error{}, err)
+	auditLog, err := baseClient.AuditLog(ctx, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, "log_level=debug", auditLog.Entries[len(auditLog.Entries)-1].Details)
+}
+
+func TestDialInvalidAddress(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	_, err := client.Dial(context.Background(), client.Config{Address: "http://127.0.0.1:8845", DataDir: dir})
+	require.Error(t, err)
+}
@@ -0,0 +1,182 @@
+package client
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+
+	"github.com/digitalbitbox/bitbox-base/middleware/src/transport"
+
+	"github.com/flynn/noise"
+)
+
+const (
+	opICanHasHandShaek          = "h"
+	opICanHasIKHandShaek        = "i"
+	opICanHasPairinVerificashun = "v"
+	responseSuccess             = "\x00"
+	responseNeedsPairing        = "\x01"
+	responseNeedsXXHandshake    = "\x02"
+)
+
+// ErrPairingRequired is returned if the BitBox Base requires a pairing, but no Config.ConfirmPairing callback is set.
+var ErrPairingRequired = errors.New("pairing with the BitBox Base is required")
+
+// session holds the noise ciphers of an established connection.
+type session struct {
+	sendCipher, receiveCipher *noise.CipherState
+}
+
+// handshake performs the noise handshake with the BitBox Base. If the client is paired and knows the static pubkey of
+// the base, the IK handshake is tried first, otherwise or if it is rejected, the XX handshake is performed.
+// After a XX handshake, the pairing is verified if the base asks for it or if the static pubkey of the base changed.
+func (client *Client) handshake(conn transport.Conn) (*session, error) {
+	cipherSuite := noise.NewCipherSuite(noise.DH25519, noise.CipherChaChaPoly, noise.HashSHA256)
+	keypair, err := client.keystore.clientKeypair(cipherSuite)
+	if err != nil {
+		return nil, err
+	}
+	pinnedPubkey := client.keystore.baseStaticPubkey()
+	if pinnedPubkey != nil {
+		session, err := handshakeIK(conn, cipherSuite, keypair, pinnedPubkey)
+		if err != nil || session != nil {
+			return session, err
+		}
+	}
+
+	session, handshake, pairingStatus, err := handshakeXX(conn, cipherSuite, keypair)
+	if err != nil {
+		return nil, err
+	}
+	baseStaticPubkey := handshake.PeerStatic()
+	if pairingStatus == responseSuccess && bytes.Equal(pinnedPubkey, baseStaticPubkey) {
+		return session, nil
+	}
+
+	// The base does not know this client or the base has a new static key, e.g. after a reset. In both cases the
+	// user has to compare the pairing code before the new static pubkey of the base is pinned.
+	if client.config.ConfirmPairing == nil {
+		return nil, ErrPairingRequired
+	}
+	if err := client.config.ConfirmPairing(pairingCode(handshake)); err != nil {
+		return nil, err
+	}
+	if err := conn.WriteMessage([]byte(opICanHasPairinVerificashun)); err != nil {
+		return nil, errors.New("connection failed to write the pairing verification request")
+	}
+	response, err := conn.ReadMessage()
+	if err != nil {
+		return nil, errors.New("connection failed to read the pairing verification response")
+	}
+	if string(response) != responseSuccess {
+		return nil, errors.New("the BitBox Base rejected the pairing")
+	}
+	if err := client.keystore.pinBaseStaticPubkey(baseStaticPubkey); err != nil {
+		return nil, errors.New("failed to store the static pubkey of the BitBox Base")
+	}
+	return session, nil
+}
+
+// handshakeIK tries the two part noise IK handshake. It returns a nil session without an error if the base rejected
+// the handshake, in which case the XX handshake can be performed on the same connection.
+func handshakeIK(conn transport.Conn, cipherSuite noise.CipherSuite, keypair noise.DHKey, baseStaticPubkey []byte) (*session, error) {
+	handshake, err := noise.NewHandshakeState(noise.Config{
+		CipherSuite:   cipherSuite,
+		Random:        rand.Reader,
+		Pattern:       noise.HandshakeIK,
+		StaticKeypair: keypair,
+		PeerStatic:    baseStaticPubkey,
+		Prologue:      []byte("Noise_IK_25519_ChaChaPoly_SHA256"),
+		Initiator:     true,
+	})
+	if err != nil {
+		return nil, errors.New("failed to generate a new noise IK handshake state")
+	}
+	msg, _, _, err := handshake.WriteMessage(nil, nil)
+	if err != nil {
+		return nil, errors.New("noise failed to write first noise IK handshake message")
+	}
+	if err := conn.WriteMessage(append([]byte(opICanHasIKHandShaek), msg...)); err != nil {
+		return nil, errors.New("connection failed to write first noise IK handshake message")
+	}
+	response, err := conn.ReadMessage()
+	if err != nil {
+		return nil, errors.New("connection failed to read second noise IK handshake message")
+	}
+	if string(response) == responseNeedsXXHandshake {
+		return nil, nil
+	}
+	payload, receiveCipher, sendCipher, err := handshake.ReadMessage(nil, response)
+	if err != nil {
+		return nil, errors.New("noise failed to read second noise IK handshake message")
+	}
+	if string(payload) != responseSuccess {
+		return nil, errors.New("unexpected noise IK handshake response")
+	}
+	return &session{sendCipher: sendCipher, receiveCipher: receiveCipher}, nil
+}
+
+// handshakeXX performs the three part noise XX handshake. Besides the session, it returns the handshake state to
+// derive the pairing code and the pairing status sent by the base.
+func handshakeXX(conn transport.Conn, cipherSuite noise.CipherSuite, keypair noise.DHKey) (*session, *noise.HandshakeState, string, error) {
+	handshake, err := noise.NewHandshakeState(noise.Config{
+		CipherSuite:   cipherSuite,
+		Random:        rand.Reader,
+		Pattern:       noise.HandshakeXX,
+		StaticKeypair: keypair,
+		Prologue:      []byte("Noise_XX_25519_ChaChaPoly_SHA256"),
+		Initiator:     true,
+	})
+	if err != nil {
+		return nil, nil, "", errors.New("failed to generate a new noise handshake state")
+	}
+	if err := conn.WriteMessage([]byte(opICanHasHandShaek)); err != nil {
+		return nil, nil, "", errors.New("connection failed to write noise handshake request")
+	}
+	response, err := conn.ReadMessage()
+	if err != nil || string(response) != responseSuccess {
+		return nil, nil, "", errors.New("the BitBox Base did not accept the noise handshake request")
+	}
+	msg, _, _, err := handshake.WriteMessage(nil, nil)
+	if err != nil {
+		return nil, nil, "", errors.New("noise failed to write first noise handshake message")
+	}
+	if err := conn.WriteMessage(msg); err != nil {
+		return nil, nil, "", errors.New("connection failed to write first noise handshake message")
+	}
+	response, err = conn.ReadMessage()
+	if err != nil {
+		return nil, nil, "", errors.New("connection failed to read second noise handshake message")
+	}
+	if _, _, _, err = handshake.ReadMessage(nil, response); err != nil {
+		return nil, nil, "", errors.New("noise failed to read second noise handshake message")
+	}
+	msg, receiveCipher, sendCipher, err := handshake.WriteMessage(nil, nil)
+	if err != nil {
+		return nil, nil, "", errors.New("noise failed to write third noise handshake message")
+	}
+	if err := conn.WriteMessage(msg); err != nil {
+		return nil, nil, "", errors.New("connection failed to write third noise handshake message")
+	}
+	pairingStatus, err := conn.ReadMessage()
+	if err != nil {
+		return nil, nil, "", errors.New("connection failed to read the pairing status")
+	}
+	if string(pairingStatus) != responseSuccess && string(pairingStatus) != responseNeedsPairing {
+		return nil, nil, "", errors.New("unexpected pairing status")
+	}
+	return &session{sendCipher: sendCipher, receiveCipher: receiveCipher}, handshake, string(pairingStatus), nil
+}
+
+// pairingCode derives the code from the channel binding that the user compares with the one shown by the BitBox Base.
+func pairingCode(handshake *noise.HandshakeState) string {
+	channelHashBase32 := base32.StdEncoding.EncodeToString(handshake.ChannelBinding())
+	return fmt.Sprintf(
+		"%s %s\n%s %s",
+		channelHashBase32[:5],
+		channelHashBase32[5:10],
+		channelHashBase32[10:15],
+		channelHashBase32[15:20])
+}
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"crypto/rand"
+	"errors"
+	"sync"
+
+	noisemanager "github.com/digitalbitbox/bitbox-base/middleware/src/noise"
+
+	"github.com/flynn/noise"
+)
+
+const keystoreFilename = "client.json"
+
+type noiseKeypair struct {
+	Private []byte `json:"private"`
+	Public  []byte `json:"public"`
+}
+
+// keystoreConfig is the persisted state of a client.
+type keystoreConfig struct {
+	ClientNoiseStaticKeypair *noiseKeypair `json:"clientNoiseStaticKeypair"`
+	BaseNoiseStaticPubkey    []byte        `json:"baseNoiseStaticPubkey"`
+}
+
+// keystore stores the noise static keypair of the client and the pinned static pubkey of the BitBox Base.
+type keystore struct {
+	file *noisemanager.File
+	mu   sync.Mutex
+}
+
+func newKeystore(dataDir string) *keystore {
+	return &keystore{file: noisemanager.NewFile(dataDir, keystoreFilename)}
+}
+
+func (keystore *keystore) read() *keystoreConfig {
+	if !keystore.file.Exists() {
+		return &keystoreConfig{}
+	}
+	var config keystoreConfig
+	if err := keystore.file.ReadJSON(&config); err != nil {
+		return &keystoreConfig{}
+	}
+	return &config
+}
+
+// clientKeypair returns the static keypair of the client. A new one is generated and stored if none exists yet.
+func (keystore *keystore) clientKeypair(cipherSuite noise.CipherSuite) (noise.DHKey, error) {
+	keystore.mu.Lock()
+	defer keystore.mu.Unlock()
+	config := keystore.read()
+	if config.ClientNoiseStaticKeypair != nil {
+		return noise.DHKey{
+			Private: config.ClientNoiseStaticKeypair.Private,
+			Public:  config.ClientNoiseStaticKeypair.Public,
+		}, nil
+	}
+	keypair, err := cipherSuite.GenerateKeypair(rand.Reader)
+	if err != nil {
+		return noise.DHKey{}, errors.New("failed to generate a new noise keypair")
+	}
+	config.ClientNoiseStaticKeypair = &noiseKeypair{
+		Private: keypair.Private,
+		Public:  keypair.Public,
+	}
+	if err := keystore.file.WriteJSON(config); err != nil {
+		return noise.DHKey{}, errors.New("failed to store the client noise keypair")
+	}
+	return keypair, nil
+}
+
+// baseStaticPubkey returns the pinned static pubkey of the BitBox Base, or nil if the client was never paired.
+func (keystore *keystore) baseStaticPubkey() []byte {
+	keystore.mu.Lock()
+	defer keystore.mu.Unlock()
+	return keystore.read().BaseNoiseStaticPubkey
+}
+
+// pinBaseStaticPubkey stores the static pubkey of the BitBox Base after a successful pairing.
+func (keystore *keystore) pinBaseStaticPubkey(pubkey []byte) error {
+	keystore.mu.Lock()
+	defer keystore.mu.Unlock()
+	config := keystore.read()
+	config.BaseNoiseStaticPubkey = pubkey
+	return keystore.file.WriteJSON(config)
+}
@@ -0,0 +1,638 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/digitalbitbox/bitbox-base/middleware/src/framing"
+	basemessages "github.com/digitalbitbox/bitbox-base/middleware/src/messages"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// SystemEnv requests the system environment of the BitBox Base.
+func (client *Client) SystemEnv(ctx context.Context) (*basemessages.BaseSystemEnvOut, error) {
+	response, err := client.Request(ctx,
+		&basemessages.BitBoxBaseIn{
+			BitBoxBaseIn: &basemessages.BitBoxBaseIn_BaseSystemEnvIn{
+				BaseSystemEnvIn: &basemessages.BaseSystemEnvIn{},
+			},
+		},
+		func(outgoing *basemessages.BitBoxBaseOut) bool {
+			return outgoing.GetBaseSystemEnvOut() != nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return response.GetBaseSystemEnvOut(), nil
+}
+
+// State returns the last known state of the BitBox Base and its version. The base sends a snapshot right after
+// connecting and patches whenever the state changes, State only waits until the snapshot was received.
+func (client *Client) State(ctx context.Context) (*basemessages.BaseState, uint64, error) {
+	select {
+	case <-client.stateReceived:
+	case <-client.closed:
+		return nil, 0, ErrClosed
+	case <-ctx.Done():
+		return nil, 0, ctx.Err()
+	}
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	return proto.Clone(client.state).(*basemessages.BaseState), client.stateVersion, nil
+}
+
+// ResyncState asks the base for the changes since the version of the last known state. The base answers with a patch,
+// or with a snapshot if it does not remember the changes since that version anymore.
+func (client *Client) ResyncState(ctx context.Context) error {
+	select {
+	case <-client.stateReceived:
+	case <-client.closed:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	client.mu.Lock()
+	version := client.stateVersion
+	client.mu.Unlock()
+	_, err := client.Request(ctx,
+		&basemessages.BitBoxBaseIn{
+			BitBoxBaseIn: &basemessages.BitBoxBaseIn_BaseStateResyncIn{
+				BaseStateResyncIn: &basemessages.BaseStateResyncIn{Version: version},
+			},
+		},
+		func(outgoing *basemessages.BitBoxBaseOut) bool {
+			stateOut := outgoing.GetBaseStateOut()
+			return stateOut != nil && (stateOut.Snapshot || stateOut.BaseVersion == version)
+		})
+	return err
+}
+
+// Services requests the state of the services running on the BitBox Base.
+func (client *Client) Services(ctx context.Context) ([]*basemessages.BaseServiceStatus, error) {
+	response, err := client.Request(ctx,
+		&basemessages.BitBoxBaseIn{
+			BitBoxBaseIn: &basemessages.BitBoxBaseIn_BaseServicesIn{
+				BaseServicesIn: &basemessages.BaseServicesIn{},
+			},
+		},
+		func(outgoing *basemessages.BitBoxBaseOut) bool {
+			return outgoing.GetBaseServicesOut() != nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return response.GetBaseServicesOut().Services, nil
+}
+
+// Health requests the detailed health of the middleware and the services it talks to.
+func (client *Client) Health(ctx context.Context) (*basemessages.BaseHealthOut, error) {
+	response, err := client.Request(ctx,
+		&basemessages.BitBoxBaseIn{
+			BitBoxBaseIn: &basemessages.BitBoxBaseIn_BaseHealthIn{
+				BaseHealthIn: &basemessages.BaseHealthIn{},
+			},
+		},
+		func(outgoing *basemessages.BitBoxBaseOut) bool {
+			return outgoing.GetBaseHealthOut() != nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return response.GetBaseHealthOut(), nil
+}
+
+// ConfigGet reads a setting of the BitBox Base, see bbb-config.sh for the available settings.
+func (client *Client) ConfigGet(ctx context.Context, key string) (string, error) {
+	response, err := client.Request(ctx,
+		&basemessages.BitBoxBaseIn{
+			BitBoxBaseIn: &basemessages.BitBoxBaseIn_BaseConfigGetIn{
+				BaseConfigGetIn: &basemessages.BaseConfigGetIn{Key: key},
+			},
+		},
+		func(outgoing *basemessages.BitBoxBaseOut) bool {
+			return outgoing.GetBaseConfigOut() != nil && outgoing.GetBaseConfigOut().Key == key
+		})
+	if err != nil {
+		return "", err
+	}
+	return response.GetBaseConfigOut().Value, nil
+}
+
+// ConfigSet changes a setting of the BitBox Base. It returns the output of the change.
+func (client *Client) ConfigSet(ctx context.Context, key, value string) (string, error) {
+	response, err := client.Request(ctx,
+		&basemessages.BitBoxBaseIn{
+			BitBoxBaseIn: &basemessages.BitBoxBaseIn_BaseConfigSetIn{
+				BaseConfigSetIn: &basemessages.BaseConfigSetIn{Key: key, Value: value},
+			},
+		},
+		func(outgoing *basemessages.BitBoxBaseOut) bool {
+			return outgoing.GetBaseConfigOut() != nil && outgoing.GetBaseConfigOut().Key == key
+		})
+	if err != nil {
+		return "", err
+	}
+	return response.GetBaseConfigOut().Value, nil
+}
+
+// PairedClients requests the clients paired with the BitBox Base and their roles. Only owners may list them.
+func (client *Client) PairedClients(ctx context.Context) ([]*basemessages.BasePairedClient, error) {
+	response, err := client.Request(ctx,
+		&basemessages.BitBoxBaseIn{
+			BitBoxBaseIn: &basemessages.BitBoxBaseIn_BasePairedClientsIn{
+				BasePairedClientsIn: &basemessages.BasePairedClientsIn{},
+			},
+		},
+		func(outgoing *basemessages.BitBoxBaseOut) bool {
+			return outgoing.GetBasePairedClientsOut() != nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return response.GetBasePairedClientsOut().Clients, nil
+}
+
+// SetClientRole changes the role of a paired client to owner, operator or read-only. Only owners may change roles. It
+// returns the updated paired clients.
+func (client *Client) SetClientRole(ctx context.Context, pubkey []byte, role string) ([]*basemessages.BasePairedClient, error) {
+	response, err := client.Request(ctx,
+		&basemessages.BitBoxBaseIn{
+			BitBoxBaseIn: &basemessages.BitBoxBaseIn_BaseSetClientRoleIn{
+				BaseSetClientRoleIn: &basemessages.BaseSetClientRoleIn{Pubkey: pubkey, Role: role},
+			},
+		},
+		func(outgoing *basemessages.BitBoxBaseOut) bool {
+			return outgoing.GetBasePairedClientsOut() != nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return response.GetBasePairedClientsOut().Clients, nil
+}
+
+// AuditLog requests up to limit entries of the audit log, starting with the entry with the sequence number offset.
+// Only owners may read the audit log. The response holds the total number of entries and reports if the log was
+// tampered with.
+func (client *Client) AuditLog(ctx context.Context, offset uint64, limit uint32) (*basemessages.BaseAuditLogOut, error) {
+	response, err := client.Request(ctx,
+		&basemessages.BitBoxBaseIn{
+			BitBoxBaseIn: &basemessages.BitBoxBaseIn_BaseAuditLogIn{
+				BaseAuditLogIn: &basemessages.BaseAuditLogIn{Offset: offset, Limit: limit},
+			},
+		},
+		func(outgoing *basemessages.BitBoxBaseOut) bool {
+			return outgoing.GetBaseAuditLogOut() != nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return response.GetBaseAuditLogOut(), nil
+}
+
+// LogLevel changes the log level of the middleware to debug, info, warning or error until it restarts, and returns
+// the current level. An empty level only requests the current one. Only owners may change the level.
+func (client *Client) LogLevel(ctx context.Context, level string) (string, error) {
+	response, err := client.Request(ctx,
+		&basemessages.BitBoxBaseIn{
+			BitBoxBaseIn: &basemessages.BitBoxBaseIn_BaseLogLevelIn{
+				BaseLogLevelIn: &basemessages.BaseLogLevelIn{Level: level},
+			},
+		},
+		func(outgoing *basemessages.BitBoxBaseOut) bool {
+			return outgoing.GetBaseLogLevelOut() != nil
+		})
+	if err != nil {
+		return "", err
+	}
+	return response.GetBaseLogLevelOut().Level, nil
+}
+
+// Logs requests the last lines log lines of a service and passes them to onLine. If follow is true, new log lines are
+// passed to onLine until ctx is done, otherwise Logs returns after the last requested line.
+func (client *Client) Logs(ctx context.Context, unit string, lines int, follow bool, onLine func(line string)) error {
+	return client.streamLogs(ctx, &basemessages.BaseLogsIn{Unit: unit, Lines: int32(lines), Follow: follow},
+		func(logs *basemessages.BaseLogsOut) {
+			for _, line := range logs.Lines {
+				onLine(line)
+			}
+		})
+}
+
+// LogEntries requests journal entries of a unit with their time and priority, as selected by request, and passes them
+// to onEntry. If request.Follow is set, new entries are passed to onEntry until ctx is done, otherwise LogEntries
+// returns after the last requested entry.
+func (client *Client) LogEntries(ctx context.Context, request *basemessages.BaseLogsIn, onEntry func(entry *basemessages.BaseLogEntry)) error {
+	structured := *request
+	structured.Structured = true
+	return client.streamLogs(ctx, &structured, func(logs *basemessages.BaseLogsOut) {
+		for _, entry := range logs.Entries {
+			onEntry(entry)
+		}
+	})
+}
+
+// streamLogs makes a logs request and passes the responses to onLogs until the end of the stream.
+func (client *Client) streamLogs(ctx context.Context, logsIn *basemessages.BaseLogsIn, onLogs func(logs *basemessages.BaseLogsOut)) error {
+	request := &basemessages.BitBoxBaseIn{
+		BitBoxBaseIn: &basemessages.BitBoxBaseIn_BaseLogsIn{BaseLogsIn: logsIn},
+	}
+	pending, err := client.send(ctx, request, nil, func(outgoing *basemessages.BitBoxBaseOut) bool {
+		return outgoing.GetBaseLogsOut() != nil
+	}, responseStream)
+	if err != nil {
+		return err
+	}
+	defer client.removePending(pending)
+	for {
+		response, err := pending.next(ctx)
+		if err != nil {
+			return err
+		}
+		onLogs(response.GetBaseLogsOut())
+		if response.GetBaseLogsOut().EndOfStream {
+			return nil
+		}
+	}
+}
+
+// Update uploads an update file to the BitBox Base, where it is staged for installation. It returns the path of the
+// staged file on the BitBox Base.
+func (client *Client) Update(ctx context.Context, filename string) (string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hasher := sha256.New()
+	size, err := io.Copy(hasher, file)
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	request := &basemessages.BitBoxBaseIn{
+		BitBoxBaseIn: &basemessages.BitBoxBaseIn_BaseUpdateIn{
+			BaseUpdateIn: &basemessages.BaseUpdateIn{Size: size, Sha256: hasher.Sum(nil)},
+		},
+	}
+	pending, err := client.send(ctx, request, file, func(outgoing *basemessages.BitBoxBaseOut) bool {
+		return outgoing.GetBaseUpdateOut() != nil
+	}, responseSingle)
+	if err != nil {
+		return "", err
+	}
+	defer client.removePending(pending)
+	response, err := pending.next(ctx)
+	if err != nil {
+		return "", err
+	}
+	return response.GetBaseUpdateOut().Path, nil
+}
+
+// SupportBundle requests a tar.gz archive with redacted diagnostics of the BitBox Base and writes it to writer, e.g. to
+// attach it to a support request. It returns the suggested filename of the archive. An error is returned if the
+// archive does not match its announced size and hash, writer may have received parts of it then.
+func (client *Client) SupportBundle(ctx context.Context, writer io.Writer) (string, error) {
+	request := &basemessages.BitBoxBaseIn{
+		BitBoxBaseIn: &basemessages.BitBoxBaseIn_BaseSupportBundleIn{
+			BaseSupportBundleIn: &basemessages.BaseSupportBundleIn{},
+		},
+	}
+	pending, err := client.send(ctx, request, nil, func(outgoing *basemessages.BitBoxBaseOut) bool {
+		return outgoing.GetBaseSupportBundleOut() != nil
+	}, responseAttachment)
+	if err != nil {
+		return "", err
+	}
+	defer client.removePending(pending)
+	response, err := pending.next(ctx)
+	if err != nil {
+		return "", err
+	}
+	bundle := response.GetBaseSupportBundleOut()
+	if err := receiveFile(ctx, pending, writer, bundle.Size, bundle.Sha256, "support bundle"); err != nil {
+		return "", err
+	}
+	return bundle.Filename, nil
+}
+
+// receiveFile writes the attachment of a response to writer. It returns an error if the attachment does not match the
+// announced size and hash, writer may have received parts of it then. what names the file in the errors.
+func receiveFile(ctx context.Context, pending *pendingRequest, writer io.Writer, size int64, hash []byte, what string) error {
+	var attachment io.Reader
+	select {
+	case reader, ok := <-pending.attachment:
+		if !ok {
+			return ErrClosed
+		}
+		attachment = reader
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return framing.CopyAttachment(writer, attachment, size, hash, what)
+}
+
+// LightningBackup requests a backup of the lightning node of the BitBox Base, encrypted with the passphrase, and writes
+// it to writer. It returns the description of the backup. An error is returned if the backup does not match its
+// announced size and hash, writer may have received parts of it then.
+func (client *Client) LightningBackup(ctx context.Context, passphrase string, writer io.Writer) (*basemessages.BaseLightningBackupOut, error) {
+	request := &basemessages.BitBoxBaseIn{
+		BitBoxBaseIn: &basemessages.BitBoxBaseIn_BaseLightningBackupIn{
+			BaseLightningBackupIn: &basemessages.BaseLightningBackupIn{Passphrase: passphrase},
+		},
+	}
+	pending, err := client.send(ctx, request, nil, func(outgoing *basemessages.BitBoxBaseOut) bool {
+		return outgoing.GetBaseLightningBackupOut() != nil
+	}, responseAttachment)
+	if err != nil {
+		return nil, err
+	}
+	defer client.removePending(pending)
+	response, err := pending.next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	backup := response.GetBaseLightningBackupOut()
+	if err := receiveFile(ctx, pending, writer, backup.Size, backup.Sha256, "backup"); err != nil {
+		return nil, err
+	}
+	return backup, nil
+}
+
+// LightningBackupToDrive makes the BitBox Base write a backup of its lightning node, encrypted with the passphrase, to
+// its backup drive. The Path of the returned description is the path of the backup on the BitBox Base.
+func (client *Client) LightningBackupToDrive(ctx context.Context, passphrase string) (*basemessages.BaseLightningBackupOut, error) {
+	request := &basemessages.BitBoxBaseIn{
+		BitBoxBaseIn: &basemessages.BitBoxBaseIn_BaseLightningBackupIn{
+			BaseLightningBackupIn: &basemessages.BaseLightningBackupIn{Passphrase: passphrase, ToDrive: true},
+		},
+	}
+	response, err := client.Request(ctx, request, func(outgoing *basemessages.BitBoxBaseOut) bool {
+		return outgoing.GetBaseLightningBackupOut() != nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response.GetBaseLightningBackupOut(), nil
+}
+
+// LightningRestore uploads a lightning backup to the BitBox Base and restores its lightning node from it. The BitBox
+// Base refuses backups of another network and does not replace a node with channels.
+func (client *Client) LightningRestore(ctx context.Context, passphrase string, filename string) (*basemessages.BaseLightningRestoreOut, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(data)
+	request := &basemessages.BitBoxBaseIn{
+		BitBoxBaseIn: &basemessages.BitBoxBaseIn_BaseLightningRestoreIn{
+			BaseLightningRestoreIn: &basemessages.BaseLightningRestoreIn{
+				Passphrase: passphrase,
+				Size:       int64(len(data)),
+				Sha256:     hash[:],
+			},
+		},
+	}
+	pending, err := client.send(ctx, request, bytes.NewReader(data), func(outgoing *basemessages.BitBoxBaseOut) bool {
+		return outgoing.GetBaseLightningRestoreOut() != nil
+	}, responseSingle)
+	if err != nil {
+		return nil, err
+	}
+	defer client.removePending(pending)
+	response, err := pending.next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return response.GetBaseLightningRestoreOut(), nil
+}
+
+// LightningRestoreFromDrive restores the lightning node of the BitBox Base from a backup on its backup drive, given
+// the filename of the backup.
+func (client *Client) LightningRestoreFromDrive(ctx context.Context, passphrase string, filename string) (*basemessages.BaseLightningRestoreOut, error) {
+	request := &basemessages.BitBoxBaseIn{
+		BitBoxBaseIn: &basemessages.BitBoxBaseIn_BaseLightningRestoreIn{
+			BaseLightningRestoreIn: &basemessages.BaseLightningRestoreIn{Passphrase: passphrase, DriveFilename: filename},
+		},
+	}
+	response, err := client.Request(ctx, request, func(outgoing *basemessages.BitBoxBaseOut) bool {
+		return outgoing.GetBaseLightningRestoreOut() != nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response.GetBaseLightningRestoreOut(), nil
+}
+
+// ConfigBackup requests a signed backup of the configuration of the BitBox Base, encrypted with the passphrase, and
+// writes it to writer. It returns the description of the backup. The backup holds the noise keystore of the BitBox
+// Base and its paired clients, the keys of its tor hidden services and its wifi credentials.
+func (client *Client) ConfigBackup(ctx context.Context, passphrase string, writer io.Writer) (*basemessages.BaseConfigBackupOut, error) {
+	request := &basemessages.BitBoxBaseIn{
+		BitBoxBaseIn: &basemessages.BitBoxBaseIn_BaseConfigBackupIn{
+			BaseConfigBackupIn: &basemessages.BaseConfigBackupIn{Passphrase: passphrase},
+		},
+	}
+	pending, err := client.send(ctx, request, nil, func(outgoing *basemessages.BitBoxBaseOut) bool {
+		return outgoing.GetBaseConfigBackupOut() != nil
+	}, responseAttachment)
+	if err != nil {
+		return nil, err
+	}
+	defer client.removePending(pending)
+	response, err := pending.next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	backup := response.GetBaseConfigBackupOut()
+	if err := receiveFile(ctx, pending, writer, backup.Size, backup.Sha256, "backup"); err != nil {
+		return nil, err
+	}
+	return backup, nil
+}
+
+// ConfigBackupToDrive makes the BitBox Base write a signed backup of its configuration, encrypted with the passphrase,
+// to its backup drive. The Path of the returned description is the path of the backup on the BitBox Base.
+func (client *Client) ConfigBackupToDrive(ctx context.Context, passphrase string) (*basemessages.BaseConfigBackupOut, error) {
+	request := &basemessages.BitBoxBaseIn{
+		BitBoxBaseIn: &basemessages.BitBoxBaseIn_BaseConfigBackupIn{
+			BaseConfigBackupIn: &basemessages.BaseConfigBackupIn{Passphrase: passphrase, ToDrive: true},
+		},
+	}
+	response, err := client.Request(ctx, request, func(outgoing *basemessages.BitBoxBaseOut) bool {
+		return outgoing.GetBaseConfigBackupOut() != nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response.GetBaseConfigBackupOut(), nil
+}
+
+// ConfigRestore uploads a configuration backup to the BitBox Base and restores its configuration from it, if it was
+// signed by signer, the Signer returned when it was made. The BitBox Base closes the connection after the response, as
+// it then uses the restored noise keypair.
+func (client *Client) ConfigRestore(ctx context.Context, passphrase string, signer string, filename string) (
+	*basemessages.BaseConfigRestoreOut, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(data)
+	request := &basemessages.BitBoxBaseIn{
+		BitBoxBaseIn: &basemessages.BitBoxBaseIn_BaseConfigRestoreIn{
+			BaseConfigRestoreIn: &basemessages.BaseConfigRestoreIn{
+				Passphrase: passphrase,
+				Size:       int64(len(data)),
+				Sha256:     hash[:],
+				Signer:     signer,
+			},
+		},
+	}
+	pending, err := client.send(ctx, request, bytes.NewReader(data), func(outgoing *basemessages.BitBoxBaseOut) bool {
+		return outgoing.GetBaseConfigRestoreOut() != nil
+	}, responseSingle)
+	if err != nil {
+		return nil, err
+	}
+	defer client.removePending(pending)
+	response, err := pending.next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return response.GetBaseConfigRestoreOut(), nil
+}
+
+// ConfigRestoreFromDrive restores the configuration of the BitBox Base from a backup on its backup drive signed by
+// signer, given the filename of the backup. The BitBox Base closes the connection after the response.
+func (client *Client) ConfigRestoreFromDrive(ctx context.Context, passphrase string, signer string, filename string) (
+	*basemessages.BaseConfigRestoreOut, error) {
+	request := &basemessages.BitBoxBaseIn{
+		BitBoxBaseIn: &basemessages.BitBoxBaseIn_BaseConfigRestoreIn{
+			BaseConfigRestoreIn: &basemessages.BaseConfigRestoreIn{
+				Passphrase:    passphrase,
+				DriveFilename: filename,
+				Signer:        signer,
+			},
+		},
+	}
+	response, err := client.Request(ctx, request, func(outgoing *basemessages.BitBoxBaseOut) bool {
+		return outgoing.GetBaseConfigRestoreOut() != nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response.GetBaseConfigRestoreOut(), nil
+}
+
+// RequestFactoryReset starts a factory reset of the BitBox Base, wiping the blockchain and the lightning node as well
+// if selected. It returns a confirmation token, when it expires and the warnings to show to the user before
+// confirming with ConfirmFactoryReset.
+func (client *Client) RequestFactoryReset(ctx context.Context, wipeBlockchain, wipeLightning bool) (*basemessages.BaseFactoryResetOut, error) {
+	request := &basemessages.BitBoxBaseIn{
+		BitBoxBaseIn: &basemessages.BitBoxBaseIn_BaseFactoryResetIn{
+			BaseFactoryResetIn: &basemessages.BaseFactoryResetIn{WipeBlockchain: wipeBlockchain, WipeLightning: wipeLightning},
+		},
+	}
+	response, err := client.Request(ctx, request, func(outgoing *basemessages.BitBoxBaseOut) bool {
+		return outgoing.GetBaseFactoryResetOut() != nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response.GetBaseFactoryResetOut(), nil
+}
+
+// ConfirmFactoryReset confirms a factory reset requested with RequestFactoryReset, with the same options. The BitBox
+// Base then wipes itself, closes the connection and reboots, the client has to pair again.
+func (client *Client) ConfirmFactoryReset(ctx context.Context, token string, wipeBlockchain, wipeLightning bool) error {
+	request := &basemessages.BitBoxBaseIn{
+		BitBoxBaseIn: &basemessages.BitBoxBaseIn_BaseFactoryResetIn{
+			BaseFactoryResetIn: &basemessages.BaseFactoryResetIn{
+				Token:          token,
+				WipeBlockchain: wipeBlockchain,
+				WipeLightning:  wipeLightning,
+			},
+		},
+	}
+	response, err := client.Request(ctx, request, func(outgoing *basemessages.BitBoxBaseOut) bool {
+		return outgoing.GetBaseFactoryResetOut() != nil
+	})
+	if err != nil {
+		return err
+	}
+	if !response.GetBaseFactoryResetOut().GetResetting() {
+		return errors.New("the BitBox Base did not confirm the factory reset")
+	}
+	return nil
+}
+
+// Power reboots or shuts down the BitBox Base, action is reboot or shutdown. progress is called with every step, like
+// stopping bitcoind, which can take minutes. Once Power returns, the BitBox Base closes the connection.
+func (client *Client) Power(ctx context.Context, action string, progress func(step string)) error {
+	request := &basemessages.BitBoxBaseIn{
+		BitBoxBaseIn: &basemessages.BitBoxBaseIn_BasePowerIn{
+			BasePowerIn: &basemessages.BasePowerIn{Action: action},
+		},
+	}
+	pending, err := client.send(ctx, request, nil, func(outgoing *basemessages.BitBoxBaseOut) bool {
+		return outgoing.GetBasePowerOut() != nil
+	}, responseStream)
+	if err != nil {
+		return err
+	}
+	defer client.removePending(pending)
+	for {
+		response, err := pending.next(ctx)
+		if err != nil {
+			return err
+		}
+		progress(response.GetBasePowerOut().Step)
+		if response.GetBasePowerOut().Done {
+			return nil
+		}
+	}
+}
+
+// powerSchedule sends a BasePowerIn request that is answered with the scheduled reboot, the zero time if there is
+// none.
+func (client *Client) powerSchedule(ctx context.Context, rpc *basemessages.BasePowerIn) (time.Time, error) {
+	request := &basemessages.BitBoxBaseIn{
+		BitBoxBaseIn: &basemessages.BitBoxBaseIn_BasePowerIn{BasePowerIn: rpc},
+	}
+	response, err := client.Request(ctx, request, func(outgoing *basemessages.BitBoxBaseOut) bool {
+		return outgoing.GetBasePowerOut() != nil
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	scheduled := response.GetBasePowerOut().ScheduledReboot
+	if scheduled == 0 {
+		return time.Time{}, nil
+	}
+	return time.Unix(scheduled, 0), nil
+}
+
+// ScheduleReboot schedules a maintenance reboot of the BitBox Base, within the next 30 days. It replaces an earlier
+// one, and is lost if the middleware restarts before.
+func (client *Client) ScheduleReboot(ctx context.Context, at time.Time) (time.Time, error) {
+	return client.powerSchedule(ctx, &basemessages.BasePowerIn{Action: "reboot", ScheduledAt: at.Unix()})
+}
+
+// CancelReboot cancels the scheduled reboot of the BitBox Base.
+func (client *Client) CancelReboot(ctx context.Context) error {
+	_, err := client.powerSchedule(ctx, &basemessages.BasePowerIn{CancelSchedule: true})
+	return err
+}
+
+// ScheduledReboot returns when the BitBox Base reboots for maintenance, the zero time if no reboot is scheduled.
+func (client *Client) ScheduledReboot(ctx context.Context) (time.Time, error) {
+	return client.powerSchedule(ctx, &basemessages.BasePowerIn{})
+}
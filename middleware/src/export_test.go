@@ -0,0 +1,48 @@
+package middleware
+
+import "time"
+
+// UpdateState exposes the state store to the tests.
+func (middleware *Middleware) UpdateState(change func(state *State)) {
+	middleware.state.update(change)
+}
+
+// RefreshBitcoind exposes a single bitcoind poll to the tests.
+func (middleware *Middleware) RefreshBitcoind() {
+	middleware.refreshBitcoind()
+}
+
+// Poll exposes a single poll of all services to the tests.
+func (middleware *Middleware) Poll() {
+	middleware.poll()
+}
+
+// Backends exposes the backends to the tests.
+func (middleware *Middleware) Backends() Backends {
+	return middleware.backends
+}
+
+// SetBitcoindTimeout changes how long the calls to bitcoind wait.
+func (middleware *Middleware) SetBitcoindTimeout(timeout time.Duration) {
+	client := middleware.backends.Bitcoin.(*bitcoindBackend).client
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.timeout = timeout
+}
+
+// SubscriberLag exposes stateStore.subscriberLag.
+func (middleware *Middleware) SubscriberLag() (int, uint64) {
+	return middleware.state.subscriberLag()
+}
+
+// SetZMQIntervals changes how often bitcoind is polled while its zmq notifications are subscribed to, and how long a
+// subscription may stay silent.
+func (middleware *Middleware) SetZMQIntervals(pollInterval, receiveTimeout time.Duration) {
+	middleware.zmqPollInterval = pollInterval
+	middleware.zmqReceiveTimeout = receiveTimeout
+}
+
+// IsBitcoindUnauthorized exposes the check of rejected bitcoind credentials to the tests.
+func IsBitcoindUnauthorized(err error) bool {
+	return isUnauthorized(err)
+}
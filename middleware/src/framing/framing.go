@@ -0,0 +1,218 @@
+// Package framing splits large logical messages into chunks that fit into a single noise message and reassembles them
+// on the receiving side. Every chunk starts with a header byte, followed by up to MaxChunkSize bytes of payload. The
+// chunks are encrypted individually, so that every encrypted chunk stays below the 65535 byte noise message limit.
+//
+// A logical message is either a protobuf message or an attachment. Attachments carry raw data, like an update file or a
+// support bundle, that belongs to the protobuf message sent right before it. Attachments can be streamed from and to an
+// io.Reader and an io.Writer, so that multi-megabyte payloads never have to be held in memory as a whole.
+package framing
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"io/ioutil"
+)
+
+const (
+	// MaxChunkSize is the maximum payload size of a single chunk. It leaves room for the header byte and the 16 byte
+	// authentication tag that noise adds to every encrypted message.
+	MaxChunkSize = 65535 - 16 - 1
+
+	// flagMore is set in the header of every chunk except the last chunk of a logical message.
+	flagMore byte = 1 << 0
+	// flagAttachment is set in the header of every chunk of an attachment.
+	flagAttachment byte = 1 << 1
+)
+
+// ErrMessageTooLarge is returned if a logical message exceeds the size limit given by the receiver.
+var ErrMessageTooLarge = errors.New("message exceeds the maximum message size")
+
+// Writer splits logical messages into chunks. It must not be used concurrently.
+type Writer struct {
+	writeChunk func(chunk []byte) error
+}
+
+// NewWriter returns a Writer that passes every chunk, including its header, to writeChunk, which usually encrypts the
+// chunk and sends it over the connection.
+func NewWriter(writeChunk func(chunk []byte) error) *Writer {
+	return &Writer{writeChunk: writeChunk}
+}
+
+// WriteMessage sends a logical protobuf message, split into as many chunks as needed.
+func (writer *Writer) WriteMessage(message []byte) error {
+	for {
+		size := len(message)
+		header := byte(0)
+		if size > MaxChunkSize {
+			size = MaxChunkSize
+			header |= flagMore
+		}
+		if err := writer.writeChunk(append([]byte{header}, message[:size]...)); err != nil {
+			return err
+		}
+		message = message[size:]
+		if header&flagMore == 0 {
+			return nil
+		}
+	}
+}
+
+// WriteAttachment streams the data of reader as an attachment until reader returns io.EOF. The data is read one chunk
+// ahead, so that the last chunk can be sent without the more flag. At most two chunks are held in memory at a time.
+func (writer *Writer) WriteAttachment(reader io.Reader) error {
+	current := make([]byte, 1+MaxChunkSize)
+	next := make([]byte, 1+MaxChunkSize)
+	size, err := io.ReadFull(reader, current[1:])
+	for {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			current[0] = flagAttachment
+			return writer.writeChunk(current[:1+size])
+		}
+		if err != nil {
+			return err
+		}
+		nextSize, nextErr := io.ReadFull(reader, next[1:])
+		if nextErr == io.EOF {
+			current[0] = flagAttachment
+			return writer.writeChunk(current[:1+size])
+		}
+		current[0] = flagAttachment | flagMore
+		if err := writer.writeChunk(current[:1+size]); err != nil {
+			return err
+		}
+		current, next = next, current
+		size, err = nextSize, nextErr
+	}
+}
+
+// Reader reassembles logical messages from chunks. It must not be used concurrently.
+type Reader struct {
+	readChunk func() ([]byte, error)
+	current   *Message
+}
+
+// NewReader returns a Reader that gets decrypted chunks, including their header, from readChunk.
+func NewReader(readChunk func() ([]byte, error)) *Reader {
+	return &Reader{readChunk: readChunk}
+}
+
+// Message is a logical message that is being received. Its data is read chunk by chunk from the connection.
+type Message struct {
+	reader *Reader
+	// attachment is true if the message carries raw data instead of a protobuf message.
+	attachment bool
+	// first holds the payload of the first chunk, so that the receiver can decide how to handle the message.
+	first []byte
+	// buffered is the part of the current chunk that has not been read yet.
+	buffered []byte
+	// more is true as long as further chunks of this message need to be read from the connection.
+	more bool
+	err  error
+}
+
+// Next returns the next logical message. The previous message is discarded, if it was not read completely.
+func (reader *Reader) Next() (*Message, error) {
+	if reader.current != nil {
+		if err := reader.current.Discard(); err != nil {
+			return nil, err
+		}
+	}
+	chunk, err := reader.readChunk()
+	if err != nil {
+		return nil, err
+	}
+	if len(chunk) == 0 {
+		return nil, errors.New("received a chunk without header")
+	}
+	reader.current = &Message{
+		reader:     reader,
+		attachment: chunk[0]&flagAttachment != 0,
+		first:      chunk[1:],
+		buffered:   chunk[1:],
+		more:       chunk[0]&flagMore != 0,
+	}
+	return reader.current, nil
+}
+
+// IsAttachment returns true if the message carries raw data instead of a protobuf message.
+func (message *Message) IsAttachment() bool {
+	return message.attachment
+}
+
+// Peek returns the payload of the first chunk of the message, without consuming it.
+func (message *Message) Peek() []byte {
+	return message.first
+}
+
+// Read implements io.Reader and streams the message chunk by chunk from the connection.
+func (message *Message) Read(p []byte) (int, error) {
+	for len(message.buffered) == 0 {
+		if message.err != nil {
+			return 0, message.err
+		}
+		if !message.more {
+			return 0, io.EOF
+		}
+		chunk, err := message.reader.readChunk()
+		if err != nil {
+			message.err = err
+			return 0, err
+		}
+		if len(chunk) == 0 || (chunk[0]&flagAttachment != 0) != message.attachment {
+			message.err = errors.New("received an unexpected chunk within a message")
+			return 0, message.err
+		}
+		message.more = chunk[0]&flagMore != 0
+		message.buffered = chunk[1:]
+	}
+	n := copy(p, message.buffered)
+	message.buffered = message.buffered[n:]
+	return n, nil
+}
+
+// ReadAll reads the complete message. It returns ErrMessageTooLarge if the message exceeds limit bytes. In that case,
+// the rest of the message is left on the connection and the connection should be closed.
+func (message *Message) ReadAll(limit int) ([]byte, error) {
+	data := make([]byte, 0, len(message.first))
+	buffer := make([]byte, MaxChunkSize)
+	for {
+		n, err := message.Read(buffer)
+		if len(data)+n > limit {
+			return nil, ErrMessageTooLarge
+		}
+		data = append(data, buffer[:n]...)
+		if err == io.EOF {
+			return data, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// CopyAttachment copies an attachment of the announced size to writer and checks its sha256 hash. It returns an error
+// if the attachment is smaller or larger than announced or the hash does not match, writer may have received parts of
+// it then. what names the attachment in the errors, like "update".
+func CopyAttachment(writer io.Writer, attachment io.Reader, size int64, hash []byte, what string) error {
+	hasher := sha256.New()
+	// Read one byte more than announced to detect attachments that are too large.
+	written, err := io.Copy(io.MultiWriter(writer, hasher), io.LimitReader(attachment, size+1))
+	if err != nil {
+		return err
+	}
+	if written != size {
+		return errors.New(what + " size does not match")
+	}
+	if !bytes.Equal(hasher.Sum(nil), hash) {
+		return errors.New(what + " hash does not match")
+	}
+	return nil
+}
+
+// Discard reads and drops the remaining chunks of the message.
+func (message *Message) Discard() error {
+	_, err := io.Copy(ioutil.Discard, message)
+	return err
+}
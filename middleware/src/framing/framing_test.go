@@ -0,0 +1,117 @@
+package framing_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-base/middleware/src/framing"
+	"github.com/stretchr/testify/require"
+)
+
+// newPipe returns a writer and a reader that pass chunks through a channel, like a connection would.
+func newPipe() (*framing.Writer, *framing.Reader, *[]int) {
+	chunks := make(chan []byte, 1024)
+	sizes := []int{}
+	writer := framing.NewWriter(func(chunk []byte) error {
+		sizes = append(sizes, len(chunk))
+		chunks <- append([]byte{}, chunk...)
+		return nil
+	})
+	reader := framing.NewReader(func() ([]byte, error) {
+		select {
+		case chunk := <-chunks:
+			return chunk, nil
+		default:
+			return nil, io.ErrUnexpectedEOF
+		}
+	})
+	return writer, reader, &sizes
+}
+
+func TestMessages(t *testing.T) {
+	writer, reader, sizes := newPipe()
+	small := []byte("small message")
+	large := bytes.Repeat([]byte{0xab}, 3*framing.MaxChunkSize+10)
+	require.NoError(t, writer.WriteMessage(small))
+	require.NoError(t, writer.WriteMessage(large))
+	require.NoError(t, writer.WriteMessage([]byte{}))
+	require.Equal(t, []int{len(small) + 1, framing.MaxChunkSize + 1, framing.MaxChunkSize + 1, framing.MaxChunkSize + 1, 11, 1}, *sizes)
+
+	message, err := reader.Next()
+	require.NoError(t, err)
+	require.False(t, message.IsAttachment())
+	require.Equal(t, small, message.Peek())
+	data, err := message.ReadAll(1024)
+	require.NoError(t, err)
+	require.Equal(t, small, data)
+
+	message, err = reader.Next()
+	require.NoError(t, err)
+	require.Equal(t, framing.MaxChunkSize, len(message.Peek()))
+	data, err = message.ReadAll(len(large))
+	require.NoError(t, err)
+	require.Equal(t, large, data)
+
+	message, err = reader.Next()
+	require.NoError(t, err)
+	data, err = message.ReadAll(0)
+	require.NoError(t, err)
+	require.Empty(t, data)
+}
+
+func TestMessageTooLarge(t *testing.T) {
+	writer, reader, _ := newPipe()
+	require.NoError(t, writer.WriteMessage(bytes.Repeat([]byte{1}, 2*framing.MaxChunkSize)))
+	require.NoError(t, writer.WriteMessage([]byte("next")))
+
+	message, err := reader.Next()
+	require.NoError(t, err)
+	_, err = message.ReadAll(framing.MaxChunkSize)
+	require.Equal(t, framing.ErrMessageTooLarge, err)
+
+	// the rest of the large message is skipped
+	message, err = reader.Next()
+	require.NoError(t, err)
+	data, err := message.ReadAll(16)
+	require.NoError(t, err)
+	require.Equal(t, []byte("next"), data)
+}
+
+func TestAttachment(t *testing.T) {
+	for _, size := range []int{0, 1, framing.MaxChunkSize, 2 * framing.MaxChunkSize, 2*framing.MaxChunkSize + 1} {
+		writer, reader, sizes := newPipe()
+		payload := bytes.Repeat([]byte{0x42}, size)
+		require.NoError(t, writer.WriteAttachment(bytes.NewReader(payload)))
+		expectedChunks := (size + framing.MaxChunkSize - 1) / framing.MaxChunkSize
+		if expectedChunks == 0 {
+			expectedChunks = 1
+		}
+		require.Len(t, *sizes, expectedChunks)
+
+		message, err := reader.Next()
+		require.NoError(t, err)
+		require.True(t, message.IsAttachment())
+		data, err := ioutil.ReadAll(message)
+		require.NoError(t, err)
+		require.Equal(t, payload, data)
+	}
+}
+
+func TestCopyAttachment(t *testing.T) {
+	data := []byte("attachment data")
+	hash := sha256.Sum256(data)
+	var copied bytes.Buffer
+	require.NoError(t, framing.CopyAttachment(&copied, bytes.NewReader(data), int64(len(data)), hash[:], "update"))
+	require.Equal(t, data, copied.Bytes())
+
+	err := framing.CopyAttachment(ioutil.Discard, bytes.NewReader(data), int64(len(data)-1), hash[:], "update")
+	require.EqualError(t, err, "update size does not match")
+	err = framing.CopyAttachment(ioutil.Discard, bytes.NewReader(data), int64(len(data)+1), hash[:], "update")
+	require.EqualError(t, err, "update size does not match")
+	other := sha256.Sum256([]byte("other data"))
+	err = framing.CopyAttachment(ioutil.Discard, bytes.NewReader(data), int64(len(data)), other[:], "update")
+	require.EqualError(t, err, "update hash does not match")
+}
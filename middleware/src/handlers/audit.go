@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/digitalbitbox/bitbox-base/middleware/src/audit"
+	basemessages "github.com/digitalbitbox/bitbox-base/middleware/src/messages"
+
+	"github.com/golang/protobuf/proto"
+)
+
+const (
+	// defaultAuditLogLimit is the number of audit log entries sent if the client does not ask for a number.
+	defaultAuditLogLimit = 100
+	// maxAuditLogLimit limits the number of audit log entries sent in one response.
+	maxAuditLogLimit = 1000
+)
+
+// recordAudit adds an entry to the audit log. client is the pubkey of the client that caused the action, nil if it is
+// not known. Failing to write the audit log does not fail the action, it is logged.
+func (handlers *Handlers) recordAudit(client []byte, action, details string) {
+	if err := handlers.audit.Append(client, action, details); err != nil {
+		handlers.logger.Error("Failed to write the audit log", "action", action, "error", err)
+	}
+}
+
+// recordConfigSet adds a changed setting to the audit log, switching the bitcoin network is recorded as such.
+func (handlers *Handlers) recordConfigSet(client []byte, key, value string) {
+	action := audit.ActionConfigChanged
+	if strings.ToLower(key) == "bitcoin_network" {
+		action = audit.ActionNetworkSwitched
+	}
+	handlers.recordAudit(client, action, key+"="+value)
+}
+
+// auditLog returns the protobuf serialized entries of the audit log, along with the result of verifying the log.
+func (handlers *Handlers) auditLog(offset uint64, limit uint32) ([]byte, error) {
+	if limit > maxAuditLogLimit {
+		return nil, errors.New("invalid number of audit log entries")
+	}
+	if limit == 0 {
+		limit = defaultAuditLogLimit
+	}
+	entries, total, err := handlers.audit.Entries(offset, int(limit))
+	if err != nil {
+		return nil, err
+	}
+	response := &basemessages.BaseAuditLogOut{
+		Entries: []*basemessages.BaseAuditEntry{},
+		Total:   total,
+	}
+	for _, entry := range entries {
+		response.Entries = append(response.Entries, &basemessages.BaseAuditEntry{
+			Sequence: entry.Sequence,
+			Time:     entry.Time,
+			Client:   entry.Client,
+			Action:   entry.Action,
+			Details:  entry.Details,
+			PrevHash: entry.PrevHash,
+			Hash:     entry.Hash,
+		})
+	}
+	if err := handlers.audit.Verify(); err != nil {
+		response.IntegrityError = err.Error()
+	}
+	return proto.Marshal(&basemessages.BitBoxBaseOut{
+		BitBoxBaseOut: &basemessages.BitBoxBaseOut_BaseAuditLogOut{BaseAuditLogOut: response},
+	})
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+
+	"github.com/digitalbitbox/bitbox-base/middleware/src/audit"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/backup"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/framing"
+	basemessages "github.com/digitalbitbox/bitbox-base/middleware/src/messages"
+	noisemanager "github.com/digitalbitbox/bitbox-base/middleware/src/noise"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// maxBackupSize limits the size of an uploaded backup, which is decrypted in memory.
+const maxBackupSize = 256 << 20
+
+// readBackup reads an uploaded backup into memory. It is only returned if its size and sha256 hash match the request.
+func readBackup(size int64, hash []byte, attachment io.Reader) ([]byte, error) {
+	if size <= 0 || size > maxBackupSize {
+		return nil, errors.New("invalid backup size")
+	}
+	if len(hash) != sha256.Size {
+		return nil, errors.New("invalid backup hash")
+	}
+	var data bytes.Buffer
+	data.Grow(int(size))
+	if err := framing.CopyAttachment(&data, attachment, size, hash, "backup"); err != nil {
+		return nil, err
+	}
+	return data.Bytes(), nil
+}
+
+// saveBackup writes a backup to the backup drive if toDrive is set and records it in the audit log. It returns the
+// path on the backup drive, if it was written there.
+func (handlers *Handlers) saveBackup(connection *connection, archive backup.Archive, toDrive bool, details string) (
+	string, error) {
+	path := ""
+	if toDrive {
+		var err error
+		path, err = handlers.middleware.SaveBackup(archive)
+		if err != nil {
+			return "", err
+		}
+		details += " to the backup drive"
+	}
+	handlers.recordAudit(connection.clientStaticPubkey, audit.ActionBackupCreated, details)
+	return path, nil
+}
+
+// attachedBackup returns the backup to send as the attachment of the response, nil if it was written to the backup
+// drive.
+func attachedBackup(archive backup.Archive, toDrive bool) []byte {
+	if toDrive {
+		return nil
+	}
+	return archive.Data
+}
+
+// lightningBackup creates a backup of the lightning node. If it is not written to the backup drive, the caller sends
+// the returned backup as the attachment of the response.
+func (handlers *Handlers) lightningBackup(connection *connection, rpc *basemessages.BaseLightningBackupIn) (
+	[]byte, []byte, error) {
+	archive, err := handlers.middleware.LightningBackup(rpc.Passphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+	path, err := handlers.saveBackup(connection, archive, rpc.ToDrive, "node "+archive.Manifest.NodeID)
+	if err != nil {
+		return nil, nil, err
+	}
+	hash := sha256.Sum256(archive.Data)
+	response, err := proto.Marshal(&basemessages.BitBoxBaseOut{
+		BitBoxBaseOut: &basemessages.BitBoxBaseOut_BaseLightningBackupOut{
+			BaseLightningBackupOut: &basemessages.BaseLightningBackupOut{
+				Filename: archive.Filename,
+				Size:     int64(len(archive.Data)),
+				Sha256:   hash[:],
+				NodeId:   archive.Manifest.NodeID,
+				Path:     path,
+			},
+		},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return response, attachedBackup(archive, rpc.ToDrive), nil
+}
+
+// configBackup creates a backup of the configuration of the Base, including the noise keystore. If it is not written
+// to the backup drive, the caller sends the returned backup as the attachment of the response.
+func (handlers *Handlers) configBackup(connection *connection, rpc *basemessages.BaseConfigBackupIn) (
+	[]byte, []byte, error) {
+	keystore, err := noisemanager.NewNoiseConfig(handlers.dataDir).Keystore()
+	if err != nil {
+		return nil, nil, err
+	}
+	archive, err := handlers.middleware.ConfigBackup(rpc.Passphrase, keystore)
+	if err != nil {
+		return nil, nil, err
+	}
+	path, err := handlers.saveBackup(connection, archive, rpc.ToDrive, "configuration")
+	if err != nil {
+		return nil, nil, err
+	}
+	hash := sha256.Sum256(archive.Data)
+	response, err := proto.Marshal(&basemessages.BitBoxBaseOut{
+		BitBoxBaseOut: &basemessages.BitBoxBaseOut_BaseConfigBackupOut{
+			BaseConfigBackupOut: &basemessages.BaseConfigBackupOut{
+				Filename: archive.Filename,
+				Size:     int64(len(archive.Data)),
+				Sha256:   hash[:],
+				Signer:   archive.Manifest.Signer,
+				Path:     path,
+			},
+		},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return response, attachedBackup(archive, rpc.ToDrive), nil
+}
+
+// restoreData returns the backup to restore, from the backup drive if driveFilename names a file on it, or else from
+// the attachment of the request, which must match the size and hash of the request.
+func (handlers *Handlers) restoreData(driveFilename string, size int64, hash []byte, attachment io.Reader) (
+	[]byte, error) {
+	if driveFilename != "" {
+		return handlers.middleware.ReadBackup(driveFilename)
+	}
+	if attachment == nil {
+		return nil, errors.New("the backup has to be sent as an attachment or read from the backup drive")
+	}
+	return readBackup(size, hash, attachment)
+}
+
+// lightningRestore restores the lightning node from a backup.
+func (handlers *Handlers) lightningRestore(connection *connection, passphrase string, data []byte) ([]byte, error) {
+	manifest, err := handlers.middleware.LightningRestore(passphrase, data)
+	if err != nil {
+		return nil, err
+	}
+	handlers.recordAudit(connection.clientStaticPubkey, audit.ActionBackupRestored, "node "+manifest.NodeID)
+	return proto.Marshal(&basemessages.BitBoxBaseOut{
+		BitBoxBaseOut: &basemessages.BitBoxBaseOut_BaseLightningRestoreOut{
+			BaseLightningRestoreOut: &basemessages.BaseLightningRestoreOut{
+				NodeId:  manifest.NodeID,
+				Network: manifest.Network,
+				Created: manifest.Created,
+			},
+		},
+	})
+}
+
+// configRestore restores the configuration of the Base from a backup signed by signer. The audit log records the
+// restore before the keystore is replaced, the caller closes all connections after sending the response, as the
+// clients have to connect to the restored keypair.
+func (handlers *Handlers) configRestore(connection *connection, passphrase string, signer string, data []byte) (
+	[]byte, error) {
+	manifest, err := handlers.middleware.ConfigRestore(passphrase, signer, data, func(keystore []byte) error {
+		handlers.recordAudit(connection.clientStaticPubkey, audit.ActionBackupRestored, "configuration")
+		return noisemanager.NewNoiseConfig(handlers.dataDir).RestoreKeystore(keystore)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(&basemessages.BitBoxBaseOut{
+		BitBoxBaseOut: &basemessages.BitBoxBaseOut_BaseConfigRestoreOut{
+			BaseConfigRestoreOut: &basemessages.BaseConfigRestoreOut{
+				Network: manifest.Network,
+				Created: manifest.Created,
+				Signer:  manifest.Signer,
+			},
+		},
+	})
+}
@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/digitalbitbox/bitbox-base/middleware/src/audit"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/framing"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/logging"
+	basemessages "github.com/digitalbitbox/bitbox-base/middleware/src/messages"
+	noisemanager "github.com/digitalbitbox/bitbox-base/middleware/src/noise"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/transport"
+
+	"github.com/golang/protobuf/proto"
+)
+
+const (
+	// defaultMaxMessageSize is the size limit for incoming messages of rpcs without an explicit limit. It is the largest
+	// limit, so it is also the most that is read before the rpc is known.
+	defaultMaxMessageSize = 4096
+
+	// The field numbers of the requests in the BitBoxBaseIn oneof.
+	fieldNumberBaseSystemEnvIn        = 1
+	fieldNumberBaseServicesIn         = 2
+	fieldNumberBaseConfigGetIn        = 3
+	fieldNumberBaseConfigSetIn        = 4
+	fieldNumberBaseLogsIn             = 5
+	fieldNumberBaseUpdateIn           = 6
+	fieldNumberBaseStateResyncIn      = 7
+	fieldNumberBaseHealthIn           = 8
+	fieldNumberBasePairedClientsIn    = 9
+	fieldNumberBaseSetClientRoleIn    = 10
+	fieldNumberBaseAuditLogIn         = 11
+	fieldNumberBaseLogLevelIn         = 12
+	fieldNumberBaseSupportBundleIn    = 13
+	fieldNumberBaseLightningBackupIn  = 14
+	fieldNumberBaseLightningRestoreIn = 15
+	fieldNumberBaseConfigBackupIn     = 16
+	fieldNumberBaseConfigRestoreIn    = 17
+	fieldNumberBaseFactoryResetIn     = 18
+	fieldNumberBasePowerIn            = 19
+)
+
+// request is a message received from the client. incoming is nil if the message could not be unmarshalled. If the rpc
+// carries an attachment, the reading loop waits until the attachment was consumed and done is closed.
+type request struct {
+	incoming   *basemessages.BitBoxBaseIn
+	attachment io.Reader
+	done       chan struct{}
+}
+
+// response is a message sent to the client that is followed by an attachment. done receives the result of sending
+// both.
+type response struct {
+	message    []byte
+	attachment io.Reader
+	done       chan error
+}
+
+// maxMessageSize returns the maximum size of an incoming message of the rpc with the field number.
+func maxMessageSize(field int32) int {
+	switch field {
+	case fieldNumberBaseSystemEnvIn, fieldNumberBaseServicesIn, fieldNumberBaseStateResyncIn, fieldNumberBaseHealthIn,
+		fieldNumberBasePairedClientsIn, fieldNumberBaseAuditLogIn, fieldNumberBaseLogLevelIn,
+		fieldNumberBaseSupportBundleIn:
+		// These requests do not carry any data, or just a number.
+		return 64
+	case fieldNumberBaseConfigGetIn, fieldNumberBaseLogsIn, fieldNumberBaseUpdateIn, fieldNumberBaseSetClientRoleIn,
+		fieldNumberBaseFactoryResetIn, fieldNumberBasePowerIn:
+		return 256
+	case fieldNumberBaseConfigSetIn, fieldNumberBaseLightningBackupIn, fieldNumberBaseLightningRestoreIn,
+		fieldNumberBaseConfigBackupIn, fieldNumberBaseConfigRestoreIn:
+		return 1024
+	default:
+		return defaultMaxMessageSize
+	}
+}
+
+// hasAttachment returns true if the request is followed by an attachment.
+func hasAttachment(incoming *basemessages.BitBoxBaseIn) bool {
+	switch rpc := incoming.GetBitBoxBaseIn().(type) {
+	case *basemessages.BitBoxBaseIn_BaseUpdateIn:
+		return true
+	case *basemessages.BitBoxBaseIn_BaseLightningRestoreIn:
+		// Backups on the backup drive are restored without an attachment.
+		return rpc.BaseLightningRestoreIn.DriveFilename == ""
+	case *basemessages.BitBoxBaseIn_BaseConfigRestoreIn:
+		return rpc.BaseConfigRestoreIn.DriveFilename == ""
+	default:
+		return false
+	}
+}
+
+// connection is a client connection that is registered for middleware events.
+type connection struct {
+	// clientStaticPubkey identifies the paired client, so that its connections can be closed when it is revoked.
+	clientStaticPubkey []byte
+	// role is the role of the paired client, empty until the pairing is verified. It is guarded by the mutex of the
+	// handlers, as owners can change it while the client is connected.
+	role string
+	// logger adds the fingerprint of the client to the lines.
+	logger *logging.Logger
+	send   chan<- []byte
+	// sendAttachment sends a message with an attachment. The message and the attachment are not interleaved with
+	// other messages.
+	sendAttachment chan<- response
+	receive        <-chan request
+	remoteHasQuit  <-chan struct{}
+	// verified is closed once the pairing is verified. Nothing but the pairing verification is sent to the client
+	// before.
+	verified chan struct{}
+	// resyncState passes the state versions requested by the client to the state subscription.
+	resyncState chan uint64
+
+	weHaveQuit chan struct{}
+	// writeLoopDone is closed when the writing loop exited, e.g. after sending the close reason.
+	writeLoopDone chan struct{}
+	closeOnce     sync.Once
+	closeCode     int
+	closeReason   string
+}
+
+// close makes the connection's goroutines quit. The client is told the reason before the connection is closed.
+// Only the first call has an effect.
+func (connection *connection) close(code int, reason string) {
+	connection.closeOnce.Do(func() {
+		connection.closeCode = code
+		connection.closeReason = reason
+		close(connection.weHaveQuit)
+	})
+}
+
+// runConnection sets up loops for sending/receiving, abstracting away the low level details about
+// timeouts, keepalive, clients closing, etc. Messages are split into chunks and reassembled with the framing package,
+// so that they can be larger than a single noise message.
+// It returns a connection with a channel to send messages to the client, one to receive messages from the client
+// and one which notifies when the client was closed.
+//
+// connection.close makes runConnection's goroutines quit.
+// The goroutines close client upon exit, due to a send/receive error, a missed keepalive or when connection.close is
+// called. If the reading loop exits, it closes the remoteHasQuit channel. If the writing loop fails, it closes the
+// client, which makes the reading loop exit as well.
+func (handlers *Handlers) runConnection(client transport.Conn, noiseConfig *noisemanager.NoiseConfig) *connection {
+	remoteHasQuitChan := make(chan struct{})
+	sendChan := make(chan []byte)
+	sendAttachmentChan := make(chan response)
+	receiveChan := make(chan request)
+	connection := &connection{
+		clientStaticPubkey: noiseConfig.ClientStaticPubkey(),
+		role:               noiseConfig.ClientRole(),
+		logger:             handlers.logger.With("client", audit.Fingerprint(noiseConfig.ClientStaticPubkey())),
+		send:               sendChan,
+		sendAttachment:     sendAttachmentChan,
+		receive:            receiveChan,
+		remoteHasQuit:      remoteHasQuitChan,
+		verified:           make(chan struct{}),
+		resyncState:        make(chan uint64),
+		weHaveQuit:         make(chan struct{}),
+		writeLoopDone:      make(chan struct{}),
+	}
+	if !noiseConfig.PairingVerificationRequired() {
+		close(connection.verified)
+	}
+
+	readLoop := func() {
+		defer func() {
+			close(remoteHasQuitChan)
+			_ = client.Close()
+		}()
+		reader := framing.NewReader(func() ([]byte, error) {
+			for {
+				msg, err := client.ReadMessage()
+				if err != nil {
+					return nil, err
+				}
+				// check if it is the message to request the pairing
+				if string(msg) == "v" {
+					verificationRequired := noiseConfig.PairingVerificationRequired()
+					msg, err = noiseConfig.CheckVerification()
+					if err != nil {
+						connection.logger.Warning("Pairing verified, but storing the client pubkey failed", "error", err)
+					}
+					// The pairing is recorded before the client learns about it and can make requests.
+					newlyVerified := verificationRequired && !noiseConfig.PairingVerificationRequired()
+					if newlyVerified {
+						handlers.recordAudit(connection.clientStaticPubkey, audit.ActionPairing, "role "+noiseConfig.ClientRole())
+					}
+					err = client.WriteMessage(msg)
+					if err != nil {
+						connection.logger.Warning("Connection failed to write channel hash verification message", "error", err)
+					}
+					if newlyVerified {
+						handlers.pairings.Inc("ok")
+						handlers.mu.Lock()
+						connection.role = noiseConfig.ClientRole()
+						handlers.mu.Unlock()
+						close(connection.verified)
+					}
+					continue
+				}
+				return noiseConfig.Decrypt(msg)
+			}
+		})
+		for {
+			message, err := reader.Next()
+			if err != nil {
+				connection.logger.Info("Connection closed in the reading loop", "error", err)
+				break
+			}
+			if message.IsAttachment() {
+				connection.logger.Warning("Received an attachment that no rpc is waiting for, discarding it")
+				continue
+			}
+			messageDecrypted, err := message.ReadAll(defaultMaxMessageSize)
+			if err != nil {
+				connection.logger.Warning("Connection could not read incoming message", "error", err)
+				break
+			}
+			incoming := &basemessages.BitBoxBaseIn{}
+			if err := proto.Unmarshal(messageDecrypted, incoming); err != nil {
+				incoming = nil
+			}
+			// The rpc is only known once the message is unmarshalled, as the order of the encoded fields is not fixed.
+			if field := basemessages.RPCField(incoming); len(messageDecrypted) > maxMessageSize(field) {
+				connection.logger.Warning("Message exceeds the size limit of its rpc, closing connection", "rpc", field)
+				break
+			}
+			if !hasAttachment(incoming) {
+				receiveChan <- request{incoming: incoming}
+				continue
+			}
+			attachment, err := reader.Next()
+			if err != nil {
+				connection.logger.Info("Connection closed in the reading loop", "error", err)
+				break
+			}
+			if !attachment.IsAttachment() {
+				connection.logger.Warning("Expected an attachment, closing connection")
+				break
+			}
+			done := make(chan struct{})
+			receiveChan <- request{incoming: incoming, attachment: attachment, done: done}
+			<-done
+		}
+	}
+
+	writeLoop := func() {
+		defer close(connection.writeLoopDone)
+		writer := framing.NewWriter(func(chunk []byte) error {
+			return client.WriteMessage(noiseConfig.Encrypt(chunk))
+		})
+		keepalive := time.NewTicker(transport.PingInterval)
+		defer keepalive.Stop()
+		for {
+			select {
+			case message := <-sendChan:
+				if err := writer.WriteMessage(message); err != nil {
+					connection.logger.Warning("Connection closed unexpectedly in the writing loop", "error", err)
+					_ = client.Close()
+					return
+				}
+			case response := <-sendAttachmentChan:
+				err := writer.WriteMessage(response.message)
+				if err == nil {
+					err = writer.WriteAttachment(response.attachment)
+				}
+				response.done <- err
+				if err != nil {
+					// The client can not tell where a broken attachment ends.
+					connection.logger.Warning("Connection failed to send an attachment", "error", err)
+					_ = client.Close()
+					return
+				}
+			case <-keepalive.C:
+				if err := client.Ping(); err != nil {
+					connection.logger.Warning("Connection failed to send keepalive ping", "error", err)
+					_ = client.Close()
+					return
+				}
+			case <-remoteHasQuitChan:
+				return
+			case <-connection.weHaveQuit:
+				connection.logger.Info("Closing connection", "reason", connection.closeReason)
+				_ = client.CloseWithReason(connection.closeCode, connection.closeReason)
+				return
+			}
+		}
+	}
+
+	go readLoop()
+	go writeLoop()
+
+	return connection
+}
@@ -2,12 +2,23 @@
 package handlers
 
 import (
-	"log"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
 	"net/http"
 	"sync"
+	"time"
 
+	"github.com/digitalbitbox/bitbox-base/middleware/src/audit"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/backup"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/logging"
 	basemessages "github.com/digitalbitbox/bitbox-base/middleware/src/messages"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/metrics"
 	noisemanager "github.com/digitalbitbox/bitbox-base/middleware/src/noise"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/system"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/transport"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/gorilla/mux"
@@ -16,23 +27,96 @@ import (
 
 // Middleware provides an interface to the middleware package.
 type Middleware interface {
-	// Start triggers the main middleware event loop that emits events to be caught by the handlers.
-	Start() <-chan []byte
+	// Start triggers the main middleware event loop that updates the state of the base.
+	Start()
+	// Subscribe returns a channel of state messages, starting with a full snapshot and followed by patches, a function
+	// to request a patch from an older version and a function to unsubscribe.
+	Subscribe() (events <-chan []byte, resync func(version uint64), unsubscribe func())
 	SystemEnv() []byte
+	Services() []byte
+	ConfigGet(key string) ([]byte, error)
+	ConfigSet(key, value string) ([]byte, error)
+	Logs(query system.JournalQuery, structured bool, stop <-chan struct{}, send func([]byte)) error
+	// HealthSummary returns the overall status, the status of each backend by name and whether the middleware still
+	// runs its health checks.
+	HealthSummary() (status string, backends map[string]string, alive bool)
+	// Health returns the protobuf serialized detailed health of the backends, only sent to paired clients.
+	Health() ([]byte, error)
+	// Metrics returns the metrics of the middleware, which are served along with the ones of the handlers.
+	Metrics() *metrics.Registry
+	// Logger returns the logger of the middleware, which the handlers log with as well.
+	Logger() *logging.Logger
+	// SupportBundle writes a tar.gz archive with redacted diagnostics of the Base to writer.
+	SupportBundle(writer io.Writer) error
+	// LightningBackup returns a backup of the lightning node, encrypted with the passphrase.
+	LightningBackup(passphrase string) (backup.Archive, error)
+	// LightningRestore restores the lightning node from a backup, after checking that it belongs to the Base.
+	LightningRestore(passphrase string, data []byte) (backup.Manifest, error)
+	// SaveBackup writes a backup to the backup drive and returns its path.
+	SaveBackup(archive backup.Archive) (string, error)
+	// ReadBackup reads a backup from the backup drive.
+	ReadBackup(filename string) ([]byte, error)
+	// ConfigBackup returns a signed backup of the configuration of the Base, including the given noise keystore,
+	// encrypted with the passphrase.
+	ConfigBackup(passphrase string, keystore []byte) (backup.Archive, error)
+	// ConfigRestore validates a configuration backup signed by signer and restores it, passing its noise keystore to
+	// restoreKeystore.
+	ConfigRestore(passphrase string, signer string, data []byte, restoreKeystore func(keystore []byte) error) (
+		backup.Manifest, error)
+	// FactoryResetWarnings returns what a factory reset with the options deletes, for the owner to confirm.
+	FactoryResetWarnings(options system.ResetOptions) []string
+	// FactoryReset wipes the Base and then calls wipeKeystore to wipe the noise keystore.
+	FactoryReset(options system.ResetOptions, wipeKeystore func() error) error
+	// StopServices stops the services before a reboot or shutdown, in order, calling progress before each one.
+	StopServices(progress func(service string)) error
+	// Power reboots or shuts down the Base, or starts the stopped services again if that fails.
+	Power(action system.PowerAction) error
 }
 
 // Handlers provides a web api
 type Handlers struct {
 	Router *mux.Router
+	// MetricsHandler serves the metrics to Prometheus. It is not authenticated, so it must only be served on a
+	// listener that is reachable from the Base itself.
+	MetricsHandler http.Handler
 	//upgrader takes an http request and upgrades the connection with its origin to websocket
-	upgrader         websocket.Upgrader
-	middleware       Middleware
-	middlewareEvents <-chan []byte
-
-	noiseConfig *noisemanager.NoiseConfig
-	nClients    int
-	clientsMap  map[int]chan<- []byte
-	mu          sync.Mutex
+	upgrader   websocket.Upgrader
+	middleware Middleware
+	logger     *logging.Logger
+
+	dataDir string
+	// audit records the security relevant actions of clients, in the data dir.
+	audit      *audit.Log
+	nClients   int
+	clientsMap map[int]*connection
+	// shuttingDown is set by Shutdown. New connections and requests are refused from then on.
+	shuttingDown bool
+	// requests tracks the requests in progress, which Shutdown waits for. Followed log streams are not tracked, as
+	// they only end with the connection.
+	requests sync.WaitGroup
+	// inProgress counts the requests in progress, including followed log streams.
+	inProgress int
+	// logStreams counts the log requests in progress.
+	logStreams int
+	// resetConfirmation is the factory reset waiting to be confirmed, nil if there is none.
+	resetConfirmation *resetConfirmation
+	// poweringDown is set while the Base is rebooted or shut down.
+	poweringDown bool
+	// scheduledReboot fires the maintenance reboot scheduled at scheduledRebootAt, nil if none is scheduled.
+	scheduledReboot   *time.Timer
+	scheduledRebootAt time.Time
+	mu                sync.Mutex
+
+	metrics     *metrics.Registry
+	handshakes  *metrics.Counter
+	pairings    *metrics.Counter
+	rpcRequests *metrics.Counter
+	rpcDuration *metrics.Histogram
+}
+
+// rpcDurationBuckets returns the upper bounds of the rpc latency histogram buckets in seconds.
+func rpcDurationBuckets() []float64 {
+	return []float64{0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30}
 }
 
 // NewHandlers returns a handler instance.
@@ -40,37 +124,91 @@ func NewHandlers(middlewareInstance Middleware, dataDir string) *Handlers {
 	router := mux.NewRouter()
 
 	handlers := &Handlers{
-		middleware:  middlewareInstance,
-		Router:      router,
-		upgrader:    websocket.Upgrader{},
-		noiseConfig: noisemanager.NewNoiseConfig(dataDir),
-		nClients:    0,
-		clientsMap:  make(map[int]chan<- []byte),
-	}
-	handlers.Router.HandleFunc("/", handlers.rootHandler).Methods("GET")
+		middleware: middlewareInstance,
+		logger:     middlewareInstance.Logger(),
+		Router:     router,
+		upgrader:   websocket.Upgrader{},
+		dataDir:    dataDir,
+		audit:      audit.New(dataDir),
+		nClients:   0,
+		clientsMap: make(map[int]*connection),
+		metrics:    metrics.NewRegistry(),
+	}
+	handlers.handshakes = handlers.metrics.Counter(
+		"base_middleware_handshakes_total", "Noise handshakes with clients, by result", "result")
+	handlers.pairings = handlers.metrics.Counter(
+		"base_middleware_pairings_total", "Pairing verifications of new clients, by result", "result")
+	handlers.rpcRequests = handlers.metrics.Counter(
+		"base_middleware_rpc_requests_total", "Requests of paired clients, by rpc and result", "rpc", "result")
+	handlers.rpcDuration = handlers.metrics.Histogram(
+		"base_middleware_rpc_duration_seconds", "Time to handle the requests of paired clients, by rpc",
+		rpcDurationBuckets(), "rpc")
+	handlers.metrics.Gauge("base_middleware_clients", "Connected clients", func() float64 {
+		handlers.mu.Lock()
+		defer handlers.mu.Unlock()
+		return float64(len(handlers.clientsMap))
+	})
+	handlers.metrics.Gauge("base_middleware_requests_in_progress", "Requests of clients that are being handled", func() float64 {
+		handlers.mu.Lock()
+		defer handlers.mu.Unlock()
+		return float64(handlers.inProgress)
+	})
+	// The root path is kept for clients that only check whether the middleware is online.
+	handlers.Router.HandleFunc("/", handlers.healthHandler).Methods("GET")
+	handlers.Router.HandleFunc("/health", handlers.healthHandler).Methods("GET")
 	handlers.Router.HandleFunc("/ws", handlers.wsHandler)
+	metricsRouter := mux.NewRouter()
+	metricsRouter.Handle("/metrics",
+		metrics.Handler(handlers.logger, handlers.metrics, middlewareInstance.Metrics())).Methods("GET")
+	handlers.MetricsHandler = metricsRouter
 
-	handlers.middlewareEvents = handlers.middleware.Start()
-	go handlers.listenEvents()
+	handlers.middleware.Start()
+	handlers.recordAudit(nil, audit.ActionMiddlewareStarted, "")
 	return handlers
 }
 
-func (handlers *Handlers) listenEvents() {
+// forwardState sends the state of the base to the client once the pairing is verified: first a full snapshot, then
+// patches with the changes, until the client quits.
+func (handlers *Handlers) forwardState(connection *connection) {
+	select {
+	case <-connection.verified:
+	case <-connection.remoteHasQuit:
+		return
+	}
+	events, resync, unsubscribe := handlers.middleware.Subscribe()
+	defer unsubscribe()
 	for {
-		event := <-handlers.middlewareEvents
-		handlers.mu.Lock()
-		for k := range handlers.clientsMap {
-			handlers.clientsMap[k] <- event
+		select {
+		case version := <-connection.resyncState:
+			resync(version)
+		case event := <-events:
+			select {
+			case connection.send <- event:
+			case <-connection.remoteHasQuit:
+				return
+			}
+		case <-connection.remoteHasQuit:
+			return
 		}
-		handlers.mu.Unlock()
 	}
 }
 
-// rootHandler provides an endpoint to indicate that the middleware is online and able to handle requests.
-func (handlers *Handlers) rootHandler(w http.ResponseWriter, r *http.Request) {
-	_, err := w.Write([]byte("OK!!\n"))
+// healthHandler provides an unauthenticated endpoint with the health of the middleware and its backends as JSON. It only
+// tells whether each backend is ok, degraded or down, the details are sent to paired clients with BaseHealthOut. The
+// response has status 503 if the middleware is wedged.
+func (handlers *Handlers) healthHandler(w http.ResponseWriter, r *http.Request) {
+	status, backends, alive := handlers.middleware.HealthSummary()
+	w.Header().Set("Content-Type", "application/json")
+	if !alive {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":   status,
+		"alive":    alive,
+		"backends": backends,
+	})
 	if err != nil {
-		log.Println(err.Error() + " Failed to write response bytes in root handler")
+		handlers.logger.Warning("Failed to write the response of the health handler", "error", err)
 	}
 }
 
@@ -79,40 +217,159 @@ func (handlers *Handlers) rootHandler(w http.ResponseWriter, r *http.Request) {
 func (handlers *Handlers) wsHandler(w http.ResponseWriter, r *http.Request) {
 	ws, err := handlers.upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Println(err.Error() + " Failed to upgrade connection")
+		handlers.logger.Warning("Failed to upgrade connection", "error", err)
+		return
 	}
+	handlers.ServeConn(transport.NewWebsocketConn(ws))
+}
 
-	err = handlers.noiseConfig.InitializeNoise(ws)
+// Serve accepts connections on a stream listener, like a TCP listener or a Unix socket, and serves the noise
+// encrypted api on them with length prefixed frames. It blocks until the listener fails.
+func (handlers *Handlers) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go handlers.ServeConn(transport.NewStreamConn(conn))
+	}
+}
+
+// ServeConn performs the noise handshake on a new client connection and subscribes the client to the state of the base.
+// It listens indefinitely to requests from the client and relays them to the middleware.
+func (handlers *Handlers) ServeConn(conn transport.Conn) {
+	handlers.mu.Lock()
+	shuttingDown := handlers.shuttingDown
+	handlers.mu.Unlock()
+	if shuttingDown {
+		_ = conn.CloseWithReason(transport.CloseGoingAway, "middleware shutting down")
+		return
+	}
+	noiseConfig := noisemanager.NewNoiseConfig(handlers.dataDir)
+	err := noiseConfig.InitializeNoise(conn)
 	if err != nil {
-		log.Println(err.Error() + "Noise connection failed to initialize")
+		handlers.logger.Warning("Noise connection failed to initialize", "error", err)
+		handlers.handshakes.Inc("failed")
+		handlers.recordAudit(noiseConfig.ClientStaticPubkey(), audit.ActionAuthFailed, "handshake failed: "+err.Error())
+		_ = conn.Close()
 		return
 	}
+	handlers.handshakes.Inc("ok")
 
-	sendChan, _, receiveChan, remoteHasQuitChan := handlers.runWebsocket(ws)
+	connection := handlers.runConnection(conn, noiseConfig)
 	handlers.mu.Lock()
-	handlers.clientsMap[0] = sendChan
+	id := handlers.nClients
+	handlers.clientsMap[id] = connection
 	handlers.nClients++
+	if handlers.shuttingDown {
+		connection.close(transport.CloseGoingAway, "middleware shutting down")
+	}
 	handlers.mu.Unlock()
+	go handlers.forwardState(connection)
 	go func() {
 		for {
 			select {
-			case message := <-receiveChan:
-				incoming := &basemessages.BitBoxBaseIn{}
-				if err := proto.Unmarshal(message, incoming); err != nil {
-					log.Println("protobuf unmarshal of incoming packet failed")
+			case request := <-connection.receive:
+				handlers.handleRequest(request, connection)
+			case <-connection.remoteHasQuit:
+				handlers.mu.Lock()
+				delete(handlers.clientsMap, id)
+				handlers.mu.Unlock()
+				select {
+				case <-connection.verified:
+				default:
+					// The client went away without verifying the pairing code.
+					handlers.pairings.Inc("failed")
+					handlers.recordAudit(connection.clientStaticPubkey, audit.ActionAuthFailed, "pairing not verified")
 				}
-
-				_, ok := incoming.BitBoxBaseIn.(*basemessages.BitBoxBaseIn_BaseSystemEnvIn)
-				if !ok {
-					log.Println("protobuf parsing into middlewareInfo failed")
-				}
-				go func() {
-					sendChan <- handlers.middleware.SystemEnv()
-				}()
-
-			case <-remoteHasQuitChan:
 				return
 			}
 		}
 	}()
 }
+
+// Shutdown drains the client connections: it refuses new connections and requests, waits for the requests in progress
+// to finish and then closes all client connections with transport.CloseGoingAway, so that clients know that they can
+// reconnect once the middleware is back. It waits until the close reasons were sent. If ctx is done before, the
+// remaining requests are abandoned and Shutdown returns early.
+func (handlers *Handlers) Shutdown(ctx context.Context) {
+	handlers.mu.Lock()
+	handlers.shuttingDown = true
+	// A scheduled reboot is lost with the middleware.
+	handlers.cancelReboot()
+	handlers.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		handlers.requests.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		handlers.logger.Warning("Requests still in progress, closing the connections anyway")
+	}
+
+	handlers.mu.Lock()
+	connections := make([]*connection, 0, len(handlers.clientsMap))
+	for _, connection := range handlers.clientsMap {
+		connection.close(transport.CloseGoingAway, "middleware shutting down")
+		connections = append(connections, connection)
+	}
+	handlers.mu.Unlock()
+	timeout := time.After(transport.WriteTimeout)
+	for _, connection := range connections {
+		select {
+		case <-connection.writeLoopDone:
+		case <-timeout:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// RevokePairing removes a paired client and closes its open connections with transport.CloseRevoked. The client has
+// to pair again to connect.
+func (handlers *Handlers) RevokePairing(clientStaticPubkey []byte) error {
+	if err := noisemanager.NewNoiseConfig(handlers.dataDir).RemoveClientStaticPubkey(clientStaticPubkey); err != nil {
+		return err
+	}
+	handlers.recordAudit(nil, audit.ActionPairingRevoked, "client "+audit.Fingerprint(clientStaticPubkey))
+	handlers.mu.Lock()
+	defer handlers.mu.Unlock()
+	for _, connection := range handlers.clientsMap {
+		if bytes.Equal(connection.clientStaticPubkey, clientStaticPubkey) {
+			connection.close(transport.CloseRevoked, "pairing revoked")
+		}
+	}
+	return nil
+}
+
+// closeConnections closes all client connections with the close code and reason.
+func (handlers *Handlers) closeConnections(code int, reason string) {
+	handlers.mu.Lock()
+	defer handlers.mu.Unlock()
+	for _, connection := range handlers.clientsMap {
+		connection.close(code, reason)
+	}
+}
+
+// logLevel changes the log level of the middleware, unless level is empty, and returns the protobuf serialized current
+// level.
+func (handlers *Handlers) logLevel(connection *connection, level string) ([]byte, error) {
+	if level != "" {
+		parsed, err := logging.ParseLevel(level)
+		if err != nil {
+			return nil, err
+		}
+		handlers.logger.SetLevel(parsed)
+		handlers.recordAudit(connection.clientStaticPubkey, audit.ActionConfigChanged, "log_level="+parsed.String())
+		connection.logger.Info("Changed the log level", "level", parsed)
+	}
+	return proto.Marshal(&basemessages.BitBoxBaseOut{
+		BitBoxBaseOut: &basemessages.BitBoxBaseOut_BaseLogLevelOut{
+			BaseLogLevelOut: &basemessages.BaseLogLevelOut{Level: handlers.logger.Level().String()},
+		},
+	})
+}
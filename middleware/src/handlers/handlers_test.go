@@ -4,40 +4,88 @@ import (
 	middleware "github.com/digitalbitbox/bitbox-base/middleware/src"
 	"github.com/digitalbitbox/bitbox-base/middleware/src/handlers"
 	basemessages "github.com/digitalbitbox/bitbox-base/middleware/src/messages"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/simulation"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/system"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/transport"
 	"github.com/stretchr/testify/require"
 
 	"github.com/golang/protobuf/proto"
 
 	"github.com/flynn/noise"
 
+	"context"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
 
+// testEnvironment returns the environment the tests run the middleware with.
+func testEnvironment() system.Environment {
+	return system.Environment{
+		Network:            "testnet",
+		ElectrsRPCPort:     "18442",
+		BitcoinRPCUser:     "user",
+		BitcoinRPCPassword: "password",
+		BitcoinRPCPort:     "8332",
+		LightningRPCPath:   "/home/bitcoin/.lightning",
+		DataDir:            ".base",
+	}
+}
+
+// testMiddleware returns a middleware running against simulated backends.
+func testMiddleware() *middleware.Middleware {
+	return middleware.NewMiddlewareWithBackends(testEnvironment(), simulation.New(system.NetworkTestnet).Backends())
+}
+
 const (
 	opICanHasHandShaek          = "h"
+	opICanHasIKHandShaek        = "i"
 	opICanHasPairinVerificashun = "v"
 	responseSuccess             = "\x00"
 	responseNeedsPairing        = "\x01"
+	responseNeedsXXHandshake    = "\x02"
+	// chunkHeaderFinal is the header of the last chunk of a message, see the framing package.
+	chunkHeaderFinal = "\x00"
+	// chunkHeaderAttachment is the header of the last chunk of an attachment.
+	chunkHeaderAttachment = "\x02"
 )
 
-func TestRootHandler(t *testing.T) {
-	middlewareInstance := middleware.NewMiddleware("user", "password", "8332", "/home/bitcoin/.lightning", "18442", "testnet")
+func TestHealthHandler(t *testing.T) {
+	middlewareInstance := testMiddleware()
 	handlers := handlers.NewHandlers(middlewareInstance, ".base")
-	req, err := http.NewRequest("GET", "/", nil)
-	require.NoError(t, err)
-	rr := httptest.NewRecorder()
-	handlers.Router.ServeHTTP(rr, req)
-	require.Equal(t, rr.Code, http.StatusOK)
-	require.Equal(t, rr.Body.String(), "OK!!\n")
+	for _, path := range []string{"/", "/health"} {
+		req, err := http.NewRequest("GET", path, nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		handlers.Router.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+		var health struct {
+			Status   string            `json:"status"`
+			Alive    bool              `json:"alive"`
+			Backends map[string]string `json:"backends"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &health))
+		require.True(t, health.Alive)
+		require.Contains(t, []string{"ok", "degraded", "down"}, health.Status)
+		require.Len(t, health.Backends, 3)
+		// Errors are only sent to paired clients.
+		require.NotContains(t, rr.Body.String(), "error")
+	}
 }
 
 func TestWebsocketHandler(t *testing.T) {
-	middlewareInstance := middleware.NewMiddleware("user", "password", "8332", "/home/bitcoin/.lightning", "18442", "testnet")
+	middlewareInstance := testMiddleware()
 	handlers := handlers.NewHandlers(middlewareInstance, ".base")
 	rr := httptest.NewServer(handlers.Router)
 	defer rr.Close()
@@ -48,7 +96,7 @@ func TestWebsocketHandler(t *testing.T) {
 	require.NoError(t, err)
 
 	//initialize noise
-	_, sendCipher := initializeNoise(ws, t)
+	_, sendCipher := initializeNoise(transport.NewWebsocketConn(ws), t)
 
 	//test sending to an unpaired api
 	encryptedMessage := sendCipher.Encrypt(nil, nil, []byte(opICanHasPairinVerificashun))
@@ -66,7 +114,7 @@ func TestWebsocketHandler(t *testing.T) {
 	defer ws.Close()
 
 	//initialize noise
-	receiveCipher, sendCipher := initializeNoise(ws, t)
+	receiveCipher, sendCipher := initializeNoise(transport.NewWebsocketConn(ws), t)
 
 	//do the pairing verificaion
 	err = ws.WriteMessage(1, []byte(opICanHasPairinVerificashun))
@@ -82,7 +130,54 @@ func TestWebsocketHandler(t *testing.T) {
 	}
 	data, err := proto.Marshal(outgoing)
 	require.NoError(t, err)
-	err = ws.WriteMessage(1, sendCipher.Encrypt(nil, nil, data))
+	err = ws.WriteMessage(1, sendCipher.Encrypt(nil, nil, append([]byte(chunkHeaderFinal), data...)))
+	require.NoError(t, err)
+	_, responseBytes, err = ws.ReadMessage()
+	require.NoError(t, err)
+	_, err = receiveCipher.Decrypt(nil, nil, responseBytes)
+	require.NoError(t, err)
+}
+
+func TestWebsocketHandlerIK(t *testing.T) {
+	middlewareInstance := testMiddleware()
+	handlers := handlers.NewHandlers(middlewareInstance, ".base")
+	rr := httptest.NewServer(handlers.Router)
+	defer rr.Close()
+
+	u := "ws://" + rr.Listener.Addr().String() + "/ws"
+
+	cipherSuite := noise.NewCipherSuite(noise.DH25519, noise.CipherChaChaPoly, noise.HashSHA256)
+	kp, err := cipherSuite.GenerateKeypair(rand.Reader)
+	require.NoError(t, err)
+
+	// an unpaired client has to fall back to the XX handshake
+	ws, _, err := websocket.DefaultDialer.Dial(u, nil)
+	require.NoError(t, err)
+	_, _, ok := initializeNoiseIK(transport.NewWebsocketConn(ws), kp, make([]byte, 32), t)
+	require.False(t, ok)
+	_, _, baseStaticPubkey := initializeNoiseWithKeypair(transport.NewWebsocketConn(ws), kp, t)
+	err = ws.WriteMessage(1, []byte(opICanHasPairinVerificashun))
+	require.NoError(t, err)
+	_, responseBytes, err := ws.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, string(responseBytes), string(responseSuccess))
+	ws.Close()
+
+	// the paired client can now reconnect with the IK handshake
+	ws, _, err = websocket.DefaultDialer.Dial(u, nil)
+	require.NoError(t, err)
+	defer ws.Close()
+	receiveCipher, sendCipher, ok := initializeNoiseIK(transport.NewWebsocketConn(ws), kp, baseStaticPubkey, t)
+	require.True(t, ok)
+
+	outgoing := &basemessages.BitBoxBaseIn{
+		BitBoxBaseIn: &basemessages.BitBoxBaseIn_BaseSystemEnvIn{
+			BaseSystemEnvIn: &basemessages.BaseSystemEnvIn{},
+		},
+	}
+	data, err := proto.Marshal(outgoing)
+	require.NoError(t, err)
+	err = ws.WriteMessage(1, sendCipher.Encrypt(nil, nil, append([]byte(chunkHeaderFinal), data...)))
 	require.NoError(t, err)
 	_, responseBytes, err = ws.ReadMessage()
 	require.NoError(t, err)
@@ -90,13 +185,257 @@ func TestWebsocketHandler(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestStreamHandler(t *testing.T) {
+	middlewareInstance := testMiddleware()
+	handlers := handlers.NewHandlers(middlewareInstance, ".base")
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		_ = handlers.Serve(listener)
+	}()
+
+	tcpConn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	client := transport.NewStreamConn(tcpConn)
+	defer client.Close()
+
+	//initialize noise and do the pairing verification
+	receiveCipher, sendCipher := initializeNoise(client, t)
+	err = client.WriteMessage([]byte(opICanHasPairinVerificashun))
+	require.NoError(t, err)
+	responseBytes, err := client.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, string(responseBytes), string(responseSuccess))
+
+	outgoing := &basemessages.BitBoxBaseIn{
+		BitBoxBaseIn: &basemessages.BitBoxBaseIn_BaseSystemEnvIn{
+			BaseSystemEnvIn: &basemessages.BaseSystemEnvIn{},
+		},
+	}
+	data, err := proto.Marshal(outgoing)
+	require.NoError(t, err)
+	err = client.WriteMessage(sendCipher.Encrypt(nil, nil, append([]byte(chunkHeaderFinal), data...)))
+	require.NoError(t, err)
+	// The state snapshot sent after the pairing may arrive before the response.
+	for {
+		responseBytes, err = client.ReadMessage()
+		require.NoError(t, err)
+		decrypted, err := receiveCipher.Decrypt(nil, nil, responseBytes)
+		require.NoError(t, err)
+		require.Equal(t, chunkHeaderFinal, string(decrypted[:1]))
+		incoming := &basemessages.BitBoxBaseOut{}
+		require.NoError(t, proto.Unmarshal(decrypted[1:], incoming))
+		if incoming.GetBaseStateOut() != nil || incoming.GetBaseMiddlewareInfoOut() != nil {
+			continue
+		}
+		require.Equal(t, "testnet", incoming.GetBaseSystemEnvOut().GetNetwork())
+		break
+	}
+
+	// The request is counted right after the response was sent.
+	var metrics string
+	for i := 0; i < 100; i++ {
+		rr := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/metrics", nil)
+		require.NoError(t, err)
+		handlers.MetricsHandler.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+		metrics = rr.Body.String()
+		if strings.Contains(metrics, `base_middleware_rpc_requests_total{rpc="BaseSystemEnvIn",result="ok"} 1`) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Contains(t, metrics, `base_middleware_rpc_requests_total{rpc="BaseSystemEnvIn",result="ok"} 1`)
+	require.Contains(t, metrics, `base_middleware_rpc_duration_seconds_count{rpc="BaseSystemEnvIn"} 1`)
+	require.Contains(t, metrics, `base_middleware_handshakes_total{result="ok"} 1`)
+	require.Contains(t, metrics, `base_middleware_pairings_total{result="ok"} 1`)
+	require.Contains(t, metrics, "\nbase_middleware_clients 1\n")
+	require.Contains(t, metrics, "\nbase_middleware_state_subscribers 1\n")
+	require.Contains(t, metrics, "# TYPE base_middleware_backend_errors_total counter\n")
+
+	// The metrics are not served along with the websocket, which is reachable over tor.
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/metrics", nil)
+	require.NoError(t, err)
+	handlers.Router.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+// dialPaired connects a new client to the handlers over TCP and verifies the pairing. The returned function stops
+// the handlers.
+func dialPaired(t *testing.T, dataDir string) (transport.Conn, *noise.CipherState, *noise.CipherState, func()) {
+	handlers := handlers.NewHandlers(testMiddleware(), dataDir)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = handlers.Serve(listener)
+	}()
+	tcpConn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	client := transport.NewStreamConn(tcpConn)
+	receiveCipher, sendCipher := initializeNoise(client, t)
+	require.NoError(t, client.WriteMessage([]byte(opICanHasPairinVerificashun)))
+	responseBytes, err := client.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, string(responseBytes), string(responseSuccess))
+	return client, receiveCipher, sendCipher, func() {
+		_ = client.Close()
+		_ = listener.Close()
+	}
+}
+
+func TestRequestIDEncodedFirst(t *testing.T) {
+	dataDir, err := ioutil.TempDir("", "bbb-handlers")
+	require.NoError(t, err)
+	defer os.RemoveAll(dataDir)
+	client, receiveCipher, sendCipher, stop := dialPaired(t, dataDir)
+	defer stop()
+
+	// Marshalling puts the RequestId before the rpc, the update has to be recognized anyway.
+	update := []byte("update file")
+	hash := sha256.Sum256(update)
+	data, err := proto.Marshal(&basemessages.BitBoxBaseIn{
+		RequestId: 7,
+		BitBoxBaseIn: &basemessages.BitBoxBaseIn_BaseUpdateIn{
+			BaseUpdateIn: &basemessages.BaseUpdateIn{Size: int64(len(update)), Sha256: hash[:]},
+		},
+	})
+	require.NoError(t, err)
+	tag, _ := proto.DecodeVarint(data)
+	require.Equal(t, uint64(100), tag>>3)
+	require.NoError(t, client.WriteMessage(sendCipher.Encrypt(nil, nil, append([]byte(chunkHeaderFinal), data...))))
+	require.NoError(t, client.WriteMessage(sendCipher.Encrypt(nil, nil, append([]byte(chunkHeaderAttachment), update...))))
+	for {
+		responseBytes, err := client.ReadMessage()
+		require.NoError(t, err)
+		decrypted, err := receiveCipher.Decrypt(nil, nil, responseBytes)
+		require.NoError(t, err)
+		incoming := &basemessages.BitBoxBaseOut{}
+		require.NoError(t, proto.Unmarshal(decrypted[1:], incoming))
+		if incoming.RequestId == 0 {
+			continue
+		}
+		require.Equal(t, uint64(7), incoming.RequestId)
+		require.Nil(t, incoming.GetBaseErrorOut())
+		require.NotEmpty(t, incoming.GetBaseUpdateOut().GetPath())
+		break
+	}
+}
+
+func TestMessageSizeLimit(t *testing.T) {
+	client, receiveCipher, sendCipher, stop := dialPaired(t, ".base")
+	defer stop()
+
+	// The limit of the rpc applies even though marshalling puts the RequestId first.
+	data, err := proto.Marshal(&basemessages.BitBoxBaseIn{
+		RequestId: 1,
+		BitBoxBaseIn: &basemessages.BitBoxBaseIn_BaseConfigSetIn{
+			BaseConfigSetIn: &basemessages.BaseConfigSetIn{Key: "tor_enabled", Value: strings.Repeat("a", 2000)},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.WriteMessage(sendCipher.Encrypt(nil, nil, append([]byte(chunkHeaderFinal), data...))))
+	// The connection is closed without an answer.
+	for {
+		responseBytes, err := client.ReadMessage()
+		if err != nil {
+			break
+		}
+		decrypted, err := receiveCipher.Decrypt(nil, nil, responseBytes)
+		require.NoError(t, err)
+		incoming := &basemessages.BitBoxBaseOut{}
+		require.NoError(t, proto.Unmarshal(decrypted[1:], incoming))
+		require.Equal(t, uint64(0), incoming.RequestId)
+	}
+}
+
+// readUntilClosed reads messages until the connection fails and returns the error.
+func readUntilClosed(client transport.Conn) error {
+	for {
+		if _, err := client.ReadMessage(); err != nil {
+			return err
+		}
+	}
+}
+
+func TestRevokePairing(t *testing.T) {
+	dataDir, err := ioutil.TempDir("", "bbb-handlers")
+	require.NoError(t, err)
+	defer os.RemoveAll(dataDir)
+	middlewareInstance := testMiddleware()
+	handlers := handlers.NewHandlers(middlewareInstance, dataDir)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		_ = handlers.Serve(listener)
+	}()
+
+	cipherSuite := noise.NewCipherSuite(noise.DH25519, noise.CipherChaChaPoly, noise.HashSHA256)
+	kp, err := cipherSuite.GenerateKeypair(rand.Reader)
+	require.NoError(t, err)
+	tcpConn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	client := transport.NewStreamConn(tcpConn)
+	_, _, baseStaticPubkey := initializeNoiseWithKeypair(client, kp, t)
+	require.NoError(t, client.WriteMessage([]byte(opICanHasPairinVerificashun)))
+	responseBytes, err := client.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, string(responseSuccess), string(responseBytes))
+
+	// The open connection of the revoked client is closed with a reason.
+	require.NoError(t, handlers.RevokePairing(kp.Public))
+	require.Equal(t, &transport.CloseError{Code: transport.CloseRevoked, Reason: "pairing revoked"}, readUntilClosed(client))
+	require.Error(t, handlers.RevokePairing(kp.Public))
+
+	// The revoked client can not reconnect with the IK handshake anymore.
+	tcpConn, err = net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	client = transport.NewStreamConn(tcpConn)
+	defer client.Close()
+	_, _, ok := initializeNoiseIK(client, kp, baseStaticPubkey, t)
+	require.False(t, ok)
+}
+
+func TestShutdown(t *testing.T) {
+	middlewareInstance := testMiddleware()
+	handlers := handlers.NewHandlers(middlewareInstance, ".base")
+	rr := httptest.NewServer(handlers.Router)
+	defer rr.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws://"+rr.Listener.Addr().String()+"/ws", nil)
+	require.NoError(t, err)
+	client := transport.NewWebsocketConn(ws)
+	defer client.Close()
+	initializeNoise(client, t)
+
+	handlers.Shutdown(context.Background())
+	require.Equal(t, &transport.CloseError{Code: transport.CloseGoingAway, Reason: "middleware shutting down"}, readUntilClosed(client))
+
+	// New connections are refused while shutting down.
+	ws, _, err = websocket.DefaultDialer.Dial("ws://"+rr.Listener.Addr().String()+"/ws", nil)
+	require.NoError(t, err)
+	refused := transport.NewWebsocketConn(ws)
+	defer refused.Close()
+	require.Equal(t, &transport.CloseError{Code: transport.CloseGoingAway, Reason: "middleware shutting down"}, readUntilClosed(refused))
+}
+
 // initializeNoise sets up a new noise connection. First a fresh keypair is generated if none is locally found.
 // Afterwards a XX handshake is performed. This is a three part handshake required to authenticate both parties.
 // The resulting pairing code is then displayed to the user to check if it matches what is displayed on the other party's device.
-func initializeNoise(client *websocket.Conn, t *testing.T) (*noise.CipherState, *noise.CipherState) {
+func initializeNoise(client transport.Conn, t *testing.T) (*noise.CipherState, *noise.CipherState) {
 	cipherSuite := noise.NewCipherSuite(noise.DH25519, noise.CipherChaChaPoly, noise.HashSHA256)
 	kp, err := cipherSuite.GenerateKeypair(rand.Reader)
 	require.NoError(t, err)
+	receiveCipher, sendCipher, _ := initializeNoiseWithKeypair(client, kp, t)
+	return receiveCipher, sendCipher
+}
+
+// initializeNoiseWithKeypair performs the XX handshake with the given client keypair and additionally returns the static pubkey of the base.
+func initializeNoiseWithKeypair(client transport.Conn, kp noise.DHKey, t *testing.T) (*noise.CipherState, *noise.CipherState, []byte) {
+	cipherSuite := noise.NewCipherSuite(noise.DH25519, noise.CipherChaChaPoly, noise.HashSHA256)
 	handshake, err := noise.NewHandshakeState(noise.Config{
 		CipherSuite:   cipherSuite,
 		Random:        rand.Reader,
@@ -108,29 +447,93 @@ func initializeNoise(client *websocket.Conn, t *testing.T) (*noise.CipherState,
 	require.NoError(t, err)
 
 	//Ask the BitBox Base to begin the noise 'XX' handshake
-	err = client.WriteMessage(1, []byte(opICanHasHandShaek))
+	err = client.WriteMessage([]byte(opICanHasHandShaek))
 	require.NoError(t, err)
-	_, responseBytes, err := client.ReadMessage()
+	responseBytes, err := client.ReadMessage()
 	require.NoError(t, err)
 	require.Equal(t, string(responseBytes), string(responseSuccess))
 	// Do 3 part noise 'XX' handshake.
 	msg, _, _, err := handshake.WriteMessage(nil, nil)
 	require.NoError(t, err)
-	err = client.WriteMessage(websocket.BinaryMessage, msg)
+	err = client.WriteMessage(msg)
 	require.NoError(t, err)
-	_, responseBytes, err = client.ReadMessage()
+	responseBytes, err = client.ReadMessage()
 	require.NoError(t, err)
 	_, _, _, err = handshake.ReadMessage(nil, responseBytes)
 	require.NoError(t, err)
 	msg, receiveCipher, sendCipher, err := handshake.WriteMessage(nil, nil)
 	require.NoError(t, err)
-	err = client.WriteMessage(websocket.BinaryMessage, msg)
+	err = client.WriteMessage(msg)
 	require.NoError(t, err)
 
 	//read the pairing verification request
-	_, responseBytes, err = client.ReadMessage()
+	responseBytes, err = client.ReadMessage()
 	require.NoError(t, err)
 	require.Equal(t, string(responseBytes), string(responseNeedsPairing))
 
-	return receiveCipher, sendCipher
+	return receiveCipher, sendCipher, handshake.PeerStatic()
+}
+
+func TestWebsocketHandlerSingleIKAttempt(t *testing.T) {
+	middlewareInstance := testMiddleware()
+	handlers := handlers.NewHandlers(middlewareInstance, ".base")
+	rr := httptest.NewServer(handlers.Router)
+	defer rr.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws://"+rr.Listener.Addr().String()+"/ws", nil)
+	require.NoError(t, err)
+	defer ws.Close()
+	client := transport.NewWebsocketConn(ws)
+	cipherSuite := noise.NewCipherSuite(noise.DH25519, noise.CipherChaChaPoly, noise.HashSHA256)
+	kp, err := cipherSuite.GenerateKeypair(rand.Reader)
+	require.NoError(t, err)
+
+	_, _, ok := initializeNoiseIK(client, kp, make([]byte, 32), t)
+	require.False(t, ok)
+	// A second IK attempt on the same connection is not answered, the connection is closed instead.
+	handshake, err := noise.NewHandshakeState(noise.Config{
+		CipherSuite:   cipherSuite,
+		Random:        rand.Reader,
+		Pattern:       noise.HandshakeIK,
+		StaticKeypair: kp,
+		PeerStatic:    make([]byte, 32),
+		Prologue:      []byte("Noise_IK_25519_ChaChaPoly_SHA256"),
+		Initiator:     true,
+	})
+	require.NoError(t, err)
+	msg, _, _, err := handshake.WriteMessage(nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, client.WriteMessage(append([]byte(opICanHasIKHandShaek), msg...)))
+	_, err = client.ReadMessage()
+	require.Error(t, err)
+}
+
+// initializeNoiseIK tries the two part noise 'IK' handshake with a known static pubkey of the base.
+// It returns false if the base rejected the handshake and the XX handshake needs to be performed instead.
+func initializeNoiseIK(client transport.Conn, kp noise.DHKey, baseStaticPubkey []byte, t *testing.T) (*noise.CipherState, *noise.CipherState, bool) {
+	cipherSuite := noise.NewCipherSuite(noise.DH25519, noise.CipherChaChaPoly, noise.HashSHA256)
+	handshake, err := noise.NewHandshakeState(noise.Config{
+		CipherSuite:   cipherSuite,
+		Random:        rand.Reader,
+		Pattern:       noise.HandshakeIK,
+		StaticKeypair: kp,
+		PeerStatic:    baseStaticPubkey,
+		Prologue:      []byte("Noise_IK_25519_ChaChaPoly_SHA256"),
+		Initiator:     true,
+	})
+	require.NoError(t, err)
+
+	msg, _, _, err := handshake.WriteMessage(nil, nil)
+	require.NoError(t, err)
+	err = client.WriteMessage(append([]byte(opICanHasIKHandShaek), msg...))
+	require.NoError(t, err)
+	responseBytes, err := client.ReadMessage()
+	require.NoError(t, err)
+	if string(responseBytes) == string(responseNeedsXXHandshake) {
+		return nil, nil, false
+	}
+	payload, receiveCipher, sendCipher, err := handshake.ReadMessage(nil, responseBytes)
+	require.NoError(t, err)
+	require.Equal(t, string(payload), string(responseSuccess))
+	return receiveCipher, sendCipher, true
 }
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	basemessages "github.com/digitalbitbox/bitbox-base/middleware/src/messages"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/system"
+)
+
+const (
+	// maxLogLines limits the number of past log lines a client can request.
+	maxLogLines = 10000
+	// maxLogStreams limits the number of log requests served at the same time, each of them runs journalctl.
+	maxLogStreams = 4
+)
+
+// logsQuery validates a logs request and returns the journal query for it.
+func logsQuery(request *basemessages.BaseLogsIn) (system.JournalQuery, error) {
+	lines := int(request.Lines)
+	if lines < 0 || lines > maxLogLines {
+		return system.JournalQuery{}, errors.New("invalid number of log lines")
+	}
+	priority, err := system.ParsePriority(request.Priority)
+	if err != nil {
+		return system.JournalQuery{}, err
+	}
+	if request.Since < 0 {
+		return system.JournalQuery{}, errors.New("invalid since timestamp")
+	}
+	query := system.JournalQuery{
+		Unit:     request.Unit,
+		Lines:    lines,
+		Follow:   request.Follow,
+		Priority: priority,
+	}
+	if request.Since > 0 {
+		query.Since = time.Unix(request.Since, 0)
+	}
+	return query, nil
+}
+
+// acquireLogStream reserves one of the log streams. It fails if maxLogStreams are in use already. The returned
+// function releases the stream.
+func (handlers *Handlers) acquireLogStream() (release func(), err error) {
+	handlers.mu.Lock()
+	defer handlers.mu.Unlock()
+	if handlers.logStreams >= maxLogStreams {
+		return nil, errors.New("too many log streams, at most " + strconv.Itoa(maxLogStreams) + " are allowed")
+	}
+	handlers.logStreams++
+	return func() {
+		handlers.mu.Lock()
+		defer handlers.mu.Unlock()
+		handlers.logStreams--
+	}, nil
+}
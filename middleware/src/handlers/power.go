@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/digitalbitbox/bitbox-base/middleware/src/audit"
+	basemessages "github.com/digitalbitbox/bitbox-base/middleware/src/messages"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/system"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/transport"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// maxRebootSchedule limits how far ahead a maintenance reboot can be scheduled.
+const maxRebootSchedule = 30 * 24 * time.Hour
+
+// powerOut returns a protobuf serialized BasePowerOut with a step of powering down. The last message of a request is
+// done and carries the scheduled reboot.
+func (handlers *Handlers) powerOut(step string, done bool) ([]byte, error) {
+	out := &basemessages.BasePowerOut{Step: step, Done: done}
+	if done {
+		handlers.mu.Lock()
+		if handlers.scheduledReboot != nil {
+			out.ScheduledReboot = handlers.scheduledRebootAt.Unix()
+		}
+		handlers.mu.Unlock()
+	}
+	return proto.Marshal(&basemessages.BitBoxBaseOut{
+		BitBoxBaseOut: &basemessages.BitBoxBaseOut_BasePowerOut{BasePowerOut: out},
+	})
+}
+
+// powerDown stops the services in order, calling progress with every step, then reboots or shuts down the Base and
+// closes all connections with transport.CloseGoingAway. progress is called with the action last, once powering down
+// succeeded. If a step fails, the clients stay connected to be told. A scheduled reboot is cancelled, and only one
+// power down runs at a time.
+func (handlers *Handlers) powerDown(action system.PowerAction, progress func(step string, done bool)) error {
+	handlers.mu.Lock()
+	if handlers.poweringDown {
+		handlers.mu.Unlock()
+		return errors.New("the Base is already powering down")
+	}
+	handlers.poweringDown = true
+	handlers.cancelReboot()
+	handlers.mu.Unlock()
+	// systemd stops the middleware right after powering down, unless that failed.
+	defer func() {
+		handlers.mu.Lock()
+		handlers.poweringDown = false
+		handlers.mu.Unlock()
+	}()
+
+	err := handlers.middleware.StopServices(func(service string) {
+		progress("stopping "+service, false)
+	})
+	if err != nil {
+		return err
+	}
+	if err := handlers.middleware.Power(action); err != nil {
+		return err
+	}
+	progress(string(action), true)
+	reason := "Base rebooting"
+	if action == system.PowerShutdown {
+		reason = "Base shutting down"
+	}
+	handlers.closeConnections(transport.CloseGoingAway, reason)
+	return nil
+}
+
+// cancelReboot cancels the scheduled reboot, if there is one. The lock must be held.
+func (handlers *Handlers) cancelReboot() {
+	if handlers.scheduledReboot != nil {
+		handlers.scheduledReboot.Stop()
+		handlers.scheduledReboot = nil
+	}
+}
+
+// scheduleReboot schedules a maintenance reboot, replacing an earlier one.
+func (handlers *Handlers) scheduleReboot(at time.Time) error {
+	if !at.After(time.Now()) || at.After(time.Now().Add(maxRebootSchedule)) {
+		return errors.New("reboots can only be scheduled within the next 30 days")
+	}
+	handlers.mu.Lock()
+	defer handlers.mu.Unlock()
+	handlers.cancelReboot()
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Until(at), func() {
+		handlers.mu.Lock()
+		// The timer may fire while it is replaced or cancelled.
+		if handlers.scheduledReboot != timer {
+			handlers.mu.Unlock()
+			return
+		}
+		handlers.scheduledReboot = nil
+		handlers.mu.Unlock()
+		handlers.recordAudit(nil, audit.ActionPower, "scheduled reboot")
+		if err := handlers.powerDown(system.PowerReboot, func(string, bool) {}); err != nil {
+			handlers.logger.Error("Scheduled reboot failed", "error", err)
+		}
+	})
+	handlers.scheduledReboot = timer
+	handlers.scheduledRebootAt = at
+	return nil
+}
+
+// power handles a BasePowerIn request, sending the protobuf serialized responses with send. Rebooting or shutting down
+// right away reports every step, the other requests are answered with a single message.
+func (handlers *Handlers) power(connection *connection, rpc *basemessages.BasePowerIn, send func([]byte)) error {
+	sendDone := func() error {
+		response, err := handlers.powerOut("", true)
+		if err != nil {
+			return err
+		}
+		send(response)
+		return nil
+	}
+	if rpc.CancelSchedule {
+		handlers.mu.Lock()
+		handlers.cancelReboot()
+		handlers.mu.Unlock()
+		handlers.recordAudit(connection.clientStaticPubkey, audit.ActionPower, "scheduled reboot cancelled")
+		return sendDone()
+	}
+	if rpc.Action == "" {
+		return sendDone()
+	}
+	action, err := system.ParsePowerAction(rpc.Action)
+	if err != nil {
+		return err
+	}
+	if rpc.ScheduledAt != 0 {
+		if action != system.PowerReboot {
+			return errors.New("only reboots can be scheduled")
+		}
+		at := time.Unix(rpc.ScheduledAt, 0)
+		if err := handlers.scheduleReboot(at); err != nil {
+			return err
+		}
+		handlers.recordAudit(connection.clientStaticPubkey, audit.ActionPower,
+			"reboot scheduled at "+at.UTC().Format(time.RFC3339))
+		connection.logger.Info("Scheduled a reboot", "at", at)
+		return sendDone()
+	}
+	handlers.recordAudit(connection.clientStaticPubkey, audit.ActionPower, string(action))
+	return handlers.powerDown(action, func(step string, done bool) {
+		response, err := handlers.powerOut(step, done)
+		if err != nil {
+			connection.logger.Error("Failed to marshal the power progress", "error", err)
+			return
+		}
+		send(response)
+	})
+}
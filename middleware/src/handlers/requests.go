@@ -0,0 +1,331 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/digitalbitbox/bitbox-base/middleware/src/audit"
+	basemessages "github.com/digitalbitbox/bitbox-base/middleware/src/messages"
+	noisemanager "github.com/digitalbitbox/bitbox-base/middleware/src/noise"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/system"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/transport"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// handleRequest relays a request of a client to the middleware and sends the response back. Every request is handled
+// in its own goroutine, so that long running requests like following logs do not block other requests.
+func (handlers *Handlers) handleRequest(request request, connection *connection) {
+	// requestID is sent back with every response, once the request is unmarshalled.
+	var requestID uint64
+	send := func(message []byte) {
+		select {
+		case connection.send <- basemessages.AppendRequestID(message, requestID):
+		case <-connection.remoteHasQuit:
+		}
+	}
+	sendWithAttachment := func(message []byte, attachment io.Reader) error {
+		done := make(chan error, 1)
+		message = basemessages.AppendRequestID(message, requestID)
+		select {
+		case connection.sendAttachment <- response{message: message, attachment: attachment, done: done}:
+			return <-done
+		case <-connection.remoteHasQuit:
+			return errors.New("connection closed")
+		}
+	}
+	// releaseAttachment lets the reading loop go on once the attachment of the request was consumed, or will not be.
+	releaseAttachment := func() {
+		if request.done != nil {
+			close(request.done)
+		}
+	}
+	// failed is set if the request is answered with an error, for the metrics.
+	failed := false
+	sendError := func(err error) {
+		failed = true
+		connection.logger.Warning("Request failed", "rpc", basemessages.RPCField(request.incoming), "error", err)
+		response, marshalErr := proto.Marshal(&basemessages.BitBoxBaseOut{
+			BitBoxBaseOut: &basemessages.BitBoxBaseOut_BaseErrorOut{
+				BaseErrorOut: &basemessages.BaseErrorOut{
+					RequestField: basemessages.RPCField(request.incoming),
+					Message:      err.Error(),
+				},
+			},
+		})
+		if marshalErr != nil {
+			connection.logger.Error("Failed to marshal error response", "error", marshalErr)
+			return
+		}
+		send(response)
+	}
+
+	incoming := request.incoming
+	if incoming == nil {
+		releaseAttachment()
+		sendError(errors.New("protobuf unmarshal of incoming packet failed"))
+		handlers.rpcRequests.Inc("invalid", "error")
+		return
+	}
+	requestID = incoming.RequestId
+	name := rpcName(incoming)
+
+	if required := requiredRole(incoming); !noisemanager.RoleAllows(handlers.clientRole(connection), required) {
+		releaseAttachment()
+		sendError(errors.New("permission denied, " + name + " requires the " + required + " role"))
+		handlers.rpcRequests.Inc(name, "denied")
+		handlers.recordAudit(connection.clientStaticPubkey, audit.ActionAuthFailed, "permission denied for "+name)
+		return
+	}
+
+	// Followed log streams only end with the connection, so Shutdown does not wait for them.
+	tracked := !incoming.GetBaseLogsIn().GetFollow()
+	handlers.mu.Lock()
+	shuttingDown := handlers.shuttingDown
+	if !shuttingDown && tracked {
+		handlers.requests.Add(1)
+	}
+	if !shuttingDown {
+		handlers.inProgress++
+	}
+	handlers.mu.Unlock()
+	if shuttingDown {
+		releaseAttachment()
+		sendError(errors.New("middleware shutting down"))
+		handlers.rpcRequests.Inc(name, "error")
+		return
+	}
+
+	go func() {
+		if tracked {
+			defer handlers.requests.Done()
+		}
+		start := time.Now()
+		defer func() {
+			handlers.mu.Lock()
+			handlers.inProgress--
+			handlers.mu.Unlock()
+			result := "ok"
+			if failed {
+				result = "error"
+			}
+			handlers.rpcRequests.Inc(name, result)
+			// Followed log streams last as long as the client wants, their duration says nothing about the latency.
+			if tracked {
+				handlers.rpcDuration.Observe(time.Since(start).Seconds(), name)
+			}
+		}()
+		switch rpc := incoming.BitBoxBaseIn.(type) {
+		case *basemessages.BitBoxBaseIn_BaseSystemEnvIn:
+			send(handlers.middleware.SystemEnv())
+		case *basemessages.BitBoxBaseIn_BaseServicesIn:
+			send(handlers.middleware.Services())
+		case *basemessages.BitBoxBaseIn_BaseHealthIn:
+			response, err := handlers.middleware.Health()
+			if err != nil {
+				sendError(err)
+				return
+			}
+			send(response)
+		case *basemessages.BitBoxBaseIn_BaseConfigGetIn:
+			response, err := handlers.middleware.ConfigGet(rpc.BaseConfigGetIn.Key)
+			if err != nil {
+				sendError(err)
+				return
+			}
+			send(response)
+		case *basemessages.BitBoxBaseIn_BaseConfigSetIn:
+			response, err := handlers.middleware.ConfigSet(rpc.BaseConfigSetIn.Key, rpc.BaseConfigSetIn.Value)
+			if err != nil {
+				sendError(err)
+				return
+			}
+			handlers.recordConfigSet(connection.clientStaticPubkey, rpc.BaseConfigSetIn.Key, rpc.BaseConfigSetIn.Value)
+			send(response)
+		case *basemessages.BitBoxBaseIn_BaseLogsIn:
+			query, err := logsQuery(rpc.BaseLogsIn)
+			if err != nil {
+				sendError(err)
+				return
+			}
+			release, err := handlers.acquireLogStream()
+			if err != nil {
+				sendError(err)
+				return
+			}
+			defer release()
+			err = handlers.middleware.Logs(query, rpc.BaseLogsIn.Structured, connection.remoteHasQuit, send)
+			if err != nil {
+				sendError(err)
+			}
+		case *basemessages.BitBoxBaseIn_BaseUpdateIn:
+			path, err := handlers.stageUpdate(rpc.BaseUpdateIn.Size, rpc.BaseUpdateIn.Sha256, request.attachment)
+			releaseAttachment()
+			if err != nil {
+				sendError(err)
+				return
+			}
+			handlers.recordAudit(connection.clientStaticPubkey, audit.ActionUpdateStaged,
+				"sha256 "+hex.EncodeToString(rpc.BaseUpdateIn.Sha256)+", "+strconv.FormatInt(rpc.BaseUpdateIn.Size, 10)+" bytes")
+			response, err := proto.Marshal(&basemessages.BitBoxBaseOut{
+				BitBoxBaseOut: &basemessages.BitBoxBaseOut_BaseUpdateOut{
+					BaseUpdateOut: &basemessages.BaseUpdateOut{Path: path},
+				},
+			})
+			if err != nil {
+				sendError(err)
+				return
+			}
+			send(response)
+		case *basemessages.BitBoxBaseIn_BaseSupportBundleIn:
+			response, bundle, err := handlers.supportBundle()
+			if err != nil {
+				sendError(err)
+				return
+			}
+			defer bundle.Close()
+			if err := sendWithAttachment(response, bundle); err != nil {
+				failed = true
+				connection.logger.Warning("Failed to send the support bundle", "error", err)
+			}
+		case *basemessages.BitBoxBaseIn_BaseLightningBackupIn:
+			response, archive, err := handlers.lightningBackup(connection, rpc.BaseLightningBackupIn)
+			if err != nil {
+				sendError(err)
+				return
+			}
+			if archive == nil {
+				send(response)
+				return
+			}
+			if err := sendWithAttachment(response, bytes.NewReader(archive)); err != nil {
+				failed = true
+				connection.logger.Warning("Failed to send the lightning backup", "error", err)
+			}
+		case *basemessages.BitBoxBaseIn_BaseLightningRestoreIn:
+			data, err := handlers.restoreData(rpc.BaseLightningRestoreIn.DriveFilename, rpc.BaseLightningRestoreIn.Size,
+				rpc.BaseLightningRestoreIn.Sha256, request.attachment)
+			releaseAttachment()
+			if err != nil {
+				sendError(err)
+				return
+			}
+			response, err := handlers.lightningRestore(connection, rpc.BaseLightningRestoreIn.Passphrase, data)
+			if err != nil {
+				sendError(err)
+				return
+			}
+			send(response)
+		case *basemessages.BitBoxBaseIn_BaseConfigBackupIn:
+			response, archive, err := handlers.configBackup(connection, rpc.BaseConfigBackupIn)
+			if err != nil {
+				sendError(err)
+				return
+			}
+			if archive == nil {
+				send(response)
+				return
+			}
+			if err := sendWithAttachment(response, bytes.NewReader(archive)); err != nil {
+				failed = true
+				connection.logger.Warning("Failed to send the configuration backup", "error", err)
+			}
+		case *basemessages.BitBoxBaseIn_BaseConfigRestoreIn:
+			data, err := handlers.restoreData(rpc.BaseConfigRestoreIn.DriveFilename, rpc.BaseConfigRestoreIn.Size,
+				rpc.BaseConfigRestoreIn.Sha256, request.attachment)
+			releaseAttachment()
+			if err != nil {
+				sendError(err)
+				return
+			}
+			response, err := handlers.configRestore(connection, rpc.BaseConfigRestoreIn.Passphrase,
+				rpc.BaseConfigRestoreIn.Signer, data)
+			if err != nil {
+				sendError(err)
+				return
+			}
+			send(response)
+			// The clients have to connect to the restored keypair.
+			handlers.closeConnections(transport.CloseRestored, "configuration restored")
+		case *basemessages.BitBoxBaseIn_BaseFactoryResetIn:
+			response, confirmed, err := handlers.factoryReset(connection, rpc.BaseFactoryResetIn)
+			if err != nil && !confirmed {
+				sendError(err)
+				return
+			}
+			if response != nil {
+				send(response)
+			}
+			if !confirmed {
+				return
+			}
+			// The clients have to pair again once the Base rebooted. Shutting the middleware down on the reboot waits
+			// until the close reasons were sent.
+			handlers.closeConnections(transport.CloseReset, "factory reset")
+			if err := handlers.powerDown(system.PowerReboot, func(string, bool) {}); err != nil {
+				failed = true
+				connection.logger.Error("Failed to reboot after the factory reset", "error", err)
+			}
+		case *basemessages.BitBoxBaseIn_BasePowerIn:
+			if err := handlers.power(connection, rpc.BasePowerIn, send); err != nil {
+				sendError(err)
+			}
+		case *basemessages.BitBoxBaseIn_BasePairedClientsIn:
+			response, err := handlers.pairedClients(connection)
+			if err != nil {
+				sendError(err)
+				return
+			}
+			send(response)
+		case *basemessages.BitBoxBaseIn_BaseSetClientRoleIn:
+			if err := handlers.SetClientRole(rpc.BaseSetClientRoleIn.Pubkey, rpc.BaseSetClientRoleIn.Role); err != nil {
+				sendError(err)
+				return
+			}
+			handlers.recordAudit(connection.clientStaticPubkey, audit.ActionRoleChanged,
+				"client "+audit.Fingerprint(rpc.BaseSetClientRoleIn.Pubkey)+" role "+rpc.BaseSetClientRoleIn.Role)
+			response, err := handlers.pairedClients(connection)
+			if err != nil {
+				sendError(err)
+				return
+			}
+			send(response)
+		case *basemessages.BitBoxBaseIn_BaseAuditLogIn:
+			response, err := handlers.auditLog(rpc.BaseAuditLogIn.Offset, rpc.BaseAuditLogIn.Limit)
+			if err != nil {
+				sendError(err)
+				return
+			}
+			send(response)
+		case *basemessages.BitBoxBaseIn_BaseLogLevelIn:
+			response, err := handlers.logLevel(connection, rpc.BaseLogLevelIn.Level)
+			if err != nil {
+				sendError(err)
+				return
+			}
+			send(response)
+		case *basemessages.BitBoxBaseIn_BaseStateResyncIn:
+			// The subscription answers with a patch or a snapshot.
+			select {
+			case connection.resyncState <- rpc.BaseStateResyncIn.Version:
+			case <-connection.remoteHasQuit:
+			}
+		default:
+			sendError(errors.New("unknown request"))
+		}
+	}()
+}
+
+// rpcName returns the name of the rpc of an incoming message, like BaseServicesIn, for the metrics.
+func rpcName(incoming *basemessages.BitBoxBaseIn) string {
+	if incoming.BitBoxBaseIn == nil {
+		return "unknown"
+	}
+	return strings.TrimPrefix(reflect.TypeOf(incoming.BitBoxBaseIn).Elem().Name(), "BitBoxBaseIn_")
+}
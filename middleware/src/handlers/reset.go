@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/digitalbitbox/bitbox-base/middleware/src/audit"
+	basemessages "github.com/digitalbitbox/bitbox-base/middleware/src/messages"
+	noisemanager "github.com/digitalbitbox/bitbox-base/middleware/src/noise"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/system"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// resetConfirmationTimeout is how long the confirmation token of a factory reset is valid.
+const resetConfirmationTimeout = 2 * time.Minute
+
+// resetConfirmation is a factory reset that a client requested and has yet to confirm.
+type resetConfirmation struct {
+	token   string
+	client  []byte
+	options system.ResetOptions
+	expires time.Time
+}
+
+// requestFactoryReset starts the confirmation of a factory reset with the options. It replaces the confirmation of an
+// earlier request, and returns the new token and when it expires.
+func (handlers *Handlers) requestFactoryReset(connection *connection, options system.ResetOptions) (
+	string, time.Time, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", time.Time{}, err
+	}
+	confirmation := &resetConfirmation{
+		token:   hex.EncodeToString(random),
+		client:  connection.clientStaticPubkey,
+		options: options,
+		expires: time.Now().Add(resetConfirmationTimeout),
+	}
+	handlers.mu.Lock()
+	handlers.resetConfirmation = confirmation
+	handlers.mu.Unlock()
+	return confirmation.token, confirmation.expires, nil
+}
+
+// confirmFactoryReset checks that the token confirms the factory reset that the client of the connection requested
+// with the same options. A token can only be used once, a wrong one cancels the confirmation.
+func (handlers *Handlers) confirmFactoryReset(connection *connection, token string, options system.ResetOptions) error {
+	handlers.mu.Lock()
+	confirmation := handlers.resetConfirmation
+	handlers.resetConfirmation = nil
+	handlers.mu.Unlock()
+	if confirmation == nil || time.Now().After(confirmation.expires) ||
+		!bytes.Equal(confirmation.client, connection.clientStaticPubkey) ||
+		subtle.ConstantTimeCompare([]byte(confirmation.token), []byte(token)) != 1 {
+		return errors.New("invalid or expired confirmation token, request a new one")
+	}
+	if confirmation.options != options {
+		return errors.New("the factory reset was requested with other options, request a new confirmation token")
+	}
+	return nil
+}
+
+// factoryReset handles both steps of a factory reset. Without a token, it returns a confirmation token and the
+// warnings for the owner. With a valid token, it wipes the Base and returns true, the caller then closes all
+// connections and reboots the Base. The audit log starts over with the reset.
+func (handlers *Handlers) factoryReset(connection *connection, rpc *basemessages.BaseFactoryResetIn) ([]byte, bool, error) {
+	options := system.ResetOptions{Blockchain: rpc.WipeBlockchain, Lightning: rpc.WipeLightning}
+	if rpc.Token == "" {
+		token, expires, err := handlers.requestFactoryReset(connection, options)
+		if err != nil {
+			return nil, false, err
+		}
+		response, err := proto.Marshal(&basemessages.BitBoxBaseOut{
+			BitBoxBaseOut: &basemessages.BitBoxBaseOut_BaseFactoryResetOut{
+				BaseFactoryResetOut: &basemessages.BaseFactoryResetOut{
+					Token:    token,
+					Expires:  expires.Unix(),
+					Warnings: handlers.middleware.FactoryResetWarnings(options),
+				},
+			},
+		})
+		return response, false, err
+	}
+	if err := handlers.confirmFactoryReset(connection, rpc.Token, options); err != nil {
+		return nil, false, err
+	}
+	err := handlers.middleware.FactoryReset(options, func() error {
+		if err := noisemanager.NewNoiseConfig(handlers.dataDir).RemoveKeystore(); err != nil {
+			return err
+		}
+		return handlers.audit.Reset()
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	handlers.recordAudit(nil, audit.ActionFactoryReset, "blockchain "+strconv.FormatBool(options.Blockchain)+
+		", lightning "+strconv.FormatBool(options.Lightning))
+	response, err := proto.Marshal(&basemessages.BitBoxBaseOut{
+		BitBoxBaseOut: &basemessages.BitBoxBaseOut_BaseFactoryResetOut{
+			BaseFactoryResetOut: &basemessages.BaseFactoryResetOut{Resetting: true},
+		},
+	})
+	// The Base is wiped, so it reboots even if the response can not be sent.
+	return response, true, err
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"bytes"
+
+	basemessages "github.com/digitalbitbox/bitbox-base/middleware/src/messages"
+	noisemanager "github.com/digitalbitbox/bitbox-base/middleware/src/noise"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// requiredRole returns the least privileged role that may make the request. Requests that are not listed, including
+// rpcs added in the future, require the owner role.
+func requiredRole(incoming *basemessages.BitBoxBaseIn) string {
+	switch incoming.BitBoxBaseIn.(type) {
+	case *basemessages.BitBoxBaseIn_BaseSystemEnvIn,
+		*basemessages.BitBoxBaseIn_BaseServicesIn,
+		*basemessages.BitBoxBaseIn_BaseHealthIn,
+		*basemessages.BitBoxBaseIn_BaseStateResyncIn:
+		return noisemanager.RoleReadOnly
+	case *basemessages.BitBoxBaseIn_BaseConfigGetIn,
+		*basemessages.BitBoxBaseIn_BaseLogsIn,
+		*basemessages.BitBoxBaseIn_BaseSupportBundleIn:
+		return noisemanager.RoleOperator
+	default:
+		return noisemanager.RoleOwner
+	}
+}
+
+// clientRole returns the current role of the client of a connection.
+func (handlers *Handlers) clientRole(connection *connection) string {
+	handlers.mu.Lock()
+	defer handlers.mu.Unlock()
+	return connection.role
+}
+
+// SetClientRole changes the role of a paired client. It applies to the open connections of the client right away.
+func (handlers *Handlers) SetClientRole(clientStaticPubkey []byte, role string) error {
+	if err := noisemanager.NewNoiseConfig(handlers.dataDir).SetClientRole(clientStaticPubkey, role); err != nil {
+		return err
+	}
+	handlers.mu.Lock()
+	defer handlers.mu.Unlock()
+	for _, connection := range handlers.clientsMap {
+		if bytes.Equal(connection.clientStaticPubkey, clientStaticPubkey) {
+			connection.role = role
+		}
+	}
+	return nil
+}
+
+// pairedClients returns the protobuf serialized paired clients, marking the client of the connection.
+func (handlers *Handlers) pairedClients(connection *connection) ([]byte, error) {
+	clients := []*basemessages.BasePairedClient{}
+	for _, client := range noisemanager.NewNoiseConfig(handlers.dataDir).PairedClients() {
+		clients = append(clients, &basemessages.BasePairedClient{
+			Pubkey: client.Pubkey,
+			Role:   client.Role,
+			Self:   bytes.Equal(client.Pubkey, connection.clientStaticPubkey),
+		})
+	}
+	return proto.Marshal(&basemessages.BitBoxBaseOut{
+		BitBoxBaseOut: &basemessages.BitBoxBaseOut_BasePairedClientsOut{
+			BasePairedClientsOut: &basemessages.BasePairedClientsOut{Clients: clients},
+		},
+	})
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	basemessages "github.com/digitalbitbox/bitbox-base/middleware/src/messages"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// supportBundle collects a support bundle into a temporary file in the data dir, so that its size and hash are known
+// before it is sent. It returns the response announcing the bundle and the file, positioned at the start, which the
+// caller sends as the attachment of the response and closes. The file is already removed from the data dir.
+func (handlers *Handlers) supportBundle() ([]byte, *os.File, error) {
+	release, err := handlers.acquireLogStream()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+	file, err := ioutil.TempFile(handlers.dataDir, "support-bundle")
+	if err != nil {
+		return nil, nil, err
+	}
+	// The open file stays readable, nothing is left behind if the middleware stops while sending it.
+	if err := os.Remove(file.Name()); err != nil {
+		_ = file.Close()
+		return nil, nil, err
+	}
+	hasher := sha256.New()
+	if err := handlers.middleware.SupportBundle(io.MultiWriter(file, hasher)); err != nil {
+		_ = file.Close()
+		return nil, nil, err
+	}
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, nil, err
+	}
+	response, err := proto.Marshal(&basemessages.BitBoxBaseOut{
+		BitBoxBaseOut: &basemessages.BitBoxBaseOut_BaseSupportBundleOut{
+			BaseSupportBundleOut: &basemessages.BaseSupportBundleOut{
+				Filename: "bitbox-base-support-" + time.Now().UTC().Format("20060102-150405") + ".tar.gz",
+				Size:     size,
+				Sha256:   hasher.Sum(nil),
+			},
+		},
+	})
+	if err != nil {
+		_ = file.Close()
+		return nil, nil, err
+	}
+	return response, file, nil
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/digitalbitbox/bitbox-base/middleware/src/framing"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/system"
+)
+
+const (
+	// maxUpdateSize limits the size of an uploaded update file.
+	maxUpdateSize  = 1 << 30
+	updateDirname  = "update"
+	updateFilename = "update.bin"
+)
+
+// stageUpdate streams an uploaded update file into the update directory below the data dir, where it is picked up by
+// the update tooling of the Base. The file is only moved into place if its size and sha256 hash match the request.
+// It returns the path of the staged file.
+func (handlers *Handlers) stageUpdate(size int64, hash []byte, attachment io.Reader) (string, error) {
+	if size <= 0 || size > maxUpdateSize {
+		return "", errors.New("invalid update size")
+	}
+	if len(hash) != sha256.Size {
+		return "", errors.New("invalid update hash")
+	}
+	if attachment == nil {
+		return "", errors.New("the update has to be sent as an attachment")
+	}
+	updateDir := filepath.Join(handlers.dataDir, updateDirname)
+	if err := os.MkdirAll(updateDir, 0700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(updateDir, updateFilename)
+	err := system.WriteFile(path, func(writer io.Writer) error {
+		return framing.CopyAttachment(writer, attachment, size, hash, "update")
+	})
+	if err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,208 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+
+	basemessages "github.com/digitalbitbox/bitbox-base/middleware/src/messages"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/metrics"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// The health statuses of the backends, from best to worst.
+const (
+	HealthOK       = "ok"
+	HealthDegraded = "degraded"
+	HealthDown     = "down"
+)
+
+// The backends the middleware talks to.
+const (
+	backendBitcoind  = "bitcoind"
+	backendLightning = "lightningd"
+	backendElectrs   = "electrs"
+)
+
+const (
+	// healthDownAfter is how long a failing backend is reported degraded after its last success, before it is
+	// reported down.
+	healthDownAfter = time.Minute
+	// healthStaleAfter is how long the health checks may not run before the middleware is considered wedged. A single
+	// poll takes almost a minute while lightningd is down, as its client retries connecting.
+	healthStaleAfter = 3 * time.Minute
+)
+
+// BackendHealth is the health of a service the middleware talks to.
+type BackendHealth struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// LastSuccess and LastErrorTime are zero if there was none yet.
+	LastSuccess   time.Time `json:"lastSuccess"`
+	LastError     string    `json:"lastError"`
+	LastErrorTime time.Time `json:"lastErrorTime"`
+}
+
+// backendHealth records the outcome of the calls to a backend.
+type backendHealth struct {
+	checked       bool
+	failing       bool
+	reachable     bool
+	lastSuccess   time.Time
+	lastError     string
+	lastErrorTime time.Time
+}
+
+// healthTracker keeps track of the health of the backends. It is safe for concurrent use.
+type healthTracker struct {
+	mu        sync.Mutex
+	backends  map[string]*backendHealth
+	lastCheck time.Time
+	// errors counts the failed calls by backend.
+	errors *metrics.Counter
+}
+
+func newHealthTracker(errors *metrics.Counter) *healthTracker {
+	return &healthTracker{
+		errors: errors,
+		backends: map[string]*backendHealth{
+			backendBitcoind:  {},
+			backendLightning: {},
+			backendElectrs:   {},
+		},
+		lastCheck: time.Now(),
+	}
+}
+
+// backendNames returns the backends in the order they are reported in.
+func backendNames() []string {
+	return []string{backendBitcoind, backendLightning, backendElectrs}
+}
+
+// success records a successful call to the backend.
+func (tracker *healthTracker) success(name string) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	backend := tracker.backends[name]
+	backend.checked = true
+	backend.failing = false
+	backend.lastSuccess = time.Now()
+}
+
+// failure records a failed call to the backend. reachable is true if the backend answered with an error, e.g. while
+// it is still starting up, which is reported as degraded.
+func (tracker *healthTracker) failure(name string, err error, reachable bool) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	backend := tracker.backends[name]
+	backend.checked = true
+	backend.failing = true
+	backend.reachable = reachable
+	backend.lastError = err.Error()
+	backend.lastErrorTime = time.Now()
+	tracker.errors.Inc(name)
+}
+
+// checked records that a round of health checks completed.
+func (tracker *healthTracker) checked() {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	tracker.lastCheck = time.Now()
+}
+
+// alive returns false if the health checks did not run for healthStaleAfter, e.g. because a call hangs.
+func (tracker *healthTracker) alive() bool {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	return time.Since(tracker.lastCheck) < healthStaleAfter
+}
+
+// get returns the health of all backends.
+func (tracker *healthTracker) get() []BackendHealth {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	result := []BackendHealth{}
+	for _, name := range backendNames() {
+		backend := tracker.backends[name]
+		status := HealthOK
+		switch {
+		case !backend.checked:
+			status = HealthDown
+		case !backend.failing:
+		case backend.reachable || time.Since(backend.lastSuccess) < healthDownAfter:
+			status = HealthDegraded
+		default:
+			status = HealthDown
+		}
+		result = append(result, BackendHealth{
+			Name:          name,
+			Status:        status,
+			LastSuccess:   backend.lastSuccess,
+			LastError:     backend.lastError,
+			LastErrorTime: backend.lastErrorTime,
+		})
+	}
+	return result
+}
+
+// worstStatus returns the worst status of the backends.
+func worstStatus(backends []BackendHealth) string {
+	status := HealthOK
+	for _, backend := range backends {
+		switch backend.Status {
+		case HealthDown:
+			return HealthDown
+		case HealthDegraded:
+			status = HealthDegraded
+		}
+	}
+	return status
+}
+
+// unixTime returns the unix timestamp of t, zero if t is zero.
+func unixTime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// BackendsHealth returns the health of the backends the middleware talks to.
+func (middleware *Middleware) BackendsHealth() []BackendHealth {
+	return middleware.health.get()
+}
+
+// Alive returns true if the middleware still runs its health checks. It drives the systemd watchdog, so that systemd
+// restarts a wedged middleware. Backends being down do not count, restarting the middleware would not help.
+func (middleware *Middleware) Alive() bool {
+	return middleware.health.alive()
+}
+
+// HealthSummary returns the overall status, the status of each backend by name and whether the middleware is alive,
+// without any details that should only be shown to paired clients.
+func (middleware *Middleware) HealthSummary() (string, map[string]string, bool) {
+	backends := middleware.health.get()
+	statuses := make(map[string]string)
+	for _, backend := range backends {
+		statuses[backend.Name] = backend.Status
+	}
+	return worstStatus(backends), statuses, middleware.health.alive()
+}
+
+// Health returns the protobuf serialized detailed health of the backends.
+func (middleware *Middleware) Health() ([]byte, error) {
+	backends := middleware.health.get()
+	outgoing := &basemessages.BaseHealthOut{Status: worstStatus(backends)}
+	for _, backend := range backends {
+		outgoing.Backends = append(outgoing.Backends, &basemessages.BaseBackendHealth{
+			Name:          backend.Name,
+			Status:        backend.Status,
+			LastSuccess:   unixTime(backend.LastSuccess),
+			LastError:     backend.LastError,
+			LastErrorTime: unixTime(backend.LastErrorTime),
+		})
+	}
+	return proto.Marshal(&basemessages.BitBoxBaseOut{
+		BitBoxBaseOut: &basemessages.BitBoxBaseOut_BaseHealthOut{BaseHealthOut: outgoing},
+	})
+}
@@ -0,0 +1,307 @@
+// Package logging provides leveled logging with key/value fields for the middleware and the tools of the Base. Lines
+// are prefixed with their syslog priority when running under systemd, so that journald records the level, and secrets
+// are redacted. It only depends on the standard library, so that the tools can use it as well.
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Level is the severity of a log line.
+type Level int
+
+// The levels, in increasing severity.
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarning
+	LevelError
+)
+
+// redacted replaces secrets in log lines.
+const redacted = "[redacted]"
+
+// String returns the name of the level, as accepted by ParseLevel.
+func (level Level) String() string {
+	switch level {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarning:
+		return "warning"
+	default:
+		return "error"
+	}
+}
+
+// priority returns the syslog priority journald records for the level.
+func (level Level) priority() int {
+	switch level {
+	case LevelDebug:
+		return 7
+	case LevelInfo:
+		return 6
+	case LevelWarning:
+		return 4
+	default:
+		return 3
+	}
+}
+
+// ParseLevel returns the level with the given name: debug, info, warning or error.
+func ParseLevel(name string) (Level, error) {
+	for _, level := range []Level{LevelDebug, LevelInfo, LevelWarning, LevelError} {
+		if strings.ToLower(name) == level.String() {
+			return level, nil
+		}
+	}
+	return LevelInfo, errors.New("invalid log level " + name + ", expected debug, info, warning or error")
+}
+
+// UnderJournald returns true if the standard error of the process is connected to journald, as systemd announces in
+// JOURNAL_STREAM.
+func UnderJournald() bool {
+	return os.Getenv("JOURNAL_STREAM") != ""
+}
+
+// secretWords returns the words that mark keys whose values are secrets.
+func secretWords() []string {
+	return []string{"password", "passphrase", "secret", "token", "cookie", "private", "privkey", "seed"}
+}
+
+// isSecretKey returns true if the values of fields with the key are secrets, like rpcpassword or noisePrivateKey.
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, secret := range secretWords() {
+		if strings.Contains(key, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// output is shared by a logger and the loggers derived from it with With.
+type output struct {
+	mu       sync.Mutex
+	writer   io.Writer
+	level    Level
+	journald bool
+	// secrets are redacted wherever they show up in a line.
+	secrets []string
+	// secretPairs matches key/value pairs with secret keys in text redacted by Redact.
+	secretPairs *regexp.Regexp
+	now         func() time.Time
+}
+
+// Logger writes log lines of a minimum level. It is safe for concurrent use.
+type Logger struct {
+	output *output
+	fields []interface{}
+}
+
+// New returns a logger writing lines of at least the given level to writer. If journald is true, lines are prefixed
+// with their syslog priority, like <6>, otherwise with the time and the level.
+func New(writer io.Writer, level Level, journald bool) *Logger {
+	secretPairs := regexp.MustCompile(`(?i)\b(\w*(?:` + strings.Join(secretWords(), "|") + `)\w*)(\s*[=:]\s*)("[^"]*"|[^\s,;"]+)`)
+	return &Logger{output: &output{
+		writer:      writer,
+		level:       level,
+		journald:    journald,
+		secretPairs: secretPairs,
+		now:         time.Now,
+	}}
+}
+
+// NewDefault returns a logger writing to standard error, formatted for journald if the process runs under systemd.
+func NewDefault(level Level) *Logger {
+	return New(os.Stderr, level, UnderJournald())
+}
+
+// With returns a logger that adds the key/value fields to every line. It shares the level and the secrets with
+// logger.
+func (logger *Logger) With(keyvals ...interface{}) *Logger {
+	fields := make([]interface{}, 0, len(logger.fields)+len(keyvals))
+	fields = append(fields, logger.fields...)
+	fields = append(fields, keyvals...)
+	return &Logger{output: logger.output, fields: fields}
+}
+
+// SetLevel changes the minimum level of the lines that are written.
+func (logger *Logger) SetLevel(level Level) {
+	logger.output.mu.Lock()
+	defer logger.output.mu.Unlock()
+	logger.output.level = level
+}
+
+// Level returns the minimum level of the lines that are written.
+func (logger *Logger) Level() Level {
+	logger.output.mu.Lock()
+	defer logger.output.mu.Unlock()
+	return logger.output.level
+}
+
+// AddSecret makes the logger redact the value wherever it shows up, e.g. the bitcoind rpc password in an error
+// message. Empty values are ignored.
+func (logger *Logger) AddSecret(secret string) {
+	if secret == "" {
+		return
+	}
+	logger.output.mu.Lock()
+	defer logger.output.mu.Unlock()
+	for _, known := range logger.output.secrets {
+		if known == secret {
+			return
+		}
+	}
+	logger.output.secrets = append(logger.output.secrets, secret)
+	// Longer secrets first, in case one contains another.
+	sort.Slice(logger.output.secrets, func(i, j int) bool {
+		return len(logger.output.secrets[i]) > len(logger.output.secrets[j])
+	})
+}
+
+// Redact replaces the secrets known to the logger in text, and the values of key/value pairs with secret keys, like
+// rpcpassword=hunter2. It is meant for text that was not logged by the logger itself, e.g. the logs of other services.
+func (logger *Logger) Redact(text string) string {
+	text = logger.output.secretPairs.ReplaceAllString(text, "${1}${2}"+redacted)
+	logger.output.mu.Lock()
+	defer logger.output.mu.Unlock()
+	for _, secret := range logger.output.secrets {
+		text = strings.Replace(text, secret, redacted, -1)
+	}
+	return text
+}
+
+// Debug logs details that are only of interest while debugging.
+func (logger *Logger) Debug(message string, keyvals ...interface{}) {
+	logger.log(LevelDebug, message, keyvals)
+}
+
+// Info logs the normal operation, like a service being started.
+func (logger *Logger) Info(message string, keyvals ...interface{}) {
+	logger.log(LevelInfo, message, keyvals)
+}
+
+// Warning logs problems that are handled, like a failed rpc call that is retried.
+func (logger *Logger) Warning(message string, keyvals ...interface{}) {
+	logger.log(LevelWarning, message, keyvals)
+}
+
+// Error logs failures that need attention.
+func (logger *Logger) Error(message string, keyvals ...interface{}) {
+	logger.log(LevelError, message, keyvals)
+}
+
+// Writer returns a writer that logs every line written to it with the level, e.g. to route the standard log package
+// through the logger.
+func (logger *Logger) Writer(level Level) io.Writer {
+	return writerFunc(func(p []byte) (int, error) {
+		for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+			logger.log(level, line, nil)
+		}
+		return len(p), nil
+	})
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (write writerFunc) Write(p []byte) (int, error) {
+	return write(p)
+}
+
+// formatValue formats a field value, quoting it if it contains spaces, quotes or equal signs.
+func formatValue(value interface{}) string {
+	var formatted string
+	switch value := value.(type) {
+	case error:
+		formatted = value.Error()
+	case string:
+		formatted = value
+	default:
+		formatted = fmt.Sprint(value)
+	}
+	if formatted == "" || strings.ContainsAny(formatted, " \t\n\"=") {
+		return strconv.Quote(formatted)
+	}
+	return formatted
+}
+
+// log writes a line if the level is enabled.
+func (logger *Logger) log(level Level, message string, keyvals []interface{}) {
+	out := logger.output
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	if level < out.level {
+		return
+	}
+	var line strings.Builder
+	if out.journald {
+		line.WriteString("<" + strconv.Itoa(level.priority()) + ">")
+	} else {
+		line.WriteString(out.now().UTC().Format(time.RFC3339) + " " + strings.ToUpper(level.String()) + " ")
+	}
+	line.WriteString(message)
+	fields := append(append([]interface{}{}, logger.fields...), keyvals...)
+	if len(fields)%2 != 0 {
+		fields = append(fields, "(missing)")
+	}
+	for i := 0; i < len(fields); i += 2 {
+		key := fmt.Sprint(fields[i])
+		value := formatValue(fields[i+1])
+		if isSecretKey(key) {
+			value = redacted
+		}
+		line.WriteString(" " + key + "=" + value)
+	}
+	text := line.String()
+	for _, secret := range out.secrets {
+		text = strings.Replace(text, secret, redacted, -1)
+	}
+	// journald takes every line as a separate entry, so line breaks in messages are escaped.
+	text = strings.Replace(text, "\n", `\n`, -1)
+	_, _ = io.WriteString(out.writer, text+"\n")
+}
+
+// HandleSignals switches the logger to the debug level on SIGUSR1 and back to the level it had before on SIGUSR2,
+// so that the level can be changed without a restart, e.g. with systemctl kill -s USR1. It returns a function that
+// stops handling the signals.
+func (logger *Logger) HandleSignals() (stop func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2)
+	done := make(chan struct{})
+	go func() {
+		previous := logger.Level()
+		for {
+			select {
+			case sig := <-signals:
+				if sig == syscall.SIGUSR1 {
+					if level := logger.Level(); level != LevelDebug {
+						previous = level
+					}
+					logger.SetLevel(LevelDebug)
+				} else {
+					logger.SetLevel(previous)
+				}
+				logger.Info("Changed the log level", "level", logger.Level(), "signal", sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
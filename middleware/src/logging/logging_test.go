@@ -0,0 +1,87 @@
+package logging_test
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/digitalbitbox/bitbox-base/middleware/src/logging"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := logging.New(&buffer, logging.LevelInfo, true)
+	logger.Debug("hidden")
+	logger.Info("Started", "version", "0.1")
+	connection := logger.With("client", "8f3c")
+	connection.Warning("Request failed", "error", errors.New("permission denied"), "rpc", "BaseConfigSetIn")
+	connection.Error("Config", "key", "rpcpassword", "rpcpassword", "hunter2", "noisePrivateKey", []byte{1})
+	require.Equal(t,
+		"<6>Started version=0.1\n"+
+			"<4>Request failed client=8f3c error=\"permission denied\" rpc=BaseConfigSetIn\n"+
+			"<3>Config client=8f3c key=rpcpassword rpcpassword=[redacted] noisePrivateKey=[redacted]\n",
+		buffer.String())
+
+	// Registered secrets are redacted anywhere, the level is shared with derived loggers.
+	buffer.Reset()
+	logger.AddSecret("hunter2")
+	connection.SetLevel(logging.LevelDebug)
+	require.Equal(t, logging.LevelDebug, logger.Level())
+	logger.Debug("connecting with user:hunter2", "empty", "", "lines", "a\nb")
+	require.Equal(t, "<7>connecting with user:[redacted] empty=\"\" lines=\"a\\nb\"\n", buffer.String())
+
+	// Lines of the standard log package are routed through the logger.
+	buffer.Reset()
+	std := log.New(logger.Writer(logging.LevelWarning), "", 0)
+	std.Println("bitcoind is unreachable, password hunter2")
+	require.Equal(t, "<4>bitcoind is unreachable, password [redacted]\n", buffer.String())
+
+	buffer.Reset()
+	logging.New(&buffer, logging.LevelInfo, false).Error("failed")
+	require.Regexp(t, `^\d{4}-\d\d-\d\dT\d\d:\d\d:\d\dZ ERROR failed\n$`, buffer.String())
+}
+
+func TestRedact(t *testing.T) {
+	logger := logging.New(&bytes.Buffer{}, logging.LevelInfo, false)
+	logger.AddSecret("hunter2")
+	require.Equal(t,
+		"connecting with [redacted]; rpcpassword=[redacted], Token: [redacted] seed=[redacted] height=5",
+		logger.Redact("connecting with hunter2; rpcpassword=abc, Token: xyz seed=\"a b\" height=5"))
+}
+
+func TestParseLevel(t *testing.T) {
+	for _, level := range []logging.Level{logging.LevelDebug, logging.LevelInfo, logging.LevelWarning, logging.LevelError} {
+		parsed, err := logging.ParseLevel(level.String())
+		require.NoError(t, err)
+		require.Equal(t, level, parsed)
+	}
+	level, err := logging.ParseLevel("WARNING")
+	require.NoError(t, err)
+	require.Equal(t, logging.LevelWarning, level)
+	_, err = logging.ParseLevel("verbose")
+	require.Error(t, err)
+}
+
+func TestHandleSignals(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := logging.New(&buffer, logging.LevelWarning, true)
+	stop := logger.HandleSignals()
+	defer stop()
+	waitForLevel := func(level logging.Level) {
+		deadline := time.Now().Add(5 * time.Second)
+		for logger.Level() != level {
+			require.True(t, time.Now().Before(deadline), "level not changed")
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+	waitForLevel(logging.LevelDebug)
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR2))
+	waitForLevel(logging.LevelWarning)
+}
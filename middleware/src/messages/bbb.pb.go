@@ -31,7 +31,7 @@ func (m *BaseMiddlewareInfoOut) Reset()         { *m = BaseMiddlewareInfoOut{} }
 func (m *BaseMiddlewareInfoOut) String() string { return proto.CompactTextString(m) }
 func (*BaseMiddlewareInfoOut) ProtoMessage()    {}
 func (*BaseMiddlewareInfoOut) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bbb_6b9b338852afad61, []int{0}
+	return fileDescriptor_bbb_ea182728b319f25a, []int{0}
 }
 func (m *BaseMiddlewareInfoOut) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_BaseMiddlewareInfoOut.Unmarshal(m, b)
@@ -72,6 +72,383 @@ func (m *BaseMiddlewareInfoOut) GetLightningAlias() string {
 	return ""
 }
 
+type BaseBitcoindState struct {
+	Blocks              int64   `protobuf:"varint,1,opt,name=Blocks,json=blocks,proto3" json:"Blocks,omitempty"`
+	Difficulty          float64 `protobuf:"fixed64,2,opt,name=Difficulty,json=difficulty,proto3" json:"Difficulty,omitempty"`
+	BestBlockHash       string  `protobuf:"bytes,3,opt,name=BestBlockHash,json=bestBlockHash,proto3" json:"BestBlockHash,omitempty"`
+	MempoolTransactions int64   `protobuf:"varint,4,opt,name=MempoolTransactions,json=mempoolTransactions,proto3" json:"MempoolTransactions,omitempty"`
+	// Unreachable is set if the middleware cannot reach bitcoind. The other fields are unset then.
+	Unreachable          bool     `protobuf:"varint,5,opt,name=Unreachable,json=unreachable,proto3" json:"Unreachable,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseBitcoindState) Reset()         { *m = BaseBitcoindState{} }
+func (m *BaseBitcoindState) String() string { return proto.CompactTextString(m) }
+func (*BaseBitcoindState) ProtoMessage()    {}
+func (*BaseBitcoindState) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{1}
+}
+func (m *BaseBitcoindState) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseBitcoindState.Unmarshal(m, b)
+}
+func (m *BaseBitcoindState) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseBitcoindState.Marshal(b, m, deterministic)
+}
+func (dst *BaseBitcoindState) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseBitcoindState.Merge(dst, src)
+}
+func (m *BaseBitcoindState) XXX_Size() int {
+	return xxx_messageInfo_BaseBitcoindState.Size(m)
+}
+func (m *BaseBitcoindState) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseBitcoindState.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseBitcoindState proto.InternalMessageInfo
+
+func (m *BaseBitcoindState) GetBlocks() int64 {
+	if m != nil {
+		return m.Blocks
+	}
+	return 0
+}
+
+func (m *BaseBitcoindState) GetDifficulty() float64 {
+	if m != nil {
+		return m.Difficulty
+	}
+	return 0
+}
+
+func (m *BaseBitcoindState) GetBestBlockHash() string {
+	if m != nil {
+		return m.BestBlockHash
+	}
+	return ""
+}
+
+func (m *BaseBitcoindState) GetMempoolTransactions() int64 {
+	if m != nil {
+		return m.MempoolTransactions
+	}
+	return 0
+}
+
+func (m *BaseBitcoindState) GetUnreachable() bool {
+	if m != nil {
+		return m.Unreachable
+	}
+	return false
+}
+
+type BaseLightningState struct {
+	Alias                string   `protobuf:"bytes,1,opt,name=Alias,json=alias,proto3" json:"Alias,omitempty"`
+	Channels             int64    `protobuf:"varint,2,opt,name=Channels,json=channels,proto3" json:"Channels,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseLightningState) Reset()         { *m = BaseLightningState{} }
+func (m *BaseLightningState) String() string { return proto.CompactTextString(m) }
+func (*BaseLightningState) ProtoMessage()    {}
+func (*BaseLightningState) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{2}
+}
+func (m *BaseLightningState) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseLightningState.Unmarshal(m, b)
+}
+func (m *BaseLightningState) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseLightningState.Marshal(b, m, deterministic)
+}
+func (dst *BaseLightningState) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseLightningState.Merge(dst, src)
+}
+func (m *BaseLightningState) XXX_Size() int {
+	return xxx_messageInfo_BaseLightningState.Size(m)
+}
+func (m *BaseLightningState) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseLightningState.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseLightningState proto.InternalMessageInfo
+
+func (m *BaseLightningState) GetAlias() string {
+	if m != nil {
+		return m.Alias
+	}
+	return ""
+}
+
+func (m *BaseLightningState) GetChannels() int64 {
+	if m != nil {
+		return m.Channels
+	}
+	return 0
+}
+
+type BaseElectrsState struct {
+	Blocks               int64    `protobuf:"varint,1,opt,name=Blocks,json=blocks,proto3" json:"Blocks,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseElectrsState) Reset()         { *m = BaseElectrsState{} }
+func (m *BaseElectrsState) String() string { return proto.CompactTextString(m) }
+func (*BaseElectrsState) ProtoMessage()    {}
+func (*BaseElectrsState) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{3}
+}
+func (m *BaseElectrsState) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseElectrsState.Unmarshal(m, b)
+}
+func (m *BaseElectrsState) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseElectrsState.Marshal(b, m, deterministic)
+}
+func (dst *BaseElectrsState) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseElectrsState.Merge(dst, src)
+}
+func (m *BaseElectrsState) XXX_Size() int {
+	return xxx_messageInfo_BaseElectrsState.Size(m)
+}
+func (m *BaseElectrsState) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseElectrsState.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseElectrsState proto.InternalMessageInfo
+
+func (m *BaseElectrsState) GetBlocks() int64 {
+	if m != nil {
+		return m.Blocks
+	}
+	return 0
+}
+
+type BaseSystemState struct {
+	Network              string   `protobuf:"bytes,1,opt,name=Network,json=network,proto3" json:"Network,omitempty"`
+	ElectrsRPCPort       string   `protobuf:"bytes,2,opt,name=ElectrsRPCPort,json=electrsRPCPort,proto3" json:"ElectrsRPCPort,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseSystemState) Reset()         { *m = BaseSystemState{} }
+func (m *BaseSystemState) String() string { return proto.CompactTextString(m) }
+func (*BaseSystemState) ProtoMessage()    {}
+func (*BaseSystemState) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{4}
+}
+func (m *BaseSystemState) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseSystemState.Unmarshal(m, b)
+}
+func (m *BaseSystemState) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseSystemState.Marshal(b, m, deterministic)
+}
+func (dst *BaseSystemState) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseSystemState.Merge(dst, src)
+}
+func (m *BaseSystemState) XXX_Size() int {
+	return xxx_messageInfo_BaseSystemState.Size(m)
+}
+func (m *BaseSystemState) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseSystemState.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseSystemState proto.InternalMessageInfo
+
+func (m *BaseSystemState) GetNetwork() string {
+	if m != nil {
+		return m.Network
+	}
+	return ""
+}
+
+func (m *BaseSystemState) GetElectrsRPCPort() string {
+	if m != nil {
+		return m.ElectrsRPCPort
+	}
+	return ""
+}
+
+type BaseState struct {
+	Bitcoind             *BaseBitcoindState  `protobuf:"bytes,1,opt,name=Bitcoind,json=bitcoind,proto3" json:"Bitcoind,omitempty"`
+	Lightning            *BaseLightningState `protobuf:"bytes,2,opt,name=Lightning,json=lightning,proto3" json:"Lightning,omitempty"`
+	Electrs              *BaseElectrsState   `protobuf:"bytes,3,opt,name=Electrs,json=electrs,proto3" json:"Electrs,omitempty"`
+	System               *BaseSystemState    `protobuf:"bytes,4,opt,name=System,json=system,proto3" json:"System,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
+	XXX_unrecognized     []byte              `json:"-"`
+	XXX_sizecache        int32               `json:"-"`
+}
+
+func (m *BaseState) Reset()         { *m = BaseState{} }
+func (m *BaseState) String() string { return proto.CompactTextString(m) }
+func (*BaseState) ProtoMessage()    {}
+func (*BaseState) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{5}
+}
+func (m *BaseState) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseState.Unmarshal(m, b)
+}
+func (m *BaseState) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseState.Marshal(b, m, deterministic)
+}
+func (dst *BaseState) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseState.Merge(dst, src)
+}
+func (m *BaseState) XXX_Size() int {
+	return xxx_messageInfo_BaseState.Size(m)
+}
+func (m *BaseState) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseState.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseState proto.InternalMessageInfo
+
+func (m *BaseState) GetBitcoind() *BaseBitcoindState {
+	if m != nil {
+		return m.Bitcoind
+	}
+	return nil
+}
+
+func (m *BaseState) GetLightning() *BaseLightningState {
+	if m != nil {
+		return m.Lightning
+	}
+	return nil
+}
+
+func (m *BaseState) GetElectrs() *BaseElectrsState {
+	if m != nil {
+		return m.Electrs
+	}
+	return nil
+}
+
+func (m *BaseState) GetSystem() *BaseSystemState {
+	if m != nil {
+		return m.System
+	}
+	return nil
+}
+
+// BaseStateOut carries the last known state of the Base. Every change of the state increments its version.
+// Right after subscribing, a client receives a full snapshot with Snapshot set. Afterwards, only patches are sent:
+// Paths lists the fields that changed between BaseVersion and Version, like "bitcoind.blocks", and State holds their
+// new values. All other fields of State are unset. A patch only applies to a client state of version BaseVersion.
+type BaseStateOut struct {
+	Snapshot             bool       `protobuf:"varint,1,opt,name=Snapshot,json=snapshot,proto3" json:"Snapshot,omitempty"`
+	Version              uint64     `protobuf:"varint,2,opt,name=Version,json=version,proto3" json:"Version,omitempty"`
+	BaseVersion          uint64     `protobuf:"varint,3,opt,name=BaseVersion,json=baseVersion,proto3" json:"BaseVersion,omitempty"`
+	Paths                []string   `protobuf:"bytes,4,rep,name=Paths,json=paths,proto3" json:"Paths,omitempty"`
+	State                *BaseState `protobuf:"bytes,5,opt,name=State,json=state,proto3" json:"State,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *BaseStateOut) Reset()         { *m = BaseStateOut{} }
+func (m *BaseStateOut) String() string { return proto.CompactTextString(m) }
+func (*BaseStateOut) ProtoMessage()    {}
+func (*BaseStateOut) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{6}
+}
+func (m *BaseStateOut) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseStateOut.Unmarshal(m, b)
+}
+func (m *BaseStateOut) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseStateOut.Marshal(b, m, deterministic)
+}
+func (dst *BaseStateOut) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseStateOut.Merge(dst, src)
+}
+func (m *BaseStateOut) XXX_Size() int {
+	return xxx_messageInfo_BaseStateOut.Size(m)
+}
+func (m *BaseStateOut) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseStateOut.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseStateOut proto.InternalMessageInfo
+
+func (m *BaseStateOut) GetSnapshot() bool {
+	if m != nil {
+		return m.Snapshot
+	}
+	return false
+}
+
+func (m *BaseStateOut) GetVersion() uint64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *BaseStateOut) GetBaseVersion() uint64 {
+	if m != nil {
+		return m.BaseVersion
+	}
+	return 0
+}
+
+func (m *BaseStateOut) GetPaths() []string {
+	if m != nil {
+		return m.Paths
+	}
+	return nil
+}
+
+func (m *BaseStateOut) GetState() *BaseState {
+	if m != nil {
+		return m.State
+	}
+	return nil
+}
+
+// BaseStateResyncIn asks for a patch from the given version to the current version. If the base does not remember the
+// changes since that version anymore, it sends a snapshot instead.
+type BaseStateResyncIn struct {
+	Version              uint64   `protobuf:"varint,1,opt,name=Version,json=version,proto3" json:"Version,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseStateResyncIn) Reset()         { *m = BaseStateResyncIn{} }
+func (m *BaseStateResyncIn) String() string { return proto.CompactTextString(m) }
+func (*BaseStateResyncIn) ProtoMessage()    {}
+func (*BaseStateResyncIn) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{7}
+}
+func (m *BaseStateResyncIn) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseStateResyncIn.Unmarshal(m, b)
+}
+func (m *BaseStateResyncIn) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseStateResyncIn.Marshal(b, m, deterministic)
+}
+func (dst *BaseStateResyncIn) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseStateResyncIn.Merge(dst, src)
+}
+func (m *BaseStateResyncIn) XXX_Size() int {
+	return xxx_messageInfo_BaseStateResyncIn.Size(m)
+}
+func (m *BaseStateResyncIn) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseStateResyncIn.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseStateResyncIn proto.InternalMessageInfo
+
+func (m *BaseStateResyncIn) GetVersion() uint64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
 type BaseSystemEnvOut struct {
 	Network              string   `protobuf:"bytes,1,opt,name=Network,json=network,proto3" json:"Network,omitempty"`
 	ElectrsRPCPort       string   `protobuf:"bytes,2,opt,name=ElectrsRPCPort,json=electrsRPCPort,proto3" json:"ElectrsRPCPort,omitempty"`
@@ -84,7 +461,7 @@ func (m *BaseSystemEnvOut) Reset()         { *m = BaseSystemEnvOut{} }
 func (m *BaseSystemEnvOut) String() string { return proto.CompactTextString(m) }
 func (*BaseSystemEnvOut) ProtoMessage()    {}
 func (*BaseSystemEnvOut) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bbb_6b9b338852afad61, []int{1}
+	return fileDescriptor_bbb_ea182728b319f25a, []int{8}
 }
 func (m *BaseSystemEnvOut) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_BaseSystemEnvOut.Unmarshal(m, b)
@@ -128,7 +505,7 @@ func (m *BaseSystemEnvIn) Reset()         { *m = BaseSystemEnvIn{} }
 func (m *BaseSystemEnvIn) String() string { return proto.CompactTextString(m) }
 func (*BaseSystemEnvIn) ProtoMessage()    {}
 func (*BaseSystemEnvIn) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bbb_6b9b338852afad61, []int{2}
+	return fileDescriptor_bbb_ea182728b319f25a, []int{9}
 }
 func (m *BaseSystemEnvIn) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_BaseSystemEnvIn.Unmarshal(m, b)
@@ -148,185 +525,3014 @@ func (m *BaseSystemEnvIn) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_BaseSystemEnvIn proto.InternalMessageInfo
 
-type BitBoxBaseIn struct {
-	// Types that are valid to be assigned to BitBoxBaseIn:
-	//	*BitBoxBaseIn_BaseSystemEnvIn
-	BitBoxBaseIn         isBitBoxBaseIn_BitBoxBaseIn `protobuf_oneof:"bitBoxBaseIn"`
-	XXX_NoUnkeyedLiteral struct{}                    `json:"-"`
-	XXX_unrecognized     []byte                      `json:"-"`
-	XXX_sizecache        int32                       `json:"-"`
+// BaseErrorOut is sent if a request failed. RequestField is the field number of the failed request in BitBoxBaseIn.
+type BaseErrorOut struct {
+	RequestField         int32    `protobuf:"varint,1,opt,name=RequestField,json=requestField,proto3" json:"RequestField,omitempty"`
+	Message              string   `protobuf:"bytes,2,opt,name=Message,json=message,proto3" json:"Message,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *BitBoxBaseIn) Reset()         { *m = BitBoxBaseIn{} }
-func (m *BitBoxBaseIn) String() string { return proto.CompactTextString(m) }
-func (*BitBoxBaseIn) ProtoMessage()    {}
-func (*BitBoxBaseIn) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bbb_6b9b338852afad61, []int{3}
+func (m *BaseErrorOut) Reset()         { *m = BaseErrorOut{} }
+func (m *BaseErrorOut) String() string { return proto.CompactTextString(m) }
+func (*BaseErrorOut) ProtoMessage()    {}
+func (*BaseErrorOut) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{10}
 }
-func (m *BitBoxBaseIn) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_BitBoxBaseIn.Unmarshal(m, b)
+func (m *BaseErrorOut) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseErrorOut.Unmarshal(m, b)
 }
-func (m *BitBoxBaseIn) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_BitBoxBaseIn.Marshal(b, m, deterministic)
+func (m *BaseErrorOut) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseErrorOut.Marshal(b, m, deterministic)
 }
-func (dst *BitBoxBaseIn) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_BitBoxBaseIn.Merge(dst, src)
+func (dst *BaseErrorOut) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseErrorOut.Merge(dst, src)
 }
-func (m *BitBoxBaseIn) XXX_Size() int {
-	return xxx_messageInfo_BitBoxBaseIn.Size(m)
+func (m *BaseErrorOut) XXX_Size() int {
+	return xxx_messageInfo_BaseErrorOut.Size(m)
 }
-func (m *BitBoxBaseIn) XXX_DiscardUnknown() {
-	xxx_messageInfo_BitBoxBaseIn.DiscardUnknown(m)
+func (m *BaseErrorOut) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseErrorOut.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_BitBoxBaseIn proto.InternalMessageInfo
-
-type isBitBoxBaseIn_BitBoxBaseIn interface {
-	isBitBoxBaseIn_BitBoxBaseIn()
-}
+var xxx_messageInfo_BaseErrorOut proto.InternalMessageInfo
 
-type BitBoxBaseIn_BaseSystemEnvIn struct {
-	BaseSystemEnvIn *BaseSystemEnvIn `protobuf:"bytes,1,opt,name=baseSystemEnvIn,proto3,oneof"`
+func (m *BaseErrorOut) GetRequestField() int32 {
+	if m != nil {
+		return m.RequestField
+	}
+	return 0
 }
 
-func (*BitBoxBaseIn_BaseSystemEnvIn) isBitBoxBaseIn_BitBoxBaseIn() {}
-
-func (m *BitBoxBaseIn) GetBitBoxBaseIn() isBitBoxBaseIn_BitBoxBaseIn {
+func (m *BaseErrorOut) GetMessage() string {
 	if m != nil {
-		return m.BitBoxBaseIn
+		return m.Message
 	}
-	return nil
+	return ""
 }
 
-func (m *BitBoxBaseIn) GetBaseSystemEnvIn() *BaseSystemEnvIn {
-	if x, ok := m.GetBitBoxBaseIn().(*BitBoxBaseIn_BaseSystemEnvIn); ok {
-		return x.BaseSystemEnvIn
-	}
-	return nil
+type BaseServicesIn struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-// XXX_OneofFuncs is for the internal use of the proto package.
-func (*BitBoxBaseIn) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
-	return _BitBoxBaseIn_OneofMarshaler, _BitBoxBaseIn_OneofUnmarshaler, _BitBoxBaseIn_OneofSizer, []interface{}{
-		(*BitBoxBaseIn_BaseSystemEnvIn)(nil),
-	}
+func (m *BaseServicesIn) Reset()         { *m = BaseServicesIn{} }
+func (m *BaseServicesIn) String() string { return proto.CompactTextString(m) }
+func (*BaseServicesIn) ProtoMessage()    {}
+func (*BaseServicesIn) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{11}
+}
+func (m *BaseServicesIn) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseServicesIn.Unmarshal(m, b)
+}
+func (m *BaseServicesIn) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseServicesIn.Marshal(b, m, deterministic)
+}
+func (dst *BaseServicesIn) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseServicesIn.Merge(dst, src)
+}
+func (m *BaseServicesIn) XXX_Size() int {
+	return xxx_messageInfo_BaseServicesIn.Size(m)
+}
+func (m *BaseServicesIn) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseServicesIn.DiscardUnknown(m)
 }
 
-func _BitBoxBaseIn_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
-	m := msg.(*BitBoxBaseIn)
-	// bitBoxBaseIn
-	switch x := m.BitBoxBaseIn.(type) {
-	case *BitBoxBaseIn_BaseSystemEnvIn:
-		b.EncodeVarint(1<<3 | proto.WireBytes)
-		if err := b.EncodeMessage(x.BaseSystemEnvIn); err != nil {
+var xxx_messageInfo_BaseServicesIn proto.InternalMessageInfo
+
+type BaseServiceStatus struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=Name,json=name,proto3" json:"Name,omitempty"`
+	ActiveState          string   `protobuf:"bytes,2,opt,name=ActiveState,json=activeState,proto3" json:"ActiveState,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseServiceStatus) Reset()         { *m = BaseServiceStatus{} }
+func (m *BaseServiceStatus) String() string { return proto.CompactTextString(m) }
+func (*BaseServiceStatus) ProtoMessage()    {}
+func (*BaseServiceStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{12}
+}
+func (m *BaseServiceStatus) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseServiceStatus.Unmarshal(m, b)
+}
+func (m *BaseServiceStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseServiceStatus.Marshal(b, m, deterministic)
+}
+func (dst *BaseServiceStatus) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseServiceStatus.Merge(dst, src)
+}
+func (m *BaseServiceStatus) XXX_Size() int {
+	return xxx_messageInfo_BaseServiceStatus.Size(m)
+}
+func (m *BaseServiceStatus) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseServiceStatus.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseServiceStatus proto.InternalMessageInfo
+
+func (m *BaseServiceStatus) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *BaseServiceStatus) GetActiveState() string {
+	if m != nil {
+		return m.ActiveState
+	}
+	return ""
+}
+
+type BaseServicesOut struct {
+	Services             []*BaseServiceStatus `protobuf:"bytes,1,rep,name=Services,json=services,proto3" json:"Services,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *BaseServicesOut) Reset()         { *m = BaseServicesOut{} }
+func (m *BaseServicesOut) String() string { return proto.CompactTextString(m) }
+func (*BaseServicesOut) ProtoMessage()    {}
+func (*BaseServicesOut) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{13}
+}
+func (m *BaseServicesOut) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseServicesOut.Unmarshal(m, b)
+}
+func (m *BaseServicesOut) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseServicesOut.Marshal(b, m, deterministic)
+}
+func (dst *BaseServicesOut) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseServicesOut.Merge(dst, src)
+}
+func (m *BaseServicesOut) XXX_Size() int {
+	return xxx_messageInfo_BaseServicesOut.Size(m)
+}
+func (m *BaseServicesOut) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseServicesOut.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseServicesOut proto.InternalMessageInfo
+
+func (m *BaseServicesOut) GetServices() []*BaseServiceStatus {
+	if m != nil {
+		return m.Services
+	}
+	return nil
+}
+
+type BaseConfigGetIn struct {
+	Key                  string   `protobuf:"bytes,1,opt,name=Key,json=key,proto3" json:"Key,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseConfigGetIn) Reset()         { *m = BaseConfigGetIn{} }
+func (m *BaseConfigGetIn) String() string { return proto.CompactTextString(m) }
+func (*BaseConfigGetIn) ProtoMessage()    {}
+func (*BaseConfigGetIn) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{14}
+}
+func (m *BaseConfigGetIn) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseConfigGetIn.Unmarshal(m, b)
+}
+func (m *BaseConfigGetIn) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseConfigGetIn.Marshal(b, m, deterministic)
+}
+func (dst *BaseConfigGetIn) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseConfigGetIn.Merge(dst, src)
+}
+func (m *BaseConfigGetIn) XXX_Size() int {
+	return xxx_messageInfo_BaseConfigGetIn.Size(m)
+}
+func (m *BaseConfigGetIn) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseConfigGetIn.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseConfigGetIn proto.InternalMessageInfo
+
+func (m *BaseConfigGetIn) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+type BaseConfigSetIn struct {
+	Key                  string   `protobuf:"bytes,1,opt,name=Key,json=key,proto3" json:"Key,omitempty"`
+	Value                string   `protobuf:"bytes,2,opt,name=Value,json=value,proto3" json:"Value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseConfigSetIn) Reset()         { *m = BaseConfigSetIn{} }
+func (m *BaseConfigSetIn) String() string { return proto.CompactTextString(m) }
+func (*BaseConfigSetIn) ProtoMessage()    {}
+func (*BaseConfigSetIn) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{15}
+}
+func (m *BaseConfigSetIn) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseConfigSetIn.Unmarshal(m, b)
+}
+func (m *BaseConfigSetIn) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseConfigSetIn.Marshal(b, m, deterministic)
+}
+func (dst *BaseConfigSetIn) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseConfigSetIn.Merge(dst, src)
+}
+func (m *BaseConfigSetIn) XXX_Size() int {
+	return xxx_messageInfo_BaseConfigSetIn.Size(m)
+}
+func (m *BaseConfigSetIn) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseConfigSetIn.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseConfigSetIn proto.InternalMessageInfo
+
+func (m *BaseConfigSetIn) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *BaseConfigSetIn) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+type BaseConfigOut struct {
+	Key                  string   `protobuf:"bytes,1,opt,name=Key,json=key,proto3" json:"Key,omitempty"`
+	Value                string   `protobuf:"bytes,2,opt,name=Value,json=value,proto3" json:"Value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseConfigOut) Reset()         { *m = BaseConfigOut{} }
+func (m *BaseConfigOut) String() string { return proto.CompactTextString(m) }
+func (*BaseConfigOut) ProtoMessage()    {}
+func (*BaseConfigOut) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{16}
+}
+func (m *BaseConfigOut) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseConfigOut.Unmarshal(m, b)
+}
+func (m *BaseConfigOut) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseConfigOut.Marshal(b, m, deterministic)
+}
+func (dst *BaseConfigOut) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseConfigOut.Merge(dst, src)
+}
+func (m *BaseConfigOut) XXX_Size() int {
+	return xxx_messageInfo_BaseConfigOut.Size(m)
+}
+func (m *BaseConfigOut) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseConfigOut.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseConfigOut proto.InternalMessageInfo
+
+func (m *BaseConfigOut) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *BaseConfigOut) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+// BaseLogsIn requests the last Lines journal entries of a unit, and the new ones if Follow is set. Priority selects
+// entries up to a syslog priority, like err or warning, Since skips entries older than the unix timestamp, unless it
+// is 0. If Structured is set, the entries are sent with their time, unit and priority instead of just the lines.
+type BaseLogsIn struct {
+	Unit                 string   `protobuf:"bytes,1,opt,name=Unit,json=unit,proto3" json:"Unit,omitempty"`
+	Lines                int32    `protobuf:"varint,2,opt,name=Lines,json=lines,proto3" json:"Lines,omitempty"`
+	Follow               bool     `protobuf:"varint,3,opt,name=Follow,json=follow,proto3" json:"Follow,omitempty"`
+	Priority             string   `protobuf:"bytes,4,opt,name=Priority,json=priority,proto3" json:"Priority,omitempty"`
+	Since                int64    `protobuf:"varint,5,opt,name=Since,json=since,proto3" json:"Since,omitempty"`
+	Structured           bool     `protobuf:"varint,6,opt,name=Structured,json=structured,proto3" json:"Structured,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseLogsIn) Reset()         { *m = BaseLogsIn{} }
+func (m *BaseLogsIn) String() string { return proto.CompactTextString(m) }
+func (*BaseLogsIn) ProtoMessage()    {}
+func (*BaseLogsIn) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{17}
+}
+func (m *BaseLogsIn) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseLogsIn.Unmarshal(m, b)
+}
+func (m *BaseLogsIn) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseLogsIn.Marshal(b, m, deterministic)
+}
+func (dst *BaseLogsIn) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseLogsIn.Merge(dst, src)
+}
+func (m *BaseLogsIn) XXX_Size() int {
+	return xxx_messageInfo_BaseLogsIn.Size(m)
+}
+func (m *BaseLogsIn) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseLogsIn.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseLogsIn proto.InternalMessageInfo
+
+func (m *BaseLogsIn) GetUnit() string {
+	if m != nil {
+		return m.Unit
+	}
+	return ""
+}
+
+func (m *BaseLogsIn) GetLines() int32 {
+	if m != nil {
+		return m.Lines
+	}
+	return 0
+}
+
+func (m *BaseLogsIn) GetFollow() bool {
+	if m != nil {
+		return m.Follow
+	}
+	return false
+}
+
+func (m *BaseLogsIn) GetPriority() string {
+	if m != nil {
+		return m.Priority
+	}
+	return ""
+}
+
+func (m *BaseLogsIn) GetSince() int64 {
+	if m != nil {
+		return m.Since
+	}
+	return 0
+}
+
+func (m *BaseLogsIn) GetStructured() bool {
+	if m != nil {
+		return m.Structured
+	}
+	return false
+}
+
+// BaseLogEntry is a journal entry. Time is in unix microseconds.
+type BaseLogEntry struct {
+	Time                 int64    `protobuf:"varint,1,opt,name=Time,json=time,proto3" json:"Time,omitempty"`
+	Unit                 string   `protobuf:"bytes,2,opt,name=Unit,json=unit,proto3" json:"Unit,omitempty"`
+	Priority             int32    `protobuf:"varint,3,opt,name=Priority,json=priority,proto3" json:"Priority,omitempty"`
+	Message              string   `protobuf:"bytes,4,opt,name=Message,json=message,proto3" json:"Message,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseLogEntry) Reset()         { *m = BaseLogEntry{} }
+func (m *BaseLogEntry) String() string { return proto.CompactTextString(m) }
+func (*BaseLogEntry) ProtoMessage()    {}
+func (*BaseLogEntry) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{18}
+}
+func (m *BaseLogEntry) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseLogEntry.Unmarshal(m, b)
+}
+func (m *BaseLogEntry) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseLogEntry.Marshal(b, m, deterministic)
+}
+func (dst *BaseLogEntry) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseLogEntry.Merge(dst, src)
+}
+func (m *BaseLogEntry) XXX_Size() int {
+	return xxx_messageInfo_BaseLogEntry.Size(m)
+}
+func (m *BaseLogEntry) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseLogEntry.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseLogEntry proto.InternalMessageInfo
+
+func (m *BaseLogEntry) GetTime() int64 {
+	if m != nil {
+		return m.Time
+	}
+	return 0
+}
+
+func (m *BaseLogEntry) GetUnit() string {
+	if m != nil {
+		return m.Unit
+	}
+	return ""
+}
+
+func (m *BaseLogEntry) GetPriority() int32 {
+	if m != nil {
+		return m.Priority
+	}
+	return 0
+}
+
+func (m *BaseLogEntry) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+// BaseLogsOut carries a batch of log lines, or entries for structured requests. The last message of a stream that is
+// not followed has EndOfStream set.
+type BaseLogsOut struct {
+	Lines                []string        `protobuf:"bytes,1,rep,name=Lines,json=lines,proto3" json:"Lines,omitempty"`
+	EndOfStream          bool            `protobuf:"varint,2,opt,name=EndOfStream,json=endOfStream,proto3" json:"EndOfStream,omitempty"`
+	Entries              []*BaseLogEntry `protobuf:"bytes,3,rep,name=Entries,json=entries,proto3" json:"Entries,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *BaseLogsOut) Reset()         { *m = BaseLogsOut{} }
+func (m *BaseLogsOut) String() string { return proto.CompactTextString(m) }
+func (*BaseLogsOut) ProtoMessage()    {}
+func (*BaseLogsOut) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{19}
+}
+func (m *BaseLogsOut) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseLogsOut.Unmarshal(m, b)
+}
+func (m *BaseLogsOut) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseLogsOut.Marshal(b, m, deterministic)
+}
+func (dst *BaseLogsOut) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseLogsOut.Merge(dst, src)
+}
+func (m *BaseLogsOut) XXX_Size() int {
+	return xxx_messageInfo_BaseLogsOut.Size(m)
+}
+func (m *BaseLogsOut) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseLogsOut.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseLogsOut proto.InternalMessageInfo
+
+func (m *BaseLogsOut) GetLines() []string {
+	if m != nil {
+		return m.Lines
+	}
+	return nil
+}
+
+func (m *BaseLogsOut) GetEndOfStream() bool {
+	if m != nil {
+		return m.EndOfStream
+	}
+	return false
+}
+
+func (m *BaseLogsOut) GetEntries() []*BaseLogEntry {
+	if m != nil {
+		return m.Entries
+	}
+	return nil
+}
+
+// BaseUpdateIn is followed by an attachment with the update file.
+type BaseUpdateIn struct {
+	Size                 int64    `protobuf:"varint,1,opt,name=Size,json=size,proto3" json:"Size,omitempty"`
+	Sha256               []byte   `protobuf:"bytes,2,opt,name=Sha256,json=sha256,proto3" json:"Sha256,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseUpdateIn) Reset()         { *m = BaseUpdateIn{} }
+func (m *BaseUpdateIn) String() string { return proto.CompactTextString(m) }
+func (*BaseUpdateIn) ProtoMessage()    {}
+func (*BaseUpdateIn) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{20}
+}
+func (m *BaseUpdateIn) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseUpdateIn.Unmarshal(m, b)
+}
+func (m *BaseUpdateIn) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseUpdateIn.Marshal(b, m, deterministic)
+}
+func (dst *BaseUpdateIn) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseUpdateIn.Merge(dst, src)
+}
+func (m *BaseUpdateIn) XXX_Size() int {
+	return xxx_messageInfo_BaseUpdateIn.Size(m)
+}
+func (m *BaseUpdateIn) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseUpdateIn.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseUpdateIn proto.InternalMessageInfo
+
+func (m *BaseUpdateIn) GetSize() int64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+func (m *BaseUpdateIn) GetSha256() []byte {
+	if m != nil {
+		return m.Sha256
+	}
+	return nil
+}
+
+type BaseUpdateOut struct {
+	Path                 string   `protobuf:"bytes,1,opt,name=Path,json=path,proto3" json:"Path,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseUpdateOut) Reset()         { *m = BaseUpdateOut{} }
+func (m *BaseUpdateOut) String() string { return proto.CompactTextString(m) }
+func (*BaseUpdateOut) ProtoMessage()    {}
+func (*BaseUpdateOut) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{21}
+}
+func (m *BaseUpdateOut) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseUpdateOut.Unmarshal(m, b)
+}
+func (m *BaseUpdateOut) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseUpdateOut.Marshal(b, m, deterministic)
+}
+func (dst *BaseUpdateOut) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseUpdateOut.Merge(dst, src)
+}
+func (m *BaseUpdateOut) XXX_Size() int {
+	return xxx_messageInfo_BaseUpdateOut.Size(m)
+}
+func (m *BaseUpdateOut) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseUpdateOut.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseUpdateOut proto.InternalMessageInfo
+
+func (m *BaseUpdateOut) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+type BaseHealthIn struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseHealthIn) Reset()         { *m = BaseHealthIn{} }
+func (m *BaseHealthIn) String() string { return proto.CompactTextString(m) }
+func (*BaseHealthIn) ProtoMessage()    {}
+func (*BaseHealthIn) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{22}
+}
+func (m *BaseHealthIn) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseHealthIn.Unmarshal(m, b)
+}
+func (m *BaseHealthIn) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseHealthIn.Marshal(b, m, deterministic)
+}
+func (dst *BaseHealthIn) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseHealthIn.Merge(dst, src)
+}
+func (m *BaseHealthIn) XXX_Size() int {
+	return xxx_messageInfo_BaseHealthIn.Size(m)
+}
+func (m *BaseHealthIn) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseHealthIn.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseHealthIn proto.InternalMessageInfo
+
+// BaseBackendHealth is the health of a service the middleware talks to. Status is ok, degraded or down. LastSuccess and
+// LastErrorTime are unix timestamps in seconds, zero if there was none yet.
+type BaseBackendHealth struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=Name,json=name,proto3" json:"Name,omitempty"`
+	Status               string   `protobuf:"bytes,2,opt,name=Status,json=status,proto3" json:"Status,omitempty"`
+	LastSuccess          int64    `protobuf:"varint,3,opt,name=LastSuccess,json=lastSuccess,proto3" json:"LastSuccess,omitempty"`
+	LastError            string   `protobuf:"bytes,4,opt,name=LastError,json=lastError,proto3" json:"LastError,omitempty"`
+	LastErrorTime        int64    `protobuf:"varint,5,opt,name=LastErrorTime,json=lastErrorTime,proto3" json:"LastErrorTime,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseBackendHealth) Reset()         { *m = BaseBackendHealth{} }
+func (m *BaseBackendHealth) String() string { return proto.CompactTextString(m) }
+func (*BaseBackendHealth) ProtoMessage()    {}
+func (*BaseBackendHealth) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{23}
+}
+func (m *BaseBackendHealth) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseBackendHealth.Unmarshal(m, b)
+}
+func (m *BaseBackendHealth) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseBackendHealth.Marshal(b, m, deterministic)
+}
+func (dst *BaseBackendHealth) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseBackendHealth.Merge(dst, src)
+}
+func (m *BaseBackendHealth) XXX_Size() int {
+	return xxx_messageInfo_BaseBackendHealth.Size(m)
+}
+func (m *BaseBackendHealth) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseBackendHealth.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseBackendHealth proto.InternalMessageInfo
+
+func (m *BaseBackendHealth) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *BaseBackendHealth) GetStatus() string {
+	if m != nil {
+		return m.Status
+	}
+	return ""
+}
+
+func (m *BaseBackendHealth) GetLastSuccess() int64 {
+	if m != nil {
+		return m.LastSuccess
+	}
+	return 0
+}
+
+func (m *BaseBackendHealth) GetLastError() string {
+	if m != nil {
+		return m.LastError
+	}
+	return ""
+}
+
+func (m *BaseBackendHealth) GetLastErrorTime() int64 {
+	if m != nil {
+		return m.LastErrorTime
+	}
+	return 0
+}
+
+// BaseHealthOut is the detailed health of the middleware. Status is the worst status of the backends.
+type BaseHealthOut struct {
+	Status               string               `protobuf:"bytes,1,opt,name=Status,json=status,proto3" json:"Status,omitempty"`
+	Backends             []*BaseBackendHealth `protobuf:"bytes,2,rep,name=Backends,json=backends,proto3" json:"Backends,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *BaseHealthOut) Reset()         { *m = BaseHealthOut{} }
+func (m *BaseHealthOut) String() string { return proto.CompactTextString(m) }
+func (*BaseHealthOut) ProtoMessage()    {}
+func (*BaseHealthOut) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{24}
+}
+func (m *BaseHealthOut) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseHealthOut.Unmarshal(m, b)
+}
+func (m *BaseHealthOut) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseHealthOut.Marshal(b, m, deterministic)
+}
+func (dst *BaseHealthOut) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseHealthOut.Merge(dst, src)
+}
+func (m *BaseHealthOut) XXX_Size() int {
+	return xxx_messageInfo_BaseHealthOut.Size(m)
+}
+func (m *BaseHealthOut) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseHealthOut.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseHealthOut proto.InternalMessageInfo
+
+func (m *BaseHealthOut) GetStatus() string {
+	if m != nil {
+		return m.Status
+	}
+	return ""
+}
+
+func (m *BaseHealthOut) GetBackends() []*BaseBackendHealth {
+	if m != nil {
+		return m.Backends
+	}
+	return nil
+}
+
+type BasePairedClientsIn struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BasePairedClientsIn) Reset()         { *m = BasePairedClientsIn{} }
+func (m *BasePairedClientsIn) String() string { return proto.CompactTextString(m) }
+func (*BasePairedClientsIn) ProtoMessage()    {}
+func (*BasePairedClientsIn) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{25}
+}
+func (m *BasePairedClientsIn) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BasePairedClientsIn.Unmarshal(m, b)
+}
+func (m *BasePairedClientsIn) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BasePairedClientsIn.Marshal(b, m, deterministic)
+}
+func (dst *BasePairedClientsIn) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BasePairedClientsIn.Merge(dst, src)
+}
+func (m *BasePairedClientsIn) XXX_Size() int {
+	return xxx_messageInfo_BasePairedClientsIn.Size(m)
+}
+func (m *BasePairedClientsIn) XXX_DiscardUnknown() {
+	xxx_messageInfo_BasePairedClientsIn.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BasePairedClientsIn proto.InternalMessageInfo
+
+// BasePairedClient is a paired client with its role: owner, operator or read-only. Self is set for the client that
+// made the request.
+type BasePairedClient struct {
+	Pubkey               []byte   `protobuf:"bytes,1,opt,name=Pubkey,json=pubkey,proto3" json:"Pubkey,omitempty"`
+	Role                 string   `protobuf:"bytes,2,opt,name=Role,json=role,proto3" json:"Role,omitempty"`
+	Self                 bool     `protobuf:"varint,3,opt,name=Self,json=self,proto3" json:"Self,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BasePairedClient) Reset()         { *m = BasePairedClient{} }
+func (m *BasePairedClient) String() string { return proto.CompactTextString(m) }
+func (*BasePairedClient) ProtoMessage()    {}
+func (*BasePairedClient) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{26}
+}
+func (m *BasePairedClient) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BasePairedClient.Unmarshal(m, b)
+}
+func (m *BasePairedClient) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BasePairedClient.Marshal(b, m, deterministic)
+}
+func (dst *BasePairedClient) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BasePairedClient.Merge(dst, src)
+}
+func (m *BasePairedClient) XXX_Size() int {
+	return xxx_messageInfo_BasePairedClient.Size(m)
+}
+func (m *BasePairedClient) XXX_DiscardUnknown() {
+	xxx_messageInfo_BasePairedClient.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BasePairedClient proto.InternalMessageInfo
+
+func (m *BasePairedClient) GetPubkey() []byte {
+	if m != nil {
+		return m.Pubkey
+	}
+	return nil
+}
+
+func (m *BasePairedClient) GetRole() string {
+	if m != nil {
+		return m.Role
+	}
+	return ""
+}
+
+func (m *BasePairedClient) GetSelf() bool {
+	if m != nil {
+		return m.Self
+	}
+	return false
+}
+
+type BasePairedClientsOut struct {
+	Clients              []*BasePairedClient `protobuf:"bytes,1,rep,name=Clients,json=clients,proto3" json:"Clients,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
+	XXX_unrecognized     []byte              `json:"-"`
+	XXX_sizecache        int32               `json:"-"`
+}
+
+func (m *BasePairedClientsOut) Reset()         { *m = BasePairedClientsOut{} }
+func (m *BasePairedClientsOut) String() string { return proto.CompactTextString(m) }
+func (*BasePairedClientsOut) ProtoMessage()    {}
+func (*BasePairedClientsOut) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{27}
+}
+func (m *BasePairedClientsOut) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BasePairedClientsOut.Unmarshal(m, b)
+}
+func (m *BasePairedClientsOut) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BasePairedClientsOut.Marshal(b, m, deterministic)
+}
+func (dst *BasePairedClientsOut) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BasePairedClientsOut.Merge(dst, src)
+}
+func (m *BasePairedClientsOut) XXX_Size() int {
+	return xxx_messageInfo_BasePairedClientsOut.Size(m)
+}
+func (m *BasePairedClientsOut) XXX_DiscardUnknown() {
+	xxx_messageInfo_BasePairedClientsOut.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BasePairedClientsOut proto.InternalMessageInfo
+
+func (m *BasePairedClientsOut) GetClients() []*BasePairedClient {
+	if m != nil {
+		return m.Clients
+	}
+	return nil
+}
+
+// BaseSetClientRoleIn changes the role of a paired client. Only owners may change roles, and the last owner can not
+// be demoted. It is answered with the updated BasePairedClientsOut.
+type BaseSetClientRoleIn struct {
+	Pubkey               []byte   `protobuf:"bytes,1,opt,name=Pubkey,json=pubkey,proto3" json:"Pubkey,omitempty"`
+	Role                 string   `protobuf:"bytes,2,opt,name=Role,json=role,proto3" json:"Role,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseSetClientRoleIn) Reset()         { *m = BaseSetClientRoleIn{} }
+func (m *BaseSetClientRoleIn) String() string { return proto.CompactTextString(m) }
+func (*BaseSetClientRoleIn) ProtoMessage()    {}
+func (*BaseSetClientRoleIn) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{28}
+}
+func (m *BaseSetClientRoleIn) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseSetClientRoleIn.Unmarshal(m, b)
+}
+func (m *BaseSetClientRoleIn) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseSetClientRoleIn.Marshal(b, m, deterministic)
+}
+func (dst *BaseSetClientRoleIn) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseSetClientRoleIn.Merge(dst, src)
+}
+func (m *BaseSetClientRoleIn) XXX_Size() int {
+	return xxx_messageInfo_BaseSetClientRoleIn.Size(m)
+}
+func (m *BaseSetClientRoleIn) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseSetClientRoleIn.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseSetClientRoleIn proto.InternalMessageInfo
+
+func (m *BaseSetClientRoleIn) GetPubkey() []byte {
+	if m != nil {
+		return m.Pubkey
+	}
+	return nil
+}
+
+func (m *BaseSetClientRoleIn) GetRole() string {
+	if m != nil {
+		return m.Role
+	}
+	return ""
+}
+
+// BaseAuditLogIn requests up to Limit entries of the audit log, starting with the entry with the sequence number
+// Offset. A Limit of 0 requests the default of 100 entries.
+type BaseAuditLogIn struct {
+	Offset               uint64   `protobuf:"varint,1,opt,name=Offset,json=offset,proto3" json:"Offset,omitempty"`
+	Limit                uint32   `protobuf:"varint,2,opt,name=Limit,json=limit,proto3" json:"Limit,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseAuditLogIn) Reset()         { *m = BaseAuditLogIn{} }
+func (m *BaseAuditLogIn) String() string { return proto.CompactTextString(m) }
+func (*BaseAuditLogIn) ProtoMessage()    {}
+func (*BaseAuditLogIn) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{29}
+}
+func (m *BaseAuditLogIn) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseAuditLogIn.Unmarshal(m, b)
+}
+func (m *BaseAuditLogIn) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseAuditLogIn.Marshal(b, m, deterministic)
+}
+func (dst *BaseAuditLogIn) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseAuditLogIn.Merge(dst, src)
+}
+func (m *BaseAuditLogIn) XXX_Size() int {
+	return xxx_messageInfo_BaseAuditLogIn.Size(m)
+}
+func (m *BaseAuditLogIn) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseAuditLogIn.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseAuditLogIn proto.InternalMessageInfo
+
+func (m *BaseAuditLogIn) GetOffset() uint64 {
+	if m != nil {
+		return m.Offset
+	}
+	return 0
+}
+
+func (m *BaseAuditLogIn) GetLimit() uint32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+// BaseAuditEntry is an entry of the audit log. Client is the fingerprint of the client that caused the action, the
+// first 8 bytes of the sha256 hash of its pubkey in hex. Hash chains the entry to the previous one.
+type BaseAuditEntry struct {
+	Sequence             uint64   `protobuf:"varint,1,opt,name=Sequence,json=sequence,proto3" json:"Sequence,omitempty"`
+	Time                 int64    `protobuf:"varint,2,opt,name=Time,json=time,proto3" json:"Time,omitempty"`
+	Client               string   `protobuf:"bytes,3,opt,name=Client,json=client,proto3" json:"Client,omitempty"`
+	Action               string   `protobuf:"bytes,4,opt,name=Action,json=action,proto3" json:"Action,omitempty"`
+	Details              string   `protobuf:"bytes,5,opt,name=Details,json=details,proto3" json:"Details,omitempty"`
+	PrevHash             string   `protobuf:"bytes,6,opt,name=PrevHash,json=prevHash,proto3" json:"PrevHash,omitempty"`
+	Hash                 string   `protobuf:"bytes,7,opt,name=Hash,json=hash,proto3" json:"Hash,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseAuditEntry) Reset()         { *m = BaseAuditEntry{} }
+func (m *BaseAuditEntry) String() string { return proto.CompactTextString(m) }
+func (*BaseAuditEntry) ProtoMessage()    {}
+func (*BaseAuditEntry) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{30}
+}
+func (m *BaseAuditEntry) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseAuditEntry.Unmarshal(m, b)
+}
+func (m *BaseAuditEntry) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseAuditEntry.Marshal(b, m, deterministic)
+}
+func (dst *BaseAuditEntry) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseAuditEntry.Merge(dst, src)
+}
+func (m *BaseAuditEntry) XXX_Size() int {
+	return xxx_messageInfo_BaseAuditEntry.Size(m)
+}
+func (m *BaseAuditEntry) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseAuditEntry.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseAuditEntry proto.InternalMessageInfo
+
+func (m *BaseAuditEntry) GetSequence() uint64 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
+}
+
+func (m *BaseAuditEntry) GetTime() int64 {
+	if m != nil {
+		return m.Time
+	}
+	return 0
+}
+
+func (m *BaseAuditEntry) GetClient() string {
+	if m != nil {
+		return m.Client
+	}
+	return ""
+}
+
+func (m *BaseAuditEntry) GetAction() string {
+	if m != nil {
+		return m.Action
+	}
+	return ""
+}
+
+func (m *BaseAuditEntry) GetDetails() string {
+	if m != nil {
+		return m.Details
+	}
+	return ""
+}
+
+func (m *BaseAuditEntry) GetPrevHash() string {
+	if m != nil {
+		return m.PrevHash
+	}
+	return ""
+}
+
+func (m *BaseAuditEntry) GetHash() string {
+	if m != nil {
+		return m.Hash
+	}
+	return ""
+}
+
+// BaseAuditLogOut holds the requested entries and the total number of entries. IntegrityError is set if the hash
+// chain of the log is broken, i.e. entries were modified or removed.
+type BaseAuditLogOut struct {
+	Entries              []*BaseAuditEntry `protobuf:"bytes,1,rep,name=Entries,json=entries,proto3" json:"Entries,omitempty"`
+	Total                uint64            `protobuf:"varint,2,opt,name=Total,json=total,proto3" json:"Total,omitempty"`
+	IntegrityError       string            `protobuf:"bytes,3,opt,name=IntegrityError,json=integrityError,proto3" json:"IntegrityError,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *BaseAuditLogOut) Reset()         { *m = BaseAuditLogOut{} }
+func (m *BaseAuditLogOut) String() string { return proto.CompactTextString(m) }
+func (*BaseAuditLogOut) ProtoMessage()    {}
+func (*BaseAuditLogOut) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{31}
+}
+func (m *BaseAuditLogOut) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseAuditLogOut.Unmarshal(m, b)
+}
+func (m *BaseAuditLogOut) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseAuditLogOut.Marshal(b, m, deterministic)
+}
+func (dst *BaseAuditLogOut) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseAuditLogOut.Merge(dst, src)
+}
+func (m *BaseAuditLogOut) XXX_Size() int {
+	return xxx_messageInfo_BaseAuditLogOut.Size(m)
+}
+func (m *BaseAuditLogOut) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseAuditLogOut.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseAuditLogOut proto.InternalMessageInfo
+
+func (m *BaseAuditLogOut) GetEntries() []*BaseAuditEntry {
+	if m != nil {
+		return m.Entries
+	}
+	return nil
+}
+
+func (m *BaseAuditLogOut) GetTotal() uint64 {
+	if m != nil {
+		return m.Total
+	}
+	return 0
+}
+
+func (m *BaseAuditLogOut) GetIntegrityError() string {
+	if m != nil {
+		return m.IntegrityError
+	}
+	return ""
+}
+
+// BaseLogLevelIn changes the minimum level of the lines logged by the middleware to debug, info, warning or error
+// until the next restart. An empty Level only requests the current one. It is answered with BaseLogLevelOut.
+type BaseLogLevelIn struct {
+	Level                string   `protobuf:"bytes,1,opt,name=Level,json=level,proto3" json:"Level,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseLogLevelIn) Reset()         { *m = BaseLogLevelIn{} }
+func (m *BaseLogLevelIn) String() string { return proto.CompactTextString(m) }
+func (*BaseLogLevelIn) ProtoMessage()    {}
+func (*BaseLogLevelIn) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{32}
+}
+func (m *BaseLogLevelIn) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseLogLevelIn.Unmarshal(m, b)
+}
+func (m *BaseLogLevelIn) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseLogLevelIn.Marshal(b, m, deterministic)
+}
+func (dst *BaseLogLevelIn) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseLogLevelIn.Merge(dst, src)
+}
+func (m *BaseLogLevelIn) XXX_Size() int {
+	return xxx_messageInfo_BaseLogLevelIn.Size(m)
+}
+func (m *BaseLogLevelIn) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseLogLevelIn.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseLogLevelIn proto.InternalMessageInfo
+
+func (m *BaseLogLevelIn) GetLevel() string {
+	if m != nil {
+		return m.Level
+	}
+	return ""
+}
+
+type BaseLogLevelOut struct {
+	Level                string   `protobuf:"bytes,1,opt,name=Level,json=level,proto3" json:"Level,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseLogLevelOut) Reset()         { *m = BaseLogLevelOut{} }
+func (m *BaseLogLevelOut) String() string { return proto.CompactTextString(m) }
+func (*BaseLogLevelOut) ProtoMessage()    {}
+func (*BaseLogLevelOut) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{33}
+}
+func (m *BaseLogLevelOut) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseLogLevelOut.Unmarshal(m, b)
+}
+func (m *BaseLogLevelOut) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseLogLevelOut.Marshal(b, m, deterministic)
+}
+func (dst *BaseLogLevelOut) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseLogLevelOut.Merge(dst, src)
+}
+func (m *BaseLogLevelOut) XXX_Size() int {
+	return xxx_messageInfo_BaseLogLevelOut.Size(m)
+}
+func (m *BaseLogLevelOut) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseLogLevelOut.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseLogLevelOut proto.InternalMessageInfo
+
+func (m *BaseLogLevelOut) GetLevel() string {
+	if m != nil {
+		return m.Level
+	}
+	return ""
+}
+
+// BaseSupportBundleIn requests a tar.gz archive with redacted diagnostics of the Base, to attach to a support request.
+type BaseSupportBundleIn struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseSupportBundleIn) Reset()         { *m = BaseSupportBundleIn{} }
+func (m *BaseSupportBundleIn) String() string { return proto.CompactTextString(m) }
+func (*BaseSupportBundleIn) ProtoMessage()    {}
+func (*BaseSupportBundleIn) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{34}
+}
+func (m *BaseSupportBundleIn) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseSupportBundleIn.Unmarshal(m, b)
+}
+func (m *BaseSupportBundleIn) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseSupportBundleIn.Marshal(b, m, deterministic)
+}
+func (dst *BaseSupportBundleIn) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseSupportBundleIn.Merge(dst, src)
+}
+func (m *BaseSupportBundleIn) XXX_Size() int {
+	return xxx_messageInfo_BaseSupportBundleIn.Size(m)
+}
+func (m *BaseSupportBundleIn) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseSupportBundleIn.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseSupportBundleIn proto.InternalMessageInfo
+
+// BaseSupportBundleOut is followed by an attachment with the archive.
+type BaseSupportBundleOut struct {
+	Filename             string   `protobuf:"bytes,1,opt,name=Filename,json=filename,proto3" json:"Filename,omitempty"`
+	Size                 int64    `protobuf:"varint,2,opt,name=Size,json=size,proto3" json:"Size,omitempty"`
+	Sha256               []byte   `protobuf:"bytes,3,opt,name=Sha256,json=sha256,proto3" json:"Sha256,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseSupportBundleOut) Reset()         { *m = BaseSupportBundleOut{} }
+func (m *BaseSupportBundleOut) String() string { return proto.CompactTextString(m) }
+func (*BaseSupportBundleOut) ProtoMessage()    {}
+func (*BaseSupportBundleOut) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{35}
+}
+func (m *BaseSupportBundleOut) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseSupportBundleOut.Unmarshal(m, b)
+}
+func (m *BaseSupportBundleOut) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseSupportBundleOut.Marshal(b, m, deterministic)
+}
+func (dst *BaseSupportBundleOut) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseSupportBundleOut.Merge(dst, src)
+}
+func (m *BaseSupportBundleOut) XXX_Size() int {
+	return xxx_messageInfo_BaseSupportBundleOut.Size(m)
+}
+func (m *BaseSupportBundleOut) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseSupportBundleOut.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseSupportBundleOut proto.InternalMessageInfo
+
+func (m *BaseSupportBundleOut) GetFilename() string {
+	if m != nil {
+		return m.Filename
+	}
+	return ""
+}
+
+func (m *BaseSupportBundleOut) GetSize() int64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+func (m *BaseSupportBundleOut) GetSha256() []byte {
+	if m != nil {
+		return m.Sha256
+	}
+	return nil
+}
+
+// BaseLightningBackupIn requests a backup of the lightning node, encrypted with Passphrase. If ToDrive is set, it is
+// written to the backup drive, otherwise BaseLightningBackupOut is followed by an attachment with the backup.
+type BaseLightningBackupIn struct {
+	Passphrase           string   `protobuf:"bytes,1,opt,name=Passphrase,json=passphrase,proto3" json:"Passphrase,omitempty"`
+	ToDrive              bool     `protobuf:"varint,2,opt,name=ToDrive,json=toDrive,proto3" json:"ToDrive,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseLightningBackupIn) Reset()         { *m = BaseLightningBackupIn{} }
+func (m *BaseLightningBackupIn) String() string { return proto.CompactTextString(m) }
+func (*BaseLightningBackupIn) ProtoMessage()    {}
+func (*BaseLightningBackupIn) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{36}
+}
+func (m *BaseLightningBackupIn) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseLightningBackupIn.Unmarshal(m, b)
+}
+func (m *BaseLightningBackupIn) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseLightningBackupIn.Marshal(b, m, deterministic)
+}
+func (dst *BaseLightningBackupIn) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseLightningBackupIn.Merge(dst, src)
+}
+func (m *BaseLightningBackupIn) XXX_Size() int {
+	return xxx_messageInfo_BaseLightningBackupIn.Size(m)
+}
+func (m *BaseLightningBackupIn) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseLightningBackupIn.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseLightningBackupIn proto.InternalMessageInfo
+
+func (m *BaseLightningBackupIn) GetPassphrase() string {
+	if m != nil {
+		return m.Passphrase
+	}
+	return ""
+}
+
+func (m *BaseLightningBackupIn) GetToDrive() bool {
+	if m != nil {
+		return m.ToDrive
+	}
+	return false
+}
+
+// BaseLightningBackupOut describes the backup. Path is the path on the backup drive, if it was written there.
+type BaseLightningBackupOut struct {
+	Filename             string   `protobuf:"bytes,1,opt,name=Filename,json=filename,proto3" json:"Filename,omitempty"`
+	Size                 int64    `protobuf:"varint,2,opt,name=Size,json=size,proto3" json:"Size,omitempty"`
+	Sha256               []byte   `protobuf:"bytes,3,opt,name=Sha256,json=sha256,proto3" json:"Sha256,omitempty"`
+	NodeId               string   `protobuf:"bytes,4,opt,name=NodeId,json=nodeId,proto3" json:"NodeId,omitempty"`
+	Path                 string   `protobuf:"bytes,5,opt,name=Path,json=path,proto3" json:"Path,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseLightningBackupOut) Reset()         { *m = BaseLightningBackupOut{} }
+func (m *BaseLightningBackupOut) String() string { return proto.CompactTextString(m) }
+func (*BaseLightningBackupOut) ProtoMessage()    {}
+func (*BaseLightningBackupOut) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{37}
+}
+func (m *BaseLightningBackupOut) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseLightningBackupOut.Unmarshal(m, b)
+}
+func (m *BaseLightningBackupOut) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseLightningBackupOut.Marshal(b, m, deterministic)
+}
+func (dst *BaseLightningBackupOut) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseLightningBackupOut.Merge(dst, src)
+}
+func (m *BaseLightningBackupOut) XXX_Size() int {
+	return xxx_messageInfo_BaseLightningBackupOut.Size(m)
+}
+func (m *BaseLightningBackupOut) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseLightningBackupOut.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseLightningBackupOut proto.InternalMessageInfo
+
+func (m *BaseLightningBackupOut) GetFilename() string {
+	if m != nil {
+		return m.Filename
+	}
+	return ""
+}
+
+func (m *BaseLightningBackupOut) GetSize() int64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+func (m *BaseLightningBackupOut) GetSha256() []byte {
+	if m != nil {
+		return m.Sha256
+	}
+	return nil
+}
+
+func (m *BaseLightningBackupOut) GetNodeId() string {
+	if m != nil {
+		return m.NodeId
+	}
+	return ""
+}
+
+func (m *BaseLightningBackupOut) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+// BaseLightningRestoreIn restores the lightning node from a backup. It is followed by an attachment with the backup,
+// unless DriveFilename names a backup on the backup drive.
+type BaseLightningRestoreIn struct {
+	Passphrase           string   `protobuf:"bytes,1,opt,name=Passphrase,json=passphrase,proto3" json:"Passphrase,omitempty"`
+	Size                 int64    `protobuf:"varint,2,opt,name=Size,json=size,proto3" json:"Size,omitempty"`
+	Sha256               []byte   `protobuf:"bytes,3,opt,name=Sha256,json=sha256,proto3" json:"Sha256,omitempty"`
+	DriveFilename        string   `protobuf:"bytes,4,opt,name=DriveFilename,json=driveFilename,proto3" json:"DriveFilename,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseLightningRestoreIn) Reset()         { *m = BaseLightningRestoreIn{} }
+func (m *BaseLightningRestoreIn) String() string { return proto.CompactTextString(m) }
+func (*BaseLightningRestoreIn) ProtoMessage()    {}
+func (*BaseLightningRestoreIn) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{38}
+}
+func (m *BaseLightningRestoreIn) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseLightningRestoreIn.Unmarshal(m, b)
+}
+func (m *BaseLightningRestoreIn) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseLightningRestoreIn.Marshal(b, m, deterministic)
+}
+func (dst *BaseLightningRestoreIn) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseLightningRestoreIn.Merge(dst, src)
+}
+func (m *BaseLightningRestoreIn) XXX_Size() int {
+	return xxx_messageInfo_BaseLightningRestoreIn.Size(m)
+}
+func (m *BaseLightningRestoreIn) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseLightningRestoreIn.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseLightningRestoreIn proto.InternalMessageInfo
+
+func (m *BaseLightningRestoreIn) GetPassphrase() string {
+	if m != nil {
+		return m.Passphrase
+	}
+	return ""
+}
+
+func (m *BaseLightningRestoreIn) GetSize() int64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+func (m *BaseLightningRestoreIn) GetSha256() []byte {
+	if m != nil {
+		return m.Sha256
+	}
+	return nil
+}
+
+func (m *BaseLightningRestoreIn) GetDriveFilename() string {
+	if m != nil {
+		return m.DriveFilename
+	}
+	return ""
+}
+
+// BaseLightningRestoreOut describes the restored backup. Created is its unix timestamp in seconds.
+type BaseLightningRestoreOut struct {
+	NodeId               string   `protobuf:"bytes,1,opt,name=NodeId,json=nodeId,proto3" json:"NodeId,omitempty"`
+	Network              string   `protobuf:"bytes,2,opt,name=Network,json=network,proto3" json:"Network,omitempty"`
+	Created              int64    `protobuf:"varint,3,opt,name=Created,json=created,proto3" json:"Created,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseLightningRestoreOut) Reset()         { *m = BaseLightningRestoreOut{} }
+func (m *BaseLightningRestoreOut) String() string { return proto.CompactTextString(m) }
+func (*BaseLightningRestoreOut) ProtoMessage()    {}
+func (*BaseLightningRestoreOut) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{39}
+}
+func (m *BaseLightningRestoreOut) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseLightningRestoreOut.Unmarshal(m, b)
+}
+func (m *BaseLightningRestoreOut) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseLightningRestoreOut.Marshal(b, m, deterministic)
+}
+func (dst *BaseLightningRestoreOut) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseLightningRestoreOut.Merge(dst, src)
+}
+func (m *BaseLightningRestoreOut) XXX_Size() int {
+	return xxx_messageInfo_BaseLightningRestoreOut.Size(m)
+}
+func (m *BaseLightningRestoreOut) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseLightningRestoreOut.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseLightningRestoreOut proto.InternalMessageInfo
+
+func (m *BaseLightningRestoreOut) GetNodeId() string {
+	if m != nil {
+		return m.NodeId
+	}
+	return ""
+}
+
+func (m *BaseLightningRestoreOut) GetNetwork() string {
+	if m != nil {
+		return m.Network
+	}
+	return ""
+}
+
+func (m *BaseLightningRestoreOut) GetCreated() int64 {
+	if m != nil {
+		return m.Created
+	}
+	return 0
+}
+
+// BaseConfigBackupIn requests a backup of the configuration of the Base: the settings, the keystore with the paired
+// clients, the keys of the tor hidden services and the network settings. It is signed by the Base and encrypted with
+// Passphrase. If ToDrive is set, it is written to the backup drive, otherwise BaseConfigBackupOut is followed by an
+// attachment with the backup.
+type BaseConfigBackupIn struct {
+	Passphrase           string   `protobuf:"bytes,1,opt,name=Passphrase,json=passphrase,proto3" json:"Passphrase,omitempty"`
+	ToDrive              bool     `protobuf:"varint,2,opt,name=ToDrive,json=toDrive,proto3" json:"ToDrive,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseConfigBackupIn) Reset()         { *m = BaseConfigBackupIn{} }
+func (m *BaseConfigBackupIn) String() string { return proto.CompactTextString(m) }
+func (*BaseConfigBackupIn) ProtoMessage()    {}
+func (*BaseConfigBackupIn) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{40}
+}
+func (m *BaseConfigBackupIn) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseConfigBackupIn.Unmarshal(m, b)
+}
+func (m *BaseConfigBackupIn) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseConfigBackupIn.Marshal(b, m, deterministic)
+}
+func (dst *BaseConfigBackupIn) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseConfigBackupIn.Merge(dst, src)
+}
+func (m *BaseConfigBackupIn) XXX_Size() int {
+	return xxx_messageInfo_BaseConfigBackupIn.Size(m)
+}
+func (m *BaseConfigBackupIn) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseConfigBackupIn.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseConfigBackupIn proto.InternalMessageInfo
+
+func (m *BaseConfigBackupIn) GetPassphrase() string {
+	if m != nil {
+		return m.Passphrase
+	}
+	return ""
+}
+
+func (m *BaseConfigBackupIn) GetToDrive() bool {
+	if m != nil {
+		return m.ToDrive
+	}
+	return false
+}
+
+// BaseConfigBackupOut describes the backup. Signer is the pubkey that signed it, Path is the path on the backup drive,
+// if it was written there.
+type BaseConfigBackupOut struct {
+	Filename             string   `protobuf:"bytes,1,opt,name=Filename,json=filename,proto3" json:"Filename,omitempty"`
+	Size                 int64    `protobuf:"varint,2,opt,name=Size,json=size,proto3" json:"Size,omitempty"`
+	Sha256               []byte   `protobuf:"bytes,3,opt,name=Sha256,json=sha256,proto3" json:"Sha256,omitempty"`
+	Signer               string   `protobuf:"bytes,4,opt,name=Signer,json=signer,proto3" json:"Signer,omitempty"`
+	Path                 string   `protobuf:"bytes,5,opt,name=Path,json=path,proto3" json:"Path,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseConfigBackupOut) Reset()         { *m = BaseConfigBackupOut{} }
+func (m *BaseConfigBackupOut) String() string { return proto.CompactTextString(m) }
+func (*BaseConfigBackupOut) ProtoMessage()    {}
+func (*BaseConfigBackupOut) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{41}
+}
+func (m *BaseConfigBackupOut) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseConfigBackupOut.Unmarshal(m, b)
+}
+func (m *BaseConfigBackupOut) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseConfigBackupOut.Marshal(b, m, deterministic)
+}
+func (dst *BaseConfigBackupOut) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseConfigBackupOut.Merge(dst, src)
+}
+func (m *BaseConfigBackupOut) XXX_Size() int {
+	return xxx_messageInfo_BaseConfigBackupOut.Size(m)
+}
+func (m *BaseConfigBackupOut) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseConfigBackupOut.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseConfigBackupOut proto.InternalMessageInfo
+
+func (m *BaseConfigBackupOut) GetFilename() string {
+	if m != nil {
+		return m.Filename
+	}
+	return ""
+}
+
+func (m *BaseConfigBackupOut) GetSize() int64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+func (m *BaseConfigBackupOut) GetSha256() []byte {
+	if m != nil {
+		return m.Sha256
+	}
+	return nil
+}
+
+func (m *BaseConfigBackupOut) GetSigner() string {
+	if m != nil {
+		return m.Signer
+	}
+	return ""
+}
+
+func (m *BaseConfigBackupOut) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+// BaseConfigRestoreIn restores the configuration of the Base from a backup. It is followed by an attachment with the
+// backup, unless DriveFilename names a backup on the backup drive. Signer is the Signer of BaseConfigBackupOut that
+// the owner was shown when the backup was made, only a backup signed by it is restored. Once BaseConfigRestoreOut is
+// sent, all connections are closed, clients that were paired when the backup was made can reconnect.
+type BaseConfigRestoreIn struct {
+	Passphrase           string   `protobuf:"bytes,1,opt,name=Passphrase,json=passphrase,proto3" json:"Passphrase,omitempty"`
+	Size                 int64    `protobuf:"varint,2,opt,name=Size,json=size,proto3" json:"Size,omitempty"`
+	Sha256               []byte   `protobuf:"bytes,3,opt,name=Sha256,json=sha256,proto3" json:"Sha256,omitempty"`
+	DriveFilename        string   `protobuf:"bytes,4,opt,name=DriveFilename,json=driveFilename,proto3" json:"DriveFilename,omitempty"`
+	Signer               string   `protobuf:"bytes,5,opt,name=Signer,json=signer,proto3" json:"Signer,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseConfigRestoreIn) Reset()         { *m = BaseConfigRestoreIn{} }
+func (m *BaseConfigRestoreIn) String() string { return proto.CompactTextString(m) }
+func (*BaseConfigRestoreIn) ProtoMessage()    {}
+func (*BaseConfigRestoreIn) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{42}
+}
+func (m *BaseConfigRestoreIn) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseConfigRestoreIn.Unmarshal(m, b)
+}
+func (m *BaseConfigRestoreIn) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseConfigRestoreIn.Marshal(b, m, deterministic)
+}
+func (dst *BaseConfigRestoreIn) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseConfigRestoreIn.Merge(dst, src)
+}
+func (m *BaseConfigRestoreIn) XXX_Size() int {
+	return xxx_messageInfo_BaseConfigRestoreIn.Size(m)
+}
+func (m *BaseConfigRestoreIn) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseConfigRestoreIn.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseConfigRestoreIn proto.InternalMessageInfo
+
+func (m *BaseConfigRestoreIn) GetPassphrase() string {
+	if m != nil {
+		return m.Passphrase
+	}
+	return ""
+}
+
+func (m *BaseConfigRestoreIn) GetSize() int64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+func (m *BaseConfigRestoreIn) GetSha256() []byte {
+	if m != nil {
+		return m.Sha256
+	}
+	return nil
+}
+
+func (m *BaseConfigRestoreIn) GetDriveFilename() string {
+	if m != nil {
+		return m.DriveFilename
+	}
+	return ""
+}
+
+func (m *BaseConfigRestoreIn) GetSigner() string {
+	if m != nil {
+		return m.Signer
+	}
+	return ""
+}
+
+// BaseConfigRestoreOut describes the restored backup. Created is its unix timestamp in seconds.
+type BaseConfigRestoreOut struct {
+	Network              string   `protobuf:"bytes,1,opt,name=Network,json=network,proto3" json:"Network,omitempty"`
+	Created              int64    `protobuf:"varint,2,opt,name=Created,json=created,proto3" json:"Created,omitempty"`
+	Signer               string   `protobuf:"bytes,3,opt,name=Signer,json=signer,proto3" json:"Signer,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseConfigRestoreOut) Reset()         { *m = BaseConfigRestoreOut{} }
+func (m *BaseConfigRestoreOut) String() string { return proto.CompactTextString(m) }
+func (*BaseConfigRestoreOut) ProtoMessage()    {}
+func (*BaseConfigRestoreOut) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{43}
+}
+func (m *BaseConfigRestoreOut) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseConfigRestoreOut.Unmarshal(m, b)
+}
+func (m *BaseConfigRestoreOut) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseConfigRestoreOut.Marshal(b, m, deterministic)
+}
+func (dst *BaseConfigRestoreOut) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseConfigRestoreOut.Merge(dst, src)
+}
+func (m *BaseConfigRestoreOut) XXX_Size() int {
+	return xxx_messageInfo_BaseConfigRestoreOut.Size(m)
+}
+func (m *BaseConfigRestoreOut) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseConfigRestoreOut.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseConfigRestoreOut proto.InternalMessageInfo
+
+func (m *BaseConfigRestoreOut) GetNetwork() string {
+	if m != nil {
+		return m.Network
+	}
+	return ""
+}
+
+func (m *BaseConfigRestoreOut) GetCreated() int64 {
+	if m != nil {
+		return m.Created
+	}
+	return 0
+}
+
+func (m *BaseConfigRestoreOut) GetSigner() string {
+	if m != nil {
+		return m.Signer
+	}
+	return ""
+}
+
+// BaseFactoryResetIn resets the Base to its factory state: it wipes the keystore with the paired clients, the keys of
+// the tor hidden services, the settings and the wifi credentials, the blockchain if WipeBlockchain is set and the
+// lightning node if WipeLightning is set, and then reboots. Without a Token, the Base answers with a confirmation token
+// and warnings. Sending the token back with the same options within its expiry resets the Base.
+type BaseFactoryResetIn struct {
+	Token                string   `protobuf:"bytes,1,opt,name=Token,json=token,proto3" json:"Token,omitempty"`
+	WipeBlockchain       bool     `protobuf:"varint,2,opt,name=WipeBlockchain,json=wipeBlockchain,proto3" json:"WipeBlockchain,omitempty"`
+	WipeLightning        bool     `protobuf:"varint,3,opt,name=WipeLightning,json=wipeLightning,proto3" json:"WipeLightning,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseFactoryResetIn) Reset()         { *m = BaseFactoryResetIn{} }
+func (m *BaseFactoryResetIn) String() string { return proto.CompactTextString(m) }
+func (*BaseFactoryResetIn) ProtoMessage()    {}
+func (*BaseFactoryResetIn) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{44}
+}
+func (m *BaseFactoryResetIn) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseFactoryResetIn.Unmarshal(m, b)
+}
+func (m *BaseFactoryResetIn) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseFactoryResetIn.Marshal(b, m, deterministic)
+}
+func (dst *BaseFactoryResetIn) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseFactoryResetIn.Merge(dst, src)
+}
+func (m *BaseFactoryResetIn) XXX_Size() int {
+	return xxx_messageInfo_BaseFactoryResetIn.Size(m)
+}
+func (m *BaseFactoryResetIn) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseFactoryResetIn.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseFactoryResetIn proto.InternalMessageInfo
+
+func (m *BaseFactoryResetIn) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+func (m *BaseFactoryResetIn) GetWipeBlockchain() bool {
+	if m != nil {
+		return m.WipeBlockchain
+	}
+	return false
+}
+
+func (m *BaseFactoryResetIn) GetWipeLightning() bool {
+	if m != nil {
+		return m.WipeLightning
+	}
+	return false
+}
+
+// BaseFactoryResetOut either carries the confirmation Token, its Expires unix timestamp in seconds and the Warnings to
+// show before confirming, or Resetting is set. All connections are closed then and the Base reboots.
+type BaseFactoryResetOut struct {
+	Token                string   `protobuf:"bytes,1,opt,name=Token,json=token,proto3" json:"Token,omitempty"`
+	Expires              int64    `protobuf:"varint,2,opt,name=Expires,json=expires,proto3" json:"Expires,omitempty"`
+	Warnings             []string `protobuf:"bytes,3,rep,name=Warnings,json=warnings,proto3" json:"Warnings,omitempty"`
+	Resetting            bool     `protobuf:"varint,4,opt,name=Resetting,json=resetting,proto3" json:"Resetting,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaseFactoryResetOut) Reset()         { *m = BaseFactoryResetOut{} }
+func (m *BaseFactoryResetOut) String() string { return proto.CompactTextString(m) }
+func (*BaseFactoryResetOut) ProtoMessage()    {}
+func (*BaseFactoryResetOut) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{45}
+}
+func (m *BaseFactoryResetOut) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BaseFactoryResetOut.Unmarshal(m, b)
+}
+func (m *BaseFactoryResetOut) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BaseFactoryResetOut.Marshal(b, m, deterministic)
+}
+func (dst *BaseFactoryResetOut) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaseFactoryResetOut.Merge(dst, src)
+}
+func (m *BaseFactoryResetOut) XXX_Size() int {
+	return xxx_messageInfo_BaseFactoryResetOut.Size(m)
+}
+func (m *BaseFactoryResetOut) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaseFactoryResetOut.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaseFactoryResetOut proto.InternalMessageInfo
+
+func (m *BaseFactoryResetOut) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+func (m *BaseFactoryResetOut) GetExpires() int64 {
+	if m != nil {
+		return m.Expires
+	}
+	return 0
+}
+
+func (m *BaseFactoryResetOut) GetWarnings() []string {
+	if m != nil {
+		return m.Warnings
+	}
+	return nil
+}
+
+func (m *BaseFactoryResetOut) GetResetting() bool {
+	if m != nil {
+		return m.Resetting
+	}
+	return false
+}
+
+// BasePowerIn reboots or shuts down the Base, Action is reboot or shutdown. The services are stopped in order first,
+// BasePowerOut reports each step, and then all connections are closed. If ScheduledAt is a unix timestamp in seconds,
+// a maintenance reboot is scheduled then instead, replacing an earlier one. CancelSchedule cancels the scheduled
+// reboot. Without an Action, the scheduled reboot is returned.
+type BasePowerIn struct {
+	Action               string   `protobuf:"bytes,1,opt,name=Action,json=action,proto3" json:"Action,omitempty"`
+	ScheduledAt          int64    `protobuf:"varint,2,opt,name=ScheduledAt,json=scheduledAt,proto3" json:"ScheduledAt,omitempty"`
+	CancelSchedule       bool     `protobuf:"varint,3,opt,name=CancelSchedule,json=cancelSchedule,proto3" json:"CancelSchedule,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BasePowerIn) Reset()         { *m = BasePowerIn{} }
+func (m *BasePowerIn) String() string { return proto.CompactTextString(m) }
+func (*BasePowerIn) ProtoMessage()    {}
+func (*BasePowerIn) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{46}
+}
+func (m *BasePowerIn) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BasePowerIn.Unmarshal(m, b)
+}
+func (m *BasePowerIn) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BasePowerIn.Marshal(b, m, deterministic)
+}
+func (dst *BasePowerIn) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BasePowerIn.Merge(dst, src)
+}
+func (m *BasePowerIn) XXX_Size() int {
+	return xxx_messageInfo_BasePowerIn.Size(m)
+}
+func (m *BasePowerIn) XXX_DiscardUnknown() {
+	xxx_messageInfo_BasePowerIn.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BasePowerIn proto.InternalMessageInfo
+
+func (m *BasePowerIn) GetAction() string {
+	if m != nil {
+		return m.Action
+	}
+	return ""
+}
+
+func (m *BasePowerIn) GetScheduledAt() int64 {
+	if m != nil {
+		return m.ScheduledAt
+	}
+	return 0
+}
+
+func (m *BasePowerIn) GetCancelSchedule() bool {
+	if m != nil {
+		return m.CancelSchedule
+	}
+	return false
+}
+
+// BasePowerOut reports a Step of powering down the Base, like stopping bitcoind. The last message of every request has
+// Done set and carries the unix timestamp of the ScheduledReboot, 0 if none is scheduled.
+type BasePowerOut struct {
+	Step                 string   `protobuf:"bytes,1,opt,name=Step,json=step,proto3" json:"Step,omitempty"`
+	Done                 bool     `protobuf:"varint,2,opt,name=Done,json=done,proto3" json:"Done,omitempty"`
+	ScheduledReboot      int64    `protobuf:"varint,3,opt,name=ScheduledReboot,json=scheduledReboot,proto3" json:"ScheduledReboot,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BasePowerOut) Reset()         { *m = BasePowerOut{} }
+func (m *BasePowerOut) String() string { return proto.CompactTextString(m) }
+func (*BasePowerOut) ProtoMessage()    {}
+func (*BasePowerOut) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{47}
+}
+func (m *BasePowerOut) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BasePowerOut.Unmarshal(m, b)
+}
+func (m *BasePowerOut) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BasePowerOut.Marshal(b, m, deterministic)
+}
+func (dst *BasePowerOut) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BasePowerOut.Merge(dst, src)
+}
+func (m *BasePowerOut) XXX_Size() int {
+	return xxx_messageInfo_BasePowerOut.Size(m)
+}
+func (m *BasePowerOut) XXX_DiscardUnknown() {
+	xxx_messageInfo_BasePowerOut.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BasePowerOut proto.InternalMessageInfo
+
+func (m *BasePowerOut) GetStep() string {
+	if m != nil {
+		return m.Step
+	}
+	return ""
+}
+
+func (m *BasePowerOut) GetDone() bool {
+	if m != nil {
+		return m.Done
+	}
+	return false
+}
+
+func (m *BasePowerOut) GetScheduledReboot() int64 {
+	if m != nil {
+		return m.ScheduledReboot
+	}
+	return 0
+}
+
+// BitBoxBaseIn is a request of a client. RequestId is chosen by the client and sent back in every response to the
+// request, so that the responses to concurrent requests of the same rpc can be told apart.
+type BitBoxBaseIn struct {
+	RequestId uint64 `protobuf:"varint,100,opt,name=RequestId,json=requestId,proto3" json:"RequestId,omitempty"`
+	// Types that are valid to be assigned to BitBoxBaseIn:
+	//	*BitBoxBaseIn_BaseSystemEnvIn
+	//	*BitBoxBaseIn_BaseServicesIn
+	//	*BitBoxBaseIn_BaseConfigGetIn
+	//	*BitBoxBaseIn_BaseConfigSetIn
+	//	*BitBoxBaseIn_BaseLogsIn
+	//	*BitBoxBaseIn_BaseUpdateIn
+	//	*BitBoxBaseIn_BaseStateResyncIn
+	//	*BitBoxBaseIn_BaseHealthIn
+	//	*BitBoxBaseIn_BasePairedClientsIn
+	//	*BitBoxBaseIn_BaseSetClientRoleIn
+	//	*BitBoxBaseIn_BaseAuditLogIn
+	//	*BitBoxBaseIn_BaseLogLevelIn
+	//	*BitBoxBaseIn_BaseSupportBundleIn
+	//	*BitBoxBaseIn_BaseLightningBackupIn
+	//	*BitBoxBaseIn_BaseLightningRestoreIn
+	//	*BitBoxBaseIn_BaseConfigBackupIn
+	//	*BitBoxBaseIn_BaseConfigRestoreIn
+	//	*BitBoxBaseIn_BaseFactoryResetIn
+	//	*BitBoxBaseIn_BasePowerIn
+	BitBoxBaseIn         isBitBoxBaseIn_BitBoxBaseIn `protobuf_oneof:"bitBoxBaseIn"`
+	XXX_NoUnkeyedLiteral struct{}                    `json:"-"`
+	XXX_unrecognized     []byte                      `json:"-"`
+	XXX_sizecache        int32                       `json:"-"`
+}
+
+func (m *BitBoxBaseIn) Reset()         { *m = BitBoxBaseIn{} }
+func (m *BitBoxBaseIn) String() string { return proto.CompactTextString(m) }
+func (*BitBoxBaseIn) ProtoMessage()    {}
+func (*BitBoxBaseIn) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{48}
+}
+func (m *BitBoxBaseIn) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BitBoxBaseIn.Unmarshal(m, b)
+}
+func (m *BitBoxBaseIn) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BitBoxBaseIn.Marshal(b, m, deterministic)
+}
+func (dst *BitBoxBaseIn) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BitBoxBaseIn.Merge(dst, src)
+}
+func (m *BitBoxBaseIn) XXX_Size() int {
+	return xxx_messageInfo_BitBoxBaseIn.Size(m)
+}
+func (m *BitBoxBaseIn) XXX_DiscardUnknown() {
+	xxx_messageInfo_BitBoxBaseIn.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BitBoxBaseIn proto.InternalMessageInfo
+
+func (m *BitBoxBaseIn) GetRequestId() uint64 {
+	if m != nil {
+		return m.RequestId
+	}
+	return 0
+}
+
+type isBitBoxBaseIn_BitBoxBaseIn interface {
+	isBitBoxBaseIn_BitBoxBaseIn()
+}
+
+type BitBoxBaseIn_BaseSystemEnvIn struct {
+	BaseSystemEnvIn *BaseSystemEnvIn `protobuf:"bytes,1,opt,name=baseSystemEnvIn,proto3,oneof"`
+}
+
+type BitBoxBaseIn_BaseServicesIn struct {
+	BaseServicesIn *BaseServicesIn `protobuf:"bytes,2,opt,name=baseServicesIn,proto3,oneof"`
+}
+
+type BitBoxBaseIn_BaseConfigGetIn struct {
+	BaseConfigGetIn *BaseConfigGetIn `protobuf:"bytes,3,opt,name=baseConfigGetIn,proto3,oneof"`
+}
+
+type BitBoxBaseIn_BaseConfigSetIn struct {
+	BaseConfigSetIn *BaseConfigSetIn `protobuf:"bytes,4,opt,name=baseConfigSetIn,proto3,oneof"`
+}
+
+type BitBoxBaseIn_BaseLogsIn struct {
+	BaseLogsIn *BaseLogsIn `protobuf:"bytes,5,opt,name=baseLogsIn,proto3,oneof"`
+}
+
+type BitBoxBaseIn_BaseUpdateIn struct {
+	BaseUpdateIn *BaseUpdateIn `protobuf:"bytes,6,opt,name=baseUpdateIn,proto3,oneof"`
+}
+
+type BitBoxBaseIn_BaseStateResyncIn struct {
+	BaseStateResyncIn *BaseStateResyncIn `protobuf:"bytes,7,opt,name=baseStateResyncIn,proto3,oneof"`
+}
+
+type BitBoxBaseIn_BaseHealthIn struct {
+	BaseHealthIn *BaseHealthIn `protobuf:"bytes,8,opt,name=baseHealthIn,proto3,oneof"`
+}
+
+type BitBoxBaseIn_BasePairedClientsIn struct {
+	BasePairedClientsIn *BasePairedClientsIn `protobuf:"bytes,9,opt,name=basePairedClientsIn,proto3,oneof"`
+}
+
+type BitBoxBaseIn_BaseSetClientRoleIn struct {
+	BaseSetClientRoleIn *BaseSetClientRoleIn `protobuf:"bytes,10,opt,name=baseSetClientRoleIn,proto3,oneof"`
+}
+
+type BitBoxBaseIn_BaseAuditLogIn struct {
+	BaseAuditLogIn *BaseAuditLogIn `protobuf:"bytes,11,opt,name=baseAuditLogIn,proto3,oneof"`
+}
+
+type BitBoxBaseIn_BaseLogLevelIn struct {
+	BaseLogLevelIn *BaseLogLevelIn `protobuf:"bytes,12,opt,name=baseLogLevelIn,proto3,oneof"`
+}
+
+type BitBoxBaseIn_BaseSupportBundleIn struct {
+	BaseSupportBundleIn *BaseSupportBundleIn `protobuf:"bytes,13,opt,name=baseSupportBundleIn,proto3,oneof"`
+}
+
+type BitBoxBaseIn_BaseLightningBackupIn struct {
+	BaseLightningBackupIn *BaseLightningBackupIn `protobuf:"bytes,14,opt,name=baseLightningBackupIn,proto3,oneof"`
+}
+
+type BitBoxBaseIn_BaseLightningRestoreIn struct {
+	BaseLightningRestoreIn *BaseLightningRestoreIn `protobuf:"bytes,15,opt,name=baseLightningRestoreIn,proto3,oneof"`
+}
+
+type BitBoxBaseIn_BaseConfigBackupIn struct {
+	BaseConfigBackupIn *BaseConfigBackupIn `protobuf:"bytes,16,opt,name=baseConfigBackupIn,proto3,oneof"`
+}
+
+type BitBoxBaseIn_BaseConfigRestoreIn struct {
+	BaseConfigRestoreIn *BaseConfigRestoreIn `protobuf:"bytes,17,opt,name=baseConfigRestoreIn,proto3,oneof"`
+}
+
+type BitBoxBaseIn_BaseFactoryResetIn struct {
+	BaseFactoryResetIn *BaseFactoryResetIn `protobuf:"bytes,18,opt,name=baseFactoryResetIn,proto3,oneof"`
+}
+
+type BitBoxBaseIn_BasePowerIn struct {
+	BasePowerIn *BasePowerIn `protobuf:"bytes,19,opt,name=basePowerIn,proto3,oneof"`
+}
+
+func (*BitBoxBaseIn_BaseSystemEnvIn) isBitBoxBaseIn_BitBoxBaseIn() {}
+
+func (*BitBoxBaseIn_BaseServicesIn) isBitBoxBaseIn_BitBoxBaseIn() {}
+
+func (*BitBoxBaseIn_BaseConfigGetIn) isBitBoxBaseIn_BitBoxBaseIn() {}
+
+func (*BitBoxBaseIn_BaseConfigSetIn) isBitBoxBaseIn_BitBoxBaseIn() {}
+
+func (*BitBoxBaseIn_BaseLogsIn) isBitBoxBaseIn_BitBoxBaseIn() {}
+
+func (*BitBoxBaseIn_BaseUpdateIn) isBitBoxBaseIn_BitBoxBaseIn() {}
+
+func (*BitBoxBaseIn_BaseStateResyncIn) isBitBoxBaseIn_BitBoxBaseIn() {}
+
+func (*BitBoxBaseIn_BaseHealthIn) isBitBoxBaseIn_BitBoxBaseIn() {}
+
+func (*BitBoxBaseIn_BasePairedClientsIn) isBitBoxBaseIn_BitBoxBaseIn() {}
+
+func (*BitBoxBaseIn_BaseSetClientRoleIn) isBitBoxBaseIn_BitBoxBaseIn() {}
+
+func (*BitBoxBaseIn_BaseAuditLogIn) isBitBoxBaseIn_BitBoxBaseIn() {}
+
+func (*BitBoxBaseIn_BaseLogLevelIn) isBitBoxBaseIn_BitBoxBaseIn() {}
+
+func (*BitBoxBaseIn_BaseSupportBundleIn) isBitBoxBaseIn_BitBoxBaseIn() {}
+
+func (*BitBoxBaseIn_BaseLightningBackupIn) isBitBoxBaseIn_BitBoxBaseIn() {}
+
+func (*BitBoxBaseIn_BaseLightningRestoreIn) isBitBoxBaseIn_BitBoxBaseIn() {}
+
+func (*BitBoxBaseIn_BaseConfigBackupIn) isBitBoxBaseIn_BitBoxBaseIn() {}
+
+func (*BitBoxBaseIn_BaseConfigRestoreIn) isBitBoxBaseIn_BitBoxBaseIn() {}
+
+func (*BitBoxBaseIn_BaseFactoryResetIn) isBitBoxBaseIn_BitBoxBaseIn() {}
+
+func (*BitBoxBaseIn_BasePowerIn) isBitBoxBaseIn_BitBoxBaseIn() {}
+
+func (m *BitBoxBaseIn) GetBitBoxBaseIn() isBitBoxBaseIn_BitBoxBaseIn {
+	if m != nil {
+		return m.BitBoxBaseIn
+	}
+	return nil
+}
+
+func (m *BitBoxBaseIn) GetBaseSystemEnvIn() *BaseSystemEnvIn {
+	if x, ok := m.GetBitBoxBaseIn().(*BitBoxBaseIn_BaseSystemEnvIn); ok {
+		return x.BaseSystemEnvIn
+	}
+	return nil
+}
+
+func (m *BitBoxBaseIn) GetBaseServicesIn() *BaseServicesIn {
+	if x, ok := m.GetBitBoxBaseIn().(*BitBoxBaseIn_BaseServicesIn); ok {
+		return x.BaseServicesIn
+	}
+	return nil
+}
+
+func (m *BitBoxBaseIn) GetBaseConfigGetIn() *BaseConfigGetIn {
+	if x, ok := m.GetBitBoxBaseIn().(*BitBoxBaseIn_BaseConfigGetIn); ok {
+		return x.BaseConfigGetIn
+	}
+	return nil
+}
+
+func (m *BitBoxBaseIn) GetBaseConfigSetIn() *BaseConfigSetIn {
+	if x, ok := m.GetBitBoxBaseIn().(*BitBoxBaseIn_BaseConfigSetIn); ok {
+		return x.BaseConfigSetIn
+	}
+	return nil
+}
+
+func (m *BitBoxBaseIn) GetBaseLogsIn() *BaseLogsIn {
+	if x, ok := m.GetBitBoxBaseIn().(*BitBoxBaseIn_BaseLogsIn); ok {
+		return x.BaseLogsIn
+	}
+	return nil
+}
+
+func (m *BitBoxBaseIn) GetBaseUpdateIn() *BaseUpdateIn {
+	if x, ok := m.GetBitBoxBaseIn().(*BitBoxBaseIn_BaseUpdateIn); ok {
+		return x.BaseUpdateIn
+	}
+	return nil
+}
+
+func (m *BitBoxBaseIn) GetBaseStateResyncIn() *BaseStateResyncIn {
+	if x, ok := m.GetBitBoxBaseIn().(*BitBoxBaseIn_BaseStateResyncIn); ok {
+		return x.BaseStateResyncIn
+	}
+	return nil
+}
+
+func (m *BitBoxBaseIn) GetBaseHealthIn() *BaseHealthIn {
+	if x, ok := m.GetBitBoxBaseIn().(*BitBoxBaseIn_BaseHealthIn); ok {
+		return x.BaseHealthIn
+	}
+	return nil
+}
+
+func (m *BitBoxBaseIn) GetBasePairedClientsIn() *BasePairedClientsIn {
+	if x, ok := m.GetBitBoxBaseIn().(*BitBoxBaseIn_BasePairedClientsIn); ok {
+		return x.BasePairedClientsIn
+	}
+	return nil
+}
+
+func (m *BitBoxBaseIn) GetBaseSetClientRoleIn() *BaseSetClientRoleIn {
+	if x, ok := m.GetBitBoxBaseIn().(*BitBoxBaseIn_BaseSetClientRoleIn); ok {
+		return x.BaseSetClientRoleIn
+	}
+	return nil
+}
+
+func (m *BitBoxBaseIn) GetBaseAuditLogIn() *BaseAuditLogIn {
+	if x, ok := m.GetBitBoxBaseIn().(*BitBoxBaseIn_BaseAuditLogIn); ok {
+		return x.BaseAuditLogIn
+	}
+	return nil
+}
+
+func (m *BitBoxBaseIn) GetBaseLogLevelIn() *BaseLogLevelIn {
+	if x, ok := m.GetBitBoxBaseIn().(*BitBoxBaseIn_BaseLogLevelIn); ok {
+		return x.BaseLogLevelIn
+	}
+	return nil
+}
+
+func (m *BitBoxBaseIn) GetBaseSupportBundleIn() *BaseSupportBundleIn {
+	if x, ok := m.GetBitBoxBaseIn().(*BitBoxBaseIn_BaseSupportBundleIn); ok {
+		return x.BaseSupportBundleIn
+	}
+	return nil
+}
+
+func (m *BitBoxBaseIn) GetBaseLightningBackupIn() *BaseLightningBackupIn {
+	if x, ok := m.GetBitBoxBaseIn().(*BitBoxBaseIn_BaseLightningBackupIn); ok {
+		return x.BaseLightningBackupIn
+	}
+	return nil
+}
+
+func (m *BitBoxBaseIn) GetBaseLightningRestoreIn() *BaseLightningRestoreIn {
+	if x, ok := m.GetBitBoxBaseIn().(*BitBoxBaseIn_BaseLightningRestoreIn); ok {
+		return x.BaseLightningRestoreIn
+	}
+	return nil
+}
+
+func (m *BitBoxBaseIn) GetBaseConfigBackupIn() *BaseConfigBackupIn {
+	if x, ok := m.GetBitBoxBaseIn().(*BitBoxBaseIn_BaseConfigBackupIn); ok {
+		return x.BaseConfigBackupIn
+	}
+	return nil
+}
+
+func (m *BitBoxBaseIn) GetBaseConfigRestoreIn() *BaseConfigRestoreIn {
+	if x, ok := m.GetBitBoxBaseIn().(*BitBoxBaseIn_BaseConfigRestoreIn); ok {
+		return x.BaseConfigRestoreIn
+	}
+	return nil
+}
+
+func (m *BitBoxBaseIn) GetBaseFactoryResetIn() *BaseFactoryResetIn {
+	if x, ok := m.GetBitBoxBaseIn().(*BitBoxBaseIn_BaseFactoryResetIn); ok {
+		return x.BaseFactoryResetIn
+	}
+	return nil
+}
+
+func (m *BitBoxBaseIn) GetBasePowerIn() *BasePowerIn {
+	if x, ok := m.GetBitBoxBaseIn().(*BitBoxBaseIn_BasePowerIn); ok {
+		return x.BasePowerIn
+	}
+	return nil
+}
+
+// XXX_OneofFuncs is for the internal use of the proto package.
+func (*BitBoxBaseIn) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
+	return _BitBoxBaseIn_OneofMarshaler, _BitBoxBaseIn_OneofUnmarshaler, _BitBoxBaseIn_OneofSizer, []interface{}{
+		(*BitBoxBaseIn_BaseSystemEnvIn)(nil),
+		(*BitBoxBaseIn_BaseServicesIn)(nil),
+		(*BitBoxBaseIn_BaseConfigGetIn)(nil),
+		(*BitBoxBaseIn_BaseConfigSetIn)(nil),
+		(*BitBoxBaseIn_BaseLogsIn)(nil),
+		(*BitBoxBaseIn_BaseUpdateIn)(nil),
+		(*BitBoxBaseIn_BaseStateResyncIn)(nil),
+		(*BitBoxBaseIn_BaseHealthIn)(nil),
+		(*BitBoxBaseIn_BasePairedClientsIn)(nil),
+		(*BitBoxBaseIn_BaseSetClientRoleIn)(nil),
+		(*BitBoxBaseIn_BaseAuditLogIn)(nil),
+		(*BitBoxBaseIn_BaseLogLevelIn)(nil),
+		(*BitBoxBaseIn_BaseSupportBundleIn)(nil),
+		(*BitBoxBaseIn_BaseLightningBackupIn)(nil),
+		(*BitBoxBaseIn_BaseLightningRestoreIn)(nil),
+		(*BitBoxBaseIn_BaseConfigBackupIn)(nil),
+		(*BitBoxBaseIn_BaseConfigRestoreIn)(nil),
+		(*BitBoxBaseIn_BaseFactoryResetIn)(nil),
+		(*BitBoxBaseIn_BasePowerIn)(nil),
+	}
+}
+
+func _BitBoxBaseIn_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
+	m := msg.(*BitBoxBaseIn)
+	// bitBoxBaseIn
+	switch x := m.BitBoxBaseIn.(type) {
+	case *BitBoxBaseIn_BaseSystemEnvIn:
+		b.EncodeVarint(1<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseSystemEnvIn); err != nil {
+			return err
+		}
+	case *BitBoxBaseIn_BaseServicesIn:
+		b.EncodeVarint(2<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseServicesIn); err != nil {
+			return err
+		}
+	case *BitBoxBaseIn_BaseConfigGetIn:
+		b.EncodeVarint(3<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseConfigGetIn); err != nil {
+			return err
+		}
+	case *BitBoxBaseIn_BaseConfigSetIn:
+		b.EncodeVarint(4<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseConfigSetIn); err != nil {
+			return err
+		}
+	case *BitBoxBaseIn_BaseLogsIn:
+		b.EncodeVarint(5<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseLogsIn); err != nil {
+			return err
+		}
+	case *BitBoxBaseIn_BaseUpdateIn:
+		b.EncodeVarint(6<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseUpdateIn); err != nil {
+			return err
+		}
+	case *BitBoxBaseIn_BaseStateResyncIn:
+		b.EncodeVarint(7<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseStateResyncIn); err != nil {
+			return err
+		}
+	case *BitBoxBaseIn_BaseHealthIn:
+		b.EncodeVarint(8<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseHealthIn); err != nil {
+			return err
+		}
+	case *BitBoxBaseIn_BasePairedClientsIn:
+		b.EncodeVarint(9<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BasePairedClientsIn); err != nil {
+			return err
+		}
+	case *BitBoxBaseIn_BaseSetClientRoleIn:
+		b.EncodeVarint(10<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseSetClientRoleIn); err != nil {
+			return err
+		}
+	case *BitBoxBaseIn_BaseAuditLogIn:
+		b.EncodeVarint(11<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseAuditLogIn); err != nil {
+			return err
+		}
+	case *BitBoxBaseIn_BaseLogLevelIn:
+		b.EncodeVarint(12<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseLogLevelIn); err != nil {
+			return err
+		}
+	case *BitBoxBaseIn_BaseSupportBundleIn:
+		b.EncodeVarint(13<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseSupportBundleIn); err != nil {
+			return err
+		}
+	case *BitBoxBaseIn_BaseLightningBackupIn:
+		b.EncodeVarint(14<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseLightningBackupIn); err != nil {
+			return err
+		}
+	case *BitBoxBaseIn_BaseLightningRestoreIn:
+		b.EncodeVarint(15<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseLightningRestoreIn); err != nil {
+			return err
+		}
+	case *BitBoxBaseIn_BaseConfigBackupIn:
+		b.EncodeVarint(16<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseConfigBackupIn); err != nil {
+			return err
+		}
+	case *BitBoxBaseIn_BaseConfigRestoreIn:
+		b.EncodeVarint(17<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseConfigRestoreIn); err != nil {
+			return err
+		}
+	case *BitBoxBaseIn_BaseFactoryResetIn:
+		b.EncodeVarint(18<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseFactoryResetIn); err != nil {
+			return err
+		}
+	case *BitBoxBaseIn_BasePowerIn:
+		b.EncodeVarint(19<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BasePowerIn); err != nil {
 			return err
 		}
-	case nil:
-	default:
-		return fmt.Errorf("BitBoxBaseIn.BitBoxBaseIn has unexpected type %T", x)
-	}
-	return nil
-}
-
-func _BitBoxBaseIn_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error) {
-	m := msg.(*BitBoxBaseIn)
-	switch tag {
-	case 1: // bitBoxBaseIn.baseSystemEnvIn
+	case nil:
+	default:
+		return fmt.Errorf("BitBoxBaseIn.BitBoxBaseIn has unexpected type %T", x)
+	}
+	return nil
+}
+
+func _BitBoxBaseIn_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error) {
+	m := msg.(*BitBoxBaseIn)
+	switch tag {
+	case 1: // bitBoxBaseIn.baseSystemEnvIn
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseSystemEnvIn)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseIn = &BitBoxBaseIn_BaseSystemEnvIn{msg}
+		return true, err
+	case 2: // bitBoxBaseIn.baseServicesIn
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseServicesIn)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseIn = &BitBoxBaseIn_BaseServicesIn{msg}
+		return true, err
+	case 3: // bitBoxBaseIn.baseConfigGetIn
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseConfigGetIn)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseIn = &BitBoxBaseIn_BaseConfigGetIn{msg}
+		return true, err
+	case 4: // bitBoxBaseIn.baseConfigSetIn
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseConfigSetIn)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseIn = &BitBoxBaseIn_BaseConfigSetIn{msg}
+		return true, err
+	case 5: // bitBoxBaseIn.baseLogsIn
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseLogsIn)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseIn = &BitBoxBaseIn_BaseLogsIn{msg}
+		return true, err
+	case 6: // bitBoxBaseIn.baseUpdateIn
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseUpdateIn)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseIn = &BitBoxBaseIn_BaseUpdateIn{msg}
+		return true, err
+	case 7: // bitBoxBaseIn.baseStateResyncIn
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseStateResyncIn)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseIn = &BitBoxBaseIn_BaseStateResyncIn{msg}
+		return true, err
+	case 8: // bitBoxBaseIn.baseHealthIn
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseHealthIn)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseIn = &BitBoxBaseIn_BaseHealthIn{msg}
+		return true, err
+	case 9: // bitBoxBaseIn.basePairedClientsIn
 		if wire != proto.WireBytes {
 			return true, proto.ErrInternalBadWireType
 		}
-		msg := new(BaseSystemEnvIn)
+		msg := new(BasePairedClientsIn)
 		err := b.DecodeMessage(msg)
-		m.BitBoxBaseIn = &BitBoxBaseIn_BaseSystemEnvIn{msg}
+		m.BitBoxBaseIn = &BitBoxBaseIn_BasePairedClientsIn{msg}
+		return true, err
+	case 10: // bitBoxBaseIn.baseSetClientRoleIn
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseSetClientRoleIn)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseIn = &BitBoxBaseIn_BaseSetClientRoleIn{msg}
+		return true, err
+	case 11: // bitBoxBaseIn.baseAuditLogIn
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseAuditLogIn)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseIn = &BitBoxBaseIn_BaseAuditLogIn{msg}
+		return true, err
+	case 12: // bitBoxBaseIn.baseLogLevelIn
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseLogLevelIn)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseIn = &BitBoxBaseIn_BaseLogLevelIn{msg}
+		return true, err
+	case 13: // bitBoxBaseIn.baseSupportBundleIn
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseSupportBundleIn)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseIn = &BitBoxBaseIn_BaseSupportBundleIn{msg}
+		return true, err
+	case 14: // bitBoxBaseIn.baseLightningBackupIn
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseLightningBackupIn)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseIn = &BitBoxBaseIn_BaseLightningBackupIn{msg}
+		return true, err
+	case 15: // bitBoxBaseIn.baseLightningRestoreIn
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseLightningRestoreIn)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseIn = &BitBoxBaseIn_BaseLightningRestoreIn{msg}
+		return true, err
+	case 16: // bitBoxBaseIn.baseConfigBackupIn
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseConfigBackupIn)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseIn = &BitBoxBaseIn_BaseConfigBackupIn{msg}
+		return true, err
+	case 17: // bitBoxBaseIn.baseConfigRestoreIn
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseConfigRestoreIn)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseIn = &BitBoxBaseIn_BaseConfigRestoreIn{msg}
+		return true, err
+	case 18: // bitBoxBaseIn.baseFactoryResetIn
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseFactoryResetIn)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseIn = &BitBoxBaseIn_BaseFactoryResetIn{msg}
+		return true, err
+	case 19: // bitBoxBaseIn.basePowerIn
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BasePowerIn)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseIn = &BitBoxBaseIn_BasePowerIn{msg}
 		return true, err
 	default:
 		return false, nil
 	}
 }
 
-func _BitBoxBaseIn_OneofSizer(msg proto.Message) (n int) {
-	m := msg.(*BitBoxBaseIn)
-	// bitBoxBaseIn
-	switch x := m.BitBoxBaseIn.(type) {
-	case *BitBoxBaseIn_BaseSystemEnvIn:
-		s := proto.Size(x.BaseSystemEnvIn)
-		n += 1 // tag and wire
-		n += proto.SizeVarint(uint64(s))
-		n += s
-	case nil:
-	default:
-		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
+func _BitBoxBaseIn_OneofSizer(msg proto.Message) (n int) {
+	m := msg.(*BitBoxBaseIn)
+	// bitBoxBaseIn
+	switch x := m.BitBoxBaseIn.(type) {
+	case *BitBoxBaseIn_BaseSystemEnvIn:
+		s := proto.Size(x.BaseSystemEnvIn)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseIn_BaseServicesIn:
+		s := proto.Size(x.BaseServicesIn)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseIn_BaseConfigGetIn:
+		s := proto.Size(x.BaseConfigGetIn)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseIn_BaseConfigSetIn:
+		s := proto.Size(x.BaseConfigSetIn)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseIn_BaseLogsIn:
+		s := proto.Size(x.BaseLogsIn)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseIn_BaseUpdateIn:
+		s := proto.Size(x.BaseUpdateIn)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseIn_BaseStateResyncIn:
+		s := proto.Size(x.BaseStateResyncIn)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseIn_BaseHealthIn:
+		s := proto.Size(x.BaseHealthIn)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseIn_BasePairedClientsIn:
+		s := proto.Size(x.BasePairedClientsIn)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseIn_BaseSetClientRoleIn:
+		s := proto.Size(x.BaseSetClientRoleIn)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseIn_BaseAuditLogIn:
+		s := proto.Size(x.BaseAuditLogIn)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseIn_BaseLogLevelIn:
+		s := proto.Size(x.BaseLogLevelIn)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseIn_BaseSupportBundleIn:
+		s := proto.Size(x.BaseSupportBundleIn)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseIn_BaseLightningBackupIn:
+		s := proto.Size(x.BaseLightningBackupIn)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseIn_BaseLightningRestoreIn:
+		s := proto.Size(x.BaseLightningRestoreIn)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseIn_BaseConfigBackupIn:
+		s := proto.Size(x.BaseConfigBackupIn)
+		n += 2 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseIn_BaseConfigRestoreIn:
+		s := proto.Size(x.BaseConfigRestoreIn)
+		n += 2 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseIn_BaseFactoryResetIn:
+		s := proto.Size(x.BaseFactoryResetIn)
+		n += 2 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseIn_BasePowerIn:
+		s := proto.Size(x.BasePowerIn)
+		n += 2 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case nil:
+	default:
+		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
+	}
+	return n
+}
+
+// BitBoxBaseOut is a response or an event. RequestId is the one of the request that is answered, 0 for events.
+type BitBoxBaseOut struct {
+	RequestId uint64 `protobuf:"varint,100,opt,name=RequestId,json=requestId,proto3" json:"RequestId,omitempty"`
+	// Types that are valid to be assigned to BitBoxBaseOut:
+	//	*BitBoxBaseOut_BaseMiddlewareInfoOut
+	//	*BitBoxBaseOut_BaseSystemEnvOut
+	//	*BitBoxBaseOut_BaseErrorOut
+	//	*BitBoxBaseOut_BaseServicesOut
+	//	*BitBoxBaseOut_BaseConfigOut
+	//	*BitBoxBaseOut_BaseLogsOut
+	//	*BitBoxBaseOut_BaseUpdateOut
+	//	*BitBoxBaseOut_BaseStateOut
+	//	*BitBoxBaseOut_BaseHealthOut
+	//	*BitBoxBaseOut_BasePairedClientsOut
+	//	*BitBoxBaseOut_BaseAuditLogOut
+	//	*BitBoxBaseOut_BaseLogLevelOut
+	//	*BitBoxBaseOut_BaseSupportBundleOut
+	//	*BitBoxBaseOut_BaseLightningBackupOut
+	//	*BitBoxBaseOut_BaseLightningRestoreOut
+	//	*BitBoxBaseOut_BaseConfigBackupOut
+	//	*BitBoxBaseOut_BaseConfigRestoreOut
+	//	*BitBoxBaseOut_BaseFactoryResetOut
+	//	*BitBoxBaseOut_BasePowerOut
+	BitBoxBaseOut        isBitBoxBaseOut_BitBoxBaseOut `protobuf_oneof:"bitBoxBaseOut"`
+	XXX_NoUnkeyedLiteral struct{}                      `json:"-"`
+	XXX_unrecognized     []byte                        `json:"-"`
+	XXX_sizecache        int32                         `json:"-"`
+}
+
+func (m *BitBoxBaseOut) Reset()         { *m = BitBoxBaseOut{} }
+func (m *BitBoxBaseOut) String() string { return proto.CompactTextString(m) }
+func (*BitBoxBaseOut) ProtoMessage()    {}
+func (*BitBoxBaseOut) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bbb_ea182728b319f25a, []int{49}
+}
+func (m *BitBoxBaseOut) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BitBoxBaseOut.Unmarshal(m, b)
+}
+func (m *BitBoxBaseOut) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BitBoxBaseOut.Marshal(b, m, deterministic)
+}
+func (dst *BitBoxBaseOut) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BitBoxBaseOut.Merge(dst, src)
+}
+func (m *BitBoxBaseOut) XXX_Size() int {
+	return xxx_messageInfo_BitBoxBaseOut.Size(m)
+}
+func (m *BitBoxBaseOut) XXX_DiscardUnknown() {
+	xxx_messageInfo_BitBoxBaseOut.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BitBoxBaseOut proto.InternalMessageInfo
+
+func (m *BitBoxBaseOut) GetRequestId() uint64 {
+	if m != nil {
+		return m.RequestId
+	}
+	return 0
+}
+
+type isBitBoxBaseOut_BitBoxBaseOut interface {
+	isBitBoxBaseOut_BitBoxBaseOut()
+}
+
+type BitBoxBaseOut_BaseMiddlewareInfoOut struct {
+	BaseMiddlewareInfoOut *BaseMiddlewareInfoOut `protobuf:"bytes,1,opt,name=baseMiddlewareInfoOut,proto3,oneof"`
+}
+
+type BitBoxBaseOut_BaseSystemEnvOut struct {
+	BaseSystemEnvOut *BaseSystemEnvOut `protobuf:"bytes,2,opt,name=baseSystemEnvOut,proto3,oneof"`
+}
+
+type BitBoxBaseOut_BaseErrorOut struct {
+	BaseErrorOut *BaseErrorOut `protobuf:"bytes,3,opt,name=baseErrorOut,proto3,oneof"`
+}
+
+type BitBoxBaseOut_BaseServicesOut struct {
+	BaseServicesOut *BaseServicesOut `protobuf:"bytes,4,opt,name=baseServicesOut,proto3,oneof"`
+}
+
+type BitBoxBaseOut_BaseConfigOut struct {
+	BaseConfigOut *BaseConfigOut `protobuf:"bytes,5,opt,name=baseConfigOut,proto3,oneof"`
+}
+
+type BitBoxBaseOut_BaseLogsOut struct {
+	BaseLogsOut *BaseLogsOut `protobuf:"bytes,6,opt,name=baseLogsOut,proto3,oneof"`
+}
+
+type BitBoxBaseOut_BaseUpdateOut struct {
+	BaseUpdateOut *BaseUpdateOut `protobuf:"bytes,7,opt,name=baseUpdateOut,proto3,oneof"`
+}
+
+type BitBoxBaseOut_BaseStateOut struct {
+	BaseStateOut *BaseStateOut `protobuf:"bytes,8,opt,name=baseStateOut,proto3,oneof"`
+}
+
+type BitBoxBaseOut_BaseHealthOut struct {
+	BaseHealthOut *BaseHealthOut `protobuf:"bytes,9,opt,name=baseHealthOut,proto3,oneof"`
+}
+
+type BitBoxBaseOut_BasePairedClientsOut struct {
+	BasePairedClientsOut *BasePairedClientsOut `protobuf:"bytes,10,opt,name=basePairedClientsOut,proto3,oneof"`
+}
+
+type BitBoxBaseOut_BaseAuditLogOut struct {
+	BaseAuditLogOut *BaseAuditLogOut `protobuf:"bytes,11,opt,name=baseAuditLogOut,proto3,oneof"`
+}
+
+type BitBoxBaseOut_BaseLogLevelOut struct {
+	BaseLogLevelOut *BaseLogLevelOut `protobuf:"bytes,12,opt,name=baseLogLevelOut,proto3,oneof"`
+}
+
+type BitBoxBaseOut_BaseSupportBundleOut struct {
+	BaseSupportBundleOut *BaseSupportBundleOut `protobuf:"bytes,13,opt,name=baseSupportBundleOut,proto3,oneof"`
+}
+
+type BitBoxBaseOut_BaseLightningBackupOut struct {
+	BaseLightningBackupOut *BaseLightningBackupOut `protobuf:"bytes,14,opt,name=baseLightningBackupOut,proto3,oneof"`
+}
+
+type BitBoxBaseOut_BaseLightningRestoreOut struct {
+	BaseLightningRestoreOut *BaseLightningRestoreOut `protobuf:"bytes,15,opt,name=baseLightningRestoreOut,proto3,oneof"`
+}
+
+type BitBoxBaseOut_BaseConfigBackupOut struct {
+	BaseConfigBackupOut *BaseConfigBackupOut `protobuf:"bytes,16,opt,name=baseConfigBackupOut,proto3,oneof"`
+}
+
+type BitBoxBaseOut_BaseConfigRestoreOut struct {
+	BaseConfigRestoreOut *BaseConfigRestoreOut `protobuf:"bytes,17,opt,name=baseConfigRestoreOut,proto3,oneof"`
+}
+
+type BitBoxBaseOut_BaseFactoryResetOut struct {
+	BaseFactoryResetOut *BaseFactoryResetOut `protobuf:"bytes,18,opt,name=baseFactoryResetOut,proto3,oneof"`
+}
+
+type BitBoxBaseOut_BasePowerOut struct {
+	BasePowerOut *BasePowerOut `protobuf:"bytes,19,opt,name=basePowerOut,proto3,oneof"`
+}
+
+func (*BitBoxBaseOut_BaseMiddlewareInfoOut) isBitBoxBaseOut_BitBoxBaseOut() {}
+
+func (*BitBoxBaseOut_BaseSystemEnvOut) isBitBoxBaseOut_BitBoxBaseOut() {}
+
+func (*BitBoxBaseOut_BaseErrorOut) isBitBoxBaseOut_BitBoxBaseOut() {}
+
+func (*BitBoxBaseOut_BaseServicesOut) isBitBoxBaseOut_BitBoxBaseOut() {}
+
+func (*BitBoxBaseOut_BaseConfigOut) isBitBoxBaseOut_BitBoxBaseOut() {}
+
+func (*BitBoxBaseOut_BaseLogsOut) isBitBoxBaseOut_BitBoxBaseOut() {}
+
+func (*BitBoxBaseOut_BaseUpdateOut) isBitBoxBaseOut_BitBoxBaseOut() {}
+
+func (*BitBoxBaseOut_BaseStateOut) isBitBoxBaseOut_BitBoxBaseOut() {}
+
+func (*BitBoxBaseOut_BaseHealthOut) isBitBoxBaseOut_BitBoxBaseOut() {}
+
+func (*BitBoxBaseOut_BasePairedClientsOut) isBitBoxBaseOut_BitBoxBaseOut() {}
+
+func (*BitBoxBaseOut_BaseAuditLogOut) isBitBoxBaseOut_BitBoxBaseOut() {}
+
+func (*BitBoxBaseOut_BaseLogLevelOut) isBitBoxBaseOut_BitBoxBaseOut() {}
+
+func (*BitBoxBaseOut_BaseSupportBundleOut) isBitBoxBaseOut_BitBoxBaseOut() {}
+
+func (*BitBoxBaseOut_BaseLightningBackupOut) isBitBoxBaseOut_BitBoxBaseOut() {}
+
+func (*BitBoxBaseOut_BaseLightningRestoreOut) isBitBoxBaseOut_BitBoxBaseOut() {}
+
+func (*BitBoxBaseOut_BaseConfigBackupOut) isBitBoxBaseOut_BitBoxBaseOut() {}
+
+func (*BitBoxBaseOut_BaseConfigRestoreOut) isBitBoxBaseOut_BitBoxBaseOut() {}
+
+func (*BitBoxBaseOut_BaseFactoryResetOut) isBitBoxBaseOut_BitBoxBaseOut() {}
+
+func (*BitBoxBaseOut_BasePowerOut) isBitBoxBaseOut_BitBoxBaseOut() {}
+
+func (m *BitBoxBaseOut) GetBitBoxBaseOut() isBitBoxBaseOut_BitBoxBaseOut {
+	if m != nil {
+		return m.BitBoxBaseOut
+	}
+	return nil
+}
+
+func (m *BitBoxBaseOut) GetBaseMiddlewareInfoOut() *BaseMiddlewareInfoOut {
+	if x, ok := m.GetBitBoxBaseOut().(*BitBoxBaseOut_BaseMiddlewareInfoOut); ok {
+		return x.BaseMiddlewareInfoOut
+	}
+	return nil
+}
+
+func (m *BitBoxBaseOut) GetBaseSystemEnvOut() *BaseSystemEnvOut {
+	if x, ok := m.GetBitBoxBaseOut().(*BitBoxBaseOut_BaseSystemEnvOut); ok {
+		return x.BaseSystemEnvOut
+	}
+	return nil
+}
+
+func (m *BitBoxBaseOut) GetBaseErrorOut() *BaseErrorOut {
+	if x, ok := m.GetBitBoxBaseOut().(*BitBoxBaseOut_BaseErrorOut); ok {
+		return x.BaseErrorOut
 	}
-	return n
+	return nil
 }
 
-type BitBoxBaseOut struct {
-	// Types that are valid to be assigned to BitBoxBaseOut:
-	//	*BitBoxBaseOut_BaseMiddlewareInfoOut
-	//	*BitBoxBaseOut_BaseSystemEnvOut
-	BitBoxBaseOut        isBitBoxBaseOut_BitBoxBaseOut `protobuf_oneof:"bitBoxBaseOut"`
-	XXX_NoUnkeyedLiteral struct{}                      `json:"-"`
-	XXX_unrecognized     []byte                        `json:"-"`
-	XXX_sizecache        int32                         `json:"-"`
+func (m *BitBoxBaseOut) GetBaseServicesOut() *BaseServicesOut {
+	if x, ok := m.GetBitBoxBaseOut().(*BitBoxBaseOut_BaseServicesOut); ok {
+		return x.BaseServicesOut
+	}
+	return nil
 }
 
-func (m *BitBoxBaseOut) Reset()         { *m = BitBoxBaseOut{} }
-func (m *BitBoxBaseOut) String() string { return proto.CompactTextString(m) }
-func (*BitBoxBaseOut) ProtoMessage()    {}
-func (*BitBoxBaseOut) Descriptor() ([]byte, []int) {
-	return fileDescriptor_bbb_6b9b338852afad61, []int{4}
+func (m *BitBoxBaseOut) GetBaseConfigOut() *BaseConfigOut {
+	if x, ok := m.GetBitBoxBaseOut().(*BitBoxBaseOut_BaseConfigOut); ok {
+		return x.BaseConfigOut
+	}
+	return nil
 }
-func (m *BitBoxBaseOut) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_BitBoxBaseOut.Unmarshal(m, b)
+
+func (m *BitBoxBaseOut) GetBaseLogsOut() *BaseLogsOut {
+	if x, ok := m.GetBitBoxBaseOut().(*BitBoxBaseOut_BaseLogsOut); ok {
+		return x.BaseLogsOut
+	}
+	return nil
 }
-func (m *BitBoxBaseOut) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_BitBoxBaseOut.Marshal(b, m, deterministic)
+
+func (m *BitBoxBaseOut) GetBaseUpdateOut() *BaseUpdateOut {
+	if x, ok := m.GetBitBoxBaseOut().(*BitBoxBaseOut_BaseUpdateOut); ok {
+		return x.BaseUpdateOut
+	}
+	return nil
 }
-func (dst *BitBoxBaseOut) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_BitBoxBaseOut.Merge(dst, src)
+
+func (m *BitBoxBaseOut) GetBaseStateOut() *BaseStateOut {
+	if x, ok := m.GetBitBoxBaseOut().(*BitBoxBaseOut_BaseStateOut); ok {
+		return x.BaseStateOut
+	}
+	return nil
 }
-func (m *BitBoxBaseOut) XXX_Size() int {
-	return xxx_messageInfo_BitBoxBaseOut.Size(m)
+
+func (m *BitBoxBaseOut) GetBaseHealthOut() *BaseHealthOut {
+	if x, ok := m.GetBitBoxBaseOut().(*BitBoxBaseOut_BaseHealthOut); ok {
+		return x.BaseHealthOut
+	}
+	return nil
 }
-func (m *BitBoxBaseOut) XXX_DiscardUnknown() {
-	xxx_messageInfo_BitBoxBaseOut.DiscardUnknown(m)
+
+func (m *BitBoxBaseOut) GetBasePairedClientsOut() *BasePairedClientsOut {
+	if x, ok := m.GetBitBoxBaseOut().(*BitBoxBaseOut_BasePairedClientsOut); ok {
+		return x.BasePairedClientsOut
+	}
+	return nil
 }
 
-var xxx_messageInfo_BitBoxBaseOut proto.InternalMessageInfo
+func (m *BitBoxBaseOut) GetBaseAuditLogOut() *BaseAuditLogOut {
+	if x, ok := m.GetBitBoxBaseOut().(*BitBoxBaseOut_BaseAuditLogOut); ok {
+		return x.BaseAuditLogOut
+	}
+	return nil
+}
 
-type isBitBoxBaseOut_BitBoxBaseOut interface {
-	isBitBoxBaseOut_BitBoxBaseOut()
+func (m *BitBoxBaseOut) GetBaseLogLevelOut() *BaseLogLevelOut {
+	if x, ok := m.GetBitBoxBaseOut().(*BitBoxBaseOut_BaseLogLevelOut); ok {
+		return x.BaseLogLevelOut
+	}
+	return nil
 }
 
-type BitBoxBaseOut_BaseMiddlewareInfoOut struct {
-	BaseMiddlewareInfoOut *BaseMiddlewareInfoOut `protobuf:"bytes,1,opt,name=baseMiddlewareInfoOut,proto3,oneof"`
+func (m *BitBoxBaseOut) GetBaseSupportBundleOut() *BaseSupportBundleOut {
+	if x, ok := m.GetBitBoxBaseOut().(*BitBoxBaseOut_BaseSupportBundleOut); ok {
+		return x.BaseSupportBundleOut
+	}
+	return nil
 }
 
-type BitBoxBaseOut_BaseSystemEnvOut struct {
-	BaseSystemEnvOut *BaseSystemEnvOut `protobuf:"bytes,2,opt,name=baseSystemEnvOut,proto3,oneof"`
+func (m *BitBoxBaseOut) GetBaseLightningBackupOut() *BaseLightningBackupOut {
+	if x, ok := m.GetBitBoxBaseOut().(*BitBoxBaseOut_BaseLightningBackupOut); ok {
+		return x.BaseLightningBackupOut
+	}
+	return nil
 }
 
-func (*BitBoxBaseOut_BaseMiddlewareInfoOut) isBitBoxBaseOut_BitBoxBaseOut() {}
+func (m *BitBoxBaseOut) GetBaseLightningRestoreOut() *BaseLightningRestoreOut {
+	if x, ok := m.GetBitBoxBaseOut().(*BitBoxBaseOut_BaseLightningRestoreOut); ok {
+		return x.BaseLightningRestoreOut
+	}
+	return nil
+}
 
-func (*BitBoxBaseOut_BaseSystemEnvOut) isBitBoxBaseOut_BitBoxBaseOut() {}
+func (m *BitBoxBaseOut) GetBaseConfigBackupOut() *BaseConfigBackupOut {
+	if x, ok := m.GetBitBoxBaseOut().(*BitBoxBaseOut_BaseConfigBackupOut); ok {
+		return x.BaseConfigBackupOut
+	}
+	return nil
+}
 
-func (m *BitBoxBaseOut) GetBitBoxBaseOut() isBitBoxBaseOut_BitBoxBaseOut {
-	if m != nil {
-		return m.BitBoxBaseOut
+func (m *BitBoxBaseOut) GetBaseConfigRestoreOut() *BaseConfigRestoreOut {
+	if x, ok := m.GetBitBoxBaseOut().(*BitBoxBaseOut_BaseConfigRestoreOut); ok {
+		return x.BaseConfigRestoreOut
 	}
 	return nil
 }
 
-func (m *BitBoxBaseOut) GetBaseMiddlewareInfoOut() *BaseMiddlewareInfoOut {
-	if x, ok := m.GetBitBoxBaseOut().(*BitBoxBaseOut_BaseMiddlewareInfoOut); ok {
-		return x.BaseMiddlewareInfoOut
+func (m *BitBoxBaseOut) GetBaseFactoryResetOut() *BaseFactoryResetOut {
+	if x, ok := m.GetBitBoxBaseOut().(*BitBoxBaseOut_BaseFactoryResetOut); ok {
+		return x.BaseFactoryResetOut
 	}
 	return nil
 }
 
-func (m *BitBoxBaseOut) GetBaseSystemEnvOut() *BaseSystemEnvOut {
-	if x, ok := m.GetBitBoxBaseOut().(*BitBoxBaseOut_BaseSystemEnvOut); ok {
-		return x.BaseSystemEnvOut
+func (m *BitBoxBaseOut) GetBasePowerOut() *BasePowerOut {
+	if x, ok := m.GetBitBoxBaseOut().(*BitBoxBaseOut_BasePowerOut); ok {
+		return x.BasePowerOut
 	}
 	return nil
 }
@@ -336,6 +3542,23 @@ func (*BitBoxBaseOut) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer)
 	return _BitBoxBaseOut_OneofMarshaler, _BitBoxBaseOut_OneofUnmarshaler, _BitBoxBaseOut_OneofSizer, []interface{}{
 		(*BitBoxBaseOut_BaseMiddlewareInfoOut)(nil),
 		(*BitBoxBaseOut_BaseSystemEnvOut)(nil),
+		(*BitBoxBaseOut_BaseErrorOut)(nil),
+		(*BitBoxBaseOut_BaseServicesOut)(nil),
+		(*BitBoxBaseOut_BaseConfigOut)(nil),
+		(*BitBoxBaseOut_BaseLogsOut)(nil),
+		(*BitBoxBaseOut_BaseUpdateOut)(nil),
+		(*BitBoxBaseOut_BaseStateOut)(nil),
+		(*BitBoxBaseOut_BaseHealthOut)(nil),
+		(*BitBoxBaseOut_BasePairedClientsOut)(nil),
+		(*BitBoxBaseOut_BaseAuditLogOut)(nil),
+		(*BitBoxBaseOut_BaseLogLevelOut)(nil),
+		(*BitBoxBaseOut_BaseSupportBundleOut)(nil),
+		(*BitBoxBaseOut_BaseLightningBackupOut)(nil),
+		(*BitBoxBaseOut_BaseLightningRestoreOut)(nil),
+		(*BitBoxBaseOut_BaseConfigBackupOut)(nil),
+		(*BitBoxBaseOut_BaseConfigRestoreOut)(nil),
+		(*BitBoxBaseOut_BaseFactoryResetOut)(nil),
+		(*BitBoxBaseOut_BasePowerOut)(nil),
 	}
 }
 
@@ -353,6 +3576,91 @@ func _BitBoxBaseOut_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
 		if err := b.EncodeMessage(x.BaseSystemEnvOut); err != nil {
 			return err
 		}
+	case *BitBoxBaseOut_BaseErrorOut:
+		b.EncodeVarint(3<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseErrorOut); err != nil {
+			return err
+		}
+	case *BitBoxBaseOut_BaseServicesOut:
+		b.EncodeVarint(4<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseServicesOut); err != nil {
+			return err
+		}
+	case *BitBoxBaseOut_BaseConfigOut:
+		b.EncodeVarint(5<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseConfigOut); err != nil {
+			return err
+		}
+	case *BitBoxBaseOut_BaseLogsOut:
+		b.EncodeVarint(6<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseLogsOut); err != nil {
+			return err
+		}
+	case *BitBoxBaseOut_BaseUpdateOut:
+		b.EncodeVarint(7<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseUpdateOut); err != nil {
+			return err
+		}
+	case *BitBoxBaseOut_BaseStateOut:
+		b.EncodeVarint(8<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseStateOut); err != nil {
+			return err
+		}
+	case *BitBoxBaseOut_BaseHealthOut:
+		b.EncodeVarint(9<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseHealthOut); err != nil {
+			return err
+		}
+	case *BitBoxBaseOut_BasePairedClientsOut:
+		b.EncodeVarint(10<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BasePairedClientsOut); err != nil {
+			return err
+		}
+	case *BitBoxBaseOut_BaseAuditLogOut:
+		b.EncodeVarint(11<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseAuditLogOut); err != nil {
+			return err
+		}
+	case *BitBoxBaseOut_BaseLogLevelOut:
+		b.EncodeVarint(12<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseLogLevelOut); err != nil {
+			return err
+		}
+	case *BitBoxBaseOut_BaseSupportBundleOut:
+		b.EncodeVarint(13<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseSupportBundleOut); err != nil {
+			return err
+		}
+	case *BitBoxBaseOut_BaseLightningBackupOut:
+		b.EncodeVarint(14<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseLightningBackupOut); err != nil {
+			return err
+		}
+	case *BitBoxBaseOut_BaseLightningRestoreOut:
+		b.EncodeVarint(15<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseLightningRestoreOut); err != nil {
+			return err
+		}
+	case *BitBoxBaseOut_BaseConfigBackupOut:
+		b.EncodeVarint(16<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseConfigBackupOut); err != nil {
+			return err
+		}
+	case *BitBoxBaseOut_BaseConfigRestoreOut:
+		b.EncodeVarint(17<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseConfigRestoreOut); err != nil {
+			return err
+		}
+	case *BitBoxBaseOut_BaseFactoryResetOut:
+		b.EncodeVarint(18<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BaseFactoryResetOut); err != nil {
+			return err
+		}
+	case *BitBoxBaseOut_BasePowerOut:
+		b.EncodeVarint(19<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.BasePowerOut); err != nil {
+			return err
+		}
 	case nil:
 	default:
 		return fmt.Errorf("BitBoxBaseOut.BitBoxBaseOut has unexpected type %T", x)
@@ -379,6 +3687,142 @@ func _BitBoxBaseOut_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.
 		err := b.DecodeMessage(msg)
 		m.BitBoxBaseOut = &BitBoxBaseOut_BaseSystemEnvOut{msg}
 		return true, err
+	case 3: // bitBoxBaseOut.baseErrorOut
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseErrorOut)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseOut = &BitBoxBaseOut_BaseErrorOut{msg}
+		return true, err
+	case 4: // bitBoxBaseOut.baseServicesOut
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseServicesOut)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseOut = &BitBoxBaseOut_BaseServicesOut{msg}
+		return true, err
+	case 5: // bitBoxBaseOut.baseConfigOut
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseConfigOut)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseOut = &BitBoxBaseOut_BaseConfigOut{msg}
+		return true, err
+	case 6: // bitBoxBaseOut.baseLogsOut
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseLogsOut)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseOut = &BitBoxBaseOut_BaseLogsOut{msg}
+		return true, err
+	case 7: // bitBoxBaseOut.baseUpdateOut
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseUpdateOut)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseOut = &BitBoxBaseOut_BaseUpdateOut{msg}
+		return true, err
+	case 8: // bitBoxBaseOut.baseStateOut
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseStateOut)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseOut = &BitBoxBaseOut_BaseStateOut{msg}
+		return true, err
+	case 9: // bitBoxBaseOut.baseHealthOut
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseHealthOut)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseOut = &BitBoxBaseOut_BaseHealthOut{msg}
+		return true, err
+	case 10: // bitBoxBaseOut.basePairedClientsOut
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BasePairedClientsOut)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseOut = &BitBoxBaseOut_BasePairedClientsOut{msg}
+		return true, err
+	case 11: // bitBoxBaseOut.baseAuditLogOut
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseAuditLogOut)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseOut = &BitBoxBaseOut_BaseAuditLogOut{msg}
+		return true, err
+	case 12: // bitBoxBaseOut.baseLogLevelOut
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseLogLevelOut)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseOut = &BitBoxBaseOut_BaseLogLevelOut{msg}
+		return true, err
+	case 13: // bitBoxBaseOut.baseSupportBundleOut
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseSupportBundleOut)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseOut = &BitBoxBaseOut_BaseSupportBundleOut{msg}
+		return true, err
+	case 14: // bitBoxBaseOut.baseLightningBackupOut
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseLightningBackupOut)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseOut = &BitBoxBaseOut_BaseLightningBackupOut{msg}
+		return true, err
+	case 15: // bitBoxBaseOut.baseLightningRestoreOut
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseLightningRestoreOut)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseOut = &BitBoxBaseOut_BaseLightningRestoreOut{msg}
+		return true, err
+	case 16: // bitBoxBaseOut.baseConfigBackupOut
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseConfigBackupOut)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseOut = &BitBoxBaseOut_BaseConfigBackupOut{msg}
+		return true, err
+	case 17: // bitBoxBaseOut.baseConfigRestoreOut
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseConfigRestoreOut)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseOut = &BitBoxBaseOut_BaseConfigRestoreOut{msg}
+		return true, err
+	case 18: // bitBoxBaseOut.baseFactoryResetOut
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BaseFactoryResetOut)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseOut = &BitBoxBaseOut_BaseFactoryResetOut{msg}
+		return true, err
+	case 19: // bitBoxBaseOut.basePowerOut
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(BasePowerOut)
+		err := b.DecodeMessage(msg)
+		m.BitBoxBaseOut = &BitBoxBaseOut_BasePowerOut{msg}
+		return true, err
 	default:
 		return false, nil
 	}
@@ -398,6 +3842,91 @@ func _BitBoxBaseOut_OneofSizer(msg proto.Message) (n int) {
 		n += 1 // tag and wire
 		n += proto.SizeVarint(uint64(s))
 		n += s
+	case *BitBoxBaseOut_BaseErrorOut:
+		s := proto.Size(x.BaseErrorOut)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseOut_BaseServicesOut:
+		s := proto.Size(x.BaseServicesOut)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseOut_BaseConfigOut:
+		s := proto.Size(x.BaseConfigOut)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseOut_BaseLogsOut:
+		s := proto.Size(x.BaseLogsOut)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseOut_BaseUpdateOut:
+		s := proto.Size(x.BaseUpdateOut)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseOut_BaseStateOut:
+		s := proto.Size(x.BaseStateOut)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseOut_BaseHealthOut:
+		s := proto.Size(x.BaseHealthOut)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseOut_BasePairedClientsOut:
+		s := proto.Size(x.BasePairedClientsOut)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseOut_BaseAuditLogOut:
+		s := proto.Size(x.BaseAuditLogOut)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseOut_BaseLogLevelOut:
+		s := proto.Size(x.BaseLogLevelOut)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseOut_BaseSupportBundleOut:
+		s := proto.Size(x.BaseSupportBundleOut)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseOut_BaseLightningBackupOut:
+		s := proto.Size(x.BaseLightningBackupOut)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseOut_BaseLightningRestoreOut:
+		s := proto.Size(x.BaseLightningRestoreOut)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseOut_BaseConfigBackupOut:
+		s := proto.Size(x.BaseConfigBackupOut)
+		n += 2 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseOut_BaseConfigRestoreOut:
+		s := proto.Size(x.BaseConfigRestoreOut)
+		n += 2 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseOut_BaseFactoryResetOut:
+		s := proto.Size(x.BaseFactoryResetOut)
+		n += 2 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *BitBoxBaseOut_BasePowerOut:
+		s := proto.Size(x.BasePowerOut)
+		n += 2 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
 	case nil:
 	default:
 		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
@@ -407,33 +3936,206 @@ func _BitBoxBaseOut_OneofSizer(msg proto.Message) (n int) {
 
 func init() {
 	proto.RegisterType((*BaseMiddlewareInfoOut)(nil), "BaseMiddlewareInfoOut")
+	proto.RegisterType((*BaseBitcoindState)(nil), "BaseBitcoindState")
+	proto.RegisterType((*BaseLightningState)(nil), "BaseLightningState")
+	proto.RegisterType((*BaseElectrsState)(nil), "BaseElectrsState")
+	proto.RegisterType((*BaseSystemState)(nil), "BaseSystemState")
+	proto.RegisterType((*BaseState)(nil), "BaseState")
+	proto.RegisterType((*BaseStateOut)(nil), "BaseStateOut")
+	proto.RegisterType((*BaseStateResyncIn)(nil), "BaseStateResyncIn")
 	proto.RegisterType((*BaseSystemEnvOut)(nil), "BaseSystemEnvOut")
 	proto.RegisterType((*BaseSystemEnvIn)(nil), "BaseSystemEnvIn")
+	proto.RegisterType((*BaseErrorOut)(nil), "BaseErrorOut")
+	proto.RegisterType((*BaseServicesIn)(nil), "BaseServicesIn")
+	proto.RegisterType((*BaseServiceStatus)(nil), "BaseServiceStatus")
+	proto.RegisterType((*BaseServicesOut)(nil), "BaseServicesOut")
+	proto.RegisterType((*BaseConfigGetIn)(nil), "BaseConfigGetIn")
+	proto.RegisterType((*BaseConfigSetIn)(nil), "BaseConfigSetIn")
+	proto.RegisterType((*BaseConfigOut)(nil), "BaseConfigOut")
+	proto.RegisterType((*BaseLogsIn)(nil), "BaseLogsIn")
+	proto.RegisterType((*BaseLogEntry)(nil), "BaseLogEntry")
+	proto.RegisterType((*BaseLogsOut)(nil), "BaseLogsOut")
+	proto.RegisterType((*BaseUpdateIn)(nil), "BaseUpdateIn")
+	proto.RegisterType((*BaseUpdateOut)(nil), "BaseUpdateOut")
+	proto.RegisterType((*BaseHealthIn)(nil), "BaseHealthIn")
+	proto.RegisterType((*BaseBackendHealth)(nil), "BaseBackendHealth")
+	proto.RegisterType((*BaseHealthOut)(nil), "BaseHealthOut")
+	proto.RegisterType((*BasePairedClientsIn)(nil), "BasePairedClientsIn")
+	proto.RegisterType((*BasePairedClient)(nil), "BasePairedClient")
+	proto.RegisterType((*BasePairedClientsOut)(nil), "BasePairedClientsOut")
+	proto.RegisterType((*BaseSetClientRoleIn)(nil), "BaseSetClientRoleIn")
+	proto.RegisterType((*BaseAuditLogIn)(nil), "BaseAuditLogIn")
+	proto.RegisterType((*BaseAuditEntry)(nil), "BaseAuditEntry")
+	proto.RegisterType((*BaseAuditLogOut)(nil), "BaseAuditLogOut")
+	proto.RegisterType((*BaseLogLevelIn)(nil), "BaseLogLevelIn")
+	proto.RegisterType((*BaseLogLevelOut)(nil), "BaseLogLevelOut")
+	proto.RegisterType((*BaseSupportBundleIn)(nil), "BaseSupportBundleIn")
+	proto.RegisterType((*BaseSupportBundleOut)(nil), "BaseSupportBundleOut")
+	proto.RegisterType((*BaseLightningBackupIn)(nil), "BaseLightningBackupIn")
+	proto.RegisterType((*BaseLightningBackupOut)(nil), "BaseLightningBackupOut")
+	proto.RegisterType((*BaseLightningRestoreIn)(nil), "BaseLightningRestoreIn")
+	proto.RegisterType((*BaseLightningRestoreOut)(nil), "BaseLightningRestoreOut")
+	proto.RegisterType((*BaseConfigBackupIn)(nil), "BaseConfigBackupIn")
+	proto.RegisterType((*BaseConfigBackupOut)(nil), "BaseConfigBackupOut")
+	proto.RegisterType((*BaseConfigRestoreIn)(nil), "BaseConfigRestoreIn")
+	proto.RegisterType((*BaseConfigRestoreOut)(nil), "BaseConfigRestoreOut")
+	proto.RegisterType((*BaseFactoryResetIn)(nil), "BaseFactoryResetIn")
+	proto.RegisterType((*BaseFactoryResetOut)(nil), "BaseFactoryResetOut")
+	proto.RegisterType((*BasePowerIn)(nil), "BasePowerIn")
+	proto.RegisterType((*BasePowerOut)(nil), "BasePowerOut")
 	proto.RegisterType((*BitBoxBaseIn)(nil), "BitBoxBaseIn")
 	proto.RegisterType((*BitBoxBaseOut)(nil), "BitBoxBaseOut")
 }
 
-func init() { proto.RegisterFile("messages/bbb.proto", fileDescriptor_bbb_6b9b338852afad61) }
-
-var fileDescriptor_bbb_6b9b338852afad61 = []byte{
-	// 304 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x91, 0xc1, 0x4f, 0xc2, 0x30,
-	0x14, 0xc6, 0x19, 0x24, 0x10, 0x1e, 0x30, 0xa0, 0x09, 0x64, 0x27, 0x43, 0x76, 0x30, 0x9c, 0x66,
-	0x82, 0x57, 0x13, 0x63, 0x95, 0x04, 0x12, 0x45, 0x52, 0x3d, 0x7a, 0x59, 0xb7, 0x6e, 0x36, 0x94,
-	0xd6, 0xac, 0xc5, 0xc9, 0x9f, 0xe5, 0x7f, 0x68, 0x56, 0x8d, 0xb2, 0xb9, 0xe3, 0xfb, 0xf6, 0xbd,
-	0xef, 0xb7, 0xf7, 0x15, 0xd0, 0x9e, 0x69, 0x1d, 0xa6, 0x4c, 0x5f, 0x50, 0x4a, 0x83, 0xb7, 0x4c,
-	0x19, 0xe5, 0xe7, 0x30, 0xc1, 0xa1, 0x66, 0x0f, 0x3c, 0x8e, 0x05, 0xcb, 0xc3, 0x8c, 0xad, 0x65,
-	0xa2, 0x1e, 0x0f, 0x06, 0x4d, 0xa1, 0x8d, 0x85, 0x8a, 0x76, 0xda, 0x73, 0x66, 0xce, 0xbc, 0x45,
-	0xda, 0xd4, 0x4e, 0xe8, 0x0c, 0xe0, 0x8e, 0x27, 0x09, 0x8f, 0x0e, 0xc2, 0x1c, 0xbd, 0xe6, 0xcc,
-	0x99, 0x37, 0x09, 0xc4, 0xbf, 0x0a, 0x3a, 0x07, 0xf7, 0x9e, 0xa7, 0xaf, 0x46, 0x72, 0x99, 0xde,
-	0x08, 0x1e, 0x6a, 0xaf, 0x35, 0x73, 0xe6, 0x5d, 0xe2, 0x8a, 0x92, 0xea, 0x3f, 0xc3, 0xa8, 0x00,
-	0x3f, 0x1d, 0xb5, 0x61, 0xfb, 0xa5, 0x7c, 0x2f, 0x98, 0x1e, 0x74, 0x36, 0xcc, 0xe4, 0x2a, 0xdb,
-	0x59, 0x68, 0x97, 0x74, 0xe4, 0xf7, 0x58, 0xa4, 0x2e, 0x05, 0x8b, 0x4c, 0xa6, 0xc9, 0xf6, 0x76,
-	0xab, 0x32, 0x63, 0xc9, 0x5d, 0xe2, 0xb2, 0x92, 0xea, 0x8f, 0x61, 0x58, 0x4a, 0x5d, 0x4b, 0xff,
-	0x05, 0xfa, 0x98, 0x1b, 0xac, 0x3e, 0x8a, 0x0f, 0x6b, 0x89, 0xae, 0x60, 0x48, 0xcb, 0x16, 0x0b,
-	0xeb, 0x2d, 0x46, 0x41, 0x65, 0x75, 0xd5, 0x20, 0x55, 0x2b, 0x76, 0xa1, 0x4f, 0x4f, 0xd2, 0xfc,
-	0x4f, 0x07, 0x06, 0x7f, 0xf1, 0xc5, 0x11, 0x1b, 0x98, 0xd0, 0xba, 0x46, 0x7f, 0x28, 0xd3, 0xa0,
-	0xb6, 0xef, 0x55, 0x83, 0xd4, 0xaf, 0xa1, 0x6b, 0x18, 0xd1, 0x4a, 0x51, 0xf6, 0xf8, 0xde, 0x62,
-	0x1c, 0x54, 0x1b, 0x5c, 0x35, 0xc8, 0x3f, 0x33, 0x1e, 0xc2, 0x80, 0x9e, 0xfe, 0x21, 0x6d, 0xdb,
-	0xa7, 0xbf, 0xfc, 0x0a, 0x00, 0x00, 0xff, 0xff, 0x14, 0x95, 0x6c, 0x90, 0x10, 0x02, 0x00, 0x00,
+func init() { proto.RegisterFile("messages/bbb.proto", fileDescriptor_bbb_ea182728b319f25a) }
+
+var fileDescriptor_bbb_ea182728b319f25a = []byte{
+	// 2341 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc4, 0x59, 0x5b, 0x6f, 0xdc, 0xc6,
+	0xf5, 0x5f, 0x6a, 0xef, 0x67, 0x6f, 0x12, 0x25, 0xdb, 0x44, 0xf0, 0xc7, 0x1f, 0x02, 0x13, 0x24,
+	0x6a, 0x83, 0xb0, 0xa9, 0x82, 0xa6, 0x48, 0x51, 0xb4, 0x90, 0x64, 0xb9, 0xbb, 0x88, 0x2c, 0x2b,
+	0xb3, 0x72, 0xfc, 0x56, 0x94, 0x97, 0x59, 0x2d, 0x21, 0xee, 0x70, 0xcb, 0x99, 0x95, 0x2c, 0xbf,
+	0xf4, 0xb9, 0x45, 0x81, 0x7e, 0x83, 0xb6, 0x2f, 0xed, 0x87, 0xe8, 0x7b, 0xbf, 0x57, 0x31, 0x37,
+	0x72, 0xc8, 0xa5, 0x8d, 0x18, 0x35, 0xd0, 0x27, 0xe9, 0xfc, 0xe6, 0x72, 0xae, 0xf3, 0x9b, 0x33,
+	0x5c, 0xb0, 0x57, 0x98, 0x52, 0xff, 0x06, 0xd3, 0x9f, 0x04, 0x41, 0xe0, 0xad, 0xb3, 0x94, 0xa5,
+	0xee, 0x3d, 0x3c, 0x3a, 0xf5, 0x29, 0x7e, 0x1e, 0x47, 0x51, 0x82, 0xef, 0xfd, 0x0c, 0xcf, 0xc8,
+	0x22, 0x7d, 0xb1, 0x61, 0xf6, 0x63, 0xe8, 0x9c, 0x26, 0x69, 0x78, 0x4b, 0x1d, 0xeb, 0xd0, 0x3a,
+	0x6a, 0xa2, 0x4e, 0x20, 0x24, 0xfb, 0xff, 0x01, 0x9e, 0xc6, 0x8b, 0x45, 0x1c, 0x6e, 0x12, 0xf6,
+	0xe0, 0xec, 0x1c, 0x5a, 0x47, 0x3b, 0x08, 0xa2, 0x1c, 0xb1, 0x3f, 0x85, 0xf1, 0x45, 0x7c, 0xb3,
+	0x64, 0x24, 0x26, 0x37, 0x27, 0x49, 0xec, 0x53, 0xa7, 0x79, 0x68, 0x1d, 0xf5, 0xd1, 0x38, 0x29,
+	0xa1, 0xee, 0xbf, 0x2d, 0xd8, 0xe3, 0x9a, 0x4f, 0x63, 0x16, 0xa6, 0x31, 0x89, 0xe6, 0xcc, 0x67,
+	0xf8, 0x3d, 0xb4, 0x5a, 0x25, 0xad, 0x9f, 0xc0, 0xe8, 0x14, 0x53, 0x26, 0xd6, 0x4e, 0x7d, 0xba,
+	0x54, 0x4a, 0x47, 0x81, 0x09, 0xda, 0x5f, 0xc2, 0xfe, 0x73, 0xbc, 0x5a, 0xa7, 0x69, 0x72, 0x9d,
+	0xf9, 0x84, 0xfa, 0x21, 0x8b, 0x53, 0x42, 0x9d, 0x96, 0x50, 0xb5, 0xbf, 0xda, 0x1e, 0xb2, 0x0f,
+	0x61, 0xf0, 0x92, 0x64, 0xd8, 0x0f, 0x97, 0x7e, 0x90, 0x60, 0xa7, 0x7d, 0x68, 0x1d, 0xf5, 0xd0,
+	0x60, 0x53, 0x40, 0xee, 0x33, 0xb0, 0xb9, 0x1b, 0xb9, 0xcf, 0xd2, 0x8f, 0x03, 0x68, 0x4b, 0xe7,
+	0x2d, 0x61, 0x47, 0xdb, 0xe7, 0x82, 0xfd, 0x11, 0xf4, 0xce, 0x96, 0x3e, 0x21, 0x38, 0xa1, 0xc2,
+	0x87, 0x26, 0xea, 0x85, 0x4a, 0x76, 0x7f, 0x0c, 0xbb, 0x7c, 0x9f, 0xf3, 0x04, 0x87, 0x2c, 0xa3,
+	0xef, 0x8c, 0x86, 0x3b, 0x87, 0x09, 0x9f, 0x3b, 0x7f, 0xa0, 0x0c, 0xaf, 0xe4, 0x54, 0x07, 0xba,
+	0x97, 0x98, 0xdd, 0xa7, 0xd9, 0xad, 0x52, 0xd9, 0x25, 0x52, 0xe4, 0x09, 0x51, 0x9b, 0xa2, 0xab,
+	0xb3, 0xab, 0x34, 0x63, 0x42, 0x75, 0x1f, 0x8d, 0x71, 0x09, 0xe5, 0x09, 0xe9, 0x8b, 0x5d, 0xc5,
+	0x7e, 0x1e, 0xf4, 0x74, 0x66, 0xc4, 0x86, 0x83, 0x63, 0xdb, 0xdb, 0x4a, 0x17, 0xea, 0x05, 0x4a,
+	0xb4, 0x7f, 0x0a, 0xfd, 0x3c, 0x04, 0x42, 0xc1, 0xe0, 0x78, 0xdf, 0xdb, 0x0e, 0x0c, 0xea, 0xe7,
+	0x65, 0x60, 0x7f, 0x0e, 0x5d, 0x65, 0x98, 0xc8, 0xd6, 0xe0, 0x78, 0xcf, 0xab, 0x46, 0x00, 0x75,
+	0x95, 0x91, 0xf6, 0x11, 0x74, 0xa4, 0xbb, 0x22, 0x5b, 0x83, 0xe3, 0x5d, 0xaf, 0x12, 0x01, 0xd4,
+	0xa1, 0x42, 0x70, 0xff, 0x6a, 0xc1, 0x30, 0xf7, 0x83, 0x57, 0xf2, 0x47, 0xd0, 0x9b, 0x13, 0x7f,
+	0x4d, 0x97, 0x29, 0x13, 0xae, 0xf4, 0x50, 0x8f, 0x2a, 0x99, 0x87, 0xed, 0x7b, 0x9c, 0xd1, 0x38,
+	0x25, 0xc2, 0xe8, 0x16, 0xea, 0xde, 0x49, 0x91, 0x67, 0x9e, 0xef, 0xa2, 0x47, 0x9b, 0x62, 0x74,
+	0x10, 0x14, 0x10, 0xcf, 0xf1, 0x95, 0xcf, 0x96, 0xbc, 0x7e, 0x9a, 0x3c, 0xc7, 0x6b, 0x2e, 0xd8,
+	0x87, 0xd0, 0x16, 0x9a, 0x45, 0xad, 0x0c, 0x8e, 0xc1, 0xcb, 0x6d, 0x41, 0x6d, 0xca, 0xff, 0xb8,
+	0x5f, 0xc0, 0x5e, 0x81, 0x61, 0xfa, 0x40, 0xc2, 0x19, 0x31, 0x0d, 0xb1, 0x4a, 0x86, 0xb8, 0xd7,
+	0xb0, 0x5b, 0xb8, 0x7a, 0x4e, 0xee, 0xb8, 0x4b, 0xff, 0x7d, 0xb6, 0xf7, 0xcc, 0x12, 0x3a, 0x27,
+	0x77, 0x33, 0xe2, 0x5e, 0xc8, 0xb8, 0x9d, 0x67, 0x59, 0x9a, 0x71, 0x25, 0x2e, 0x0c, 0x11, 0xfe,
+	0xfd, 0x06, 0x53, 0xf6, 0x2c, 0xc6, 0x89, 0x2c, 0x83, 0x36, 0x1a, 0x66, 0x06, 0xc6, 0x0d, 0x79,
+	0x2e, 0x49, 0x45, 0xe9, 0xe9, 0x2a, 0x8e, 0x71, 0x77, 0x61, 0x2c, 0x14, 0xe0, 0xec, 0x2e, 0x0e,
+	0x31, 0x9d, 0x11, 0x77, 0x06, 0x7b, 0x06, 0xc2, 0xdd, 0xdf, 0x50, 0xdb, 0x86, 0xd6, 0xa5, 0xbf,
+	0xc2, 0xca, 0x8d, 0x16, 0xf1, 0x57, 0x98, 0x87, 0xfe, 0x24, 0x64, 0xf1, 0x9d, 0x0c, 0x91, 0xda,
+	0x78, 0xe0, 0x17, 0x90, 0x7b, 0x02, 0x13, 0x63, 0x2b, 0xca, 0xad, 0xf5, 0xa0, 0xa7, 0x45, 0xc7,
+	0x3a, 0x6c, 0xe6, 0x05, 0x5b, 0x52, 0x87, 0x7a, 0x54, 0xcd, 0x71, 0x3f, 0x96, 0x5b, 0x9c, 0xa5,
+	0x64, 0x11, 0xdf, 0xfc, 0x06, 0xb3, 0x19, 0xb1, 0x77, 0xa1, 0xf9, 0x2d, 0x7e, 0x50, 0xa6, 0x34,
+	0x6f, 0xf1, 0x83, 0xfb, 0x8d, 0x39, 0x69, 0x5e, 0x3f, 0x89, 0xd7, 0xc1, 0xf7, 0x7e, 0xb2, 0xd1,
+	0x86, 0xb6, 0xef, 0xb8, 0xe0, 0xfe, 0x1c, 0x46, 0xc5, 0x52, 0x6e, 0xe0, 0x0f, 0x5d, 0xf8, 0x77,
+	0x0b, 0x40, 0x1c, 0x9c, 0xf4, 0x86, 0xce, 0x08, 0x0f, 0xd0, 0x4b, 0x12, 0x33, 0x1d, 0xa0, 0x0d,
+	0x89, 0x19, 0x5f, 0x78, 0x11, 0x13, 0x2c, 0x49, 0xa4, 0x8d, 0xda, 0x09, 0x17, 0x38, 0x5b, 0x3c,
+	0x4b, 0x93, 0x24, 0xbd, 0x17, 0xc5, 0xda, 0x43, 0x9d, 0x85, 0x90, 0x78, 0xfd, 0x5f, 0x65, 0x71,
+	0x9a, 0xc5, 0xec, 0x41, 0x1c, 0x9e, 0x3e, 0xea, 0xad, 0x95, 0xcc, 0x77, 0x9a, 0xc7, 0x24, 0x94,
+	0xd5, 0xda, 0x44, 0x6d, 0xca, 0x05, 0xce, 0xb6, 0x73, 0x96, 0x6d, 0x42, 0xb6, 0xc9, 0x70, 0xe4,
+	0x74, 0xc4, 0x6e, 0x40, 0x73, 0xc4, 0x4d, 0x64, 0xa5, 0x5c, 0xa4, 0x37, 0xe7, 0x84, 0x65, 0x0f,
+	0xdc, 0xc6, 0xeb, 0x58, 0x25, 0xb1, 0x89, 0x5a, 0x2c, 0x5e, 0xe1, 0xdc, 0xee, 0x1d, 0xc3, 0x6e,
+	0xd3, 0x92, 0xa6, 0x30, 0xbd, 0xb0, 0xc4, 0xa8, 0xa4, 0x56, 0xb9, 0x92, 0x08, 0x0c, 0x74, 0x3c,
+	0x5e, 0x6c, 0x0c, 0xe7, 0x2d, 0x79, 0xec, 0xa4, 0xf3, 0x87, 0x30, 0x38, 0x27, 0xd1, 0x8b, 0xc5,
+	0x9c, 0x65, 0xd8, 0x5f, 0x09, 0xad, 0x3d, 0x34, 0xc0, 0x05, 0x64, 0x7f, 0x06, 0x5d, 0x6e, 0x6d,
+	0x8c, 0x39, 0xdd, 0xf0, 0xfa, 0x18, 0x79, 0xa6, 0x13, 0xa8, 0x8b, 0xe5, 0xa8, 0xfb, 0x0b, 0xe9,
+	0xdd, 0xcb, 0x75, 0xe4, 0x33, 0x2c, 0x33, 0x30, 0x8f, 0xdf, 0xe4, 0xde, 0xd1, 0xf8, 0x8d, 0x60,
+	0xe6, 0xf9, 0xd2, 0x3f, 0xfe, 0xd9, 0xd7, 0x42, 0xd3, 0x10, 0x75, 0xa8, 0x90, 0xdc, 0x8f, 0x61,
+	0x54, 0xac, 0xe5, 0xd6, 0xda, 0xd0, 0xe2, 0x24, 0xa1, 0xd3, 0xc7, 0x39, 0xc2, 0x1d, 0x4b, 0x05,
+	0x53, 0xec, 0x27, 0x6c, 0x39, 0x23, 0xee, 0x3f, 0xf4, 0x55, 0xe8, 0x87, 0xb7, 0x98, 0x44, 0x12,
+	0xaf, 0x3d, 0x19, 0x5c, 0xad, 0x28, 0x64, 0x15, 0xd6, 0x0e, 0x15, 0x12, 0xf7, 0xfe, 0xc2, 0xa7,
+	0x6c, 0xbe, 0x09, 0x43, 0x4c, 0x25, 0x9d, 0x36, 0xd1, 0x20, 0x29, 0x20, 0xfb, 0xff, 0xa0, 0xcf,
+	0x67, 0x88, 0xc3, 0xad, 0x02, 0xdc, 0x4f, 0x34, 0xc0, 0xaf, 0xcf, 0x7c, 0x54, 0x64, 0x52, 0x96,
+	0xc3, 0x28, 0x31, 0x41, 0xf7, 0x95, 0x74, 0x4e, 0xda, 0xa7, 0x7a, 0x04, 0x65, 0x8e, 0x55, 0x32,
+	0x87, 0x5f, 0x1e, 0xd2, 0x17, 0x6e, 0x68, 0x71, 0x16, 0x4b, 0x0e, 0xa2, 0x5e, 0xa0, 0xe6, 0xb8,
+	0x8f, 0x60, 0x9f, 0x0f, 0x5f, 0xf9, 0x71, 0x86, 0xa3, 0xb3, 0x24, 0xc6, 0x84, 0x71, 0xc2, 0x40,
+	0xb0, 0x5b, 0x85, 0xb9, 0xca, 0xab, 0x4d, 0x70, 0xab, 0x0e, 0xd2, 0x10, 0x75, 0xd6, 0x42, 0xe2,
+	0xd1, 0x42, 0x69, 0xa2, 0x8f, 0x52, 0x2b, 0x4b, 0x13, 0x51, 0x82, 0x73, 0x9c, 0x2c, 0xd4, 0x71,
+	0x68, 0x51, 0x9c, 0x2c, 0xdc, 0x33, 0x38, 0xd8, 0x52, 0xc5, 0x5d, 0xf9, 0x1c, 0xba, 0x4a, 0x52,
+	0xec, 0xb1, 0xe7, 0x55, 0xe7, 0xa1, 0x6e, 0x28, 0x67, 0xb8, 0x27, 0xd2, 0xde, 0x39, 0x66, 0x6a,
+	0x24, 0x4d, 0x78, 0xa1, 0xbc, 0x87, 0x6d, 0xee, 0xaf, 0x24, 0x3d, 0x9e, 0x6c, 0xa2, 0x98, 0x5d,
+	0xa4, 0x37, 0x72, 0xf5, 0x8b, 0xc5, 0x82, 0x62, 0xa6, 0x2e, 0x80, 0x4e, 0x2a, 0x24, 0x59, 0xef,
+	0x2b, 0x75, 0x92, 0x46, 0xbc, 0xde, 0x57, 0x31, 0x73, 0xff, 0x65, 0x19, 0x1b, 0xc8, 0x53, 0xc8,
+	0xef, 0x39, 0xce, 0xcd, 0xfc, 0x38, 0xcb, 0x2d, 0x7a, 0x54, 0xc9, 0xf9, 0x09, 0xdd, 0x31, 0x4e,
+	0xe8, 0x63, 0xe8, 0x48, 0xf3, 0x55, 0xb3, 0xd4, 0x09, 0xf3, 0x10, 0x9f, 0x88, 0xf6, 0x47, 0xd5,
+	0x49, 0x47, 0x36, 0x43, 0xfc, 0x84, 0x3e, 0xc5, 0xcc, 0x8f, 0x13, 0x2a, 0xca, 0xa3, 0x8f, 0xba,
+	0x91, 0x14, 0xe5, 0xb9, 0xc6, 0x77, 0xa2, 0xf1, 0xea, 0x68, 0x86, 0x91, 0x32, 0xd7, 0x2c, 0xf0,
+	0xae, 0x74, 0x7e, 0xe9, 0xd3, 0xa5, 0xfb, 0x06, 0x26, 0xb9, 0xed, 0x17, 0xa9, 0x60, 0xc7, 0x1f,
+	0x15, 0xa7, 0x53, 0xc6, 0x7f, 0xe2, 0x95, 0xdd, 0xcb, 0xcf, 0x27, 0x0f, 0xc8, 0x75, 0xca, 0xfc,
+	0x44, 0xdd, 0xd8, 0x6d, 0xc6, 0x05, 0x7e, 0xf1, 0xcd, 0x08, 0xc3, 0x37, 0x9c, 0x4c, 0x64, 0x95,
+	0xab, 0xbe, 0x33, 0x2e, 0xa1, 0xee, 0xa7, 0x32, 0x6e, 0x17, 0xe9, 0xcd, 0x05, 0xbe, 0xc3, 0xc9,
+	0x4c, 0xdc, 0xe3, 0xe2, 0x5f, 0xdd, 0xab, 0x25, 0x5c, 0x70, 0x3f, 0x83, 0x89, 0x39, 0x4f, 0x33,
+	0xcf, 0xf6, 0x44, 0x55, 0xbc, 0xf3, 0xcd, 0x7a, 0x9d, 0x66, 0xec, 0x74, 0x43, 0x22, 0x5e, 0x0c,
+	0xee, 0x6f, 0xe1, 0x60, 0x0b, 0x56, 0xdd, 0xc8, 0xb3, 0x38, 0xc1, 0xa4, 0x38, 0xda, 0xbd, 0x85,
+	0x92, 0x73, 0xa6, 0xd9, 0xa9, 0x65, 0x9a, 0x66, 0x89, 0x69, 0xbe, 0x83, 0x47, 0xa5, 0xf6, 0x8a,
+	0x9f, 0xad, 0xcd, 0x7a, 0x46, 0x38, 0x79, 0x5f, 0xf9, 0x94, 0xae, 0x97, 0x99, 0x4f, 0xb5, 0x0a,
+	0x58, 0xe7, 0x08, 0x4f, 0xe3, 0x75, 0xfa, 0x34, 0x8b, 0xef, 0xb0, 0x62, 0xc9, 0x2e, 0x93, 0xa2,
+	0xfb, 0x67, 0x0b, 0x1e, 0xd7, 0xec, 0xf9, 0x01, 0xad, 0xe6, 0xf8, 0x65, 0x1a, 0xe1, 0x59, 0xa4,
+	0x6b, 0x8b, 0x08, 0x29, 0xa7, 0xc9, 0xb6, 0x41, 0x93, 0x7f, 0xaa, 0x9a, 0x83, 0x30, 0x65, 0x69,
+	0x86, 0x7f, 0x80, 0x8f, 0xef, 0x63, 0xd2, 0x27, 0x30, 0x12, 0xee, 0xe7, 0xfe, 0x49, 0xcb, 0x46,
+	0x91, 0x09, 0xba, 0x18, 0x9e, 0xd4, 0xd9, 0xa2, 0x58, 0x50, 0xf9, 0x64, 0x95, 0x7c, 0x32, 0x9a,
+	0xb4, 0x9d, 0x72, 0x93, 0xe6, 0x40, 0xf7, 0x2c, 0xc3, 0x3e, 0xc3, 0x91, 0xa2, 0xea, 0x6e, 0x28,
+	0x45, 0xf7, 0x12, 0xec, 0xa2, 0x6b, 0xf8, 0x00, 0x29, 0xfd, 0xa3, 0x05, 0xfb, 0xd5, 0x0d, 0x3f,
+	0x70, 0x3e, 0xe7, 0xf1, 0x0d, 0xc1, 0xfa, 0x4e, 0xe9, 0x50, 0x21, 0xd5, 0xe6, 0xf3, 0x6f, 0x25,
+	0x5b, 0xfe, 0x87, 0xc9, 0x34, 0xac, 0x6e, 0x9b, 0x56, 0xbb, 0x01, 0x1c, 0x6c, 0x19, 0xf8, 0xee,
+	0x76, 0xdb, 0xc8, 0xe4, 0x4e, 0x29, 0x93, 0x86, 0x8e, 0x66, 0x49, 0xc7, 0x6b, 0x99, 0xe1, 0x67,
+	0x7e, 0xc8, 0xd2, 0xec, 0x01, 0x61, 0x2a, 0xba, 0x4a, 0xc1, 0x69, 0xb7, 0x98, 0x68, 0x6a, 0x61,
+	0x5c, 0xe0, 0x9c, 0xf6, 0x2a, 0x5e, 0x63, 0xf1, 0x06, 0x0c, 0x97, 0x7e, 0x4c, 0x54, 0x7a, 0xc7,
+	0xf7, 0x25, 0x94, 0x7b, 0xcd, 0xe7, 0x15, 0x0f, 0x30, 0x79, 0xe3, 0x8d, 0xee, 0x4d, 0xd0, 0xfd,
+	0x03, 0xec, 0x57, 0x35, 0x2b, 0x56, 0xab, 0x51, 0xed, 0x40, 0xf7, 0xfc, 0xf5, 0x3a, 0xce, 0xb0,
+	0x7e, 0xa9, 0x76, 0xb1, 0x14, 0x79, 0xe9, 0xbc, 0xf2, 0x33, 0xbe, 0xa3, 0x6c, 0xa4, 0xfa, 0xa8,
+	0x77, 0xaf, 0x64, 0xde, 0x65, 0x88, 0x7d, 0x19, 0x37, 0xa2, 0x25, 0x8c, 0xe8, 0x67, 0x1a, 0x70,
+	0x53, 0xd9, 0xc8, 0x5d, 0xa5, 0xf7, 0x38, 0x9b, 0x11, 0xe3, 0x9e, 0xb1, 0x4a, 0xf7, 0xcc, 0x21,
+	0x0c, 0xe6, 0xe1, 0x12, 0x47, 0x9b, 0x04, 0x47, 0x27, 0x4c, 0xa9, 0x1f, 0xd0, 0x02, 0xe2, 0x71,
+	0x39, 0xf3, 0x49, 0x88, 0x13, 0x3d, 0x4f, 0x39, 0x3c, 0x0e, 0x4b, 0xa8, 0xfb, 0x3b, 0x18, 0xe6,
+	0x0a, 0x55, 0x33, 0x36, 0x67, 0x78, 0xad, 0x5b, 0x2a, 0xca, 0xf0, 0x9a, 0x63, 0x4f, 0x53, 0xa2,
+	0x0f, 0x4e, 0x2b, 0x4a, 0x09, 0xb6, 0x8f, 0x60, 0x92, 0x5b, 0x80, 0x70, 0x90, 0xa6, 0x4c, 0x9d,
+	0xd3, 0x09, 0x2d, 0xc3, 0xee, 0x5f, 0x00, 0x86, 0xa7, 0x31, 0x3b, 0x4d, 0x5f, 0x73, 0x45, 0x33,
+	0x22, 0x23, 0x20, 0x1e, 0x48, 0xb3, 0xc8, 0x89, 0xc4, 0x05, 0xd5, 0xcf, 0x34, 0x60, 0xff, 0x12,
+	0x26, 0x41, 0xf9, 0xd5, 0xe5, 0x58, 0x5b, 0xcf, 0x59, 0x81, 0x4f, 0x1b, 0xa8, 0x3a, 0xd5, 0xfe,
+	0x06, 0xc6, 0x41, 0xe9, 0x49, 0xa5, 0x1e, 0xda, 0x13, 0xaf, 0xfc, 0xd2, 0x9a, 0x36, 0x50, 0x65,
+	0xa2, 0x56, 0x6c, 0xbc, 0x76, 0x9c, 0xa6, 0xa1, 0xd8, 0xc0, 0xb5, 0x62, 0x03, 0x2a, 0xaf, 0x16,
+	0xcf, 0xa0, 0xd2, 0x2b, 0xdc, 0xc0, 0xcb, 0xab, 0x05, 0x64, 0x7f, 0x01, 0x10, 0xe4, 0xef, 0x19,
+	0xf5, 0x2c, 0x1e, 0x78, 0xc5, 0x13, 0x67, 0xda, 0x40, 0xc6, 0x04, 0xfb, 0x2b, 0x18, 0x06, 0x46,
+	0xfb, 0x2d, 0x1a, 0x0a, 0xdd, 0xac, 0x6b, 0x70, 0xda, 0x40, 0xa5, 0x49, 0xf6, 0x29, 0xec, 0x05,
+	0xd5, 0x37, 0xb5, 0x68, 0x39, 0xf2, 0x67, 0xa0, 0x39, 0x32, 0x6d, 0xa0, 0xed, 0xe9, 0x5a, 0xb1,
+	0x6e, 0xcb, 0x9d, 0x9e, 0xa1, 0x58, 0x83, 0x5a, 0xb1, 0x96, 0xed, 0x29, 0xec, 0x07, 0xdb, 0xad,
+	0xab, 0xd3, 0x17, 0x6b, 0x0f, 0xbc, 0x9a, 0xb6, 0x76, 0xda, 0x40, 0x75, 0x4b, 0xf4, 0x4e, 0x95,
+	0xa6, 0xd2, 0x01, 0x63, 0xa7, 0xca, 0x98, 0xde, 0xa9, 0x02, 0xeb, 0x3a, 0x29, 0x7a, 0x4b, 0x67,
+	0x60, 0xd4, 0x49, 0x01, 0xeb, 0x3a, 0x29, 0x10, 0xbd, 0xb4, 0xe8, 0x8e, 0x9c, 0xa1, 0xb1, 0xb4,
+	0x80, 0xf5, 0xd2, 0x02, 0xc9, 0xed, 0x2f, 0xf7, 0x41, 0xce, 0xc8, 0xb4, 0xbf, 0x3c, 0x96, 0xdb,
+	0x5f, 0x86, 0xed, 0x4b, 0x78, 0x14, 0xd4, 0xb5, 0x36, 0xce, 0x58, 0xec, 0xf5, 0xd8, 0xab, 0x6d,
+	0x7c, 0xa6, 0x0d, 0x54, 0xbf, 0xcc, 0xfe, 0x0e, 0x1e, 0x07, 0xb5, 0x7d, 0x84, 0x33, 0x11, 0x1b,
+	0x3e, 0xf1, 0xea, 0xdb, 0x8c, 0x69, 0x03, 0xbd, 0x65, 0xa1, 0x7d, 0x0e, 0x76, 0xb0, 0x75, 0x4f,
+	0x3b, 0xbb, 0xc6, 0x77, 0xaf, 0xf2, 0xd0, 0xb4, 0x81, 0x6a, 0x16, 0xe8, 0x98, 0x55, 0x6e, 0x44,
+	0x67, 0xcf, 0x88, 0x59, 0x65, 0x4c, 0xc7, 0xac, 0x02, 0x6b, 0x83, 0xca, 0xd7, 0x8a, 0x63, 0x1b,
+	0x06, 0x95, 0x87, 0xb4, 0x41, 0x65, 0xd4, 0xfe, 0x12, 0x06, 0x81, 0x66, 0xcc, 0x19, 0x71, 0xf6,
+	0xc5, 0xfa, 0xa1, 0x67, 0xd0, 0xf6, 0xb4, 0x81, 0xcc, 0x29, 0xa7, 0x63, 0x18, 0x06, 0x06, 0x01,
+	0xba, 0xff, 0x04, 0x18, 0x15, 0x8c, 0xc8, 0x59, 0xf7, 0xdd, 0x94, 0xa8, 0x92, 0xbd, 0xf5, 0x01,
+	0xda, 0xb1, 0x8c, 0x64, 0x6f, 0x8d, 0xea, 0x64, 0x6f, 0x0d, 0xd8, 0xbf, 0x86, 0xdd, 0xa0, 0xf2,
+	0xb9, 0x4c, 0xd1, 0xe4, 0x9e, 0x57, 0xfd, 0x8e, 0x36, 0x6d, 0xa0, 0xad, 0xc9, 0x9a, 0x06, 0xf4,
+	0x67, 0x30, 0xa7, 0x69, 0xd0, 0x80, 0x06, 0x35, 0x0d, 0x68, 0x39, 0x27, 0xf6, 0xe2, 0x83, 0x54,
+	0xf9, 0x3b, 0x65, 0x81, 0xe7, 0xc4, 0x5e, 0x40, 0xf6, 0xd7, 0x30, 0x0a, 0xcc, 0x6f, 0x45, 0x8a,
+	0x24, 0xc7, 0x5e, 0xe9, 0x0b, 0xd2, 0xb4, 0x81, 0xca, 0xd3, 0x74, 0xb6, 0xd4, 0x97, 0x11, 0xa7,
+	0x63, 0x64, 0x4b, 0x61, 0x3a, 0x5b, 0x4a, 0xd4, 0x9a, 0xf2, 0xef, 0x13, 0x4e, 0xd7, 0xd0, 0x94,
+	0xa3, 0x5a, 0x53, 0x0e, 0xe8, 0xa0, 0xe8, 0x6f, 0xaa, 0x25, 0x6e, 0xd4, 0xa0, 0x0e, 0x8a, 0x96,
+	0xb5, 0xb2, 0xfc, 0x7b, 0x81, 0xd3, 0x37, 0x94, 0xe5, 0xa8, 0x56, 0x96, 0x03, 0xf6, 0xb7, 0x70,
+	0x10, 0xd4, 0xbc, 0xd1, 0x15, 0x15, 0x3e, 0xf2, 0xea, 0x1e, 0xf0, 0xd3, 0x06, 0xaa, 0x5d, 0xa4,
+	0x33, 0x63, 0xbc, 0x35, 0x9d, 0x81, 0x91, 0x19, 0x03, 0xd7, 0x99, 0x31, 0x20, 0xbd, 0xda, 0x78,
+	0x05, 0x3a, 0x43, 0x63, 0xb5, 0x81, 0xeb, 0xd5, 0x06, 0xa4, 0x1d, 0xa9, 0xbe, 0x01, 0x9d, 0x91,
+	0xe1, 0x48, 0x75, 0x50, 0x3b, 0x52, 0xc5, 0xb7, 0x58, 0x2c, 0x6f, 0xe6, 0x9d, 0x71, 0x1d, 0x8b,
+	0xe5, 0xc3, 0x5b, 0x2c, 0x96, 0x8f, 0xd8, 0xd7, 0xf0, 0x24, 0xa8, 0x7f, 0xd4, 0x28, 0x66, 0x74,
+	0xbc, 0xb7, 0x3c, 0x7a, 0xa6, 0x0d, 0xf4, 0xb6, 0xa5, 0x65, 0x52, 0x2b, 0xac, 0xdc, 0xdd, 0x22,
+	0x35, 0xd3, 0xc4, 0xba, 0x25, 0x3a, 0x7e, 0xd5, 0x7e, 0xdc, 0xd9, 0x33, 0xe2, 0x57, 0x1d, 0xd4,
+	0xf1, 0xab, 0xe2, 0xda, 0xac, 0x4a, 0xfb, 0xeb, 0xd8, 0x86, 0x59, 0x95, 0x31, 0x6d, 0x56, 0x05,
+	0xd6, 0x87, 0x41, 0xb7, 0x95, 0xce, 0xbe, 0x71, 0x18, 0x34, 0xa8, 0x0f, 0x83, 0x96, 0x4f, 0x27,
+	0x30, 0x0a, 0x4c, 0x5a, 0x0c, 0x3a, 0xe2, 0x07, 0xb8, 0xaf, 0xfe, 0x33, 0x00, 0xc9, 0xdc, 0x85,
+	0x88, 0x96, 0x1b, 0x00, 0x00,
 }
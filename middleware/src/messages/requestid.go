@@ -0,0 +1,18 @@
+package basemessages
+
+import "github.com/golang/protobuf/proto"
+
+// requestIDField is the field number of RequestId in BitBoxBaseIn and BitBoxBaseOut.
+const requestIDField = 100
+
+// AppendRequestID sets the RequestId of a protobuf serialized BitBoxBaseIn or BitBoxBaseOut. Serialized protobuf
+// messages are merged by concatenating them, so the field is appended, which spares unmarshalling the message again.
+func AppendRequestID(message []byte, requestID uint64) []byte {
+	if requestID == 0 {
+		return message
+	}
+	buffer := proto.NewBuffer(append([]byte{}, message...))
+	_ = buffer.EncodeVarint(requestIDField<<3 | proto.WireVarint)
+	_ = buffer.EncodeVarint(requestID)
+	return buffer.Bytes()
+}
@@ -0,0 +1,22 @@
+package basemessages
+
+import (
+	"reflect"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// RPCField returns the field number of the rpc in the BitBoxBaseIn oneof, 0 if the request carries none. The field
+// order of a serialized message is not fixed, so the rpc has to be taken from the unmarshalled message.
+func RPCField(request *BitBoxBaseIn) int32 {
+	if request == nil || request.BitBoxBaseIn == nil {
+		return 0
+	}
+	rpcType := reflect.TypeOf(request.BitBoxBaseIn)
+	for _, oneof := range proto.GetProperties(reflect.TypeOf(request).Elem()).OneofTypes {
+		if oneof.Type == rpcType {
+			return int32(oneof.Prop.Tag)
+		}
+	}
+	return 0
+}
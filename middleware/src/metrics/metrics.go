@@ -0,0 +1,250 @@
+// Package metrics exposes metrics of the middleware to Prometheus in its text exposition format. It only implements
+// the counters, gauges and histograms the middleware needs.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/digitalbitbox/bitbox-base/middleware/src/logging"
+)
+
+// metric is a metric family that writes its samples in the text exposition format.
+type metric interface {
+	write(w io.Writer)
+}
+
+// Registry holds the metrics of a component. It is safe for concurrent use.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (registry *Registry) register(metric metric) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.metrics = append(registry.metrics, metric)
+}
+
+// Write writes all metrics of the registry in the text exposition format.
+func (registry *Registry) Write(w io.Writer) {
+	registry.mu.Lock()
+	metrics := append([]metric{}, registry.metrics...)
+	registry.mu.Unlock()
+	for _, metric := range metrics {
+		metric.write(w)
+	}
+}
+
+// Handler returns an http handler serving the metrics of the registries to Prometheus. Failures are logged with logger.
+func Handler(logger *logging.Logger, registries ...*Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		buffered := bufio.NewWriter(w)
+		for _, registry := range registries {
+			registry.Write(buffered)
+		}
+		if err := buffered.Flush(); err != nil {
+			logger.Warning("Failed to write metrics", "error", err)
+		}
+	})
+}
+
+// family holds the name, help text and label names shared by the metrics with labels.
+type family struct {
+	name   string
+	help   string
+	labels []string
+}
+
+func (family family) writeHeader(w io.Writer, metricType string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", family.name, family.help, family.name, metricType)
+}
+
+// key joins label values into a map key.
+func (family family) key(labelValues []string) string {
+	if len(labelValues) != len(family.labels) {
+		panic("metric " + family.name + " expects the labels " + strings.Join(family.labels, ", "))
+	}
+	return strings.Join(labelValues, "\x00")
+}
+
+// labelPairs formats the labels of a series, like {rpc="BaseServicesIn"}, with extra pairs appended.
+func (family family) labelPairs(key string, extra ...string) string {
+	pairs := []string{}
+	if len(family.labels) > 0 {
+		for i, value := range strings.Split(key, "\x00") {
+			pairs = append(pairs, family.labels[i]+"="+quote(value))
+		}
+	}
+	pairs = append(pairs, extra...)
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// quote quotes a label value, escaping backslashes, quotes and newlines.
+func quote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
+
+// sortedKeys returns the keys of the series in a stable order.
+func sortedKeys(series map[string]float64) []string {
+	keys := make([]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatValue formats a sample value.
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+// Counter is a counter with labels, e.g. the number of requests by rpc.
+type Counter struct {
+	family
+	mu     sync.Mutex
+	series map[string]float64
+}
+
+// Counter registers a new counter. The label values are passed in the same order when counting.
+func (registry *Registry) Counter(name, help string, labels ...string) *Counter {
+	counter := &Counter{family: family{name: name, help: help, labels: labels}, series: make(map[string]float64)}
+	registry.register(counter)
+	return counter
+}
+
+// Inc increments the counter of the given label values.
+func (counter *Counter) Inc(labelValues ...string) {
+	counter.Add(1, labelValues...)
+}
+
+// Add adds a value to the counter of the given label values.
+func (counter *Counter) Add(value float64, labelValues ...string) {
+	key := counter.key(labelValues)
+	counter.mu.Lock()
+	defer counter.mu.Unlock()
+	counter.series[key] += value
+}
+
+// Value returns the counter of the given label values.
+func (counter *Counter) Value(labelValues ...string) float64 {
+	key := counter.key(labelValues)
+	counter.mu.Lock()
+	defer counter.mu.Unlock()
+	return counter.series[key]
+}
+
+func (counter *Counter) write(w io.Writer) {
+	counter.mu.Lock()
+	defer counter.mu.Unlock()
+	counter.writeHeader(w, "counter")
+	for _, key := range sortedKeys(counter.series) {
+		fmt.Fprintf(w, "%s%s %s\n", counter.name, counter.labelPairs(key), formatValue(counter.series[key]))
+	}
+}
+
+// gauge is a gauge without labels whose value is read when the metrics are collected.
+type gauge struct {
+	family
+	value func() float64
+}
+
+// Gauge registers a gauge whose value is read from the given function whenever the metrics are collected.
+func (registry *Registry) Gauge(name, help string, value func() float64) {
+	registry.register(&gauge{family: family{name: name, help: help}, value: value})
+}
+
+func (gauge *gauge) write(w io.Writer) {
+	gauge.writeHeader(w, "gauge")
+	fmt.Fprintf(w, "%s %s\n", gauge.name, formatValue(gauge.value()))
+}
+
+// histogramSeries are the observations of a histogram for one set of label values.
+type histogramSeries struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// Histogram counts observations, like durations, in buckets.
+type Histogram struct {
+	family
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+// Histogram registers a new histogram with the given upper bounds of the buckets, in increasing order.
+func (registry *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	histogram := &Histogram{
+		family:  family{name: name, help: help, labels: labels},
+		buckets: buckets,
+		series:  make(map[string]*histogramSeries),
+	}
+	registry.register(histogram)
+	return histogram
+}
+
+// Observe adds an observation for the given label values.
+func (histogram *Histogram) Observe(value float64, labelValues ...string) {
+	key := histogram.key(labelValues)
+	histogram.mu.Lock()
+	defer histogram.mu.Unlock()
+	series, ok := histogram.series[key]
+	if !ok {
+		series = &histogramSeries{counts: make([]uint64, len(histogram.buckets))}
+		histogram.series[key] = series
+	}
+	for i, bound := range histogram.buckets {
+		if value <= bound {
+			series.counts[i]++
+		}
+	}
+	series.sum += value
+	series.count++
+}
+
+func (histogram *Histogram) write(w io.Writer) {
+	histogram.mu.Lock()
+	defer histogram.mu.Unlock()
+	histogram.writeHeader(w, "histogram")
+	keys := make([]string, 0, len(histogram.series))
+	for key := range histogram.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		series := histogram.series[key]
+		for i, bound := range histogram.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n",
+				histogram.name, histogram.labelPairs(key, "le="+quote(formatValue(bound))), series.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", histogram.name, histogram.labelPairs(key, `le="+Inf"`), series.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", histogram.name, histogram.labelPairs(key), formatValue(series.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", histogram.name, histogram.labelPairs(key), series.count)
+	}
+}
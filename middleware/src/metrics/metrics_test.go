@@ -0,0 +1,67 @@
+package metrics_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-base/middleware/src/logging"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := metrics.NewRegistry()
+	requests := registry.Counter("requests_total", "Requests by rpc and result", "rpc", "result")
+	duration := registry.Histogram("duration_seconds", "Request duration", []float64{0.1, 1}, "rpc")
+	registry.Gauge("clients", "Connected clients", func() float64 { return 2 })
+
+	requests.Inc("BaseServicesIn", "ok")
+	requests.Inc("BaseServicesIn", "ok")
+	requests.Inc("BaseConfigGetIn", "error")
+	duration.Observe(0.05, "BaseServicesIn")
+	duration.Observe(0.5, "BaseServicesIn")
+	require.Equal(t, float64(2), requests.Value("BaseServicesIn", "ok"))
+	require.Panics(t, func() { requests.Inc("BaseServicesIn") })
+
+	var buffer bytes.Buffer
+	registry.Write(&buffer)
+	require.Equal(t, `# HELP requests_total Requests by rpc and result
+# TYPE requests_total counter
+requests_total{rpc="BaseConfigGetIn",result="error"} 1
+requests_total{rpc="BaseServicesIn",result="ok"} 2
+# HELP duration_seconds Request duration
+# TYPE duration_seconds histogram
+duration_seconds_bucket{rpc="BaseServicesIn",le="0.1"} 1
+duration_seconds_bucket{rpc="BaseServicesIn",le="1"} 2
+duration_seconds_bucket{rpc="BaseServicesIn",le="+Inf"} 2
+duration_seconds_sum{rpc="BaseServicesIn"} 0.55
+duration_seconds_count{rpc="BaseServicesIn"} 2
+# HELP clients Connected clients
+# TYPE clients gauge
+clients 2
+`, buffer.String())
+
+	// Label values are escaped.
+	escaped := metrics.NewRegistry()
+	escaped.Counter("escaped_total", "Escaped labels", "value").Inc("a\"b\\c\nd")
+	buffer.Reset()
+	escaped.Write(&buffer)
+	require.Contains(t, buffer.String(), `escaped_total{value="a\"b\\c\nd"} 1`)
+}
+
+func TestHandler(t *testing.T) {
+	first := metrics.NewRegistry()
+	first.Gauge("first", "First registry", func() float64 { return 1 })
+	second := metrics.NewRegistry()
+	second.Gauge("second", "Second registry", func() float64 { return 2 })
+
+	recorder := httptest.NewRecorder()
+	metrics.Handler(logging.New(ioutil.Discard, logging.LevelError, false), first, second).ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "text/plain; version=0.0.4", recorder.Header().Get("Content-Type"))
+	require.Contains(t, recorder.Body.String(), "\nfirst 1\n")
+	require.Contains(t, recorder.Body.String(), "\nsecond 2\n")
+}
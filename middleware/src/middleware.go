@@ -2,142 +2,523 @@
 package middleware
 
 import (
-	"log"
+	"encoding/hex"
+	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/logging"
 	basemessages "github.com/digitalbitbox/bitbox-base/middleware/src/messages"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/metrics"
 	"github.com/digitalbitbox/bitbox-base/middleware/src/system"
-	lightning "github.com/fiatjaf/lightningd-gjson-rpc"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/zmq"
 
 	"github.com/golang/protobuf/proto"
 )
 
 //go:generate protoc --go_out=import_path=messages:. messages/bbb.proto
 
-// SampleInfo holds sample information from c-lightning and bitcoind. It is temporary for testing purposes.
-type SampleInfo struct {
-	Blocks         int64   `json:"blocks"`
-	Difficulty     float64 `json:"difficulty"`
-	LightningAlias string  `json:"lightningAlias"`
-}
+const (
+	// pollInterval is the interval in which the services are polled. bitcoind is polled that often while its zmq
+	// notifications are not available.
+	pollInterval = 5 * time.Second
+	// zmqPollInterval is the interval in which bitcoind is polled while its zmq notifications are subscribed to, so
+	// that a wedged bitcoind still shows up in the state and the health.
+	zmqPollInterval = time.Minute
+	// zmqReceiveTimeout is how long a zmq subscription may stay silent before it is renewed, in case the connection
+	// died without being closed. Blocks can take longer, renewing the subscription is cheap.
+	zmqReceiveTimeout = 30 * time.Minute
+	// mempoolRefreshDelay collects bursts of new transactions into a single mempool refresh.
+	mempoolRefreshDelay = time.Second
+
+	zmqMinReconnectDelay = time.Second
+	zmqMaxReconnectDelay = time.Minute
+
+	// maxLogBatch and maxLogBatchBytes limit the number of journal entries and the size of the messages sent in one
+	// message.
+	maxLogBatch      = 100
+	maxLogBatchBytes = 64 * 1024
+
+	zmqTopicHashblock = "hashblock"
+	zmqTopicRawtx     = "rawtx"
+)
 
 // Middleware connects to services on the base with provided parrameters and emits events for the handler.
 type Middleware struct {
-	info        SampleInfo
 	environment system.Environment
-	events      chan []byte
+	state       *stateStore
+	health      *healthTracker
+	thermal     *thermalHistory
+	metrics     *metrics.Registry
+	logger      *logging.Logger
 	mu          sync.RWMutex
+
+	backends Backends
+	// bitcoindMu serializes refreshes of the bitcoind state, so that a slow poll does not overwrite newer values.
+	bitcoindMu sync.Mutex
+	// bitcoindRefreshed is when the bitcoind state was last refreshed. It is guarded by bitcoindMu.
+	bitcoindRefreshed time.Time
+	// zmqPollInterval and zmqReceiveTimeout are the constants of the same name, changed by the tests.
+	zmqPollInterval   time.Duration
+	zmqReceiveTimeout time.Duration
+	// zmqAddresses maps the bitcoind zmq notification topics to the addresses they are published on.
+	zmqAddresses map[string]string
+	// zmqConnected counts the connected zmq subscriptions. bitcoind is polled unless all of them are connected.
+	zmqConnected int32
+	// mempoolRefreshPending is 1 while a mempool refresh is scheduled.
+	mempoolRefreshPending int32
+	// backupMu serializes creating and restoring backups.
+	backupMu sync.Mutex
 }
 
 // NewMiddleware returns a new instance of the middleware
-func NewMiddleware(bitcoinRPCUser, bitcoinRPCPassword, bitcoinRPCPort, lightningRPCPath, electrsRPCPort, network string) *Middleware {
+func NewMiddleware(environment system.Environment) *Middleware {
+	middleware := newMiddleware(environment)
+	middleware.backends = newBackends(middleware)
+	// Without zmq notifications, bitcoind is polled.
+	if environment.BitcoinZMQHashblock != "" {
+		middleware.zmqAddresses[zmqTopicHashblock] = environment.BitcoinZMQHashblock
+	}
+	if environment.BitcoinZMQRawtx != "" {
+		middleware.zmqAddresses[zmqTopicRawtx] = environment.BitcoinZMQRawtx
+	}
+	return middleware
+}
+
+// NewMiddlewareWithBackends returns a new instance of the middleware that reports on and controls the given backends
+// instead of the services of the Base, e.g. a simulation. bitcoind zmq notifications are not used, the bitcoin
+// backend is polled.
+func NewMiddlewareWithBackends(environment system.Environment, backends Backends) *Middleware {
+	middleware := newMiddleware(environment)
+	middleware.backends = backends
+	return middleware
+}
+
+func newMiddleware(environment system.Environment) *Middleware {
+	registry := metrics.NewRegistry()
+	// The level is validated with the environment, tests may leave it empty.
+	level, err := logging.ParseLevel(environment.LogLevel)
+	if err != nil {
+		level = logging.LevelInfo
+	}
+	logger := logging.NewDefault(level)
+	logger.AddSecret(environment.BitcoinRPCPassword)
 	middleware := &Middleware{
-		environment: system.NewEnvironment(bitcoinRPCUser, bitcoinRPCPassword, bitcoinRPCPort, lightningRPCPath, electrsRPCPort, network),
-		//TODO(TheCharlatan) find a better way to increase the channel size
-		events: make(chan []byte), //the channel size needs to be increased every time we had an extra endpoint
-		info: SampleInfo{
-			Blocks:         0,
-			Difficulty:     0.0,
-			LightningAlias: "disconnected",
-		},
+		environment: environment,
+		metrics:     registry,
+		logger:      logger,
+		state: newStateStore(logger, State{
+			Lightning: LightningState{Alias: "disconnected"},
+			System: SystemState{
+				Network:        string(environment.Network),
+				ElectrsRPCPort: environment.ElectrsRPCPort,
+			},
+		}),
+		health: newHealthTracker(registry.Counter(
+			"base_middleware_backend_errors_total", "Failed calls to the backends, by backend", "backend")),
+		thermal:           &thermalHistory{},
+		zmqAddresses:      make(map[string]string),
+		zmqPollInterval:   zmqPollInterval,
+		zmqReceiveTimeout: zmqReceiveTimeout,
 	}
+	registry.Gauge("base_middleware_state_subscribers", "Clients subscribed to the state", func() float64 {
+		subscribers, _ := middleware.state.subscriberLag()
+		return float64(subscribers)
+	})
+	registry.Gauge("base_middleware_state_queue_versions", "State versions the slowest subscriber has not received yet", func() float64 {
+		_, lag := middleware.state.subscriberLag()
+		return float64(lag)
+	})
+	registry.Gauge("base_middleware_zmq_subscribed", "1 if all bitcoind zmq notifications are subscribed to, 0 while polling", func() float64 {
+		if middleware.zmqSubscribed() {
+			return 1
+		}
+		return 0
+	})
 
 	return middleware
 }
 
-// demoBitcoinRPC is a function that demonstrates a connection to bitcoind. Currently it gets the blockcount and difficulty and writes it into the SampleInfo.
-func (middleware *Middleware) demoBitcoinRPC() {
-	connCfg := rpcclient.ConnConfig{
-		HTTPPostMode: true,
-		DisableTLS:   true,
-		Host:         "127.0.0.1:" + middleware.environment.GetBitcoinRPCPort(),
-		User:         middleware.environment.GetBitcoinRPCUser(),
-		Pass:         middleware.environment.GetBitcoinRPCPassword(),
+// getEnvironment returns a copy of the current environment.
+func (middleware *Middleware) getEnvironment() system.Environment {
+	middleware.mu.RLock()
+	defer middleware.mu.RUnlock()
+	return middleware.environment
+}
+
+// Reload applies a reloaded environment while the middleware is running. Settings that cannot be changed at runtime
+// keep their values and are logged, they take effect after a restart.
+func (middleware *Middleware) Reload(reloaded system.Environment) {
+	middleware.mu.Lock()
+	environment, restartRequired := middleware.environment.Reload(reloaded)
+	middleware.environment = environment
+	middleware.mu.Unlock()
+	for _, key := range restartRequired {
+		middleware.logger.Warning("Setting changed, it takes effect after a restart", "setting", key)
 	}
-	client, err := rpcclient.New(&connCfg, nil)
-	if err != nil {
-		log.Println(err.Error() + " Failed to create new bitcoind rpc client")
+	middleware.logger.AddSecret(environment.BitcoinRPCPassword)
+	if level, err := logging.ParseLevel(environment.LogLevel); err == nil {
+		middleware.logger.SetLevel(level)
 	}
-	//client is shutdown/deconstructed again as soon as this function returns
-	defer client.Shutdown()
+	middleware.state.update(func(state *State) {
+		state.System.ElectrsRPCPort = environment.ElectrsRPCPort
+	})
+	middleware.logger.Info("Reloaded the configuration")
+}
 
-	//Get current block count.
-	var blockCount int64
-	blockCount, err = client.GetBlockCount()
-	if err != nil {
-		log.Println(err.Error() + " No blockcount received")
-	} else {
-		middleware.info.Blocks = blockCount
+// demoBitcoinRPC is a function that demonstrates a connection to bitcoind. Currently it gets the blockcount, difficulty,
+// best block hash and the number of mempool transactions. If bitcoind is unreachable, the returned state only reports
+// that instead of stale values.
+func (middleware *Middleware) demoBitcoinRPC(state BitcoindState) BitcoindState {
+	chainInfo, err := middleware.backends.Bitcoin.ChainInfo()
+	if err == nil {
+		state = chainInfo
 	}
-	blockChainInfo, err := client.GetBlockChainInfo()
-	if err != nil {
-		log.Println(err.Error() + " GetBlockChainInfo rpc call failed")
-	} else {
-		middleware.info.Difficulty = blockChainInfo.Difficulty
+	return middleware.bitcoindError(state, err)
+}
+
+// bitcoindError returns the state to report after a bitcoind rpc call returned err.
+func (middleware *Middleware) bitcoindError(state BitcoindState, err error) BitcoindState {
+	if err == nil {
+		middleware.health.success(backendBitcoind)
+		return state
+	}
+	if isReachable(err) {
+		// bitcoind is reachable, but not ready yet, e.g. while warming up.
+		middleware.logger.Warning("bitcoind rpc call failed", "error", err)
+		middleware.health.failure(backendBitcoind, err, true)
+		state.Unreachable = false
+		return state
+	}
+	// While backing off, the error that made bitcoind unreachable is kept.
+	if err != errBitcoindUnreachable {
+		middleware.health.failure(backendBitcoind, err, false)
+	}
+	if !state.Unreachable {
+		middleware.logger.Error("bitcoind is unreachable", "error", err)
 	}
+	return BitcoindState{Unreachable: true}
+}
 
+// refreshBitcoind fetches the state of bitcoind and updates it.
+func (middleware *Middleware) refreshBitcoind() {
+	middleware.bitcoindMu.Lock()
+	defer middleware.bitcoindMu.Unlock()
+	bitcoind := middleware.demoBitcoinRPC(middleware.state.get().Bitcoind)
+	middleware.state.update(func(state *State) {
+		state.Bitcoind = bitcoind
+	})
+	middleware.bitcoindRefreshed = time.Now()
 }
 
-// demoCLightningRPC demonstrates a connection with lightnind. Currently it gets the lightningd alias and writes it into the SampleInfo.
-func (middleware *Middleware) demoCLightningRPC() {
-	ln := &lightning.Client{
-		Path: middleware.environment.GetLightningRPCPath(),
+// bitcoindRefreshDue returns true if bitcoind has to be polled. While its zmq notifications are subscribed to, it is
+// only polled every zmqPollInterval.
+func (middleware *Middleware) bitcoindRefreshDue() bool {
+	if !middleware.zmqSubscribed() {
+		return true
 	}
+	middleware.bitcoindMu.Lock()
+	defer middleware.bitcoindMu.Unlock()
+	return time.Since(middleware.bitcoindRefreshed) >= middleware.zmqPollInterval
+}
 
-	nodeinfo, err := ln.Call("getinfo")
-	if err != nil {
-		log.Println(err.Error() + " Lightningd getinfo called failed.")
-		return
+// refreshMempool fetches the number of mempool transactions and updates it.
+func (middleware *Middleware) refreshMempool() {
+	middleware.bitcoindMu.Lock()
+	defer middleware.bitcoindMu.Unlock()
+	bitcoind := middleware.state.get().Bitcoind
+	mempoolTransactions, err := middleware.backends.Bitcoin.MempoolTransactions()
+	if err == nil {
+		bitcoind.MempoolTransactions = mempoolTransactions
 	}
-	middleware.info.LightningAlias = nodeinfo.Get("alias").String()
+	bitcoind = middleware.bitcoindError(bitcoind, err)
+	middleware.state.update(func(state *State) {
+		state.Bitcoind = bitcoind
+	})
 }
 
-//TODO rpcLoop just sends an event to the first client that catches it. In future, this information should properly fan out to all connected clients.
-func (middleware *Middleware) rpcLoop() {
-	for {
-		middleware.demoBitcoinRPC()
-		middleware.demoCLightningRPC()
-		outgoing := &basemessages.BitBoxBaseOut{
-			BitBoxBaseOut: &basemessages.BitBoxBaseOut_BaseMiddlewareInfoOut{
-				BaseMiddlewareInfoOut: &basemessages.BaseMiddlewareInfoOut{
-					Blocks:         middleware.info.Blocks,
-					Difficulty:     float32(middleware.info.Difficulty),
-					LightningAlias: middleware.info.LightningAlias,
-				},
-			},
+// handleZMQ updates the state on a bitcoind notification. New blocks are pushed to the clients right away, new
+// transactions schedule a refresh of the mempool size.
+func (middleware *Middleware) handleZMQ(message *zmq.Message) {
+	switch message.Topic {
+	case zmqTopicHashblock:
+		// bitcoind publishes the block hash in the byte order used by the rpc interface.
+		hash := hex.EncodeToString(message.Body)
+		middleware.bitcoindMu.Lock()
+		middleware.state.update(func(state *State) {
+			state.Bitcoind.BestBlockHash = hash
+		})
+		middleware.bitcoindMu.Unlock()
+		middleware.refreshBitcoind()
+	case zmqTopicRawtx:
+		if atomic.CompareAndSwapInt32(&middleware.mempoolRefreshPending, 0, 1) {
+			time.AfterFunc(mempoolRefreshDelay, func() {
+				atomic.StoreInt32(&middleware.mempoolRefreshPending, 0)
+				middleware.refreshMempool()
+			})
 		}
-		response, err := proto.Marshal(outgoing)
+	}
+}
+
+// zmqLoop subscribes to bitcoind notifications on address and handles them. It resubscribes with an increasing delay
+// when the connection fails, bitcoind is polled in the meantime.
+func (middleware *Middleware) zmqLoop(address string, topics []string) {
+	delay := zmqMinReconnectDelay
+	for {
+		subscriber, err := zmq.Dial(address, topics...)
 		if err != nil {
-			log.Println("Just print some generic error, because this will take some time to get right")
+			middleware.logger.Warning("Failed to subscribe to bitcoind zmq notifications, polling instead",
+				"address", address, "error", err)
+			time.Sleep(delay)
+			delay *= 2
+			if delay > zmqMaxReconnectDelay {
+				delay = zmqMaxReconnectDelay
+			}
+			continue
+		}
+		delay = zmqMinReconnectDelay
+		atomic.AddInt32(&middleware.zmqConnected, 1)
+		middleware.logger.Info("Subscribed to bitcoind zmq notifications", "address", address)
+		// Catch up with the blocks found while not subscribed.
+		middleware.refreshBitcoind()
+		for {
+			message, err := subscriber.Receive(middleware.zmqReceiveTimeout)
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				middleware.logger.Info("No bitcoind zmq notification for a while, subscribing again", "address", address)
+				break
+			}
+			if err != nil {
+				middleware.logger.Warning("Lost bitcoind zmq subscription, polling instead", "address", address, "error", err)
+				break
+			}
+			middleware.handleZMQ(message)
 		}
-		middleware.events <- response
-		time.Sleep(5 * time.Second)
+		atomic.AddInt32(&middleware.zmqConnected, -1)
+		_ = subscriber.Close()
+	}
+}
+
+// zmqSubscribed returns true if all configured bitcoind zmq notifications are subscribed to.
+func (middleware *Middleware) zmqSubscribed() bool {
+	_, hashblock := middleware.zmqAddresses[zmqTopicHashblock]
+	return hashblock && int(atomic.LoadInt32(&middleware.zmqConnected)) == len(middleware.zmqTopics())
+}
+
+// zmqTopics groups the configured topics by address, as bitcoind can publish several notifications on one address.
+func (middleware *Middleware) zmqTopics() map[string][]string {
+	topics := make(map[string][]string)
+	for topic, address := range middleware.zmqAddresses {
+		topics[address] = append(topics[address], topic)
+	}
+	return topics
+}
+
+// demoCLightningRPC demonstrates a connection with lightnind. Currently it gets the lightningd alias and the number of
+// active channels.
+func (middleware *Middleware) demoCLightningRPC(state LightningState) LightningState {
+	info, err := middleware.backends.Lightning.Info()
+	if err != nil {
+		middleware.logger.Warning("lightningd getinfo call failed", "error", err)
+		middleware.health.failure(backendLightning, err, isReachable(err))
+		return state
+	}
+	middleware.health.success(backendLightning)
+	return info
+}
+
+// electrsRPC gets the block height of electrs.
+func (middleware *Middleware) electrsRPC(state ElectrsState) ElectrsState {
+	blocks, err := middleware.backends.Electrs.Blocks()
+	if err != nil {
+		middleware.logger.Warning("Failed to get the block height of electrs", "error", err)
+		middleware.health.failure(backendElectrs, err, isReachable(err))
+		return state
+	}
+	middleware.health.success(backendElectrs)
+	state.Blocks = blocks
+	return state
+}
+
+// poll fetches the state of the services on the base once and updates it, which notifies the subscribed clients of
+// changes. The calls double as the health checks of the backends.
+func (middleware *Middleware) poll() {
+	if middleware.bitcoindRefreshDue() {
+		middleware.refreshBitcoind()
+	}
+	state := middleware.state.get()
+	lightning := middleware.demoCLightningRPC(state.Lightning)
+	electrs := middleware.electrsRPC(state.Electrs)
+	middleware.state.update(func(state *State) {
+		state.Lightning = lightning
+		state.Electrs = electrs
+	})
+	middleware.health.checked()
+}
+
+// rpcLoop polls the services on the base.
+func (middleware *Middleware) rpcLoop() {
+	for {
+		middleware.poll()
+		time.Sleep(pollInterval)
 	}
 }
 
 // Start gives a trigger for the handler to start the rpc event loop
-func (middleware *Middleware) Start() <-chan []byte {
+func (middleware *Middleware) Start() {
+	for address, topics := range middleware.zmqTopics() {
+		go middleware.zmqLoop(address, topics)
+	}
 	go middleware.rpcLoop()
-	return middleware.events
+	go middleware.thermalLoop()
+}
+
+// State returns the last known state of the services on the base.
+func (middleware *Middleware) State() State {
+	return middleware.state.get()
+}
+
+// Logger returns the logger of the middleware, which the handlers log with as well.
+func (middleware *Middleware) Logger() *logging.Logger {
+	return middleware.logger
+}
+
+// Metrics returns the metrics of the middleware, like the failed calls to the backends.
+func (middleware *Middleware) Metrics() *metrics.Registry {
+	return middleware.metrics
+}
+
+// Subscribe returns a channel of protobuf serialized state messages. The first messages carry a full snapshot of the
+// last known state, afterwards only patches with the changed fields are sent. Calling resync sends a patch from the
+// given version, calling unsubscribe ends the subscription.
+func (middleware *Middleware) Subscribe() (events <-chan []byte, resync func(version uint64), unsubscribe func()) {
+	return middleware.state.subscribe()
 }
 
 // SystemEnv returns a protobuf serialized system environment information object
 func (middleware *Middleware) SystemEnv() []byte {
-	middleware.mu.Lock()
-	defer middleware.mu.Unlock()
+	environment := middleware.getEnvironment()
 	outgoing := &basemessages.BitBoxBaseOut{
 		BitBoxBaseOut: &basemessages.BitBoxBaseOut_BaseSystemEnvOut{
 			BaseSystemEnvOut: &basemessages.BaseSystemEnvOut{
-				Network:        middleware.environment.Network,
-				ElectrsRPCPort: middleware.environment.ElectrsRPCPort,
+				Network:        string(environment.Network),
+				ElectrsRPCPort: environment.ElectrsRPCPort,
 			},
 		},
 	}
 	response, err := proto.Marshal(outgoing)
 	if err != nil {
-		log.Println("Just print some generic error, because this will take some time to get right")
+		middleware.logger.Error("Failed to marshal the system environment", "error", err)
+	}
+	return response
+}
+
+// Services returns a protobuf serialized list of the BitBox Base services and their systemd state.
+func (middleware *Middleware) Services() []byte {
+	services := []*basemessages.BaseServiceStatus{}
+	for _, status := range middleware.backends.System.ServicesStatus() {
+		services = append(services, &basemessages.BaseServiceStatus{
+			Name:        status.Name,
+			ActiveState: status.ActiveState,
+		})
+	}
+	outgoing := &basemessages.BitBoxBaseOut{
+		BitBoxBaseOut: &basemessages.BitBoxBaseOut_BaseServicesOut{
+			BaseServicesOut: &basemessages.BaseServicesOut{Services: services},
+		},
+	}
+	response, err := proto.Marshal(outgoing)
+	if err != nil {
+		middleware.logger.Error("Failed to marshal services", "error", err)
 	}
 	return response
 }
+
+// ConfigGet returns a protobuf serialized setting of the BitBox Base.
+func (middleware *Middleware) ConfigGet(key string) ([]byte, error) {
+	value, err := middleware.backends.System.ConfigGet(key)
+	if err != nil {
+		return nil, err
+	}
+	return marshalConfig(key, value)
+}
+
+// ConfigSet changes a setting of the BitBox Base and returns the protobuf serialized output of the change.
+func (middleware *Middleware) ConfigSet(key, value string) ([]byte, error) {
+	output, err := middleware.backends.System.ConfigSet(key, value)
+	if err != nil {
+		return nil, err
+	}
+	return marshalConfig(key, output)
+}
+
+func marshalConfig(key, value string) ([]byte, error) {
+	outgoing := &basemessages.BitBoxBaseOut{
+		BitBoxBaseOut: &basemessages.BitBoxBaseOut_BaseConfigOut{
+			BaseConfigOut: &basemessages.BaseConfigOut{Key: key, Value: value},
+		},
+	}
+	return proto.Marshal(outgoing)
+}
+
+// Logs streams the protobuf serialized journal entries selected by the query to send, see SystemBackend.Journal. If
+// structured is false, only the lines are sent. Entries are sent in batches as fast as send returns, reading the
+// journal is held up while send blocks. If the query does not follow the journal, the stream is terminated by a
+// message with EndOfStream set.
+func (middleware *Middleware) Logs(query system.JournalQuery, structured bool, stop <-chan struct{}, send func([]byte)) error {
+	sendLogs := func(logs *basemessages.BaseLogsOut) {
+		response, err := proto.Marshal(&basemessages.BitBoxBaseOut{
+			BitBoxBaseOut: &basemessages.BitBoxBaseOut_BaseLogsOut{BaseLogsOut: logs},
+		})
+		if err != nil {
+			middleware.logger.Error("Failed to marshal log lines", "error", err)
+			return
+		}
+		send(response)
+	}
+	entries := make(chan system.JournalEntry, maxLogBatch)
+	journalErr := make(chan error, 1)
+	go func() {
+		journalErr <- middleware.backends.System.Journal(query, stop, func(entry system.JournalEntry) {
+			entries <- entry
+		})
+		close(entries)
+	}()
+	for entry := range entries {
+		// Entries that are already waiting are sent along.
+		batch := []system.JournalEntry{entry}
+		size := len(entry.Message)
+	collect:
+		for len(batch) < maxLogBatch && size < maxLogBatchBytes {
+			select {
+			case entry, ok := <-entries:
+				if !ok {
+					break collect
+				}
+				batch = append(batch, entry)
+				size += len(entry.Message)
+			default:
+				break collect
+			}
+		}
+		logs := &basemessages.BaseLogsOut{}
+		for _, entry := range batch {
+			if !structured {
+				logs.Lines = append(logs.Lines, entry.Message)
+				continue
+			}
+			logs.Entries = append(logs.Entries, &basemessages.BaseLogEntry{
+				Time:     entry.Time.UnixNano() / int64(time.Microsecond),
+				Unit:     entry.Unit,
+				Priority: int32(entry.Priority),
+				Message:  entry.Message,
+			})
+		}
+		sendLogs(logs)
+	}
+	if err := <-journalErr; err != nil {
+		return err
+	}
+	sendLogs(&basemessages.BaseLogsOut{EndOfStream: true})
+	return nil
+}
@@ -1,16 +1,50 @@
 package middleware_test
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/btcsuite/btcd/rpcclient"
 	middleware "github.com/digitalbitbox/bitbox-base/middleware/src"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/backup"
 	basemessages "github.com/digitalbitbox/bitbox-base/middleware/src/messages"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/regtest"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/simulation"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/system"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/zmq/zmqtest"
 	"github.com/golang/protobuf/proto"
 	"github.com/stretchr/testify/require"
 )
 
+// testEnvironment returns the environment the tests run the middleware with.
+func testEnvironment() system.Environment {
+	return system.Environment{
+		Network:            "testnet",
+		ElectrsRPCPort:     "18442",
+		BitcoinRPCUser:     "user",
+		BitcoinRPCPassword: "password",
+		BitcoinRPCPort:     "8332",
+		LightningRPCPath:   "/home/bitcoin/.lightning",
+		DataDir:            ".base",
+	}
+}
+
 func TestMiddleware(t *testing.T) {
-	middlewareInstance := middleware.NewMiddleware("user", "password", "8332", "/home/bitcoin/.lightning", "18442", "testnet")
+	middlewareInstance := middleware.NewMiddleware(testEnvironment())
 	marshalled := middlewareInstance.SystemEnv()
 	unmarshalled := &basemessages.BitBoxBaseOut{}
 	err := proto.Unmarshal(marshalled, unmarshalled)
@@ -25,3 +59,774 @@ func TestMiddleware(t *testing.T) {
 	network := unmarshalledSystemEnv.BaseSystemEnvOut.Network
 	require.Equal(t, network, "testnet")
 }
+
+// nextState waits for the next state message of a subscription.
+func nextState(t *testing.T, events <-chan []byte) *basemessages.BaseStateOut {
+	for {
+		select {
+		case event := <-events:
+			outgoing := &basemessages.BitBoxBaseOut{}
+			require.NoError(t, proto.Unmarshal(event, outgoing))
+			if stateOut := outgoing.GetBaseStateOut(); stateOut != nil {
+				return stateOut
+			}
+		case <-time.After(time.Second):
+			require.FailNow(t, "no state received")
+		}
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	middlewareInstance := middleware.NewMiddleware(testEnvironment())
+	events, resync, unsubscribe := middlewareInstance.Subscribe()
+	defer unsubscribe()
+
+	// A new subscriber gets the full snapshot right away.
+	snapshot := nextState(t, events)
+	require.True(t, snapshot.Snapshot)
+	require.Equal(t, uint64(1), snapshot.Version)
+	require.Equal(t, "testnet", snapshot.State.System.Network)
+	require.Equal(t, "disconnected", snapshot.State.Lightning.Alias)
+	require.NotNil(t, snapshot.State.Bitcoind)
+
+	// Afterwards, only patches with the changed fields are sent.
+	middlewareInstance.UpdateState(func(state *middleware.State) {
+		state.Bitcoind.Blocks = 100
+	})
+	patch := nextState(t, events)
+	require.False(t, patch.Snapshot)
+	require.Equal(t, uint64(1), patch.BaseVersion)
+	require.Equal(t, uint64(2), patch.Version)
+	require.Equal(t, []string{"bitcoind.blocks"}, patch.Paths)
+	require.Equal(t, int64(100), patch.State.Bitcoind.Blocks)
+	require.Nil(t, patch.State.Lightning)
+	require.Nil(t, patch.State.System)
+	// The legacy middleware info follows changes of bitcoind and lightning.
+	outgoing := &basemessages.BitBoxBaseOut{}
+	require.NoError(t, proto.Unmarshal(<-events, outgoing))
+	require.Equal(t, int64(100), outgoing.GetBaseMiddlewareInfoOut().GetBlocks())
+
+	// Nothing is sent if nothing changed.
+	middlewareInstance.UpdateState(func(state *middleware.State) {
+		state.Bitcoind.Blocks = 100
+	})
+	select {
+	case <-events:
+		require.FailNow(t, "unexpected state update")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Changes are coalesced while the subscriber is not reading.
+	middlewareInstance.UpdateState(func(state *middleware.State) {
+		state.Electrs.Blocks = 99
+	})
+	middlewareInstance.UpdateState(func(state *middleware.State) {
+		state.Electrs.Blocks = 100
+		state.Lightning.Alias = "base"
+	})
+	for patch.Version != 4 {
+		patch = nextState(t, events)
+	}
+	require.Equal(t, int64(100), patch.State.Electrs.Blocks)
+	require.Equal(t, "base", patch.State.Lightning.Alias)
+	require.Nil(t, patch.State.Bitcoind)
+	require.Equal(t, int64(100), middlewareInstance.State().Electrs.Blocks)
+
+	// A resync from an older version sends the changes since that version.
+	resync(2)
+	patch = nextState(t, events)
+	require.False(t, patch.Snapshot)
+	require.Equal(t, uint64(2), patch.BaseVersion)
+	require.Equal(t, uint64(4), patch.Version)
+	require.Equal(t, []string{"electrs.blocks", "lightning.alias"}, patch.Paths)
+
+	// A resync from the current version is answered with an empty patch.
+	resync(4)
+	patch = nextState(t, events)
+	require.Equal(t, uint64(4), patch.BaseVersion)
+	require.Empty(t, patch.Paths)
+
+	// A resync from an unknown version is answered with a snapshot, and does not count as lagging behind.
+	resync(100)
+	subscribers, lag := middlewareInstance.SubscriberLag()
+	require.Equal(t, 1, subscribers)
+	require.Equal(t, uint64(0), lag)
+	patch = nextState(t, events)
+	require.True(t, patch.Snapshot)
+	require.Equal(t, uint64(4), patch.Version)
+	require.Equal(t, "base", patch.State.Lightning.Alias)
+}
+
+func TestSubscribeHistory(t *testing.T) {
+	middlewareInstance := middleware.NewMiddleware(testEnvironment())
+	events, resync, unsubscribe := middlewareInstance.Subscribe()
+	defer unsubscribe()
+	require.True(t, nextState(t, events).Snapshot)
+
+	for blocks := int64(1); blocks <= 100; blocks++ {
+		middlewareInstance.UpdateState(func(state *middleware.State) {
+			state.Electrs.Blocks = blocks
+		})
+	}
+	patch := nextState(t, events)
+	for patch.Version != 101 {
+		patch = nextState(t, events)
+	}
+
+	// Versions that are still in the history get a patch, older ones a snapshot.
+	resync(50)
+	patch = nextState(t, events)
+	require.False(t, patch.Snapshot)
+	require.Equal(t, int64(100), patch.State.Electrs.Blocks)
+	resync(2)
+	patch = nextState(t, events)
+	require.True(t, patch.Snapshot)
+	require.Equal(t, int64(100), patch.State.Electrs.Blocks)
+}
+
+// fakeBitcoind answers the rpc calls of the middleware, authenticating with a cookie.
+type fakeBitcoind struct {
+	mu            sync.Mutex
+	cookie        string
+	blocks        int64
+	bestBlockHash string
+	// hang blocks the answers until it is closed, if set.
+	hang chan struct{}
+}
+
+// startFakeBitcoind serves a fake bitcoind and returns its rpc port.
+func startFakeBitcoind(t *testing.T, bitcoind *fakeBitcoind) (*httptest.Server, string) {
+	server := httptest.NewServer(bitcoind)
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	return server, port
+}
+
+func (bitcoind *fakeBitcoind) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bitcoind.mu.Lock()
+	hang := bitcoind.hang
+	bitcoind.mu.Unlock()
+	if hang != nil {
+		<-hang
+	}
+	bitcoind.mu.Lock()
+	defer bitcoind.mu.Unlock()
+	user, password, ok := r.BasicAuth()
+	if !ok || user+":"+password != bitcoind.cookie {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var request struct {
+		Method string          `json:"method"`
+		ID     json.RawMessage `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var result interface{}
+	switch request.Method {
+	case "getblockcount":
+		result = bitcoind.blocks
+	case "getblockchaininfo":
+		result = map[string]interface{}{"blocks": bitcoind.blocks, "difficulty": 1.5, "bestblockhash": bitcoind.bestBlockHash}
+	case "getmempoolinfo":
+		result = map[string]interface{}{"size": 3}
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"result": result, "error": nil, "id": request.ID})
+}
+
+func TestBitcoindCookie(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bbb-middleware")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	cookiePath := filepath.Join(dir, ".cookie")
+	require.NoError(t, ioutil.WriteFile(cookiePath, []byte("__cookie__:first"), 0600))
+	bitcoind := &fakeBitcoind{cookie: "__cookie__:first", blocks: 100, bestBlockHash: "00ff"}
+	server, port := startFakeBitcoind(t, bitcoind)
+
+	environment := testEnvironment()
+	environment.BitcoinRPCPassword = "wrong"
+	environment.BitcoinRPCPort = port
+	environment.BitcoinRPCCookie = cookiePath
+	middlewareInstance := middleware.NewMiddleware(environment)
+	middlewareInstance.RefreshBitcoind()
+	state := middlewareInstance.State().Bitcoind
+	require.Equal(t, middleware.BitcoindState{
+		Blocks:              100,
+		Difficulty:          1.5,
+		BestBlockHash:       "00ff",
+		MempoolTransactions: 3,
+	}, state)
+
+	// A restarted bitcoind writes a new cookie, which is picked up.
+	bitcoind.mu.Lock()
+	bitcoind.cookie = "__cookie__:second"
+	bitcoind.blocks = 101
+	bitcoind.mu.Unlock()
+	require.NoError(t, ioutil.WriteFile(cookiePath, []byte("__cookie__:second"), 0600))
+	middlewareInstance.RefreshBitcoind()
+	require.Equal(t, int64(101), middlewareInstance.State().Bitcoind.Blocks)
+
+	// An unreachable bitcoind is reported instead of the stale values.
+	server.Close()
+	middlewareInstance.RefreshBitcoind()
+	require.Equal(t, middleware.BitcoindState{Unreachable: true}, middlewareInstance.State().Bitcoind)
+}
+
+func TestBitcoindTimeout(t *testing.T) {
+	bitcoind := &fakeBitcoind{cookie: "user:password", blocks: 100, hang: make(chan struct{})}
+	server, port := startFakeBitcoind(t, bitcoind)
+	defer server.Close()
+	defer close(bitcoind.hang)
+	environment := testEnvironment()
+	environment.BitcoinRPCPort = port
+	middlewareInstance := middleware.NewMiddleware(environment)
+	middlewareInstance.SetBitcoindTimeout(100 * time.Millisecond)
+
+	// Calls to a hanging bitcoind wait for their own timeout only, not for each other.
+	start := time.Now()
+	errs := make(chan error, 2)
+	go func() {
+		_, err := middlewareInstance.Backends().Bitcoin.ChainInfo()
+		errs <- err
+	}()
+	go func() {
+		_, err := middlewareInstance.Backends().Bitcoin.MempoolTransactions()
+		errs <- err
+	}()
+	for i := 0; i < 2; i++ {
+		require.EqualError(t, <-errs, "bitcoind did not answer within 100ms")
+	}
+	require.True(t, time.Since(start) < time.Second)
+
+	// bitcoind is reported unreachable until the backoff delay passed.
+	middlewareInstance.RefreshBitcoind()
+	require.Equal(t, middleware.BitcoindState{Unreachable: true}, middlewareInstance.State().Bitcoind)
+	_, err := middlewareInstance.Backends().Bitcoin.ChainInfo()
+	require.EqualError(t, err, "bitcoind unreachable")
+}
+
+func TestBitcoindUnauthorized(t *testing.T) {
+	for status, unauthorized := range map[int]bool{
+		http.StatusUnauthorized:        true,
+		http.StatusInternalServerError: false,
+	} {
+		status := status
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+		client, err := rpcclient.New(&rpcclient.ConnConfig{
+			HTTPPostMode: true,
+			DisableTLS:   true,
+			Host:         server.Listener.Addr().String(),
+			User:         "user",
+			Pass:         "password",
+		}, nil)
+		require.NoError(t, err)
+		_, err = client.GetBlockCount()
+		require.Error(t, err)
+		require.Equal(t, unauthorized, middleware.IsBitcoindUnauthorized(err), err.Error())
+		client.Shutdown()
+		server.Close()
+	}
+	require.False(t, middleware.IsBitcoindUnauthorized(nil))
+}
+
+// startFakeLightningd answers getinfo calls on a Unix socket at path.
+func startFakeLightningd(t *testing.T, path string) net.Listener {
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			var request struct {
+				ID interface{} `json:"id"`
+			}
+			if json.NewDecoder(conn).Decode(&request) == nil {
+				_ = json.NewEncoder(conn).Encode(map[string]interface{}{
+					"jsonrpc": "2.0",
+					"id":      request.ID,
+					"result":  map[string]string{"alias": "fake"},
+				})
+			}
+			_ = conn.Close()
+		}
+	}()
+	return listener
+}
+
+func TestHealth(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bbb-middleware")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	bitcoind := &fakeBitcoind{cookie: "user:password", blocks: 100, bestBlockHash: "00ff"}
+	server, port := startFakeBitcoind(t, bitcoind)
+	lightningd := startFakeLightningd(t, filepath.Join(dir, "lightning-rpc"))
+	defer lightningd.Close()
+	environment := testEnvironment()
+	environment.BitcoinRPCPort = port
+	environment.LightningRPCPath = filepath.Join(dir, "lightning-rpc")
+	middlewareInstance := middleware.NewMiddleware(environment)
+
+	// Backends that were not checked yet are down.
+	status, backends, alive := middlewareInstance.HealthSummary()
+	require.Equal(t, middleware.HealthDown, status)
+	require.Equal(t, map[string]string{"bitcoind": "down", "lightningd": "down", "electrs": "down"}, backends)
+	require.True(t, alive)
+
+	// electrs does not run in the tests.
+	middlewareInstance.Poll()
+	require.Equal(t, "fake", middlewareInstance.State().Lightning.Alias)
+	health := middlewareInstance.BackendsHealth()
+	require.Len(t, health, 3)
+	require.Equal(t, "bitcoind", health[0].Name)
+	require.Equal(t, middleware.HealthOK, health[0].Status)
+	require.False(t, health[0].LastSuccess.IsZero())
+	require.Equal(t, middleware.HealthOK, health[1].Status)
+	require.Equal(t, "electrs", health[2].Name)
+	require.Equal(t, middleware.HealthDown, health[2].Status)
+	require.True(t, health[2].LastSuccess.IsZero())
+	require.NotEmpty(t, health[2].LastError)
+
+	// A backend that failed right after a success is degraded.
+	server.Close()
+	middlewareInstance.RefreshBitcoind()
+	health = middlewareInstance.BackendsHealth()
+	require.Equal(t, middleware.HealthDegraded, health[0].Status)
+	require.NotEmpty(t, health[0].LastError)
+	require.True(t, middlewareInstance.Alive())
+}
+
+func TestBitcoindZMQ(t *testing.T) {
+	publisher, err := zmqtest.NewPublisher()
+	require.NoError(t, err)
+	defer publisher.Close()
+	bitcoind := &fakeBitcoind{cookie: "user:password", blocks: 100, bestBlockHash: "00ff"}
+	server, port := startFakeBitcoind(t, bitcoind)
+	defer server.Close()
+
+	environment := testEnvironment()
+	environment.BitcoinRPCPort = port
+	environment.BitcoinZMQHashblock = publisher.Address()
+	environment.BitcoinZMQRawtx = publisher.Address()
+	middlewareInstance := middleware.NewMiddleware(environment)
+	middlewareInstance.SetZMQIntervals(0, time.Hour)
+	events, _, unsubscribe := middlewareInstance.Subscribe()
+	defer unsubscribe()
+	require.True(t, nextState(t, events).Snapshot)
+	middlewareInstance.Start()
+	require.True(t, publisher.WaitSubscribed("hashblock", time.Second))
+	require.True(t, publisher.WaitSubscribed("rawtx", time.Second))
+	patch := nextState(t, events)
+	for patch.State.GetBitcoind().GetBlocks() != 100 {
+		patch = nextState(t, events)
+	}
+
+	// A new block is pushed to the clients right away.
+	hash := bytes.Repeat([]byte{0xab}, 32)
+	bitcoind.mu.Lock()
+	bitcoind.blocks = 101
+	bitcoind.bestBlockHash = hex.EncodeToString(hash)
+	bitcoind.mu.Unlock()
+	publisher.Publish("hashblock", hash)
+	patch = nextState(t, events)
+	require.Contains(t, patch.Paths, "bitcoind.bestBlockHash")
+	require.Equal(t, hex.EncodeToString(hash), patch.State.Bitcoind.BestBlockHash)
+	for patch.State.GetBitcoind().GetBlocks() != 101 {
+		patch = nextState(t, events)
+	}
+
+	// bitcoind is still polled while subscribed, in case a notification is missed.
+	bitcoind.mu.Lock()
+	bitcoind.blocks = 102
+	bitcoind.mu.Unlock()
+	middlewareInstance.Poll()
+	for patch.State.GetBitcoind().GetBlocks() != 102 {
+		patch = nextState(t, events)
+	}
+}
+
+// TestBitcoindZMQResubscribes checks that a subscription is renewed when it stays silent, as its connection may have
+// died without being closed.
+func TestBitcoindZMQResubscribes(t *testing.T) {
+	publisher, err := zmqtest.NewPublisher()
+	require.NoError(t, err)
+	defer publisher.Close()
+	server, port := startFakeBitcoind(t, &fakeBitcoind{cookie: "user:password", blocks: 100, bestBlockHash: "00ff"})
+	defer server.Close()
+
+	environment := testEnvironment()
+	environment.BitcoinRPCPort = port
+	environment.BitcoinZMQHashblock = publisher.Address()
+	middlewareInstance := middleware.NewMiddleware(environment)
+	middlewareInstance.SetZMQIntervals(time.Minute, 50*time.Millisecond)
+	middlewareInstance.Start()
+	require.True(t, publisher.WaitSubscribed("hashblock", time.Second))
+	publisher.Forget()
+	require.True(t, publisher.WaitSubscribed("hashblock", time.Second))
+}
+
+// TestRegtest runs the middleware against a real bitcoind, and lightningd if available, on regtest. It is skipped if
+// the binaries are not in the PATH.
+func TestRegtest(t *testing.T) {
+	if testing.Short() || !regtest.Available(false) {
+		t.Skip("bitcoind not available")
+	}
+	dir, err := ioutil.TempDir("", "bbb-regtest")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	lightning := regtest.Available(true)
+	node, err := regtest.Start(dir, lightning)
+	require.NoError(t, err)
+	defer node.Stop()
+	require.NoError(t, node.Generate(101))
+
+	middlewareInstance := middleware.NewMiddleware(node.Environment())
+	middlewareInstance.RefreshBitcoind()
+	require.Equal(t, int64(101), middlewareInstance.State().Bitcoind.Blocks)
+	require.Equal(t, middleware.HealthOK, middlewareInstance.BackendsHealth()[0].Status)
+	if lightning {
+		middlewareInstance.Poll()
+		require.Equal(t, middleware.HealthOK, middlewareInstance.BackendsHealth()[1].Status)
+	}
+}
+
+func TestSimulation(t *testing.T) {
+	simulated := simulation.New(system.NetworkRegtest)
+	middlewareInstance := middleware.NewMiddlewareWithBackends(
+		system.Environment{Network: system.NetworkRegtest}, simulated.Backends())
+	middlewareInstance.Poll()
+	state := middlewareInstance.State()
+	require.Equal(t, int64(100), state.Bitcoind.Blocks)
+	require.Equal(t, int64(100), state.Electrs.Blocks)
+	require.Equal(t, "simulation", state.Lightning.Alias)
+	status, _, _ := middlewareInstance.HealthSummary()
+	require.Equal(t, middleware.HealthOK, status)
+
+	steps, err := simulation.ParseScript(strings.NewReader(`0s mine 2
+0s transactions 3
+0s open-channel 2
+0s degrade bitcoind Loading block index
+0s fail electrs connection refused
+`))
+	require.NoError(t, err)
+	simulated.Run(steps, false, nil)
+	middlewareInstance.Poll()
+	state = middlewareInstance.State()
+	require.Equal(t, int64(2), state.Lightning.Channels)
+	// The last values of bitcoind and electrs are kept while they fail.
+	require.Equal(t, int64(100), state.Bitcoind.Blocks)
+	require.False(t, state.Bitcoind.Unreachable)
+	require.Equal(t, int64(100), state.Electrs.Blocks)
+	health := middlewareInstance.BackendsHealth()
+	require.Equal(t, middleware.HealthDegraded, health[0].Status)
+	require.Equal(t, "Loading block index", health[0].LastError)
+	require.Equal(t, "connection refused", health[2].LastError)
+
+	simulated.Recover("bitcoind")
+	simulated.Recover("electrs")
+	middlewareInstance.Poll()
+	state = middlewareInstance.State()
+	require.Equal(t, int64(102), state.Bitcoind.Blocks)
+	require.Equal(t, int64(3), state.Bitcoind.MempoolTransactions)
+	require.Equal(t, int64(102), state.Electrs.Blocks)
+	status, _, _ = middlewareInstance.HealthSummary()
+	require.Equal(t, middleware.HealthOK, status)
+}
+
+func TestSupportBundle(t *testing.T) {
+	simulated := simulation.New(system.NetworkTestnet)
+	environment := testEnvironment()
+	environment.BitcoinRPCPassword = "correct-horse"
+	middlewareInstance := middleware.NewMiddlewareWithBackends(environment, simulated.Backends())
+	simulated.SetThermal(52.5, 180)
+	require.NoError(t, simulated.Fail("electrs", "connection refused, rpcpassword=hunter2", false))
+	require.NoError(t, simulated.Fail("lightningd", "bitcoin-cli -rpcpassword correct-horse failed", true))
+	middlewareInstance.Poll()
+
+	var bundle bytes.Buffer
+	require.NoError(t, middlewareInstance.SupportBundle(&bundle))
+	gzipReader, err := gzip.NewReader(&bundle)
+	require.NoError(t, err)
+	archive := tar.NewReader(gzipReader)
+	files := make(map[string]string)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data, err := ioutil.ReadAll(archive)
+		require.NoError(t, err)
+		files[header.Name] = string(data)
+	}
+
+	var version struct{ Version string }
+	require.NoError(t, json.Unmarshal([]byte(files["version.json"]), &version))
+	require.Equal(t, middleware.Version, version.Version)
+	var config map[string]string
+	require.NoError(t, json.Unmarshal([]byte(files["config.json"]), &config))
+	require.Equal(t, "testnet", config["bitcoin_network"])
+	require.Equal(t, "false", config["tor_ssh"])
+	require.NotContains(t, config, "root_pw")
+	require.Contains(t, files["services.json"], `"activeState": "failed"`)
+	require.Contains(t, files["status.json"], `"lastError": "connection refused, rpcpassword=[redacted]"`)
+	require.Contains(t, files["disks.json"], `"path": "/mnt/ssd"`)
+	var thermal []system.ThermalSample
+	require.NoError(t, json.Unmarshal([]byte(files["thermal.json"]), &thermal))
+	require.Len(t, thermal, 1)
+	require.Equal(t, 52.5, thermal[0].Temperature)
+	require.Equal(t, 180, thermal[0].Fan)
+	for _, unit := range system.LogUnits() {
+		require.Contains(t, files, "logs/"+unit+".log")
+	}
+	require.Contains(t, files["logs/electrs.log"], "<3> connection refused, rpcpassword=[redacted]")
+	for name, data := range files {
+		require.NotContains(t, data, "hunter2", name)
+		require.NotContains(t, data, "correct-horse", name)
+	}
+}
+
+func TestLightningBackup(t *testing.T) {
+	simulated := simulation.New(system.NetworkTestnet)
+	middlewareInstance := middleware.NewMiddlewareWithBackends(testEnvironment(), simulated.Backends())
+	simulated.OpenChannels(2)
+
+	_, err := middlewareInstance.LightningBackup("short")
+	require.Error(t, err)
+	archive, err := middlewareInstance.LightningBackup("correct horse battery")
+	require.NoError(t, err)
+	require.Equal(t, backup.KindLightning, archive.Manifest.Kind)
+	require.Equal(t, "testnet", archive.Manifest.Network)
+	require.Regexp(t, `^lightning-testnet-\d{8}-\d{6}\.backup$`, archive.Filename)
+	node, err := simulated.Backends().Lightning.Node()
+	require.NoError(t, err)
+	require.Equal(t, node.ID, archive.Manifest.NodeID)
+
+	// The backup drive is only used if one is mounted.
+	_, err = middlewareInstance.SaveBackup(archive)
+	require.Error(t, err)
+	dir, err := ioutil.TempDir("", "bbb-backup-drive")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	simulated.SetBackupDrive(dir)
+	path, err := middlewareInstance.SaveBackup(archive)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, archive.Filename), path)
+	data, err := middlewareInstance.ReadBackup(archive.Filename)
+	require.NoError(t, err)
+	require.Equal(t, archive.Data, data)
+	_, err = middlewareInstance.ReadBackup("../" + archive.Filename)
+	require.Error(t, err)
+
+	// A node with channels is not replaced.
+	_, err = middlewareInstance.LightningRestore("correct horse battery", archive.Data)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "2 channels")
+
+	fresh := simulation.New(system.NetworkTestnet)
+	freshMiddleware := middleware.NewMiddlewareWithBackends(testEnvironment(), fresh.Backends())
+	_, err = freshMiddleware.LightningRestore("wrong passphrase", archive.Data)
+	require.Equal(t, backup.ErrDecrypt, err)
+	require.NoError(t, fresh.Fail("lightningd", "connection refused", false))
+	_, err = freshMiddleware.LightningRestore("correct horse battery", archive.Data)
+	require.Error(t, err)
+	fresh.Recover("lightningd")
+	manifest, err := freshMiddleware.LightningRestore("correct horse battery", archive.Data)
+	require.NoError(t, err)
+	require.Equal(t, archive.Manifest.NodeID, manifest.NodeID)
+	freshMiddleware.Poll()
+	require.Equal(t, int64(2), freshMiddleware.State().Lightning.Channels)
+
+	// Backups of another network are refused.
+	regtestSimulation := simulation.New(system.NetworkRegtest)
+	regtestMiddleware := middleware.NewMiddlewareWithBackends(
+		system.Environment{Network: system.NetworkRegtest}, regtestSimulation.Backends())
+	_, err = regtestMiddleware.LightningRestore("correct horse battery", archive.Data)
+	require.EqualError(t, err, "the backup is of a testnet node, but the Base runs on regtest")
+}
+
+// testKeystore returns a noise keystore with a static keypair derived from seed and one paired client.
+func testKeystore(t *testing.T, seed byte) []byte {
+	keystore, err := json.Marshal(map[string]interface{}{
+		"appNoiseStaticKeypair": map[string][]byte{
+			"private": bytes.Repeat([]byte{seed}, 32),
+			"public":  bytes.Repeat([]byte{seed + 1}, 32),
+		},
+		"pairedClients": []map[string]interface{}{{"pubkey": bytes.Repeat([]byte{3}, 32), "role": "owner"}},
+	})
+	require.NoError(t, err)
+	return keystore
+}
+
+func TestConfigBackup(t *testing.T) {
+	simulated := simulation.New(system.NetworkTestnet)
+	middlewareInstance := middleware.NewMiddlewareWithBackends(testEnvironment(), simulated.Backends())
+	_, err := simulated.Backends().System.ConfigSet("tor_ssh", "true")
+	require.NoError(t, err)
+	_, err = simulated.Backends().System.ConfigSet("hostname", "mybase")
+	require.NoError(t, err)
+	keystore := testKeystore(t, 1)
+
+	_, err = middlewareInstance.ConfigBackup("correct horse battery", []byte(`{}`))
+	require.Error(t, err)
+	archive, err := middlewareInstance.ConfigBackup("correct horse battery", keystore)
+	require.NoError(t, err)
+	require.Equal(t, backup.KindConfig, archive.Manifest.Kind)
+	require.Equal(t, hex.EncodeToString(backup.SigningKey(bytes.Repeat([]byte{1}, 32)).PubKey().SerializeCompressed()),
+		archive.Manifest.Signer)
+	require.Regexp(t, `^config-testnet-\d{8}-\d{6}\.backup$`, archive.Filename)
+	systemFiles, err := simulated.Backends().System.ConfigFiles()
+	require.NoError(t, err)
+
+	// The backup is restored onto a fresh Base.
+	fresh := simulation.New(system.NetworkTestnet)
+	freshMiddleware := middleware.NewMiddlewareWithBackends(testEnvironment(), fresh.Backends())
+	signer := archive.Manifest.Signer
+	_, err = freshMiddleware.ConfigRestore("wrong passphrase", signer, archive.Data, nil)
+	require.Equal(t, backup.ErrDecrypt, err)
+	_, err = freshMiddleware.ConfigRestore("correct horse battery", "", archive.Data, nil)
+	require.EqualError(t, err, "the signer of the backup, shown when it was made, must be confirmed")
+	var restoredKeystore []byte
+	manifest, err := freshMiddleware.ConfigRestore("correct horse battery", signer, archive.Data, func(keystore []byte) error {
+		restoredKeystore = keystore
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, archive.Manifest.Signer, manifest.Signer)
+	require.Equal(t, keystore, restoredKeystore)
+	value, err := fresh.Backends().System.ConfigGet("tor_ssh")
+	require.NoError(t, err)
+	require.Equal(t, "true", value)
+	value, err = fresh.Backends().System.ConfigGet("hostname")
+	require.NoError(t, err)
+	require.Equal(t, "mybase", value)
+	restoredFiles, err := fresh.Backends().System.ConfigFiles()
+	require.NoError(t, err)
+	require.Equal(t, systemFiles, restoredFiles)
+
+	// Backups signed with another key than the one of the keystore they hold are refused.
+	files := []backup.File{
+		{Name: "settings.json", Data: []byte(`{}`)},
+		{Name: "middleware/base.json", Data: keystore},
+	}
+	otherKey := backup.SigningKey([]byte("other"))
+	otherSigner := hex.EncodeToString(otherKey.PubKey().SerializeCompressed())
+	forged, err := backup.SealSigned("correct horse battery",
+		backup.Manifest{Kind: backup.KindConfig, Network: "testnet"}, files, otherKey)
+	require.NoError(t, err)
+	_, err = freshMiddleware.ConfigRestore("correct horse battery", otherSigner, forged, nil)
+	require.EqualError(t, err, "the backup was not signed by the Base whose keys it holds")
+	unsigned, err := backup.Seal("correct horse battery",
+		backup.Manifest{Kind: backup.KindConfig, Network: "testnet"}, files)
+	require.NoError(t, err)
+	_, err = freshMiddleware.ConfigRestore("correct horse battery", signer, unsigned, nil)
+	require.EqualError(t, err, "the configuration backup is not signed")
+
+	// A consistent backup made with another keystore is refused unless the owner confirmed its signer.
+	otherKeystore := testKeystore(t, 2)
+	otherArchive, err := middlewareInstance.ConfigBackup("correct horse battery", otherKeystore)
+	require.NoError(t, err)
+	_, err = freshMiddleware.ConfigRestore("correct horse battery", signer, otherArchive.Data, nil)
+	require.EqualError(t, err, "the backup was signed by "+otherArchive.Manifest.Signer+", not by the confirmed signer")
+
+	// Lightning backups are no configuration backups.
+	lightningArchive, err := middlewareInstance.LightningBackup("correct horse battery")
+	require.NoError(t, err)
+	_, err = freshMiddleware.ConfigRestore("correct horse battery", signer, lightningArchive.Data, nil)
+	require.EqualError(t, err, "not a configuration backup, but a lightning backup")
+}
+
+func TestFactoryReset(t *testing.T) {
+	simulated := simulation.New(system.NetworkTestnet)
+	middlewareInstance := middleware.NewMiddlewareWithBackends(testEnvironment(), simulated.Backends())
+	simulated.OpenChannels(2)
+	node, err := simulated.Backends().Lightning.Node()
+	require.NoError(t, err)
+
+	require.Len(t, middlewareInstance.FactoryResetWarnings(system.ResetOptions{}), 1)
+	warnings := middlewareInstance.FactoryResetWarnings(system.ResetOptions{Blockchain: true, Lightning: true})
+	require.Len(t, warnings, 3)
+	require.Contains(t, warnings[2], node.ID+" with 2 channels")
+
+	// The keystore is only wiped once the system was reset.
+	wiped := false
+	require.NoError(t, middlewareInstance.FactoryReset(system.ResetOptions{Lightning: true}, func() error {
+		wiped = true
+		return nil
+	}))
+	require.True(t, wiped)
+	files, err := simulated.Backends().System.ConfigFiles()
+	require.NoError(t, err)
+	require.Empty(t, files)
+	value, err := simulated.Backends().System.ConfigGet("hostname")
+	require.NoError(t, err)
+	require.Equal(t, "false", value)
+	chainInfo, err := simulated.Backends().Bitcoin.ChainInfo()
+	require.NoError(t, err)
+	require.Equal(t, int64(100), chainInfo.Blocks)
+	wipedNode, err := simulated.Backends().Lightning.Node()
+	require.NoError(t, err)
+	require.NotEqual(t, node.ID, wipedNode.ID)
+	require.Equal(t, int64(0), wipedNode.Channels)
+	for _, service := range simulated.Backends().System.ServicesStatus() {
+		if service.Name == "lightningd" {
+			require.Equal(t, "inactive", service.ActiveState)
+		}
+	}
+
+}
+
+func TestPower(t *testing.T) {
+	simulated := simulation.New(system.NetworkTestnet)
+	middlewareInstance := middleware.NewMiddlewareWithBackends(testEnvironment(), simulated.Backends())
+	serviceStates := func() map[string]string {
+		states := map[string]string{}
+		for _, service := range simulated.Backends().System.ServicesStatus() {
+			states[service.Name] = service.ActiveState
+		}
+		return states
+	}
+
+	// lightningd is stopped before bitcoind.
+	stopped := []string{}
+	require.NoError(t, middlewareInstance.StopServices(func(service string) {
+		stopped = append(stopped, service)
+	}))
+	require.Equal(t, []string{"lightningd", "electrs", "bitcoind"}, stopped)
+	states := serviceStates()
+	require.Equal(t, "inactive", states["bitcoind"])
+	require.Equal(t, "inactive", states["lightningd"])
+	require.Equal(t, "active", states["nginx"])
+
+	require.NoError(t, middlewareInstance.Power(system.PowerReboot))
+	require.Equal(t, "active", serviceStates()["bitcoind"])
+
+	// If a service does not stop, the services stopped before are started again.
+	require.NoError(t, simulated.Fail(simulation.Electrs, "timed out", true))
+	err := middlewareInstance.StopServices(func(string) {})
+	require.EqualError(t, err, "stopping electrs failed: timed out")
+	require.Equal(t, "active", serviceStates()["lightningd"])
+	require.Equal(t, "active", serviceStates()["bitcoind"])
+	simulated.Recover(simulation.Electrs)
+
+	// If the Base does not power down, the stopped services are started again.
+	simulated.FailPower("transaction is destructive")
+	require.NoError(t, middlewareInstance.StopServices(func(string) {}))
+	err = middlewareInstance.Power(system.PowerReboot)
+	require.EqualError(t, err, "reboot failed: transaction is destructive")
+	require.Equal(t, "active", serviceStates()["lightningd"])
+	require.Equal(t, "active", serviceStates()["electrs"])
+	require.Equal(t, "active", serviceStates()["bitcoind"])
+	simulated.FailPower("")
+
+	require.NoError(t, middlewareInstance.Power(system.PowerShutdown))
+	require.Equal(t, []system.PowerAction{system.PowerReboot, system.PowerShutdown}, simulated.PowerActions())
+
+	_, err = system.ParsePowerAction("halt")
+	require.Error(t, err)
+}
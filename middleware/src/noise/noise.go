@@ -9,28 +9,37 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
+	"github.com/digitalbitbox/bitbox-base/middleware/src/transport"
+
 	"github.com/flynn/noise"
-	"github.com/gorilla/websocket"
 )
 
 const (
-	opICanHasHandShaek   = "h"
-	responseSuccess      = "\x00"
-	responseNeedsPairing = "\x01"
+	opICanHasHandShaek       = "h"
+	opICanHasIKHandShaek     = "i"
+	responseSuccess          = "\x00"
+	responseNeedsPairing     = "\x01"
+	responseNeedsXXHandshake = "\x02"
 )
 
+// errIKHandshakeRejected is returned by performIKHandshake if the client needs to fall back to the XX handshake.
+var errIKHandshakeRejected = errors.New("noise IK handshake rejected, client needs to perform the XX handshake")
+
 type NoiseConfig struct {
-	clientStaticPubkey          []byte
-	channelHash                 string
-	sendCipher, receiveCipher   *noise.CipherState
+	clientStaticPubkey        []byte
+	channelHash               string
+	sendCipher, receiveCipher *noise.CipherState
+	initialized               bool
+	dataDir                   string
+
+	// mu guards pairingVerificationRequired, which the reading loop sets and the writing loop reads.
+	mu                          sync.Mutex
 	pairingVerificationRequired bool
-	initialized                 bool
-	dataDir                     string
 }
 
 func NewNoiseConfig(dataDir string) *NoiseConfig {
@@ -41,25 +50,51 @@ func NewNoiseConfig(dataDir string) *NoiseConfig {
 	return noise
 }
 
-// initializeNoise sets up a new noise connection. First a fresh keypair is generated if none is locally found.
-// Afterwards a XX handshake is performed. This is a three part handshake required to authenticate both parties.
-// The resulting pairing code is then displayed to the user to check if it matches what is displayed on the other party's device.
-func (noiseConfig *NoiseConfig) InitializeNoise(ws *websocket.Conn) error {
+// InitializeNoise sets up a new noise connection. First a fresh keypair is generated if none is locally found.
+// Clients that are not paired yet ask for the XX handshake with the "h" op. Clients that are already paired and
+// know the static pubkey of the BitBox Base may instead send the "i" op, directly followed by the first message
+// of a noise IK handshake, which saves a round trip. If the IK handshake is rejected, the client can only fall back to
+// the XX handshake on the same connection.
+func (noiseConfig *NoiseConfig) InitializeNoise(conn transport.Conn) error {
 	cipherSuite := noise.NewCipherSuite(noise.DH25519, noise.CipherChaChaPoly, noise.HashSHA256)
 	keypair := noiseConfig.getMiddlewareNoiseStaticKeypair()
 	if keypair == nil {
-		if true {
-			kp, err := cipherSuite.GenerateKeypair(rand.Reader)
-			if err != nil {
-				return errors.New("failed to generate a new noise keypair")
-			}
-			keypair = &kp
+		kp, err := cipherSuite.GenerateKeypair(rand.Reader)
+		if err != nil {
+			return errors.New("failed to generate a new noise keypair")
+		}
+		keypair = &kp
 
-			if err := noiseConfig.setMiddlewareNoiseStaticKeypair(keypair); err != nil {
-				log.Println("could not store app noise static keypair")
-			}
+		// Clients pin the static key, so a key that is not stored must not be used.
+		if err := noiseConfig.setMiddlewareNoiseStaticKeypair(keypair); err != nil {
+			return errors.New("could not store the noise static keypair: " + err.Error())
+		}
+	}
+
+	request, err := conn.ReadMessage()
+	if err != nil {
+		return errors.New("connection failed to read noise handshake request")
+	}
+	if len(request) > 1 && string(request[:1]) == opICanHasIKHandShaek {
+		err = noiseConfig.performIKHandshake(conn, cipherSuite, keypair, request[1:])
+		if err != errIKHandshakeRejected {
+			return err
 		}
+		// Only one IK attempt is allowed per connection, afterwards the client has to fall back to XX.
+		request, err = conn.ReadMessage()
+		if err != nil {
+			return errors.New("connection failed to read noise handshake request")
+		}
+	}
+	if string(request) != opICanHasHandShaek {
+		return errors.New("initial response bytes did not match what we were expecting")
 	}
+	return noiseConfig.performXXHandshake(conn, cipherSuite, keypair)
+}
+
+// performXXHandshake performs the three part noise XX handshake required to authenticate both parties.
+// The resulting pairing code is then displayed to the user to check if it matches what is displayed on the other party's device.
+func (noiseConfig *NoiseConfig) performXXHandshake(conn transport.Conn, cipherSuite noise.CipherSuite, keypair *noise.DHKey) error {
 	handshake, err := noise.NewHandshakeState(noise.Config{
 		CipherSuite:   cipherSuite,
 		Random:        rand.Reader,
@@ -72,23 +107,15 @@ func (noiseConfig *NoiseConfig) InitializeNoise(ws *websocket.Conn) error {
 		return errors.New("failed to generate a new noise handshake state for the wallet app communication with the BitBox Base")
 	}
 
-	// check the websocket connection
-	_, responseBytes, err := ws.ReadMessage()
-	if err != nil {
-		return errors.New("websocket failed to read noise handshake request")
-	}
-	if string(responseBytes) != string(opICanHasHandShaek) {
-		return errors.New("initial response bytes did not match what we were expecting")
-	}
-	err = ws.WriteMessage(1, []byte(responseSuccess))
+	err = conn.WriteMessage([]byte(responseSuccess))
 	if err != nil {
-		return errors.New("websocket failed to write the noise handshake request response")
+		return errors.New("connection failed to write the noise handshake request response")
 	}
 
 	// do 3 part noise 'XX' handshake
-	_, responseBytes, err = ws.ReadMessage()
+	responseBytes, err := conn.ReadMessage()
 	if err != nil {
-		return errors.New("websocket failed to read first noise handshake message")
+		return errors.New("connection failed to read first noise handshake message")
 	}
 	_, _, _, err = handshake.ReadMessage(nil, responseBytes)
 	if err != nil {
@@ -98,13 +125,13 @@ func (noiseConfig *NoiseConfig) InitializeNoise(ws *websocket.Conn) error {
 	if err != nil {
 		return errors.New("noise failed to write second noise handshake message")
 	}
-	err = ws.WriteMessage(1, msg)
+	err = conn.WriteMessage(msg)
 	if err != nil {
-		return errors.New("websocket failed to write second noise handshake message")
+		return errors.New("connection failed to write second noise handshake message")
 	}
-	_, responseBytes, err = ws.ReadMessage()
+	responseBytes, err = conn.ReadMessage()
 	if err != nil {
-		return errors.New("websocket failed to read third noise handshake message")
+		return errors.New("connection failed to read third noise handshake message")
 	}
 	_, noiseConfig.sendCipher, noiseConfig.receiveCipher, err = handshake.ReadMessage(nil, responseBytes)
 	if err != nil {
@@ -116,22 +143,74 @@ func (noiseConfig *NoiseConfig) InitializeNoise(ws *websocket.Conn) error {
 	if len(noiseConfig.clientStaticPubkey) != 32 {
 		return errors.New("expected 32 byte remote static pubkey")
 	}
-	noiseConfig.pairingVerificationRequired = !noiseConfig.containsClientStaticPubkey(noiseConfig.clientStaticPubkey)
+	pairingVerificationRequired := !noiseConfig.containsClientStaticPubkey(noiseConfig.clientStaticPubkey)
+	noiseConfig.setPairingVerificationRequired(pairingVerificationRequired)
 
 	// If the user has not authenticated, the connected client needs to ask for verification before being able to interact with the base
-	if noiseConfig.pairingVerificationRequired {
-		err = ws.WriteMessage(websocket.BinaryMessage, []byte(responseNeedsPairing))
+	if pairingVerificationRequired {
+		err = conn.WriteMessage([]byte(responseNeedsPairing))
 		if err != nil {
-			return errors.New("websocket failed to write second noise handshake message")
+			return errors.New("connection failed to write second noise handshake message")
 		}
 
 	} else {
-		err = ws.WriteMessage(websocket.BinaryMessage, []byte(responseSuccess))
+		err = conn.WriteMessage([]byte(responseSuccess))
 		if err != nil {
-			return errors.New("websocket failed to write second noise handshake message")
+			return errors.New("connection failed to write second noise handshake message")
 		}
 
 	}
+	noiseConfig.setChannelHash(handshake)
+	noiseConfig.initialized = true
+	return nil
+}
+
+// performIKHandshake performs the two part noise IK handshake for clients that already know the static pubkey of the
+// BitBox Base. The first handshake message is sent by the client together with the "i" op. Since the client static
+// pubkey is transmitted in the first message, the pairing status is checked before responding. Unpaired clients and
+// clients using an outdated static pubkey of the base get a responseNeedsXXHandshake and errIKHandshakeRejected is
+// returned, so that the client can continue with the XX handshake.
+func (noiseConfig *NoiseConfig) performIKHandshake(conn transport.Conn, cipherSuite noise.CipherSuite, keypair *noise.DHKey, firstMessage []byte) error {
+	handshake, err := noise.NewHandshakeState(noise.Config{
+		CipherSuite:   cipherSuite,
+		Random:        rand.Reader,
+		Pattern:       noise.HandshakeIK,
+		StaticKeypair: *keypair,
+		Prologue:      []byte("Noise_IK_25519_ChaChaPoly_SHA256"),
+		Initiator:     false,
+	})
+	if err != nil {
+		return errors.New("failed to generate a new noise IK handshake state for the wallet app communication with the BitBox Base")
+	}
+
+	_, _, _, err = handshake.ReadMessage(nil, firstMessage)
+	if err != nil || !noiseConfig.containsClientStaticPubkey(handshake.PeerStatic()) {
+		err = conn.WriteMessage([]byte(responseNeedsXXHandshake))
+		if err != nil {
+			return errors.New("connection failed to write the noise IK handshake rejection")
+		}
+		return errIKHandshakeRejected
+	}
+
+	// The pairing status is sent as the payload of the second and last handshake message.
+	msg, sendCipher, receiveCipher, err := handshake.WriteMessage(nil, []byte(responseSuccess))
+	if err != nil {
+		return errors.New("noise failed to write second noise IK handshake message")
+	}
+	err = conn.WriteMessage(msg)
+	if err != nil {
+		return errors.New("connection failed to write second noise IK handshake message")
+	}
+	noiseConfig.sendCipher, noiseConfig.receiveCipher = sendCipher, receiveCipher
+	noiseConfig.clientStaticPubkey = handshake.PeerStatic()
+	noiseConfig.setPairingVerificationRequired(false)
+	noiseConfig.setChannelHash(handshake)
+	noiseConfig.initialized = true
+	return nil
+}
+
+// setChannelHash derives the pairing code that is shown to the user from the handshake's channel binding.
+func (noiseConfig *NoiseConfig) setChannelHash(handshake *noise.HandshakeState) {
 	channelHashBase32 := base32.StdEncoding.EncodeToString(handshake.ChannelBinding())
 	noiseConfig.channelHash = fmt.Sprintf(
 		"%s %s\n%s %s",
@@ -139,27 +218,25 @@ func (noiseConfig *NoiseConfig) InitializeNoise(ws *websocket.Conn) error {
 		channelHashBase32[5:10],
 		channelHashBase32[10:15],
 		channelHashBase32[15:20])
-	noiseConfig.initialized = true
-	return nil
 }
 
-func (noiseConfig *NoiseConfig) CheckVerification() []byte {
+// CheckVerification verifies the pairing and returns the response for the client. The pairing is verified even if the
+// pubkey of the client could not be stored, which is returned as an error, the client then has to pair again on the
+// next connection.
+func (noiseConfig *NoiseConfig) CheckVerification() ([]byte, error) {
 	// TODO(TheCharlatan) At this point, the channel Hash should be displayed on the screen, with a blocking call.
 	// For now, just add a dummy timer, since we do not have a screen yet, and make every verification a success.
 	time.Sleep(2 * time.Second)
 	err := noiseConfig.addClientStaticPubkey(noiseConfig.clientStaticPubkey)
-	if err != nil {
-		log.Println("Pairing Successful, but unable to write baseNoiseStaticPubkey to file")
-	}
-	noiseConfig.pairingVerificationRequired = false
-	return []byte(responseSuccess)
+	noiseConfig.setPairingVerificationRequired(false)
+	return []byte(responseSuccess), err
 }
 
 func (noiseConfig *NoiseConfig) Encrypt(message []byte) []byte {
 	if !noiseConfig.initialized {
 		return []byte("Error: noise session not initialized")
 	}
-	if noiseConfig.pairingVerificationRequired {
+	if noiseConfig.PairingVerificationRequired() {
 		message = []byte("Error: encrypted connection not verified")
 	}
 	return noiseConfig.sendCipher.Encrypt(nil, nil, message)
@@ -169,7 +246,7 @@ func (noiseConfig *NoiseConfig) Decrypt(message []byte) ([]byte, error) {
 	if !noiseConfig.initialized {
 		return []byte(""), errors.New("noise not initialized")
 	}
-	if noiseConfig.pairingVerificationRequired {
+	if noiseConfig.PairingVerificationRequired() {
 		return []byte(""), errors.New("pairing verification has not been done with this client")
 	}
 	return noiseConfig.receiveCipher.Decrypt(nil, nil, message)
@@ -182,9 +259,47 @@ type noiseKeypair struct {
 	Public  []byte `json:"public"`
 }
 
+// The roles of paired clients. Owners may do everything, including changing the roles of other clients. Operators may
+// additionally read the settings and logs, read-only clients can only view the status of the Base.
+const (
+	RoleOwner    = "owner"
+	RoleOperator = "operator"
+	RoleReadOnly = "read-only"
+)
+
+// Roles returns the roles of paired clients, from the least to the most privileged.
+func Roles() []string {
+	return []string{RoleReadOnly, RoleOperator, RoleOwner}
+}
+
+// roleRank returns the privilege level of a role, -1 for unknown roles.
+func roleRank(role string) int {
+	for rank, known := range Roles() {
+		if role == known {
+			return rank
+		}
+	}
+	return -1
+}
+
+// RoleAllows returns true if a client with the given role may do what requires the required role.
+func RoleAllows(role, required string) bool {
+	rank := roleRank(role)
+	return rank >= 0 && rank >= roleRank(required)
+}
+
+// PairedClient is the record of a paired client.
+type PairedClient struct {
+	Pubkey []byte `json:"pubkey"`
+	Role   string `json:"role"`
+}
+
 type configuration struct {
 	MiddlewareNoiseStaticKeypair *noiseKeypair `json:"appNoiseStaticKeypair"`
-	ClientNoiseStaticPubkeys     [][]byte      `json:"deviceNoiseStaticPubkeys"`
+	// ClientNoiseStaticPubkeys are the clients paired before roles were introduced. They are migrated to
+	// PairedClients as owners, as they used to have full power.
+	ClientNoiseStaticPubkeys [][]byte       `json:"deviceNoiseStaticPubkeys,omitempty"`
+	PairedClients            []PairedClient `json:"pairedClients"`
 }
 
 func (noiseConfig *NoiseConfig) readConfig() *configuration {
@@ -196,31 +311,122 @@ func (noiseConfig *NoiseConfig) readConfig() *configuration {
 	if err := configFile.ReadJSON(&conf); err != nil {
 		return &configuration{}
 	}
+	for _, pubkey := range conf.ClientNoiseStaticPubkeys {
+		if conf.pairedClient(pubkey) == nil {
+			conf.PairedClients = append(conf.PairedClients, PairedClient{Pubkey: pubkey, Role: RoleOwner})
+		}
+	}
+	conf.ClientNoiseStaticPubkeys = nil
 	return &conf
 }
 
+// pairedClient returns the record of a paired client, nil if it is not paired.
+func (conf *configuration) pairedClient(pubkey []byte) *PairedClient {
+	for i := range conf.PairedClients {
+		if bytes.Equal(conf.PairedClients[i].Pubkey, pubkey) {
+			return &conf.PairedClients[i]
+		}
+	}
+	return nil
+}
+
 func (noiseConfig *NoiseConfig) storeConfig(conf *configuration) error {
 	configFile := NewFile(noiseConfig.dataDir, configFilename)
 	return configFile.WriteJSON(conf)
 }
 
 func (noiseConfig *NoiseConfig) containsClientStaticPubkey(pubkey []byte) bool {
-	for _, configPubkey := range noiseConfig.readConfig().ClientNoiseStaticPubkeys {
-		if bytes.Equal(configPubkey, pubkey) {
-			return true
+	return noiseConfig.readConfig().pairedClient(pubkey) != nil
+}
+
+// PairingVerificationRequired returns true if the client still has to verify the pairing code.
+func (noiseConfig *NoiseConfig) PairingVerificationRequired() bool {
+	noiseConfig.mu.Lock()
+	defer noiseConfig.mu.Unlock()
+	return noiseConfig.pairingVerificationRequired
+}
+
+// setPairingVerificationRequired sets whether the client still has to verify the pairing code.
+func (noiseConfig *NoiseConfig) setPairingVerificationRequired(required bool) {
+	noiseConfig.mu.Lock()
+	defer noiseConfig.mu.Unlock()
+	noiseConfig.pairingVerificationRequired = required
+}
+
+// ClientStaticPubkey returns the static pubkey of the client after the handshake.
+func (noiseConfig *NoiseConfig) ClientStaticPubkey() []byte {
+	return noiseConfig.clientStaticPubkey
+}
+
+// ClientRole returns the role of the connected client, an empty string if it is not paired.
+func (noiseConfig *NoiseConfig) ClientRole() string {
+	client := noiseConfig.readConfig().pairedClient(noiseConfig.clientStaticPubkey)
+	if client == nil {
+		return ""
+	}
+	return client.Role
+}
+
+// PairedClients returns the records of all paired clients.
+func (noiseConfig *NoiseConfig) PairedClients() []PairedClient {
+	return noiseConfig.readConfig().PairedClients
+}
+
+// SetClientRole changes the role of a paired client. The last owner can not be demoted, so that the Base can always be
+// managed.
+func (noiseConfig *NoiseConfig) SetClientRole(pubkey []byte, role string) error {
+	if roleRank(role) < 0 {
+		return errors.New("unknown role " + role + ", expected owner, operator or read-only")
+	}
+	config := noiseConfig.readConfig()
+	client := config.pairedClient(pubkey)
+	if client == nil {
+		return errors.New("client is not paired")
+	}
+	if client.Role == RoleOwner && role != RoleOwner {
+		owners := 0
+		for _, other := range config.PairedClients {
+			if other.Role == RoleOwner {
+				owners++
+			}
+		}
+		if owners == 1 {
+			return errors.New("the last owner can not be demoted")
 		}
 	}
-	return false
+	client.Role = role
+	return noiseConfig.storeConfig(config)
 }
 
+// RemoveClientStaticPubkey removes a paired client, so that it has to pair again.
+func (noiseConfig *NoiseConfig) RemoveClientStaticPubkey(pubkey []byte) error {
+	config := noiseConfig.readConfig()
+	clients := []PairedClient{}
+	for _, client := range config.PairedClients {
+		if !bytes.Equal(client.Pubkey, pubkey) {
+			clients = append(clients, client)
+		}
+	}
+	if len(clients) == len(config.PairedClients) {
+		return errors.New("client is not paired")
+	}
+	config.PairedClients = clients
+	return noiseConfig.storeConfig(config)
+}
+
+// addClientStaticPubkey pairs a client. The first client becomes the owner of the Base, later ones can only view its
+// status until an owner gives them another role.
 func (noiseConfig *NoiseConfig) addClientStaticPubkey(pubkey []byte) error {
-	if noiseConfig.containsClientStaticPubkey(pubkey) {
+	config := noiseConfig.readConfig()
+	if config.pairedClient(pubkey) != nil {
 		// Don't add again if already present.
 		return nil
 	}
-
-	config := noiseConfig.readConfig()
-	config.ClientNoiseStaticPubkeys = append(config.ClientNoiseStaticPubkeys, pubkey)
+	role := RoleReadOnly
+	if len(config.PairedClients) == 0 {
+		role = RoleOwner
+	}
+	config.PairedClients = append(config.PairedClients, PairedClient{Pubkey: pubkey, Role: role})
 	return noiseConfig.storeConfig(config)
 }
 
@@ -244,6 +450,56 @@ func (noiseConfig *NoiseConfig) setMiddlewareNoiseStaticKeypair(key *noise.DHKey
 	return noiseConfig.storeConfig(config)
 }
 
+// Keystore returns the stored static keypair of the middleware and the paired clients with their roles, e.g. to back
+// them up.
+func (noiseConfig *NoiseConfig) Keystore() ([]byte, error) {
+	configFile := NewFile(noiseConfig.dataDir, configFilename)
+	if !configFile.Exists() {
+		return nil, errors.New("the keystore has not been created yet")
+	}
+	return configFile.read()
+}
+
+// parseKeystore parses a keystore returned by Keystore and returns an error if it holds no static keypair.
+func parseKeystore(keystore []byte) (*configuration, error) {
+	var conf configuration
+	if err := json.Unmarshal(keystore, &conf); err != nil {
+		return nil, errors.New("invalid keystore")
+	}
+	keypair := conf.MiddlewareNoiseStaticKeypair
+	if keypair == nil || len(keypair.Private) != 32 || len(keypair.Public) != 32 {
+		return nil, errors.New("the keystore holds no static keypair")
+	}
+	return &conf, nil
+}
+
+// KeystorePrivateKey returns the static private key of the middleware in a keystore returned by Keystore.
+func KeystorePrivateKey(keystore []byte) ([]byte, error) {
+	conf, err := parseKeystore(keystore)
+	if err != nil {
+		return nil, err
+	}
+	return conf.MiddlewareNoiseStaticKeypair.Private, nil
+}
+
+// RestoreKeystore replaces the static keypair and the paired clients with a keystore returned by Keystore. Clients
+// paired with the replaced keypair have to pair again.
+func (noiseConfig *NoiseConfig) RestoreKeystore(keystore []byte) error {
+	if _, err := parseKeystore(keystore); err != nil {
+		return err
+	}
+	return NewFile(noiseConfig.dataDir, configFilename).write(keystore)
+}
+
+// RemoveKeystore removes the static keypair and the paired clients, e.g. for a factory reset. A new keypair is
+// generated with the next connection, all clients have to pair again.
+func (noiseConfig *NoiseConfig) RemoveKeystore() error {
+	if err := NewFile(noiseConfig.dataDir, configFilename).Remove(); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
 // File models a config file in the application's directory.
 // Callers can use MiddlewareDir function to obtain the default app config dir.
 type File struct {
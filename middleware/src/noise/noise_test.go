@@ -1,6 +1,11 @@
 package noisemanager_test
 
 import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	noisemanager "github.com/digitalbitbox/bitbox-base/middleware/src/noise"
@@ -9,7 +14,7 @@ import (
 
 func TestNoise(t *testing.T) {
 	noiseInstance := noisemanager.NewNoiseConfig(".base")
-	response := noiseInstance.CheckVerification()
+	response, _ := noiseInstance.CheckVerification()
 	require.Equal(t, string(response), "\x00")
 	msg := noiseInstance.Encrypt([]byte("test"))
 	if string(msg) == "" {
@@ -20,3 +25,75 @@ func TestNoise(t *testing.T) {
 		t.Error("did not receive error when decrypting from unitialized noise")
 	}
 }
+
+func TestRoles(t *testing.T) {
+	require.True(t, noisemanager.RoleAllows(noisemanager.RoleOwner, noisemanager.RoleOperator))
+	require.True(t, noisemanager.RoleAllows(noisemanager.RoleOperator, noisemanager.RoleOperator))
+	require.False(t, noisemanager.RoleAllows(noisemanager.RoleReadOnly, noisemanager.RoleOperator))
+	require.False(t, noisemanager.RoleAllows("", noisemanager.RoleReadOnly))
+
+	dataDir, err := ioutil.TempDir("", "bbb-noise")
+	require.NoError(t, err)
+	defer os.RemoveAll(dataDir)
+	owner := bytes.Repeat([]byte{1}, 32)
+	tablet := bytes.Repeat([]byte{2}, 32)
+	// Clients paired before roles were introduced become owners.
+	legacy, err := json.Marshal(map[string][][]byte{"deviceNoiseStaticPubkeys": {owner, tablet}})
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dataDir, "base.json"), legacy, 0600))
+	noiseInstance := noisemanager.NewNoiseConfig(dataDir)
+	require.Equal(t, []noisemanager.PairedClient{
+		{Pubkey: owner, Role: noisemanager.RoleOwner},
+		{Pubkey: tablet, Role: noisemanager.RoleOwner},
+	}, noiseInstance.PairedClients())
+
+	require.NoError(t, noiseInstance.SetClientRole(tablet, noisemanager.RoleReadOnly))
+	require.Error(t, noiseInstance.SetClientRole(tablet, "kid"))
+	require.Error(t, noiseInstance.SetClientRole(bytes.Repeat([]byte{3}, 32), noisemanager.RoleOperator))
+	require.EqualError(t, noiseInstance.SetClientRole(owner, noisemanager.RoleOperator), "the last owner can not be demoted")
+	require.Equal(t, []noisemanager.PairedClient{
+		{Pubkey: owner, Role: noisemanager.RoleOwner},
+		{Pubkey: tablet, Role: noisemanager.RoleReadOnly},
+	}, noiseInstance.PairedClients())
+	stored, err := ioutil.ReadFile(filepath.Join(dataDir, "base.json"))
+	require.NoError(t, err)
+	require.NotContains(t, string(stored), "deviceNoiseStaticPubkeys")
+
+	require.NoError(t, noiseInstance.RemoveClientStaticPubkey(tablet))
+	require.Len(t, noiseInstance.PairedClients(), 1)
+	require.NoError(t, noiseInstance.RemoveKeystore())
+	_, err = noiseInstance.Keystore()
+	require.Error(t, err)
+	require.NoError(t, noiseInstance.RemoveKeystore())
+}
+
+func TestKeystore(t *testing.T) {
+	dataDir, err := ioutil.TempDir("", "bbb-noise")
+	require.NoError(t, err)
+	defer os.RemoveAll(dataDir)
+	noiseInstance := noisemanager.NewNoiseConfig(dataDir)
+	_, err = noiseInstance.Keystore()
+	require.Error(t, err)
+
+	keystore, err := json.Marshal(map[string]interface{}{
+		"appNoiseStaticKeypair": map[string][]byte{
+			"private": bytes.Repeat([]byte{1}, 32),
+			"public":  bytes.Repeat([]byte{2}, 32),
+		},
+		"pairedClients": []noisemanager.PairedClient{{Pubkey: bytes.Repeat([]byte{3}, 32), Role: noisemanager.RoleOwner}},
+	})
+	require.NoError(t, err)
+	privateKey, err := noisemanager.KeystorePrivateKey(keystore)
+	require.NoError(t, err)
+	require.Equal(t, bytes.Repeat([]byte{1}, 32), privateKey)
+	require.Error(t, noiseInstance.RestoreKeystore([]byte(`{"pairedClients":[]}`)))
+	require.NoError(t, noiseInstance.RestoreKeystore(keystore))
+	restored, err := noiseInstance.Keystore()
+	require.NoError(t, err)
+	require.Equal(t, keystore, restored)
+	require.Len(t, noiseInstance.PairedClients(), 1)
+	require.NoError(t, noiseInstance.RemoveKeystore())
+	_, err = noiseInstance.Keystore()
+	require.Error(t, err)
+	require.NoError(t, noiseInstance.RemoveKeystore())
+}
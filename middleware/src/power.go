@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"errors"
+
+	"github.com/digitalbitbox/bitbox-base/middleware/src/system"
+)
+
+// StopServices stops the services in the order of system.StopOrder, calling progress before each one. It waits until
+// each service stopped, so that bitcoind can flush its chainstate. If a service fails to stop, the services stopped
+// before are started again, so that the Base is not left half down, and the returned error names the failed step.
+func (middleware *Middleware) StopServices(progress func(service string)) error {
+	stopped := []string{}
+	for _, service := range system.StopOrder() {
+		progress(service)
+		middleware.logger.Info("Stopping a service", "service", service)
+		if err := middleware.backends.System.StopService(service); err != nil {
+			middleware.logger.Error("Failed to stop a service, starting the stopped services again",
+				"service", service, "error", err)
+			middleware.startServices(stopped)
+			return errors.New("stopping " + service + " failed: " + err.Error())
+		}
+		stopped = append(stopped, service)
+	}
+	return nil
+}
+
+// startServices starts the services again, in the reverse order they were stopped in. Failures are logged.
+func (middleware *Middleware) startServices(stopped []string) {
+	for i := len(stopped) - 1; i >= 0; i-- {
+		if err := middleware.backends.System.StartService(stopped[i]); err != nil {
+			middleware.logger.Error("Failed to start a service again", "service", stopped[i], "error", err)
+		}
+	}
+}
+
+// Power reboots or shuts down the Base. The services should be stopped with StopServices first. If powering down
+// fails, they are started again, so that the Base keeps running with all its services.
+func (middleware *Middleware) Power(action system.PowerAction) error {
+	middleware.logger.Info("Powering down the Base", "action", action)
+	if err := middleware.backends.System.Power(action); err != nil {
+		middleware.logger.Error("Failed to power down, starting the stopped services again", "action", action,
+			"error", err)
+		middleware.startServices(system.StopOrder())
+		return errors.New(string(action) + " failed: " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+// Package regtest runs bitcoind, and optionally lightningd, on regtest for integration tests. The binaries are taken
+// from the PATH, no docker is needed. Tests should skip if they are not available.
+package regtest
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/system"
+)
+
+const (
+	rpcUser     = "regtest"
+	rpcPassword = "regtest"
+	// startTimeout is how long to wait for bitcoind and lightningd to accept rpc calls.
+	startTimeout = 30 * time.Second
+)
+
+// Available returns true if bitcoind is in the PATH. With lightning, lightningd and bitcoin-cli, which lightningd uses
+// to talk to bitcoind, are needed too.
+func Available(lightning bool) bool {
+	binaries := []string{"bitcoind"}
+	if lightning {
+		binaries = append(binaries, "lightningd", "bitcoin-cli")
+	}
+	for _, binary := range binaries {
+		if _, err := exec.LookPath(binary); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Node is a running regtest bitcoind, and lightningd if it was started with lightning.
+type Node struct {
+	dir          string
+	rpcPort      string
+	zmqHashblock string
+	zmqRawtx     string
+	bitcoind     *exec.Cmd
+	client       *rpcclient.Client
+	lightningd   *exec.Cmd
+	lightningRPC string
+}
+
+// freePort returns a local tcp port that is currently not in use.
+func freePort() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	defer listener.Close()
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	return port, err
+}
+
+// freePorts returns n distinct free ports.
+func freePorts(n int) ([]string, error) {
+	ports := []string{}
+	for len(ports) < n {
+		port, err := freePort()
+		if err != nil {
+			return nil, err
+		}
+		unique := true
+		for _, other := range ports {
+			unique = unique && other != port
+		}
+		if unique {
+			ports = append(ports, port)
+		}
+	}
+	return ports, nil
+}
+
+// Start starts bitcoind on regtest with its data in dir, which should be empty, e.g. a temporary directory. If
+// lightning is true, lightningd is started too. The node must be stopped after use.
+func Start(dir string, lightning bool) (*Node, error) {
+	ports, err := freePorts(5)
+	if err != nil {
+		return nil, err
+	}
+	node := &Node{
+		dir:          dir,
+		rpcPort:      ports[0],
+		zmqHashblock: "tcp://127.0.0.1:" + ports[2],
+		zmqRawtx:     "tcp://127.0.0.1:" + ports[3],
+	}
+	node.bitcoind = exec.Command("bitcoind",
+		"-regtest",
+		"-datadir="+dir,
+		"-server",
+		"-listen=0",
+		"-port="+ports[1],
+		"-rpcport="+node.rpcPort,
+		"-rpcuser="+rpcUser,
+		"-rpcpassword="+rpcPassword,
+		"-zmqpubhashblock="+node.zmqHashblock,
+		"-zmqpubrawtx="+node.zmqRawtx,
+		"-fallbackfee=0.0002",
+		"-printtoconsole=0",
+	)
+	if err := node.bitcoind.Start(); err != nil {
+		return nil, err
+	}
+	client, err := rpcclient.New(&rpcclient.ConnConfig{
+		Host:         "127.0.0.1:" + node.rpcPort,
+		User:         rpcUser,
+		Pass:         rpcPassword,
+		HTTPPostMode: true,
+		DisableTLS:   true,
+	}, nil)
+	if err != nil {
+		node.Stop()
+		return nil, err
+	}
+	node.client = client
+	if err := waitFor(func() bool { _, err := client.GetBlockCount(); return err == nil }); err != nil {
+		node.Stop()
+		return nil, errors.New("bitcoind did not start: " + err.Error())
+	}
+	if lightning {
+		if err := node.startLightningd(ports[4]); err != nil {
+			node.Stop()
+			return nil, err
+		}
+	}
+	return node, nil
+}
+
+// startLightningd starts lightningd on top of the running bitcoind.
+func (node *Node) startLightningd(port string) error {
+	lightningDir := filepath.Join(node.dir, "lightning")
+	node.lightningd = exec.Command("lightningd",
+		"--network=regtest",
+		"--lightning-dir="+lightningDir,
+		"--addr=127.0.0.1:"+port,
+		"--bitcoin-datadir="+node.dir,
+		"--bitcoin-rpcconnect=127.0.0.1",
+		"--bitcoin-rpcport="+node.rpcPort,
+		"--bitcoin-rpcuser="+rpcUser,
+		"--bitcoin-rpcpassword="+rpcPassword,
+		"--log-file="+filepath.Join(lightningDir, "log"),
+	)
+	if err := os.MkdirAll(lightningDir, 0700); err != nil {
+		return err
+	}
+	if err := node.lightningd.Start(); err != nil {
+		return err
+	}
+	// Newer versions of lightningd put the rpc socket into a subdirectory per network.
+	candidates := []string{
+		filepath.Join(lightningDir, "lightning-rpc"),
+		filepath.Join(lightningDir, "regtest", "lightning-rpc"),
+	}
+	err := waitFor(func() bool {
+		for _, candidate := range candidates {
+			if _, err := os.Stat(candidate); err == nil {
+				node.lightningRPC = candidate
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		return errors.New("lightningd did not start: " + err.Error())
+	}
+	return nil
+}
+
+// waitFor polls ready until it returns true or startTimeout passed.
+func waitFor(ready func() bool) error {
+	deadline := time.Now().Add(startTimeout)
+	for !ready() {
+		if time.Now().After(deadline) {
+			return errors.New("timeout after " + startTimeout.String())
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return nil
+}
+
+// Environment returns the environment to run the middleware against the node with.
+func (node *Node) Environment() system.Environment {
+	return system.Environment{
+		Network:             system.NetworkRegtest,
+		BitcoinRPCUser:      rpcUser,
+		BitcoinRPCPassword:  rpcPassword,
+		BitcoinRPCPort:      node.rpcPort,
+		BitcoinZMQHashblock: node.zmqHashblock,
+		BitcoinZMQRawtx:     node.zmqRawtx,
+		LightningRPCPath:    node.lightningRPC,
+		ElectrsRPCPort:      system.NetworkRegtest.ElectrsRPCPort(),
+		DataDir:             filepath.Join(node.dir, "base"),
+	}
+}
+
+// Generate mines blocks to a new address of the node's wallet, creating the wallet first if needed.
+func (node *Node) Generate(blocks int) error {
+	// The wallet may exist already, or be created by default in older versions of bitcoind.
+	_, _ = node.client.RawRequest("createwallet", []json.RawMessage{json.RawMessage(`"regtest"`)})
+	address, err := node.client.RawRequest("getnewaddress", nil)
+	if err != nil {
+		return err
+	}
+	_, err = node.client.RawRequest("generatetoaddress",
+		[]json.RawMessage{json.RawMessage(strconv.Itoa(blocks)), address})
+	return err
+}
+
+// Stop stops lightningd and bitcoind and waits for them to exit.
+func (node *Node) Stop() {
+	if node.lightningd != nil && node.lightningd.Process != nil {
+		_ = node.lightningd.Process.Signal(os.Interrupt)
+		_ = node.lightningd.Wait()
+	}
+	if node.client != nil {
+		node.client.Shutdown()
+	}
+	if node.bitcoind.Process != nil {
+		_ = node.bitcoind.Process.Signal(os.Interrupt)
+		_ = node.bitcoind.Wait()
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"strconv"
+
+	"github.com/digitalbitbox/bitbox-base/middleware/src/system"
+)
+
+// FactoryResetWarnings returns what a factory reset with the options irrevocably deletes, for the owner to confirm.
+func (middleware *Middleware) FactoryResetWarnings(options system.ResetOptions) []string {
+	warnings := []string{
+		"all clients have to pair again, and the onion addresses and the wifi credentials of the Base are deleted",
+	}
+	if options.Blockchain {
+		warnings = append(warnings, "the blockchain is deleted and has to be downloaded and validated again, "+
+			"which takes days")
+	}
+	if options.Lightning {
+		node, err := middleware.backends.Lightning.Node()
+		if err != nil {
+			warnings = append(warnings, "the lightning node is deleted, its funds are lost without a lightning backup")
+		} else {
+			warnings = append(warnings, "the lightning node "+node.ID+" with "+strconv.FormatInt(node.Channels, 10)+
+				" channels is deleted, its funds are lost without a lightning backup")
+		}
+	}
+	return warnings
+}
+
+// FactoryReset wipes the configuration of the Base and the data on the SSD selected by options. Once that succeeded,
+// wipeKeystore is called to wipe the noise keystore and the paired clients, so that a failed reset can be retried by
+// the same client. The Base has to be rebooted afterwards.
+func (middleware *Middleware) FactoryReset(options system.ResetOptions, wipeKeystore func() error) error {
+	middleware.backupMu.Lock()
+	defer middleware.backupMu.Unlock()
+	middleware.logger.Warning("Factory reset", "blockchain", options.Blockchain, "lightning", options.Lightning)
+	if err := middleware.backends.System.FactoryReset(options); err != nil {
+		return err
+	}
+	return wipeKeystore()
+}
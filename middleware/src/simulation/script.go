@@ -0,0 +1,147 @@
+package simulation
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Step is a command of a script, run after waiting for Delay since the previous step.
+type Step struct {
+	Delay   time.Duration
+	Command string
+	Args    []string
+}
+
+// DefaultScript returns the script the middleware runs with -simulate if no script is given: a block every minute,
+// transactions arriving in between and a channel opening now and then.
+func DefaultScript() string {
+	return `# delay command arguments
+20s transactions 3
+20s transactions 5
+20s mine 1
+0s open-channel 1
+20s transactions 2
+40s mine 1
+`
+}
+
+// ParseScript parses a script with one step per line: a delay like 10s, a command and its arguments. Empty lines and
+// lines starting with # are skipped. The commands are:
+//
+//	mine <blocks>                    mine blocks, which include all mempool transactions
+//	transactions <count>             add transactions to the mempool
+//	open-channel <count>             open lightning channels
+//	close-channel <count>            close lightning channels
+//	fail <backend> <message...>      make bitcoind, lightningd or electrs unreachable
+//	degrade <backend> <message...>   make a backend answer with an error, like bitcoind while warming up
+//	recover <backend>                end the failure of a backend
+func ParseScript(script io.Reader) ([]Step, error) {
+	steps := []Step{}
+	scanner := bufio.NewScanner(script)
+	for number := 1; scanner.Scan(); number++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		lineError := func(message string) error {
+			return errors.New("line " + strconv.Itoa(number) + " of the script: " + message)
+		}
+		if len(fields) < 2 {
+			return nil, lineError("expected a delay and a command")
+		}
+		delay, err := time.ParseDuration(fields[0])
+		if err != nil {
+			return nil, lineError(err.Error())
+		}
+		step := Step{Delay: delay, Command: fields[1], Args: fields[2:]}
+		if err := step.validate(); err != nil {
+			return nil, lineError(err.Error())
+		}
+		steps = append(steps, step)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return steps, nil
+}
+
+// validate checks the command and the number of arguments of the step.
+func (step Step) validate() error {
+	switch step.Command {
+	case "mine", "transactions", "open-channel", "close-channel":
+		if len(step.Args) != 1 {
+			return errors.New(step.Command + " expects a count")
+		}
+		if _, err := strconv.ParseInt(step.Args[0], 10, 64); err != nil {
+			return errors.New(step.Command + " expects a count, got " + step.Args[0])
+		}
+	case "fail", "degrade":
+		if len(step.Args) < 2 {
+			return errors.New(step.Command + " expects a backend and a message")
+		}
+		return validateBackend(step.Args[0])
+	case "recover":
+		if len(step.Args) != 1 {
+			return errors.New("recover expects a backend")
+		}
+		return validateBackend(step.Args[0])
+	default:
+		return errors.New("unknown command " + step.Command)
+	}
+	return nil
+}
+
+func validateBackend(backend string) error {
+	if backend != Bitcoind && backend != Lightningd && backend != Electrs {
+		return errors.New("unknown backend " + backend + ", expected bitcoind, lightningd or electrs")
+	}
+	return nil
+}
+
+// Apply runs the command of a step, without waiting for its delay.
+func (simulation *Simulation) Apply(step Step) error {
+	if err := step.validate(); err != nil {
+		return err
+	}
+	switch step.Command {
+	case "fail", "degrade":
+		return simulation.Fail(step.Args[0], strings.Join(step.Args[1:], " "), step.Command == "degrade")
+	case "recover":
+		simulation.Recover(step.Args[0])
+		return nil
+	}
+	count, _ := strconv.ParseInt(step.Args[0], 10, 64)
+	switch step.Command {
+	case "mine":
+		simulation.Mine(count)
+	case "transactions":
+		simulation.AddTransactions(count)
+	case "open-channel":
+		simulation.OpenChannels(count)
+	case "close-channel":
+		simulation.OpenChannels(-count)
+	}
+	return nil
+}
+
+// Run runs the steps of a script until stop is closed. If repeat is true, the script starts over after the last step.
+func (simulation *Simulation) Run(steps []Step, repeat bool, stop <-chan struct{}) {
+	for {
+		for _, step := range steps {
+			select {
+			case <-stop:
+				return
+			case <-time.After(step.Delay):
+			}
+			// The steps were validated when parsing the script.
+			_ = simulation.Apply(step)
+		}
+		if !repeat || len(steps) == 0 {
+			return
+		}
+	}
+}
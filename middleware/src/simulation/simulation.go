@@ -0,0 +1,553 @@
+// Package simulation provides deterministic fakes of the services of the Base, so that the middleware can run on a
+// laptop without bitcoind, lightningd and electrs, e.g. to develop apps against it, and tests are hermetic. The
+// simulation is driven by scripts, see ParseScript.
+package simulation
+
+import (
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	middleware "github.com/digitalbitbox/bitbox-base/middleware/src"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/backup"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/system"
+)
+
+// The backends of the simulation, named like their systemd services.
+const (
+	Bitcoind   = "bitcoind"
+	Lightningd = "lightningd"
+	Electrs    = "electrs"
+)
+
+// startHeight is the block height the simulated chain starts at.
+const startHeight = 100
+
+// failure is a simulated failure of a backend.
+type failure struct {
+	err error
+	// reachable is true if the backend still answers, with an error.
+	reachable bool
+}
+
+// Simulation is the simulated state of the Base. It is safe for concurrent use.
+type Simulation struct {
+	mu       sync.Mutex
+	blocks   int64
+	mempool  int64
+	channels int64
+	failures map[string]failure
+	services map[string]string
+	config   map[string]string
+	// logs holds the journal entries by service. logged is closed and replaced whenever an entry is added.
+	logs   map[string][]system.JournalEntry
+	logged chan struct{}
+	// hsmSecret is the secret the lightning node id is derived from.
+	hsmSecret []byte
+	// backupDrive is the directory of the simulated backup drive, empty if none is mounted.
+	backupDrive string
+	// configFiles are the system files that configuration backups hold, by name.
+	configFiles map[string]backup.File
+	// powerActions records the reboots and shutdowns.
+	powerActions []system.PowerAction
+	// powerFailure makes reboots and shutdowns fail, unless it is nil.
+	powerFailure error
+	// temperature and fan are reported by the thermal sensors.
+	temperature float64
+	fan         int
+}
+
+// New returns a simulation of a freshly set up Base on the given network, with a chain at height 100 and all
+// services running.
+func New(network system.Network) *Simulation {
+	simulation := &Simulation{
+		blocks:   startHeight,
+		failures: make(map[string]failure),
+		services: make(map[string]string),
+		config: map[string]string{
+			"bitcoin_network": string(network),
+			"hostname":        "bitbox-base-simulation",
+		},
+		logs:        make(map[string][]system.JournalEntry),
+		logged:      make(chan struct{}),
+		temperature: 40,
+	}
+	hsmSecret := sha256.Sum256([]byte("simulated hsm_secret " + string(network)))
+	simulation.hsmSecret = hsmSecret[:]
+	onion := sha256.Sum256([]byte("simulated onion " + string(network)))
+	simulation.configFiles = map[string]backup.File{}
+	for _, file := range []backup.File{
+		{Name: "var/lib/tor/hidden_service_middleware/hs_ed25519_secret_key", Data: onion[:], Mode: 0600},
+		{Name: "var/lib/tor/hidden_service_middleware/hostname",
+			Data: []byte(strings.ToLower(base32.StdEncoding.EncodeToString(onion[:]))[:56] + ".onion\n"), Mode: 0600},
+		{Name: "opt/shift/config/wifi/wlan0.conf", Data: []byte("allow-hotplug wlan0\n"), Mode: 0644},
+	} {
+		simulation.configFiles[file.Name] = file
+	}
+	for _, service := range system.Services() {
+		simulation.services[service] = "active"
+	}
+	return simulation
+}
+
+// Backends returns the simulated backends for middleware.NewMiddlewareWithBackends.
+func (simulation *Simulation) Backends() middleware.Backends {
+	return middleware.Backends{
+		Bitcoin:   bitcoinBackend{simulation},
+		Lightning: lightningBackend{simulation},
+		Electrs:   electrsBackend{simulation},
+		System:    systemBackend{simulation},
+	}
+}
+
+// The syslog priorities of the simulated journal entries.
+const (
+	priorityError = 3
+	priorityInfo  = 6
+)
+
+// log adds a journal entry of a service. The lock must be held.
+func (simulation *Simulation) log(service string, priority int, message string) {
+	simulation.logs[service] = append(simulation.logs[service], system.JournalEntry{
+		Time:     time.Now(),
+		Unit:     service,
+		Priority: priority,
+		Message:  message,
+	})
+	close(simulation.logged)
+	simulation.logged = make(chan struct{})
+}
+
+// blockHash returns the deterministic hash of the simulated block at the given height.
+func blockHash(height int64) string {
+	var buffer [8]byte
+	binary.BigEndian.PutUint64(buffer[:], uint64(height))
+	hash := sha256.Sum256(buffer[:])
+	return hex.EncodeToString(hash[:])
+}
+
+// Mine mines blocks, which include all transactions of the mempool.
+func (simulation *Simulation) Mine(blocks int64) {
+	simulation.mu.Lock()
+	defer simulation.mu.Unlock()
+	for i := int64(0); i < blocks; i++ {
+		simulation.blocks++
+		simulation.mempool = 0
+		simulation.log(Bitcoind, priorityInfo, "UpdateTip: new best="+blockHash(simulation.blocks)+" height="+
+			strconv.FormatInt(simulation.blocks, 10))
+	}
+}
+
+// AddTransactions adds transactions to the mempool.
+func (simulation *Simulation) AddTransactions(transactions int64) {
+	simulation.mu.Lock()
+	defer simulation.mu.Unlock()
+	simulation.mempool += transactions
+}
+
+// OpenChannels opens lightning channels, negative numbers close channels.
+func (simulation *Simulation) OpenChannels(channels int64) {
+	simulation.mu.Lock()
+	defer simulation.mu.Unlock()
+	simulation.channels += channels
+	if simulation.channels < 0 {
+		simulation.channels = 0
+	}
+	simulation.log(Lightningd, priorityInfo, strconv.FormatInt(simulation.channels, 10)+" active channels")
+}
+
+// SetThermal changes the temperature in °C and the fan pwm value reported by the simulated sensors.
+func (simulation *Simulation) SetThermal(temperature float64, fan int) {
+	simulation.mu.Lock()
+	defer simulation.mu.Unlock()
+	simulation.temperature = temperature
+	simulation.fan = fan
+}
+
+// SetBackupDrive mounts a directory as the backup drive, an empty dir unmounts it.
+func (simulation *Simulation) SetBackupDrive(dir string) {
+	simulation.mu.Lock()
+	defer simulation.mu.Unlock()
+	simulation.backupDrive = dir
+}
+
+// PowerActions returns the reboots and shutdowns of the Base, in the order they happened.
+func (simulation *Simulation) PowerActions() []system.PowerAction {
+	simulation.mu.Lock()
+	defer simulation.mu.Unlock()
+	return append([]system.PowerAction{}, simulation.powerActions...)
+}
+
+// FailPower makes reboots and shutdowns fail with the message, like systemctl refusing them. An empty message lets them
+// succeed again.
+func (simulation *Simulation) FailPower(message string) {
+	simulation.mu.Lock()
+	defer simulation.mu.Unlock()
+	simulation.powerFailure = nil
+	if message != "" {
+		simulation.powerFailure = errors.New(message)
+	}
+}
+
+// Fail makes a backend fail with the message. If reachable is true, the backend still answers, with an error, like
+// bitcoind while it warms up. Otherwise its service is reported as failed.
+func (simulation *Simulation) Fail(backend, message string, reachable bool) error {
+	if err := validateBackend(backend); err != nil {
+		return err
+	}
+	simulation.mu.Lock()
+	defer simulation.mu.Unlock()
+	simulation.failures[backend] = failure{err: errors.New(message), reachable: reachable}
+	if !reachable {
+		simulation.services[backend] = "failed"
+	}
+	simulation.log(backend, priorityError, message)
+	return nil
+}
+
+// Recover ends a failure of a backend.
+func (simulation *Simulation) Recover(backend string) {
+	simulation.mu.Lock()
+	defer simulation.mu.Unlock()
+	delete(simulation.failures, backend)
+	simulation.services[backend] = "active"
+	simulation.log(backend, priorityInfo, "recovered")
+}
+
+// failed returns the error of a failing backend, nil if it works. The lock must be held.
+func (simulation *Simulation) failed(backend string) error {
+	failure, ok := simulation.failures[backend]
+	if !ok {
+		return nil
+	}
+	if failure.reachable {
+		return &middleware.ReachableError{Err: failure.err}
+	}
+	return failure.err
+}
+
+type bitcoinBackend struct {
+	*Simulation
+}
+
+func (bitcoin bitcoinBackend) ChainInfo() (middleware.BitcoindState, error) {
+	bitcoin.mu.Lock()
+	defer bitcoin.mu.Unlock()
+	if err := bitcoin.failed(Bitcoind); err != nil {
+		return middleware.BitcoindState{}, err
+	}
+	return middleware.BitcoindState{
+		Blocks:              bitcoin.blocks,
+		Difficulty:          1,
+		BestBlockHash:       blockHash(bitcoin.blocks),
+		MempoolTransactions: bitcoin.mempool,
+	}, nil
+}
+
+func (bitcoin bitcoinBackend) MempoolTransactions() (int64, error) {
+	bitcoin.mu.Lock()
+	defer bitcoin.mu.Unlock()
+	if err := bitcoin.failed(Bitcoind); err != nil {
+		return 0, err
+	}
+	return bitcoin.mempool, nil
+}
+
+type lightningBackend struct {
+	*Simulation
+}
+
+func (lightning lightningBackend) Info() (middleware.LightningState, error) {
+	lightning.mu.Lock()
+	defer lightning.mu.Unlock()
+	if err := lightning.failed(Lightningd); err != nil {
+		return middleware.LightningState{}, err
+	}
+	return middleware.LightningState{Alias: "simulation", Channels: lightning.channels}, nil
+}
+
+// Node returns the id derived from the simulated hsm_secret.
+func (lightning lightningBackend) Node() (middleware.LightningNode, error) {
+	lightning.mu.Lock()
+	defer lightning.mu.Unlock()
+	if err := lightning.failed(Lightningd); err != nil {
+		return middleware.LightningNode{}, err
+	}
+	nodeID, err := backup.LightningNodeID(lightning.hsmSecret)
+	if err != nil {
+		return middleware.LightningNode{}, err
+	}
+	return middleware.LightningNode{ID: nodeID, Channels: lightning.channels}, nil
+}
+
+// simulatedDatabase is the content of the simulated lightning database.
+type simulatedDatabase struct {
+	Channels int64 `json:"channels"`
+}
+
+// Files returns a database that records the number of channels.
+func (lightning lightningBackend) Files() ([]byte, []byte, error) {
+	lightning.mu.Lock()
+	defer lightning.mu.Unlock()
+	database, err := json.Marshal(simulatedDatabase{Channels: lightning.channels})
+	if err != nil {
+		return nil, nil, err
+	}
+	return database, append([]byte{}, lightning.hsmSecret...), nil
+}
+
+// Restore takes over the channels of the database and the hsm_secret.
+func (lightning lightningBackend) Restore(database, hsmSecret []byte) error {
+	var restored simulatedDatabase
+	if err := json.Unmarshal(database, &restored); err != nil {
+		return errors.New("invalid lightning database")
+	}
+	lightning.mu.Lock()
+	defer lightning.mu.Unlock()
+	lightning.channels = restored.Channels
+	lightning.hsmSecret = append([]byte{}, hsmSecret...)
+	lightning.log(Lightningd, priorityInfo, "restored the node with "+strconv.FormatInt(restored.Channels, 10)+" channels")
+	return nil
+}
+
+type electrsBackend struct {
+	*Simulation
+}
+
+// Blocks returns the height of the simulated chain, electrs is always in sync.
+func (electrs electrsBackend) Blocks() (int64, error) {
+	electrs.mu.Lock()
+	defer electrs.mu.Unlock()
+	if err := electrs.failed(Electrs); err != nil {
+		return 0, err
+	}
+	return electrs.blocks, nil
+}
+
+type systemBackend struct {
+	*Simulation
+}
+
+func (base systemBackend) ServicesStatus() []system.ServiceStatus {
+	base.mu.Lock()
+	defer base.mu.Unlock()
+	statuses := []system.ServiceStatus{}
+	for _, service := range system.Services() {
+		statuses = append(statuses, system.ServiceStatus{Name: service, ActiveState: base.services[service]})
+	}
+	return statuses
+}
+
+// ConfigGet reads a setting. Settings that were never set are reported as disabled.
+func (base systemBackend) ConfigGet(key string) (string, error) {
+	if err := system.ValidateConfigGet(key); err != nil {
+		return "", err
+	}
+	base.mu.Lock()
+	defer base.mu.Unlock()
+	key = strings.ToLower(key)
+	if value, ok := base.config[key]; ok {
+		return value, nil
+	}
+	return "false", nil
+}
+
+// ConfigSet validates and stores a setting, nothing is changed on the system.
+func (base systemBackend) ConfigSet(key, value string) (string, error) {
+	if err := system.ValidateConfigSet(key, value); err != nil {
+		return "", err
+	}
+	base.mu.Lock()
+	defer base.mu.Unlock()
+	base.config[strings.ToLower(key)] = value
+	return "System configuration " + key + " will be enabled on next boot.", nil
+}
+
+// Journal sends the selected entries logged by the simulation for the service, and the new ones if the query follows
+// the journal.
+func (base systemBackend) Journal(query system.JournalQuery, stop <-chan struct{}, onEntry func(entry system.JournalEntry)) error {
+	if !system.IsLogUnit(query.Unit) {
+		return errors.New("logs of unit " + query.Unit + " are not available")
+	}
+	selected := func(entry system.JournalEntry) bool {
+		return entry.Priority <= query.Priority && !entry.Time.Before(query.Since)
+	}
+	base.mu.Lock()
+	logs := base.logs[query.Unit]
+	logged := base.logged
+	base.mu.Unlock()
+	// Like journalctl, the last query.Lines of the matching entries are sent.
+	past := []system.JournalEntry{}
+	for _, entry := range logs {
+		if selected(entry) {
+			past = append(past, entry)
+		}
+	}
+	if len(past) > query.Lines {
+		past = past[len(past)-query.Lines:]
+	}
+	for _, entry := range past {
+		onEntry(entry)
+	}
+	if !query.Follow {
+		return nil
+	}
+	sent := len(logs)
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-logged:
+		}
+		base.mu.Lock()
+		logs = base.logs[query.Unit]
+		logged = base.logged
+		base.mu.Unlock()
+		for _, entry := range logs[sent:] {
+			if selected(entry) {
+				onEntry(entry)
+			}
+		}
+		sent = len(logs)
+	}
+}
+
+// Disks reports a root file system and an SSD that are a quarter full.
+func (base systemBackend) Disks() []system.DiskUsage {
+	return []system.DiskUsage{
+		{Path: "/", Mounted: true, TotalBytes: 16 << 30, FreeBytes: 12 << 30},
+		{Path: "/mnt/ssd", Mounted: true, TotalBytes: 1 << 40, FreeBytes: 3 << 38},
+	}
+}
+
+func (base systemBackend) Thermal() (system.ThermalSample, error) {
+	base.mu.Lock()
+	defer base.mu.Unlock()
+	return system.ThermalSample{Time: time.Now(), Temperature: base.temperature, Fan: base.fan}, nil
+}
+
+// ConfigFiles returns the simulated system files, a hidden service and the wifi settings.
+func (base systemBackend) ConfigFiles() ([]backup.File, error) {
+	base.mu.Lock()
+	defer base.mu.Unlock()
+	names := []string{}
+	for name := range base.configFiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	files := []backup.File{}
+	for _, name := range names {
+		file := base.configFiles[name]
+		file.Data = append([]byte{}, file.Data...)
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// RestoreConfigFiles replaces the simulated system files.
+func (base systemBackend) RestoreConfigFiles(files []backup.File) error {
+	for _, file := range files {
+		if err := system.ValidateConfigFile(file); err != nil {
+			return err
+		}
+	}
+	base.mu.Lock()
+	defer base.mu.Unlock()
+	base.configFiles = map[string]backup.File{}
+	for _, file := range files {
+		base.configFiles[file.Name] = file
+	}
+	base.log("tor", priorityInfo, "restarted with "+strconv.Itoa(len(files))+" restored configuration files")
+	return nil
+}
+
+// FactoryReset stops the services, forgets the settings and the system files and wipes the simulated chain and
+// lightning node if selected. A wiped node gets a new hsm_secret.
+func (base systemBackend) FactoryReset(options system.ResetOptions) error {
+	base.mu.Lock()
+	defer base.mu.Unlock()
+	for _, service := range system.ResetServices(options) {
+		base.services[service] = "inactive"
+	}
+	base.config = map[string]string{}
+	base.configFiles = map[string]backup.File{}
+	if options.Blockchain {
+		base.blocks = 0
+		base.mempool = 0
+	}
+	if options.Lightning {
+		base.channels = 0
+		hsmSecret := sha256.Sum256(base.hsmSecret)
+		base.hsmSecret = hsmSecret[:]
+	}
+	base.log("base-middleware", priorityInfo, "factory reset")
+	return nil
+}
+
+// StopService marks a service as stopped. Stopping a failing backend that still answers fails with its error, like a
+// unit that does not stop in time.
+func (base systemBackend) StopService(name string) error {
+	if !system.IsService(name) {
+		return errors.New("unknown service " + name)
+	}
+	base.mu.Lock()
+	defer base.mu.Unlock()
+	if failure, ok := base.failures[name]; ok && failure.reachable {
+		return failure.err
+	}
+	base.services[name] = "inactive"
+	base.log(name, priorityInfo, "stopped")
+	return nil
+}
+
+// StartService marks a service as running, unless its backend is failing.
+func (base systemBackend) StartService(name string) error {
+	if !system.IsService(name) {
+		return errors.New("unknown service " + name)
+	}
+	base.mu.Lock()
+	defer base.mu.Unlock()
+	if failure, ok := base.failures[name]; ok {
+		return failure.err
+	}
+	base.services[name] = "active"
+	base.log(name, priorityInfo, "started")
+	return nil
+}
+
+// Power records the power action. All services run again after a reboot, and stay stopped after a shutdown until the
+// simulation is restarted.
+func (base systemBackend) Power(action system.PowerAction) error {
+	base.mu.Lock()
+	defer base.mu.Unlock()
+	if base.powerFailure != nil {
+		return base.powerFailure
+	}
+	base.powerActions = append(base.powerActions, action)
+	if action == system.PowerReboot {
+		for _, service := range system.Services() {
+			if _, failed := base.failures[service]; !failed {
+				base.services[service] = "active"
+			}
+		}
+	}
+	base.log("base-middleware", priorityInfo, string(action))
+	return nil
+}
+
+func (base systemBackend) BackupDrive() (string, error) {
+	base.mu.Lock()
+	defer base.mu.Unlock()
+	if base.backupDrive == "" {
+		return "", errors.New("no backup drive is mounted")
+	}
+	return base.backupDrive, nil
+}
@@ -0,0 +1,127 @@
+package simulation_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	middleware "github.com/digitalbitbox/bitbox-base/middleware/src"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/simulation"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/system"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScript(t *testing.T) {
+	steps, err := simulation.ParseScript(strings.NewReader(simulation.DefaultScript()))
+	require.NoError(t, err)
+	require.NotEmpty(t, steps)
+
+	steps, err = simulation.ParseScript(strings.NewReader("# comment\n\n1s mine 2\n0s degrade bitcoind Loading block index\n"))
+	require.NoError(t, err)
+	require.Equal(t, []simulation.Step{
+		{Delay: time.Second, Command: "mine", Args: []string{"2"}},
+		{Delay: 0, Command: "degrade", Args: []string{"bitcoind", "Loading", "block", "index"}},
+	}, steps)
+
+	for _, script := range []string{"mine 1", "1s", "1s dance", "1s mine x", "1s recover nginx", "1s fail electrs"} {
+		_, err := simulation.ParseScript(strings.NewReader(script))
+		require.Error(t, err, script)
+	}
+}
+
+func TestBackends(t *testing.T) {
+	simulated := simulation.New(system.NetworkRegtest)
+	backends := simulated.Backends()
+	info, err := backends.Bitcoin.ChainInfo()
+	require.NoError(t, err)
+	require.Equal(t, int64(100), info.Blocks)
+
+	// Mining is deterministic and clears the mempool.
+	simulated.AddTransactions(3)
+	mempool, err := backends.Bitcoin.MempoolTransactions()
+	require.NoError(t, err)
+	require.Equal(t, int64(3), mempool)
+	simulated.Mine(1)
+	other := simulation.New(system.NetworkTestnet)
+	other.Mine(1)
+	otherInfo, err := other.Backends().Bitcoin.ChainInfo()
+	require.NoError(t, err)
+	info, err = backends.Bitcoin.ChainInfo()
+	require.NoError(t, err)
+	require.Equal(t, int64(101), info.Blocks)
+	require.Equal(t, int64(0), info.MempoolTransactions)
+	require.Equal(t, otherInfo.BestBlockHash, info.BestBlockHash)
+
+	// Failed backends are reported by the services and logged, degraded ones answer with an error.
+	require.NoError(t, simulated.Fail("electrs", "connection refused", false))
+	require.NoError(t, simulated.Fail("lightningd", "starting up", true))
+	_, err = backends.Electrs.Blocks()
+	require.EqualError(t, err, "connection refused")
+	_, err = backends.Lightning.Info()
+	require.IsType(t, &middleware.ReachableError{}, err)
+	require.Contains(t, backends.System.ServicesStatus(), system.ServiceStatus{Name: "electrs", ActiveState: "failed"})
+	require.Contains(t, backends.System.ServicesStatus(), system.ServiceStatus{Name: "lightningd", ActiveState: "active"})
+	entries := []system.JournalEntry{}
+	query := system.JournalQuery{Unit: "electrs", Lines: 10, Priority: system.PriorityDebug}
+	require.NoError(t, backends.System.Journal(query, nil, func(entry system.JournalEntry) { entries = append(entries, entry) }))
+	require.Len(t, entries, 1)
+	require.Equal(t, "connection refused", entries[0].Message)
+	require.Equal(t, 3, entries[0].Priority)
+	query = system.JournalQuery{Unit: "sshd", Lines: 10, Priority: system.PriorityDebug}
+	require.Error(t, backends.System.Journal(query, nil, func(system.JournalEntry) {}))
+	simulated.Recover("electrs")
+	blocks, err := backends.Electrs.Blocks()
+	require.NoError(t, err)
+	require.Equal(t, int64(101), blocks)
+
+	// Settings are validated and kept in memory.
+	value, err := backends.System.ConfigGet("bitcoin_network")
+	require.NoError(t, err)
+	require.Equal(t, "regtest", value)
+	_, err = backends.System.ConfigSet("bitcoin_network", "moonnet")
+	require.Error(t, err)
+	_, err = backends.System.ConfigSet("wifi", "true")
+	require.NoError(t, err)
+	value, err = backends.System.ConfigGet("wifi")
+	require.NoError(t, err)
+	require.Equal(t, "true", value)
+}
+
+func TestJournalFollow(t *testing.T) {
+	simulated := simulation.New(system.NetworkTestnet)
+	simulated.Mine(2)
+	stop := make(chan struct{})
+	lines := make(chan string, 10)
+	done := make(chan error)
+	query := system.JournalQuery{Unit: "bitcoind", Lines: 1, Follow: true, Priority: system.PriorityDebug}
+	go func() {
+		done <- simulated.Backends().System.Journal(query, stop, func(entry system.JournalEntry) { lines <- entry.Message })
+	}()
+	require.Contains(t, <-lines, "height=102")
+	simulated.Mine(1)
+	require.Contains(t, <-lines, "height=103")
+	close(stop)
+	require.NoError(t, <-done)
+}
+
+func TestJournalFilters(t *testing.T) {
+	simulated := simulation.New(system.NetworkTestnet)
+	simulated.Mine(1)
+	require.NoError(t, simulated.Fail("bitcoind", "disk full", false))
+	simulated.Recover("bitcoind")
+	messages := func(query system.JournalQuery) []string {
+		messages := []string{}
+		require.NoError(t, simulated.Backends().System.Journal(query, nil, func(entry system.JournalEntry) {
+			messages = append(messages, entry.Message)
+		}))
+		return messages
+	}
+	query := system.JournalQuery{Unit: "bitcoind", Lines: 10, Priority: system.PriorityDebug}
+	require.Len(t, messages(query), 3)
+	query.Lines = 2
+	require.Equal(t, []string{"disk full", "recovered"}, messages(query))
+	query.Priority = 4
+	require.Equal(t, []string{"disk full"}, messages(query))
+	query.Since = time.Now().Add(time.Hour)
+	require.Empty(t, messages(query))
+}
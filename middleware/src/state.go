@@ -0,0 +1,371 @@
+package middleware
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/digitalbitbox/bitbox-base/middleware/src/logging"
+	basemessages "github.com/digitalbitbox/bitbox-base/middleware/src/messages"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// BitcoindState is the last known state of bitcoind.
+type BitcoindState struct {
+	Blocks              int64   `json:"blocks"`
+	Difficulty          float64 `json:"difficulty"`
+	BestBlockHash       string  `json:"bestBlockHash"`
+	MempoolTransactions int64   `json:"mempoolTransactions"`
+	// Unreachable is true if bitcoind could not be reached. The other values are not set then.
+	Unreachable bool `json:"unreachable"`
+}
+
+// LightningState is the last known state of c-lightning.
+type LightningState struct {
+	Alias string `json:"alias"`
+	// Channels is the number of active channels.
+	Channels int64 `json:"channels"`
+}
+
+// ElectrsState is the last known state of electrs.
+type ElectrsState struct {
+	Blocks int64 `json:"blocks"`
+}
+
+// SystemState is the last known state of the system the middleware runs on.
+type SystemState struct {
+	Network        string `json:"network"`
+	ElectrsRPCPort string `json:"electrsRPCPort"`
+}
+
+// State is the last known state of the services running on the Base.
+type State struct {
+	Bitcoind  BitcoindState  `json:"bitcoind"`
+	Lightning LightningState `json:"lightning"`
+	Electrs   ElectrsState   `json:"electrs"`
+	System    SystemState    `json:"system"`
+}
+
+// stateHistorySize is the number of changes the store remembers to send patches to clients resyncing from an older
+// version. Clients that are further behind receive a snapshot.
+const stateHistorySize = 64
+
+// statePaths returns the paths of all fields of the state, like "bitcoind.blocks", built from the json tags.
+func statePaths(value reflect.Value, prefix string) []string {
+	paths := []string{}
+	for i := 0; i < value.NumField(); i++ {
+		path := prefix + strings.Split(value.Type().Field(i).Tag.Get("json"), ",")[0]
+		if value.Field(i).Kind() == reflect.Struct {
+			paths = append(paths, statePaths(value.Field(i), path+".")...)
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// stateField returns the field of the state at the given path.
+func stateField(state reflect.Value, path string) reflect.Value {
+	for _, name := range strings.Split(path, ".") {
+		for i := 0; i < state.NumField(); i++ {
+			if strings.Split(state.Type().Field(i).Tag.Get("json"), ",")[0] == name {
+				state = state.Field(i)
+				break
+			}
+		}
+	}
+	return state
+}
+
+// changedPaths returns the paths of the fields that differ between two states.
+func changedPaths(old, new State) []string {
+	paths := []string{}
+	for _, path := range statePaths(reflect.ValueOf(old), "") {
+		if stateField(reflect.ValueOf(old), path).Interface() != stateField(reflect.ValueOf(new), path).Interface() {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// maskState returns a state that only has the fields at the given paths set.
+func maskState(state State, paths []string) State {
+	var masked State
+	for _, path := range paths {
+		stateField(reflect.ValueOf(&masked).Elem(), path).Set(stateField(reflect.ValueOf(state), path))
+	}
+	return masked
+}
+
+// stateToProto converts the state to its protobuf message. Sections without any of the given paths are left out.
+func stateToProto(state State, paths []string) *basemessages.BaseState {
+	hasSection := func(section string) bool {
+		for _, path := range paths {
+			if strings.HasPrefix(path, section+".") {
+				return true
+			}
+		}
+		return false
+	}
+	stateProto := &basemessages.BaseState{}
+	if hasSection("bitcoind") {
+		stateProto.Bitcoind = &basemessages.BaseBitcoindState{
+			Blocks:              state.Bitcoind.Blocks,
+			Difficulty:          state.Bitcoind.Difficulty,
+			BestBlockHash:       state.Bitcoind.BestBlockHash,
+			MempoolTransactions: state.Bitcoind.MempoolTransactions,
+			Unreachable:         state.Bitcoind.Unreachable,
+		}
+	}
+	if hasSection("lightning") {
+		stateProto.Lightning = &basemessages.BaseLightningState{
+			Alias:    state.Lightning.Alias,
+			Channels: state.Lightning.Channels,
+		}
+	}
+	if hasSection("electrs") {
+		stateProto.Electrs = &basemessages.BaseElectrsState{Blocks: state.Electrs.Blocks}
+	}
+	if hasSection("system") {
+		stateProto.System = &basemessages.BaseSystemState{
+			Network:        state.System.Network,
+			ElectrsRPCPort: state.System.ElectrsRPCPort,
+		}
+	}
+	return stateProto
+}
+
+// stateChange records the paths that changed from version-1 to version.
+type stateChange struct {
+	version uint64
+	paths   []string
+}
+
+// subscriber tracks the version of the state the client has. Changes are coalesced, so a slow client never falls
+// behind by more than one message and never misses a change.
+type subscriber struct {
+	// version is the version of the state last delivered to the subscriber.
+	version uint64
+	// snapshot is true if the next delivery must be a full snapshot.
+	snapshot bool
+	// reply is true if the next delivery must be sent even if nothing changed, to answer a resync.
+	reply  bool
+	notify chan struct{}
+}
+
+// stateStore holds the last known state of the Base and notifies subscribers about changes. It is safe for concurrent
+// use.
+type stateStore struct {
+	mu      sync.Mutex
+	state   State
+	version uint64
+	// history holds the most recent changes, oldest first.
+	history     []stateChange
+	subscribers map[int]*subscriber
+	nextID      int
+	logger      *logging.Logger
+}
+
+func newStateStore(logger *logging.Logger, state State) *stateStore {
+	return &stateStore{
+		logger:      logger,
+		state:       state,
+		version:     1,
+		subscribers: make(map[int]*subscriber),
+	}
+}
+
+// get returns a copy of the current state.
+func (store *stateStore) get() State {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return store.state
+}
+
+// update changes the state with the given function. If anything changed, the version is incremented and the
+// subscribers are notified.
+func (store *stateStore) update(change func(state *State)) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	old := store.state
+	change(&store.state)
+	paths := changedPaths(old, store.state)
+	if len(paths) == 0 {
+		return
+	}
+	store.version++
+	store.history = append(store.history, stateChange{version: store.version, paths: paths})
+	if len(store.history) > stateHistorySize {
+		store.history = store.history[len(store.history)-stateHistorySize:]
+	}
+	for _, subscriber := range store.subscribers {
+		store.notify(subscriber)
+	}
+}
+
+// notify wakes up the subscriber. It must be called with mu held.
+func (store *stateStore) notify(subscriber *subscriber) {
+	select {
+	case subscriber.notify <- struct{}{}:
+	default:
+		// The subscriber was already notified and picks up the accumulated changes.
+	}
+}
+
+// changesSince returns the paths that changed after the given version. ok is false if the version is unknown or older
+// than the history. It must be called with mu held.
+func (store *stateStore) changesSince(version uint64) (paths []string, ok bool) {
+	if version > store.version {
+		return nil, false
+	}
+	if version == store.version {
+		return []string{}, true
+	}
+	if len(store.history) == 0 || version < store.history[0].version-1 {
+		return nil, false
+	}
+	seen := map[string]bool{}
+	paths = []string{}
+	for _, change := range store.history {
+		if change.version <= version {
+			continue
+		}
+		for _, path := range change.paths {
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths, true
+}
+
+// subscribe returns a channel that delivers the protobuf serialized state: first a full snapshot, then patches with the
+// changes. Calling resync makes the subscription send a patch from the given version, or a snapshot if the version is
+// too old. Calling unsubscribe ends the subscription.
+func (store *stateStore) subscribe() (events <-chan []byte, resync func(version uint64), unsubscribe func()) {
+	store.mu.Lock()
+	id := store.nextID
+	store.nextID++
+	subscriber := &subscriber{
+		snapshot: true,
+		notify:   make(chan struct{}, 1),
+	}
+	store.notify(subscriber)
+	store.subscribers[id] = subscriber
+	store.mu.Unlock()
+
+	eventsChan := make(chan []byte)
+	quit := make(chan struct{})
+	var quitOnce sync.Once
+	unsubscribe = func() {
+		quitOnce.Do(func() {
+			store.mu.Lock()
+			delete(store.subscribers, id)
+			store.mu.Unlock()
+			close(quit)
+		})
+	}
+	resync = func(version uint64) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		// A version ahead of the store, e.g. from before a restart, can only be answered with a snapshot. It is not
+		// kept, as it would make the subscriber appear to lag by almost 2^64 versions.
+		subscriber.snapshot = version > store.version
+		if subscriber.snapshot {
+			version = store.version
+		}
+		subscriber.version = version
+		subscriber.reply = true
+		store.notify(subscriber)
+	}
+	go func() {
+		for {
+			select {
+			case <-subscriber.notify:
+			case <-quit:
+				return
+			}
+			for _, message := range store.collect(subscriber) {
+				select {
+				case eventsChan <- message:
+				case <-quit:
+					return
+				}
+			}
+		}
+	}()
+	return eventsChan, resync, unsubscribe
+}
+
+// subscriberLag returns the number of subscribers and how many versions the furthest behind one lags behind the state.
+func (store *stateStore) subscriberLag() (int, uint64) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	var maxLag uint64
+	for _, subscriber := range store.subscribers {
+		if lag := store.version - subscriber.version; lag > maxLag {
+			maxLag = lag
+		}
+	}
+	return len(store.subscribers), maxLag
+}
+
+// collect returns the protobuf serialized messages bringing the subscriber to the current version.
+func (store *stateStore) collect(subscriber *subscriber) [][]byte {
+	store.mu.Lock()
+	state := store.state
+	stateOut := &basemessages.BaseStateOut{
+		Version:     store.version,
+		BaseVersion: subscriber.version,
+	}
+	paths, ok := store.changesSince(subscriber.version)
+	if subscriber.snapshot || !ok {
+		stateOut.Snapshot = true
+		stateOut.BaseVersion = 0
+		paths = statePaths(reflect.ValueOf(state), "")
+	}
+	reply := subscriber.reply
+	subscriber.version = store.version
+	subscriber.snapshot = false
+	subscriber.reply = false
+	store.mu.Unlock()
+	if len(paths) == 0 && !reply {
+		return nil
+	}
+
+	if stateOut.Snapshot {
+		stateOut.State = stateToProto(state, paths)
+	} else {
+		stateOut.Paths = paths
+		stateOut.State = stateToProto(maskState(state, paths), paths)
+	}
+	outgoing := []proto.Message{
+		&basemessages.BitBoxBaseOut{
+			BitBoxBaseOut: &basemessages.BitBoxBaseOut_BaseStateOut{BaseStateOut: stateOut},
+		},
+	}
+	// BaseMiddlewareInfoOut is still sent for clients that do not know BaseStateOut yet.
+	if stateOut.State.Bitcoind != nil || stateOut.State.Lightning != nil {
+		outgoing = append(outgoing, &basemessages.BitBoxBaseOut{
+			BitBoxBaseOut: &basemessages.BitBoxBaseOut_BaseMiddlewareInfoOut{
+				BaseMiddlewareInfoOut: &basemessages.BaseMiddlewareInfoOut{
+					Blocks:         state.Bitcoind.Blocks,
+					Difficulty:     float32(state.Bitcoind.Difficulty),
+					LightningAlias: state.Lightning.Alias,
+				},
+			},
+		})
+	}
+	messages := make([][]byte, 0, len(outgoing))
+	for _, message := range outgoing {
+		data, err := proto.Marshal(message)
+		if err != nil {
+			store.logger.Error("Failed to marshal state", "error", err)
+			continue
+		}
+		messages = append(messages, data)
+	}
+	return messages
+}
@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/digitalbitbox/bitbox-base/middleware/src/system"
+)
+
+// Version is the version of the middleware.
+const Version = "0.1.0"
+
+// supportBundleLogLines is the number of journal entries of every unit in a support bundle.
+const supportBundleLogLines = 1000
+
+// buildInfo describes the middleware binary.
+type buildInfo struct {
+	Version   string `json:"version"`
+	GoVersion string `json:"goVersion"`
+	Platform  string `json:"platform"`
+	// Module and Dependencies are only known for binaries built in module mode.
+	Module       string   `json:"module,omitempty"`
+	Dependencies []string `json:"dependencies,omitempty"`
+}
+
+// newBuildInfo returns the build info of the running binary.
+func newBuildInfo() buildInfo {
+	info := buildInfo{
+		Version:   Version,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if moduleInfo, ok := debug.ReadBuildInfo(); ok {
+		info.Module = moduleInfo.Main.Path + "@" + moduleInfo.Main.Version
+		for _, dependency := range moduleInfo.Deps {
+			info.Dependencies = append(info.Dependencies, dependency.Path+"@"+dependency.Version)
+		}
+	}
+	return info
+}
+
+// formatJournalEntry formats an entry for the logs of a support bundle, like journalctl with the time and the
+// priority.
+func formatJournalEntry(entry system.JournalEntry) string {
+	return entry.Time.UTC().Format(time.RFC3339Nano) + " <" + strconv.Itoa(entry.Priority) + "> " + entry.Message + "\n"
+}
+
+// SupportBundle writes a tar.gz archive with diagnostics of the Base to writer, for users to attach to a support
+// request. It contains the build info of the middleware, the settings, the state and health of the services, the disk
+// usage, the temperature history and the recent logs of every unit. Passwords are not included and secrets are
+// redacted from the logs. Parts that can not be collected contain the error instead.
+func (middleware *Middleware) SupportBundle(writer io.Writer) error {
+	gzipWriter := gzip.NewWriter(writer)
+	archive := tar.NewWriter(gzipWriter)
+	created := time.Now()
+	addFile := func(name string, data string) error {
+		data = middleware.logger.Redact(data)
+		header := &tar.Header{
+			Name:     name,
+			Typeflag: tar.TypeReg,
+			Mode:     0600,
+			Size:     int64(len(data)),
+			ModTime:  created,
+		}
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+		_, err := io.WriteString(archive, data)
+		return err
+	}
+	addJSON := func(name string, value interface{}) error {
+		encoded, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return err
+		}
+		return addFile(name, string(encoded)+"\n")
+	}
+
+	config := make(map[string]string)
+	for _, key := range system.ConfigKeys() {
+		value, err := middleware.backends.System.ConfigGet(key)
+		if err != nil {
+			value = "error: " + err.Error()
+		}
+		config[key] = strings.TrimSpace(value)
+	}
+	// The current sample is added, so that the bundle shows the temperature even right after a restart.
+	middleware.sampleThermal()
+	files := []struct {
+		name  string
+		value interface{}
+	}{
+		{"version.json", newBuildInfo()},
+		{"config.json", config},
+		{"services.json", middleware.backends.System.ServicesStatus()},
+		{"status.json", struct {
+			State  State           `json:"state"`
+			Health []BackendHealth `json:"health"`
+		}{middleware.State(), middleware.BackendsHealth()}},
+		{"disks.json", middleware.backends.System.Disks()},
+		{"thermal.json", middleware.ThermalHistory()},
+	}
+	for _, file := range files {
+		if err := addJSON(file.name, file.value); err != nil {
+			return err
+		}
+	}
+
+	for _, unit := range system.LogUnits() {
+		var logs strings.Builder
+		query := system.JournalQuery{Unit: unit, Lines: supportBundleLogLines, Priority: system.PriorityDebug}
+		err := middleware.backends.System.Journal(query, nil, func(entry system.JournalEntry) {
+			logs.WriteString(formatJournalEntry(entry))
+		})
+		if err != nil {
+			logs.WriteString("error: " + err.Error() + "\n")
+		}
+		if err := addFile("logs/"+unit+".log", logs.String()); err != nil {
+			return err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
@@ -0,0 +1,131 @@
+package system
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// configScript is the system configuration utility of the BitBox Base.
+const configScript = "/usr/local/sbin/bbb-config.sh"
+
+// configToggles lists the settings that bbb-config.sh switches with the enable and disable commands.
+func configToggles() []string {
+	return []string{"dashboard_hdmi", "dashboard_web", "wifi", "autosetup_ssd", "tor_ssh", "tor_electrum", "overlayroot"}
+}
+
+// configValues lists the settings that bbb-config.sh sets with the set command. The root password is deliberately
+// left out, it can only be changed locally.
+func configValues() []string {
+	return []string{"bitcoin_network", "hostname"}
+}
+
+// configReadOnly lists the settings that can only be read.
+func configReadOnly() []string {
+	return []string{"all", "tor_ssh_onion", "tor_electrum_onion"}
+}
+
+// ConfigKeys returns the settings that can be changed, e.g. to collect them for a support bundle. It includes neither
+// passwords nor the onion addresses of the Base.
+func ConfigKeys() []string {
+	return append(configToggles(), configValues()...)
+}
+
+// ConfigValue returns the value of a setting in the output of ConfigGet, which bbb-config.sh prints like KEY=VALUE,
+// in the form ConfigSet takes it: settings that are switched on and off are true or false.
+func ConfigValue(key, output string) string {
+	value := strings.TrimSpace(output)
+	if index := strings.Index(value, "="); index >= 0 && strings.EqualFold(value[:index], key) {
+		value = value[index+1:]
+	}
+	if contains(configToggles(), strings.ToLower(key)) {
+		switch value {
+		case "1":
+			return "true"
+		case "0":
+			return "false"
+		}
+	}
+	return value
+}
+
+func contains(list []string, key string) bool {
+	for _, item := range list {
+		if item == key {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateConfigGet returns an error if key is not a setting that can be read.
+func ValidateConfigGet(key string) error {
+	key = strings.ToLower(key)
+	if !contains(configToggles(), key) && !contains(configValues(), key) && !contains(configReadOnly(), key) {
+		return errors.New("unknown setting " + key)
+	}
+	return nil
+}
+
+// ConfigGet reads a setting of the BitBox Base with bbb-config.sh.
+func ConfigGet(key string) (string, error) {
+	if err := ValidateConfigGet(key); err != nil {
+		return "", err
+	}
+	return runConfigScript("get", strings.ToLower(key))
+}
+
+// configSetArgs returns the arguments of bbb-config.sh that change a setting, or an error if the setting can not be
+// changed to the value.
+func configSetArgs(key, value string) ([]string, error) {
+	key = strings.ToLower(key)
+	switch {
+	case contains(configToggles(), key):
+		switch value {
+		case "true":
+			return []string{"enable", key}, nil
+		case "false":
+			return []string{"disable", key}, nil
+		default:
+			return nil, errors.New("setting " + key + " can only be set to true or false")
+		}
+	case key == "bitcoin_network":
+		if _, err := ParseNetwork(value); err != nil {
+			return nil, err
+		}
+		return []string{"set", key, value}, nil
+	case contains(configValues(), key):
+		return []string{"set", key, value}, nil
+	default:
+		return nil, errors.New("unknown or read-only setting " + key)
+	}
+}
+
+// ValidateConfigSet returns an error if the setting can not be changed to the value.
+func ValidateConfigSet(key, value string) error {
+	_, err := configSetArgs(key, value)
+	return err
+}
+
+// ConfigSet changes a setting of the BitBox Base with bbb-config.sh. Settings that are switched on and off take the
+// values true and false.
+func ConfigSet(key, value string) (string, error) {
+	args, err := configSetArgs(key, value)
+	if err != nil {
+		return "", err
+	}
+	return runConfigScript(args...)
+}
+
+// runConfigScript runs bbb-config.sh without a shell, so that values can not inject commands.
+func runConfigScript(args ...string) (string, error) {
+	output, err := exec.Command(configScript, args...).CombinedOutput()
+	trimmed := strings.TrimSpace(string(output))
+	if err != nil {
+		if trimmed == "" {
+			return "", errors.New(err.Error() + " bbb-config.sh failed")
+		}
+		return "", errors.New(trimmed)
+	}
+	return trimmed, nil
+}
@@ -0,0 +1,193 @@
+package system
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/digitalbitbox/bitbox-base/middleware/src/backup"
+)
+
+// configBackupFiles returns the system files that a configuration backup holds, relative to the root directory: the
+// keys of the tor hidden services, so that the onion addresses survive reflashing, and the network settings, including
+// the wifi credentials. The settings of bbb-config.sh are backed up separately, as they are applied again with it.
+func configBackupFiles() []string {
+	files := interfacesFiles()
+	for _, hiddenService := range []string{
+		"hidden_service_ssh", "hidden_service_electrum", "lightningd-service_v3", "hidden_service_middleware",
+	} {
+		for _, name := range []string{"hs_ed25519_secret_key", "hs_ed25519_public_key", "hostname"} {
+			files = append(files, filepath.Join("var/lib/tor", hiddenService, name))
+		}
+	}
+	return files
+}
+
+// interfacesFiles returns the files of a configuration backup in the format of interfaces(5). bbb-config.sh copies the
+// wifi settings to /etc/network/interfaces.d, so they are read by ifupdown as well.
+func interfacesFiles() []string {
+	return []string{"opt/shift/config/wifi/wlan0.conf", "etc/network/interfaces"}
+}
+
+// interfacesOptions returns the options of interface stanzas that network settings in configuration backups may use.
+// Everything else is refused, in particular the hooks like pre-up and up, which run commands as root.
+func interfacesOptions() map[string]bool {
+	options := map[string]bool{}
+	for _, option := range []string{
+		"address", "netmask", "gateway", "broadcast", "network", "metric", "mtu", "hwaddress", "pointopoint",
+		"scope", "hostname", "dns-nameservers", "dns-search", "dns-domain",
+		"wpa-ssid", "wpa-psk", "wpa-key-mgmt", "wpa-scan-ssid", "wpa-ap-scan", "wpa-proto", "wpa-pairwise",
+		"wpa-group", "wpa-bssid", "wpa-country",
+	} {
+		options[option] = true
+	}
+	return options
+}
+
+// validateInterfaces returns an error if the network settings in data use anything but interface stanzas with the
+// options of interfacesOptions, and the include of /etc/network/interfaces.d of the default settings.
+func validateInterfaces(name string, data []byte) error {
+	options := interfacesOptions()
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if strings.HasSuffix(strings.TrimSpace(line), "\\") {
+			return errors.New("continued lines are not supported in " + name)
+		}
+		switch keyword := fields[0]; {
+		case keyword == "auto" || keyword == "iface" || strings.HasPrefix(keyword, "allow-"):
+		case keyword == "source" && len(fields) == 2 && fields[1] == "/etc/network/interfaces.d/*":
+		case keyword == "source-directory" && len(fields) == 2 && fields[1] == "/etc/network/interfaces.d":
+		case !options[keyword]:
+			return errors.New("unsupported directive " + keyword + " in " + name)
+		}
+	}
+	return nil
+}
+
+// ValidateConfigFile returns an error if file is not a system file that configuration backups hold, or if it holds
+// network settings that could run commands.
+func ValidateConfigFile(file backup.File) error {
+	for _, name := range interfacesFiles() {
+		if name == file.Name {
+			return validateInterfaces(file.Name, file.Data)
+		}
+	}
+	for _, name := range configBackupFiles() {
+		if name == file.Name {
+			return nil
+		}
+	}
+	return errors.New("unexpected file " + file.Name + " in the configuration backup")
+}
+
+// ConfigFiles reads the system files that configuration backups hold below the root directory, named relative to it.
+// Files that do not exist, e.g. of hidden services that were never enabled, are left out.
+func ConfigFiles(root string) ([]backup.File, error) {
+	files := []backup.File{}
+	for _, name := range configBackupFiles() {
+		path := filepath.Join(root, name)
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, backup.File{Name: name, Data: data, Mode: info.Mode().Perm()})
+	}
+	return files, nil
+}
+
+// ownerOf returns the user and group that own path.
+func ownerOf(path string) (uid, gid int, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, errors.New("can not determine the owner of " + path)
+	}
+	return int(stat.Uid), int(stat.Gid), nil
+}
+
+// makeDir creates dir and its missing parents, owned like the closest existing parent and only accessible by it, as
+// tor requires for the directories of hidden services.
+func makeDir(dir string) error {
+	if _, err := os.Stat(dir); err == nil {
+		return nil
+	}
+	parent := filepath.Dir(dir)
+	if parent == dir {
+		return errors.New("can not create " + dir)
+	}
+	if err := makeDir(parent); err != nil {
+		return err
+	}
+	uid, gid, err := ownerOf(parent)
+	if err != nil {
+		return err
+	}
+	if err := os.Mkdir(dir, 0700); err != nil {
+		return err
+	}
+	return os.Chown(dir, uid, gid)
+}
+
+// RestoreConfigFiles puts the system files of a configuration backup in place below the root directory. Every file
+// is owned by the owner of its directory and keeps the permissions it was backed up with. The files are validated
+// with ValidateConfigFile first.
+func RestoreConfigFiles(root string, files []backup.File) error {
+	for _, file := range files {
+		if err := ValidateConfigFile(file); err != nil {
+			return err
+		}
+	}
+	for _, file := range files {
+		path := filepath.Join(root, file.Name)
+		if err := makeDir(filepath.Dir(path)); err != nil {
+			return err
+		}
+		uid, gid, err := ownerOf(filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		stagedPath, err := stageFile(path, file.Data, uid, gid)
+		if err != nil {
+			return err
+		}
+		mode := file.Mode.Perm()
+		if mode == 0 {
+			mode = 0600
+		}
+		err = os.Chmod(stagedPath, mode)
+		if err == nil {
+			err = os.Rename(stagedPath, path)
+		}
+		if err != nil {
+			_ = os.Remove(stagedPath)
+			return err
+		}
+	}
+	return nil
+}
+
+// RestartTor restarts tor, e.g. so that it serves the hidden services with restored keys.
+func RestartTor() error {
+	output, err := exec.Command("systemctl", "restart", "tor.service").CombinedOutput()
+	if err != nil {
+		return errors.New("systemctl restart tor failed: " + strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,339 @@
+package system
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/digitalbitbox/bitbox-base/middleware/src/logging"
+)
+
+// DefaultConfigFile is the config file of the middleware on the Base. bbb-config.sh edits it as well.
+const DefaultConfigFile = "/etc/base-middleware/base-middleware.conf"
+
+// legacyLightningRPCPath is where the lightning rpc socket was expected before LIGHTNING_RPCPATH defaulted to the
+// lightning directory of the network.
+const legacyLightningRPCPath = "/home/bitcoin/.lightning/lightning-rpc"
+
+// The layers the settings are taken from, in increasing priority. Defaults that depend on the network, like the rpc
+// port of bitcoind, are marked as network defaults.
+const (
+	sourceDefault     = "default"
+	sourceNetwork     = "network default"
+	sourceConfigFile  = "config file"
+	sourceEnvironment = "environment"
+	sourceFlag        = "flag"
+)
+
+// setting describes a single setting of the environment. The key is used in the config file and as the name of the
+// environment variable.
+type setting struct {
+	key          string
+	flag         string
+	usage        string
+	defaultValue string
+	// secret settings are not shown by Dump.
+	secret bool
+	// reloadable settings are applied by Reload while the middleware is running. Others require a restart.
+	reloadable bool
+	// networkDefault returns the default for the network, if the default depends on it.
+	networkDefault func(network Network) string
+	field          func(environment *Environment) *string
+}
+
+// settings returns all settings of the environment.
+func settings() []setting {
+	return []setting{
+		{key: "BITCOIN_NETWORK", flag: "network", defaultValue: string(NetworkTestnet),
+			usage: "Bitcoin network: " + networkNames(),
+			field: func(environment *Environment) *string { return (*string)(&environment.Network) }},
+		{key: "BITCOIN_RPCUSER", flag: "rpcuser", defaultValue: "rpcuser", reloadable: true,
+			usage: "Bitcoin rpc user name",
+			field: func(environment *Environment) *string { return &environment.BitcoinRPCUser }},
+		{key: "BITCOIN_RPCPASSWORD", flag: "rpcpassword", defaultValue: "rpcpassword", reloadable: true, secret: true,
+			usage: "Bitcoin rpc password. Prefer the config file or the environment, flags show up in the process list",
+			field: func(environment *Environment) *string { return &environment.BitcoinRPCPassword }},
+		{key: "BITCOIN_RPCCOOKIE", flag: "rpccookie", reloadable: true,
+			usage: "Path of the bitcoind rpc cookie file, e.g. /mnt/ssd/bitcoin/.bitcoin/.cookie. Used instead of -rpcuser and -rpcpassword if set",
+			field: func(environment *Environment) *string { return &environment.BitcoinRPCCookie }},
+		{key: "BITCOIN_RPCPORT", flag: "rpcport", reloadable: true,
+			usage:          "Bitcoin rpc port, localhost is assumed as an address. Defaults to the port of the network, e.g. 18332 on testnet",
+			networkDefault: Network.BitcoinRPCPort,
+			field:          func(environment *Environment) *string { return &environment.BitcoinRPCPort }},
+		{key: "BITCOIN_ZMQ_HASHBLOCK", flag: "zmq-hashblock",
+			usage: "Address of the bitcoind zmqpubhashblock notifications, e.g. tcp://127.0.0.1:28332. bitcoind is polled if empty",
+			field: func(environment *Environment) *string { return &environment.BitcoinZMQHashblock }},
+		{key: "BITCOIN_ZMQ_RAWTX", flag: "zmq-rawtx",
+			usage: "Address of the bitcoind zmqpubrawtx notifications, e.g. tcp://127.0.0.1:28333. Disabled if empty",
+			field: func(environment *Environment) *string { return &environment.BitcoinZMQRawtx }},
+		{key: "LIGHTNING_RPCPATH", flag: "lightning-rpc-path", reloadable: true,
+			usage:          "Path to the lightning rpc unix socket. Defaults to the lightning directory of the network, e.g. /mnt/ssd/bitcoin/.lightning-testnet/lightning-rpc",
+			networkDefault: func(network Network) string { return network.LightningDir() + "/lightning-rpc" },
+			field:          func(environment *Environment) *string { return &environment.LightningRPCPath }},
+		{key: "ELECTRS_RPCPORT", flag: "electrsport", reloadable: true,
+			usage:          "Electrs rpc port. Defaults to the port of the network, e.g. 51002 on testnet",
+			networkDefault: Network.ElectrsRPCPort,
+			field:          func(environment *Environment) *string { return &environment.ElectrsRPCPort }},
+		{key: "MIDDLEWARE_DATADIR", flag: "datadir", defaultValue: ".base",
+			usage: "Directory where middleware persistent data like noise keys is stored",
+			field: func(environment *Environment) *string { return &environment.DataDir }},
+		{key: "MIDDLEWARE_LISTEN", flag: "listen", defaultValue: ":8845",
+			usage: "Comma separated addresses to serve the http api and the websocket on, like :8845, 127.0.0.1:8845 or unix:/run/base-middleware/http.sock",
+			field: func(environment *Environment) *string { return &environment.Listen }},
+		{key: "MIDDLEWARE_METRICS_LISTEN", flag: "metrics-listen", defaultValue: "127.0.0.1:8847",
+			usage: "Loopback address or unix:/path to serve the Prometheus metrics on, only reachable from the Base. Disabled if empty",
+			field: func(environment *Environment) *string { return &environment.MetricsListen }},
+		{key: "MIDDLEWARE_TLS_CERT", flag: "tls-cert",
+			usage: "Path of the TLS certificate for the http api on TCP addresses, e.g. /etc/ssl/certs/nginx-selfsigned.crt. Plain http if empty",
+			field: func(environment *Environment) *string { return &environment.TLSCert }},
+		{key: "MIDDLEWARE_TLS_KEY", flag: "tls-key",
+			usage: "Path of the private key of the TLS certificate",
+			field: func(environment *Environment) *string { return &environment.TLSKey }},
+		{key: "MIDDLEWARE_TCP_ADDRESS", flag: "tcp-address",
+			usage: "Address to serve the noise api on with length prefixed frames over plain TCP, e.g. 127.0.0.1:8846. Disabled if empty",
+			field: func(environment *Environment) *string { return &environment.TCPAddress }},
+		{key: "MIDDLEWARE_UNIX_SOCKET", flag: "unix-socket",
+			usage: "Path of a Unix socket to serve the noise api on with length prefixed frames. Disabled if empty",
+			field: func(environment *Environment) *string { return &environment.UnixSocket }},
+		{key: "MIDDLEWARE_LOG_LEVEL", flag: "log-level", defaultValue: "info", reloadable: true,
+			usage: "Minimum level of the logged lines: debug, info, warning or error. SIGUSR1 switches to debug until SIGUSR2",
+			field: func(environment *Environment) *string { return &environment.LogLevel }},
+	}
+}
+
+// RegisterFlags defines a flag for every setting of the environment. Only flags that are set on the command line
+// override the other layers.
+func RegisterFlags(flagSet *flag.FlagSet) {
+	for _, setting := range settings() {
+		flagSet.String(setting.flag, setting.defaultValue, setting.usage+" ("+setting.key+")")
+	}
+}
+
+// LoadEnvironment builds the environment in layers: the defaults, then the config file, then the environment
+// variables looked up with lookupEnv, then the flags of flagSet that were set. A missing config file is skipped. The
+// resulting environment is validated. Skipped settings, and a lightning rpc socket that is only found at its old
+// default path, are logged with logger.
+func LoadEnvironment(logger *logging.Logger, configFile string, lookupEnv func(key string) (string, bool),
+	flagSet *flag.FlagSet) (Environment, error) {
+	environment := Environment{sources: make(map[string]string)}
+	set := func(setting setting, value, source string) {
+		*setting.field(&environment) = value
+		environment.sources[setting.key] = source
+	}
+	for _, setting := range settings() {
+		set(setting, setting.defaultValue, sourceDefault)
+	}
+
+	values, err := readConfigFile(logger, configFile)
+	if err != nil {
+		return Environment{}, err
+	}
+	for _, setting := range settings() {
+		if value, ok := values[setting.key]; ok {
+			set(setting, value, sourceConfigFile)
+		}
+	}
+	for _, setting := range settings() {
+		if value, ok := lookupEnv(setting.key); ok {
+			set(setting, value, sourceEnvironment)
+		}
+	}
+	if flagSet != nil {
+		flagSet.Visit(func(setFlag *flag.Flag) {
+			for _, setting := range settings() {
+				if setting.flag == setFlag.Name {
+					set(setting, setFlag.Value.String(), sourceFlag)
+				}
+			}
+		})
+	}
+	// Settings that were not set otherwise take the default of the network.
+	if _, err := ParseNetwork(string(environment.Network)); err == nil {
+		for _, setting := range settings() {
+			if setting.networkDefault != nil && environment.sources[setting.key] == sourceDefault {
+				set(setting, setting.networkDefault(environment.Network), sourceNetwork)
+			}
+		}
+	}
+	if environment.sources["LIGHTNING_RPCPATH"] == sourceNetwork && !fileExists(environment.LightningRPCPath) &&
+		fileExists(legacyLightningRPCPath) {
+		logger.Warning("LIGHTNING_RPCPATH defaults to the lightning directory of the network now, set it to keep "+
+			"using the old socket", "path", environment.LightningRPCPath, "old", legacyLightningRPCPath)
+	}
+	if err := environment.Validate(); err != nil {
+		return Environment{}, err
+	}
+	return environment, nil
+}
+
+// fileExists returns true if there is a file, or a socket, at path.
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// readConfigFile parses a config file with KEY=VALUE lines, as used by systemd EnvironmentFile. Empty lines and lines
+// starting with # are skipped, values may be quoted. Unknown keys are logged and ignored.
+func readConfigFile(logger *logging.Logger, path string) (map[string]string, error) {
+	values := make(map[string]string)
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		logger.Info("Config file not found, using defaults", "path", path)
+		return values, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	known := make(map[string]bool)
+	for _, setting := range settings() {
+		known[setting.key] = true
+	}
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, lineNumber)
+		}
+		key := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		if !known[key] {
+			logger.Warning("Ignoring unknown setting", "path", path, "line", lineNumber, "key", key)
+			continue
+		}
+		values[key] = value
+	}
+	return values, scanner.Err()
+}
+
+// Validate checks that the settings are usable.
+func (environment Environment) Validate() error {
+	if _, err := ParseNetwork(string(environment.Network)); err != nil {
+		return errors.New("BITCOIN_NETWORK must be " + networkNames())
+	}
+	for key, port := range map[string]string{
+		"BITCOIN_RPCPORT": environment.BitcoinRPCPort,
+		"ELECTRS_RPCPORT": environment.ElectrsRPCPort,
+	} {
+		number, err := strconv.Atoi(port)
+		if err != nil || number < 1 || number > 65535 {
+			return errors.New(key + " must be a port number")
+		}
+	}
+	for key, address := range map[string]string{
+		"BITCOIN_ZMQ_HASHBLOCK": environment.BitcoinZMQHashblock,
+		"BITCOIN_ZMQ_RAWTX":     environment.BitcoinZMQRawtx,
+	} {
+		if address != "" && !strings.HasPrefix(address, "tcp://") {
+			return errors.New(key + " must be a tcp:// address")
+		}
+	}
+	if environment.LightningRPCPath == "" {
+		return errors.New("LIGHTNING_RPCPATH must not be empty")
+	}
+	if environment.DataDir == "" {
+		return errors.New("MIDDLEWARE_DATADIR must not be empty")
+	}
+	if len(environment.ListenAddresses()) == 0 {
+		return errors.New("MIDDLEWARE_LISTEN must contain at least one address")
+	}
+	for _, address := range environment.ListenAddresses() {
+		if strings.HasPrefix(address, "unix:") {
+			if strings.TrimPrefix(address, "unix:") == "" {
+				return errors.New("MIDDLEWARE_LISTEN contains a unix socket without a path")
+			}
+			continue
+		}
+		_, port, err := net.SplitHostPort(address)
+		if number, portErr := strconv.Atoi(port); err != nil || portErr != nil || number < 0 || number > 65535 {
+			return errors.New("MIDDLEWARE_LISTEN contains the invalid address " + address)
+		}
+	}
+	if environment.MetricsListen != "" && !isLocalAddress(environment.MetricsListen) {
+		return errors.New("MIDDLEWARE_METRICS_LISTEN must be a loopback address like 127.0.0.1:8847 or a unix socket")
+	}
+	if (environment.TLSCert == "") != (environment.TLSKey == "") {
+		return errors.New("MIDDLEWARE_TLS_CERT and MIDDLEWARE_TLS_KEY must be set together")
+	}
+	if _, err := logging.ParseLevel(environment.LogLevel); err != nil {
+		return errors.New("MIDDLEWARE_LOG_LEVEL must be debug, info, warning or error")
+	}
+	return nil
+}
+
+// isLocalAddress returns true if address is a Unix socket given as unix:/path or a TCP address on a loopback interface.
+func isLocalAddress(address string) bool {
+	if strings.HasPrefix(address, "unix:") {
+		return strings.TrimPrefix(address, "unix:") != ""
+	}
+	host, port, err := net.SplitHostPort(address)
+	if number, portErr := strconv.Atoi(port); err != nil || portErr != nil || number < 0 || number > 65535 {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return host == "localhost" || (ip != nil && ip.IsLoopback())
+}
+
+// ListenAddresses returns the addresses to serve the http api on. Unix sockets are prefixed with unix:.
+func (environment Environment) ListenAddresses() []string {
+	addresses := []string{}
+	for _, address := range strings.Split(environment.Listen, ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
+
+// Dump returns the effective settings in the config file format, each preceded by a comment naming the layer it was
+// taken from. Secrets are redacted.
+func (environment Environment) Dump() string {
+	var dump strings.Builder
+	for _, setting := range settings() {
+		value := *setting.field(&environment)
+		if setting.secret && value != "" {
+			value = "<redacted>"
+		}
+		source := environment.sources[setting.key]
+		if source == "" {
+			source = sourceDefault
+		}
+		fmt.Fprintf(&dump, "# %s\n%s=%s\n", source, setting.key, value)
+	}
+	return dump.String()
+}
+
+// Reload returns the environment with the reloadable settings taken from the reloaded environment. The keys of the
+// settings that changed but require a restart are returned as well, those keep their current values.
+func (environment Environment) Reload(reloaded Environment) (Environment, []string) {
+	restartRequired := []string{}
+	result := environment
+	result.sources = make(map[string]string)
+	for _, setting := range settings() {
+		current := *setting.field(&environment)
+		value := *setting.field(&reloaded)
+		result.sources[setting.key] = environment.sources[setting.key]
+		if value == current {
+			continue
+		}
+		if !setting.reloadable {
+			restartRequired = append(restartRequired, setting.key)
+			continue
+		}
+		*setting.field(&result) = value
+		result.sources[setting.key] = reloaded.sources[setting.key]
+	}
+	return result, restartRequired
+}
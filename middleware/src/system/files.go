@@ -0,0 +1,32 @@
+package system
+
+import (
+	"io"
+	"os"
+)
+
+// WriteFile writes a file through a partial file next to path, which is synced to disk and only moved into place if
+// write succeeds. path is left untouched otherwise.
+func WriteFile(path string, write func(writer io.Writer) error) error {
+	partialPath := path + ".partial"
+	file, err := os.OpenFile(partialPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Removing fails once the file was moved into place.
+		_ = os.Remove(partialPath)
+	}()
+	err = write(file)
+	if err == nil {
+		// The file may be on a drive that is unplugged right after.
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(partialPath, path)
+}
@@ -0,0 +1,96 @@
+package system
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// temperatureFile and fanFile are the sysfs files of the ROCKPro64 bbbfancontrol reads and writes.
+	temperatureFile = "/sys/class/thermal/thermal_zone0/temp"
+	fanFile         = "/sys/class/hwmon/hwmon0/pwm1"
+)
+
+// DiskUsage is the usage of a file system of the Base.
+type DiskUsage struct {
+	Path string `json:"path"`
+	// Mounted is false if the path is not a mount point, e.g. if the SSD is missing. The sizes are not set then.
+	Mounted    bool   `json:"mounted"`
+	TotalBytes uint64 `json:"totalBytes"`
+	FreeBytes  uint64 `json:"freeBytes"`
+}
+
+// diskPaths returns the mount points of the root file system and the SSD.
+func diskPaths() []string {
+	return []string{"/", "/mnt/ssd"}
+}
+
+// Disks returns the usage of the root file system and the SSD.
+func Disks() []DiskUsage {
+	disks := []DiskUsage{}
+	for _, path := range diskPaths() {
+		disk := DiskUsage{Path: path}
+		var stat syscall.Statfs_t
+		if isMountPoint(path) && syscall.Statfs(path, &stat) == nil {
+			disk.Mounted = true
+			disk.TotalBytes = stat.Blocks * uint64(stat.Bsize)
+			disk.FreeBytes = stat.Bavail * uint64(stat.Bsize)
+		}
+		disks = append(disks, disk)
+	}
+	return disks
+}
+
+// isMountPoint returns true if path is the root directory or on another device than its parent directory.
+func isMountPoint(path string) bool {
+	if path == "/" {
+		return true
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	parentInfo, err := os.Stat(filepath.Dir(path))
+	if err != nil {
+		return false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	parentStat, parentOk := parentInfo.Sys().(*syscall.Stat_t)
+	return ok && parentOk && stat.Dev != parentStat.Dev
+}
+
+// ThermalSample is the temperature of the Base and the speed of its fan at a point in time.
+type ThermalSample struct {
+	Time time.Time `json:"time"`
+	// Temperature is the temperature of the CPU in °C.
+	Temperature float64 `json:"temperature"`
+	// Fan is the pwm value of the fan, from 0 (off) to 255 (full speed).
+	Fan int `json:"fan"`
+}
+
+// readNumber reads a file of sysfs that contains a single number.
+func readNumber(path string) (int, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+// Thermal reads the current temperature and fan speed from sysfs.
+func Thermal() (ThermalSample, error) {
+	millidegrees, err := readNumber(temperatureFile)
+	if err != nil {
+		return ThermalSample{}, err
+	}
+	fan, err := readNumber(fanFile)
+	if err != nil {
+		return ThermalSample{}, err
+	}
+	return ThermalSample{Time: time.Now(), Temperature: float64(millidegrees) / 1000, Fan: fan}, nil
+}
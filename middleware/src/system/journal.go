@@ -0,0 +1,174 @@
+package system
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PriorityDebug is the least severe syslog priority, a journal query with it returns entries of all priorities.
+const PriorityDebug = 7
+
+// priorityNames returns the names of the syslog priorities, indexed by priority, as journalctl accepts them.
+func priorityNames() []string {
+	return []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+}
+
+// ParsePriority returns the syslog priority given by its name, like err or warning, or by its number. An empty name
+// returns PriorityDebug, so that entries of all priorities are selected.
+func ParsePriority(name string) (int, error) {
+	if name == "" {
+		return PriorityDebug, nil
+	}
+	for priority, priorityName := range priorityNames() {
+		if strings.ToLower(name) == priorityName || name == strconv.Itoa(priority) {
+			return priority, nil
+		}
+	}
+	return 0, errors.New("invalid priority " + name + ", expected one of " + strings.Join(priorityNames(), ", "))
+}
+
+// LogUnits returns the systemd units whose logs can be read: the services of the Base, tor and the supervisor.
+func LogUnits() []string {
+	return append(Services(), "tor", "bbbsupervisor")
+}
+
+// IsLogUnit returns true if unit is one of the units returned by LogUnits.
+func IsLogUnit(unit string) bool {
+	for _, logUnit := range LogUnits() {
+		if logUnit == unit {
+			return true
+		}
+	}
+	return false
+}
+
+// JournalQuery selects the journal entries of a unit.
+type JournalQuery struct {
+	Unit string
+	// Lines is the number of past entries.
+	Lines int
+	// Follow keeps streaming new entries.
+	Follow bool
+	// Priority is the least severe syslog priority of the entries, e.g. 4 for warnings and more severe entries.
+	Priority int
+	// Since skips older entries, unless it is zero.
+	Since time.Time
+}
+
+// JournalEntry is an entry of the journal.
+type JournalEntry struct {
+	Time     time.Time
+	Unit     string
+	Priority int
+	Message  string
+}
+
+// ParseJournalEntry parses an entry in the JSON output format of journalctl.
+func ParseJournalEntry(line []byte) (JournalEntry, error) {
+	var fields struct {
+		Time     string          `json:"__REALTIME_TIMESTAMP"`
+		Unit     string          `json:"_SYSTEMD_UNIT"`
+		Priority string          `json:"PRIORITY"`
+		Message  json.RawMessage `json:"MESSAGE"`
+	}
+	if err := json.Unmarshal(line, &fields); err != nil {
+		return JournalEntry{}, err
+	}
+	microseconds, err := strconv.ParseInt(fields.Time, 10, 64)
+	if err != nil {
+		return JournalEntry{}, errors.New("invalid journal timestamp " + fields.Time)
+	}
+	entry := JournalEntry{
+		Time: time.Unix(0, microseconds*int64(time.Microsecond)),
+		Unit: strings.TrimSuffix(fields.Unit, ".service"),
+		// Entries without a priority are logged with the default priority info.
+		Priority: 6,
+	}
+	if priority, err := strconv.Atoi(fields.Priority); err == nil {
+		entry.Priority = priority
+	}
+	// journalctl encodes messages that are not valid UTF-8 as an array of bytes.
+	var bytes []byte
+	if err := json.Unmarshal(fields.Message, &entry.Message); err != nil {
+		if err := json.Unmarshal(fields.Message, &bytes); err != nil {
+			return JournalEntry{}, errors.New("invalid journal message")
+		}
+		// Invalid sequences are replaced, protobuf strings must be valid UTF-8.
+		var message strings.Builder
+		for _, r := range string(bytes) {
+			message.WriteRune(r)
+		}
+		entry.Message = message.String()
+	}
+	return entry, nil
+}
+
+// Journal streams the journal entries of a unit returned by LogUnits. It passes the selected past entries to onEntry
+// and, if the query follows the journal, keeps streaming new entries until stop is closed. onEntry may block, the
+// journal is read as fast as the entries are consumed.
+func Journal(query JournalQuery, stop <-chan struct{}, onEntry func(entry JournalEntry)) error {
+	if !IsLogUnit(query.Unit) {
+		return errors.New("logs of unit " + query.Unit + " are not available")
+	}
+	args := []string{
+		"--unit", query.Unit + ".service",
+		"--lines", strconv.Itoa(query.Lines),
+		"--priority", strconv.Itoa(query.Priority),
+		"--output", "json",
+		"--no-pager",
+	}
+	if !query.Since.IsZero() {
+		args = append(args, "--since", "@"+strconv.FormatInt(query.Since.Unix(), 10))
+	}
+	if query.Follow {
+		args = append(args, "--follow")
+	}
+	cmd := exec.Command("journalctl", args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return errors.New(err.Error() + " failed to start journalctl")
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-stop:
+			_ = cmd.Process.Kill()
+		case <-done:
+		}
+	}()
+	scanner := bufio.NewScanner(stdout)
+	// Entries can be larger than the default limit of the scanner.
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		entry, err := ParseJournalEntry(scanner.Bytes())
+		if err != nil {
+			continue
+		}
+		onEntry(entry)
+	}
+	if scanErr := scanner.Err(); scanErr != nil {
+		// journalctl --follow never exits on its own, and blocks once nobody reads its output.
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return errors.New(scanErr.Error() + " reading the journal failed")
+	}
+	err = cmd.Wait()
+	select {
+	case <-stop:
+		return nil
+	default:
+	}
+	if err != nil {
+		return errors.New(err.Error() + " journalctl failed")
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package system
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// The files of c-lightning in its lightning directory that are backed up.
+const (
+	lightningDatabase  = "lightningd.sqlite3"
+	lightningHSMSecret = "hsm_secret"
+)
+
+// LightningFiles reads the database and the hsm_secret of c-lightning from its lightning directory. The database is
+// copied with the backup api of sqlite, so that the copy is consistent while lightningd keeps writing to it.
+func LightningFiles(dir string) (database, hsmSecret []byte, err error) {
+	hsmSecret, err = ioutil.ReadFile(filepath.Join(dir, lightningHSMSecret))
+	if err != nil {
+		return nil, nil, err
+	}
+	copyFile, err := ioutil.TempFile("", "lightningd-backup")
+	if err != nil {
+		return nil, nil, err
+	}
+	_ = copyFile.Close()
+	defer os.Remove(copyFile.Name())
+	output, err := exec.Command("sqlite3", filepath.Join(dir, lightningDatabase),
+		".backup '"+copyFile.Name()+"'").CombinedOutput()
+	if err != nil {
+		return nil, nil, errors.New("copying the lightning database failed: " + strings.TrimSpace(string(output)))
+	}
+	database, err = ioutil.ReadFile(copyFile.Name())
+	if err != nil {
+		return nil, nil, err
+	}
+	return database, hsmSecret, nil
+}
+
+// stageFile writes data to a file next to path, synced to disk and owned by uid and gid, and returns its path. Moving
+// it into place makes path hold either the old or the new data after a power loss.
+func stageFile(path string, data []byte, uid, gid int) (string, error) {
+	stagedPath := path + ".partial"
+	file, err := os.OpenFile(stagedPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", err
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chown(stagedPath, uid, gid)
+	}
+	if err != nil {
+		_ = os.Remove(stagedPath)
+		return "", err
+	}
+	return stagedPath, nil
+}
+
+// RestoreLightningFiles puts the database and the hsm_secret of c-lightning in place in its lightning directory. The
+// files are owned by the owner of the directory. lightningd must be stopped.
+func RestoreLightningFiles(dir string, database, hsmSecret []byte) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return errors.New("can not determine the owner of " + dir)
+	}
+	files := []struct {
+		path string
+		data []byte
+	}{
+		{filepath.Join(dir, lightningDatabase), database},
+		{filepath.Join(dir, lightningHSMSecret), hsmSecret},
+	}
+	// Both files are written before either is moved into place.
+	staged := []string{}
+	defer func() {
+		// Removing fails for the files that were moved into place.
+		for _, stagedPath := range staged {
+			_ = os.Remove(stagedPath)
+		}
+	}()
+	for _, file := range files {
+		stagedPath, err := stageFile(file.path, file.data, int(stat.Uid), int(stat.Gid))
+		if err != nil {
+			return err
+		}
+		staged = append(staged, stagedPath)
+	}
+	// A leftover write-ahead log would be applied to the restored database.
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		if err := os.Remove(filepath.Join(dir, lightningDatabase+suffix)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	for i, file := range files {
+		if err := os.Rename(staged[i], file.path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BackupDriveDir is where a second drive for backups is mounted.
+const BackupDriveDir = "/mnt/backup"
+
+// BackupDrive returns BackupDriveDir if a drive is mounted there.
+func BackupDrive() (string, error) {
+	if !isMountPoint(BackupDriveDir) {
+		return "", errors.New("no backup drive is mounted at " + BackupDriveDir)
+	}
+	return BackupDriveDir, nil
+}
@@ -0,0 +1,88 @@
+package system
+
+import (
+	"errors"
+	"strings"
+)
+
+// Network is a bitcoin network the BitBox Base can run on.
+type Network string
+
+// The supported networks. regtest and signet are meant for development and testing.
+const (
+	NetworkMainnet Network = "mainnet"
+	NetworkTestnet Network = "testnet"
+	NetworkRegtest Network = "regtest"
+	NetworkSignet  Network = "signet"
+)
+
+// Networks returns all supported networks.
+func Networks() []Network {
+	return []Network{NetworkMainnet, NetworkTestnet, NetworkRegtest, NetworkSignet}
+}
+
+// networkNames returns the names of the supported networks for error messages, like "mainnet, testnet, regtest or
+// signet".
+func networkNames() string {
+	names := []string{}
+	for _, network := range Networks() {
+		names = append(names, string(network))
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
+
+// ParseNetwork returns the network with the given name.
+func ParseNetwork(name string) (Network, error) {
+	for _, network := range Networks() {
+		if name == string(network) {
+			return network, nil
+		}
+	}
+	return "", errors.New("unknown network " + name + ", expected " + networkNames())
+}
+
+// BitcoinRPCPort returns the default rpc port of bitcoind on the network.
+func (network Network) BitcoinRPCPort() string {
+	switch network {
+	case NetworkMainnet:
+		return "8332"
+	case NetworkRegtest:
+		return "18443"
+	case NetworkSignet:
+		return "38332"
+	default:
+		return "18332"
+	}
+}
+
+// ElectrsRPCPort returns the port the electrum server of the Base is reached on. On mainnet and testnet, nginx
+// terminates TLS in front of electrs, on regtest and signet electrs is reached directly on its default port.
+func (network Network) ElectrsRPCPort() string {
+	switch network {
+	case NetworkMainnet:
+		return "50002"
+	case NetworkRegtest:
+		return "60401"
+	case NetworkSignet:
+		return "60601"
+	default:
+		return "51002"
+	}
+}
+
+// LightningDir returns the lightning directory of c-lightning on the Base. Every network has its own directory, so
+// that switching the network keeps the channels of the other networks.
+func (network Network) LightningDir() string {
+	if network == NetworkMainnet {
+		return "/mnt/ssd/bitcoin/.lightning"
+	}
+	return "/mnt/ssd/bitcoin/.lightning-" + string(network)
+}
+
+// LightningNetwork returns the name c-lightning uses for the network.
+func (network Network) LightningNetwork() string {
+	if network == NetworkMainnet {
+		return "bitcoin"
+	}
+	return string(network)
+}
@@ -0,0 +1,46 @@
+package system
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// PowerAction is what happens to the Base once its services are stopped.
+type PowerAction string
+
+// The power actions of the Base.
+const (
+	PowerReboot   PowerAction = "reboot"
+	PowerShutdown PowerAction = "shutdown"
+)
+
+// ParsePowerAction returns the power action named by action.
+func ParsePowerAction(action string) (PowerAction, error) {
+	switch PowerAction(action) {
+	case PowerReboot, PowerShutdown:
+		return PowerAction(action), nil
+	default:
+		return "", errors.New("unknown power action " + action + ", use reboot or shutdown")
+	}
+}
+
+// StopOrder returns the services that are stopped before the Base reboots or shuts down, in the order they are
+// stopped: lightningd before bitcoind, which it depends on, and electrs, which indexes it. Stopping bitcoind waits
+// until it flushed its chainstate, its unit allows for that, as a killed bitcoind has to reindex.
+func StopOrder() []string {
+	return []string{"lightningd", "electrs", "bitcoind"}
+}
+
+// Power reboots or shuts down the Base with systemd, which stops the remaining services first.
+func Power(action PowerAction) error {
+	command := "reboot"
+	if action == PowerShutdown {
+		command = "poweroff"
+	}
+	output, err := exec.Command("systemctl", command).CombinedOutput()
+	if err != nil {
+		return errors.New("systemctl " + command + " failed: " + strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package system
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// ResetOptions selects the data on the SSD that a factory reset wipes besides the configuration of the Base.
+type ResetOptions struct {
+	// Blockchain wipes the blocks and the chainstate of bitcoind and the index of electrs, which are then synced again.
+	Blockchain bool
+	// Lightning wipes the lightning directories, including the hsm_secret and the channels of the node.
+	Lightning bool
+}
+
+// resetDirs returns the directories below the root directory whose contents a factory reset wipes, as glob patterns:
+// the settings of bbb-config.sh, the keys of the tor hidden services and the data on the SSD selected by options. The
+// directories are kept, so that they keep their owners.
+func resetDirs(options ResetOptions) []string {
+	dirs := []string{
+		"opt/shift/sysconfig",
+		"var/lib/tor/hidden_service_*",
+		"var/lib/tor/lightningd-service_v3",
+	}
+	if options.Blockchain {
+		dirs = append(dirs, "mnt/ssd/bitcoin/.bitcoin", "mnt/ssd/electrs/db")
+	}
+	if options.Lightning {
+		dirs = append(dirs, "mnt/ssd/bitcoin/.lightning", "mnt/ssd/bitcoin/.lightning-*")
+	}
+	return dirs
+}
+
+// resetFiles returns the files below the root directory that a factory reset removes, the wifi credentials.
+func resetFiles() []string {
+	return []string{"opt/shift/config/wifi/wlan0.conf"}
+}
+
+// ResetServices returns the services that have to be stopped before a factory reset with the options, in the order
+// they are stopped: lightningd before bitcoind, which it depends on.
+func ResetServices(options ResetOptions) []string {
+	services := []string{}
+	if options.Blockchain || options.Lightning {
+		services = append(services, "lightningd")
+	}
+	if options.Blockchain {
+		services = append(services, "electrs", "bitcoind")
+	}
+	return services
+}
+
+// wipeDir removes the contents of a directory, but keeps the directory itself.
+func wipeDir(dir string) error {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FactoryReset wipes the configuration of the Base below the root directory, and the data on the SSD selected by
+// options. The services returned by ResetServices must be stopped. The noise keystore of the middleware is wiped by
+// the middleware itself. Everything is attempted, the first error is returned.
+func FactoryReset(root string, options ResetOptions) error {
+	var firstErr error
+	for _, pattern := range resetDirs(options) {
+		dirs, err := filepath.Glob(filepath.Join(root, pattern))
+		if err != nil {
+			return err
+		}
+		for _, dir := range dirs {
+			if err := wipeDir(dir); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	for _, name := range resetFiles() {
+		if err := os.Remove(filepath.Join(root, name)); err != nil && !os.IsNotExist(err) && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,78 @@
+package system
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// ServiceStatus is the state of a systemd unit of the BitBox Base.
+type ServiceStatus struct {
+	Name        string `json:"name"`
+	ActiveState string `json:"activeState"`
+}
+
+// Services returns the systemd units of the BitBox Base services, in the same order as bbb-systemctl.sh lists them.
+func Services() []string {
+	return []string{
+		"bitcoind",
+		"electrs",
+		"lightningd",
+		"base-middleware",
+		"nginx",
+		"prometheus",
+		"prometheus-node-exporter",
+		"prometheus-base",
+		"prometheus-bitcoind",
+		"grafana-server",
+		"bbbfancontrol",
+	}
+}
+
+// IsService returns true if name is one of the systemd units returned by Services.
+func IsService(name string) bool {
+	for _, service := range Services() {
+		if service == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ServicesStatus queries systemd for the active state of every unit returned by Services.
+func ServicesStatus() []ServiceStatus {
+	services := Services()
+	statuses := make([]ServiceStatus, len(services))
+	for i, service := range services {
+		// systemctl is-active exits with a non-zero code for units that are not active, but still prints the state.
+		output, _ := exec.Command("systemctl", "is-active", service+".service").Output()
+		state := strings.TrimSpace(string(output))
+		if state == "" {
+			state = "unknown"
+		}
+		statuses[i] = ServiceStatus{Name: service, ActiveState: state}
+	}
+	return statuses
+}
+
+// StopService stops a systemd unit returned by Services and waits until it stopped.
+func StopService(name string) error {
+	return runSystemctl("stop", name)
+}
+
+// StartService starts a systemd unit returned by Services and waits until it started.
+func StartService(name string) error {
+	return runSystemctl("start", name)
+}
+
+// runSystemctl runs a systemctl command on a unit returned by Services.
+func runSystemctl(command, name string) error {
+	if !IsService(name) {
+		return errors.New("unknown service " + name)
+	}
+	output, err := exec.Command("systemctl", command, name+".service").CombinedOutput()
+	if err != nil {
+		return errors.New("systemctl " + command + " " + name + " failed: " + strings.TrimSpace(string(output)))
+	}
+	return nil
+}
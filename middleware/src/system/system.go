@@ -1,46 +1,28 @@
 //package system provides functionality to get data such as open ports and services running on the system the middleware is deployed on
 package system
 
-// Environment provides some information on the system we are running on.
+// Environment is the configuration of the middleware and provides some information on the system we are running on.
+// It is built by LoadEnvironment from the defaults, the config file, the process environment and the command line
+// flags.
 type Environment struct {
-	Network            string `json:"network"`
-	ElectrsRPCPort     string `json:"electrsRPCPort"`
-	bitcoinRPCUser     string
-	bitcoinRPCPassword string
-	bitcoinRPCPort     string
-	lightningRPCPath   string
-}
-
-// NewEnvironment returns a new Environment instance.
-func NewEnvironment(bitcoinRPCUser, bitcoinRPCPassword, bitcoinRPCPort, lightningRPCPath, electrsRPCPort, network string) Environment {
-	// TODO(TheCharlatan) Instead of just accepting a long list of arguments, use a map here and check if the arguments can be read from a system config.
-	environment := Environment{
-		bitcoinRPCUser:     bitcoinRPCUser,
-		bitcoinRPCPassword: bitcoinRPCPassword,
-		bitcoinRPCPort:     bitcoinRPCPort,
-		lightningRPCPath:   lightningRPCPath,
-		ElectrsRPCPort:     electrsRPCPort,
-		Network:            network,
-	}
-	return environment
-}
-
-// GetBitcoinRPCUser is a getter for bitcoinRPCUser
-func (environment *Environment) GetBitcoinRPCUser() string {
-	return environment.bitcoinRPCUser
-}
-
-// GetBitcoinRPCPassword is a getter for the bitcoinRPCPassword
-func (environment *Environment) GetBitcoinRPCPassword() string {
-	return environment.bitcoinRPCPassword
-}
-
-// GetBitcoinRPCPort is a getter for the bitcoinRPCPort
-func (environment *Environment) GetBitcoinRPCPort() string {
-	return environment.bitcoinRPCPort
-}
+	Network             Network `json:"network"`
+	ElectrsRPCPort      string `json:"electrsRPCPort"`
+	BitcoinRPCUser      string `json:"-"`
+	BitcoinRPCPassword  string `json:"-"`
+	BitcoinRPCCookie    string `json:"-"`
+	BitcoinRPCPort      string `json:"-"`
+	BitcoinZMQHashblock string `json:"-"`
+	BitcoinZMQRawtx     string `json:"-"`
+	LightningRPCPath    string `json:"-"`
+	DataDir             string `json:"-"`
+	Listen              string `json:"-"`
+	MetricsListen       string `json:"-"`
+	TLSCert             string `json:"-"`
+	TLSKey              string `json:"-"`
+	TCPAddress          string `json:"-"`
+	UnixSocket          string `json:"-"`
+	LogLevel            string `json:"-"`
 
-// GetLightningRPCPath is a getter for the lightningRPCPath
-func (environment *Environment) GetLightningRPCPath() string {
-	return environment.lightningRPCPath
+	// sources records where each setting, identified by its key, was taken from.
+	sources map[string]string
 }
@@ -1,18 +1,415 @@
 package system_test
 
 import (
+	"flag"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/digitalbitbox/bitbox-base/middleware/src/backup"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/logging"
 	"github.com/digitalbitbox/bitbox-base/middleware/src/system"
 	"github.com/stretchr/testify/require"
 )
 
-func TestSystem(t *testing.T) {
-	environmentInstance := system.NewEnvironment("user", "password", "8332", "/home/bitcoin/.lightning", "18442", "testnet")
-	require.Equal(t, environmentInstance.GetBitcoinRPCPort(), "8332")
-	require.Equal(t, environmentInstance.GetBitcoinRPCUser(), "user")
-	require.Equal(t, environmentInstance.GetBitcoinRPCPassword(), "password")
-	require.Equal(t, environmentInstance.GetLightningRPCPath(), "/home/bitcoin/.lightning")
-	require.Equal(t, environmentInstance.Network, "testnet")
-	require.Equal(t, environmentInstance.ElectrsRPCPort, "18442")
+// testLogger returns a logger that discards the lines.
+func testLogger() *logging.Logger {
+	return logging.New(ioutil.Discard, logging.LevelError, false)
+}
+
+func TestLoadEnvironment(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bbb-system")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	configFile := filepath.Join(dir, "base-middleware.conf")
+	require.NoError(t, ioutil.WriteFile(configFile, []byte(`
+# written by bbb-config.sh
+BITCOIN_RPCPORT=8332
+LIGHTNING_RPCPATH="/mnt/ssd/bitcoin/.lightning/lightning-rpc"
+BITCOIN_RPCPASSWORD=secret
+UNKNOWN_SETTING=1
+`), 0600))
+	env := map[string]string{"BITCOIN_RPCPORT": "8333", "ELECTRS_RPCPORT": "50001"}
+	lookupEnv := func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+	flagSet := flag.NewFlagSet("middleware", flag.ContinueOnError)
+	system.RegisterFlags(flagSet)
+	require.NoError(t, flagSet.Parse([]string{"-electrsport", "50002", "-network", "mainnet"}))
+
+	// Defaults are overridden by the config file, then the environment, then the flags.
+	environment, err := system.LoadEnvironment(testLogger(), configFile, lookupEnv, flagSet)
+	require.NoError(t, err)
+	require.Equal(t, "rpcuser", environment.BitcoinRPCUser)
+	require.Equal(t, "secret", environment.BitcoinRPCPassword)
+	require.Equal(t, "/mnt/ssd/bitcoin/.lightning/lightning-rpc", environment.LightningRPCPath)
+	require.Equal(t, "8333", environment.BitcoinRPCPort)
+	require.Equal(t, "50002", environment.ElectrsRPCPort)
+	require.Equal(t, system.NetworkMainnet, environment.Network)
+
+	dump := environment.Dump()
+	require.Contains(t, dump, "# default\nBITCOIN_RPCUSER=rpcuser\n")
+	require.Contains(t, dump, "# config file\nBITCOIN_RPCPASSWORD=<redacted>\n")
+	require.Contains(t, dump, "# environment\nBITCOIN_RPCPORT=8333\n")
+	require.Contains(t, dump, "# flag\nELECTRS_RPCPORT=50002\n")
+
+	// A missing config file leaves the defaults.
+	environment, err = system.LoadEnvironment(testLogger(), filepath.Join(dir, "missing.conf"), lookupEnv, nil)
+	require.NoError(t, err)
+	require.Equal(t, system.NetworkTestnet, environment.Network)
+
+	// Invalid settings are rejected.
+	env["BITCOIN_NETWORK"] = "moonnet"
+	_, err = system.LoadEnvironment(testLogger(), configFile, lookupEnv, nil)
+	require.Error(t, err)
+	env["BITCOIN_NETWORK"] = "testnet"
+	env["BITCOIN_ZMQ_HASHBLOCK"] = "ipc:///tmp/bitcoind"
+	_, err = system.LoadEnvironment(testLogger(), configFile, lookupEnv, nil)
+	require.Error(t, err)
+	delete(env, "BITCOIN_ZMQ_HASHBLOCK")
+	env["MIDDLEWARE_TLS_CERT"] = "/etc/ssl/certs/nginx-selfsigned.crt"
+	_, err = system.LoadEnvironment(testLogger(), configFile, lookupEnv, nil)
+	require.Error(t, err)
+	delete(env, "MIDDLEWARE_TLS_CERT")
+	require.NoError(t, ioutil.WriteFile(configFile, []byte("BITCOIN_RPCPORT\n"), 0600))
+	_, err = system.LoadEnvironment(testLogger(), configFile, lookupEnv, nil)
+	require.Error(t, err)
+}
+
+func TestNetworkDefaults(t *testing.T) {
+	env := map[string]string{"BITCOIN_NETWORK": "regtest"}
+	lookupEnv := func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+	environment, err := system.LoadEnvironment(testLogger(), "/nonexistent", lookupEnv, nil)
+	require.NoError(t, err)
+	require.Equal(t, system.NetworkRegtest, environment.Network)
+	require.Equal(t, "18443", environment.BitcoinRPCPort)
+	require.Equal(t, "60401", environment.ElectrsRPCPort)
+	require.Equal(t, "/mnt/ssd/bitcoin/.lightning-regtest/lightning-rpc", environment.LightningRPCPath)
+	require.Contains(t, environment.Dump(), "# network default\nBITCOIN_RPCPORT=18443\n")
+
+	// Settings that are set explicitly are kept.
+	env["BITCOIN_NETWORK"] = "mainnet"
+	env["BITCOIN_RPCPORT"] = "18000"
+	environment, err = system.LoadEnvironment(testLogger(), "/nonexistent", lookupEnv, nil)
+	require.NoError(t, err)
+	require.Equal(t, "18000", environment.BitcoinRPCPort)
+	require.Equal(t, "50002", environment.ElectrsRPCPort)
+	require.Equal(t, "/mnt/ssd/bitcoin/.lightning/lightning-rpc", environment.LightningRPCPath)
+
+	for _, network := range system.Networks() {
+		parsed, err := system.ParseNetwork(string(network))
+		require.NoError(t, err)
+		require.Equal(t, network, parsed)
+	}
+	_, err = system.ParseNetwork("bitcoin")
+	require.EqualError(t, err, "unknown network bitcoin, expected mainnet, testnet, regtest or signet")
+	require.Equal(t, "bitcoin", system.NetworkMainnet.LightningNetwork())
+	require.Equal(t, "signet", system.NetworkSignet.LightningNetwork())
+}
+
+func TestListenAddresses(t *testing.T) {
+	environment, err := system.LoadEnvironment(testLogger(), "/nonexistent", func(string) (string, bool) { return "", false }, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{":8845"}, environment.ListenAddresses())
+
+	environment.Listen = "127.0.0.1:8845, unix:/run/base-middleware/http.sock"
+	require.NoError(t, environment.Validate())
+	require.Equal(t, []string{"127.0.0.1:8845", "unix:/run/base-middleware/http.sock"}, environment.ListenAddresses())
+
+	for _, listen := range []string{"", " , ", "unix:", "127.0.0.1", "127.0.0.1:http", "[::1]:65536"} {
+		environment.Listen = listen
+		require.Error(t, environment.Validate(), listen)
+	}
+}
+
+func TestMetricsListen(t *testing.T) {
+	environment, err := system.LoadEnvironment(testLogger(), "/nonexistent", func(string) (string, bool) { return "", false }, nil)
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1:8847", environment.MetricsListen)
+	for _, listen := range []string{"", "localhost:8847", "[::1]:8847", "unix:/run/base-middleware/metrics.sock"} {
+		environment.MetricsListen = listen
+		require.NoError(t, environment.Validate(), listen)
+	}
+	// The metrics must not be reachable from the network or over tor.
+	for _, listen := range []string{":8847", "0.0.0.0:8847", "192.168.1.2:8847", "127.0.0.1", "unix:"} {
+		environment.MetricsListen = listen
+		require.Error(t, environment.Validate(), listen)
+	}
+}
+
+func TestReloadEnvironment(t *testing.T) {
+	environment := system.Environment{Network: "testnet", BitcoinRPCPort: "18332", DataDir: ".base"}
+	reloaded := system.Environment{Network: "mainnet", BitcoinRPCPort: "8332", DataDir: ".base"}
+	environment, restartRequired := environment.Reload(reloaded)
+	require.Equal(t, "8332", environment.BitcoinRPCPort)
+	require.Equal(t, system.NetworkTestnet, environment.Network)
+	require.Equal(t, []string{"BITCOIN_NETWORK"}, restartRequired)
+}
+
+func TestConfig(t *testing.T) {
+	_, err := system.ConfigGet("root_pw")
+	require.Error(t, err)
+	_, err = system.ConfigSet("tor_onion", "true")
+	require.Error(t, err)
+	_, err = system.ConfigSet("tor_ssh_onion", "abc")
+	require.Error(t, err)
+	_, err = system.ConfigSet("wifi", "yes")
+	require.Error(t, err)
+	_, err = system.ConfigSet("bitcoin_network", "moonnet")
+	require.EqualError(t, err, "unknown network moonnet, expected mainnet, testnet, regtest or signet")
+
+	require.Equal(t, "true", system.ConfigValue("tor_ssh", "TOR_SSH=1\n"))
+	require.Equal(t, "false", system.ConfigValue("wifi", "WIFI=0"))
+	require.Equal(t, "bitbox-base", system.ConfigValue("hostname", "HOSTNAME=bitbox-base"))
+	require.Equal(t, "testnet", system.ConfigValue("bitcoin_network", "testnet"))
+}
+
+func TestServices(t *testing.T) {
+	require.True(t, system.IsService("bitcoind"))
+	require.False(t, system.IsService("sshd"))
+	require.False(t, system.IsService("bitcoind.service"))
+}
+
+func TestNotify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bbb-system")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "notify")
+	listener, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	require.NoError(t, err)
+	defer listener.Close()
+
+	defer os.Unsetenv("NOTIFY_SOCKET")
+	require.NoError(t, os.Unsetenv("NOTIFY_SOCKET"))
+	require.NoError(t, system.Notify("READY=1"))
+	require.NoError(t, os.Setenv("NOTIFY_SOCKET", socket))
+	require.NoError(t, system.Notify("WATCHDOG=1"))
+	buffer := make([]byte, 64)
+	n, err := listener.Read(buffer)
+	require.NoError(t, err)
+	require.Equal(t, "WATCHDOG=1", string(buffer[:n]))
+
+	defer os.Unsetenv("WATCHDOG_USEC")
+	defer os.Unsetenv("WATCHDOG_PID")
+	require.NoError(t, os.Setenv("WATCHDOG_USEC", "30000000"))
+	require.Equal(t, 30*time.Second, system.WatchdogInterval())
+	require.NoError(t, os.Setenv("WATCHDOG_PID", "1"))
+	require.Equal(t, time.Duration(0), system.WatchdogInterval())
+}
+
+func TestJournal(t *testing.T) {
+	priority, err := system.ParsePriority("warning")
+	require.NoError(t, err)
+	require.Equal(t, 4, priority)
+	priority, err = system.ParsePriority("3")
+	require.NoError(t, err)
+	require.Equal(t, 3, priority)
+	priority, err = system.ParsePriority("")
+	require.NoError(t, err)
+	require.Equal(t, system.PriorityDebug, priority)
+	_, err = system.ParsePriority("loud")
+	require.Error(t, err)
+
+	require.True(t, system.IsLogUnit("tor"))
+	require.True(t, system.IsLogUnit("bitcoind"))
+	require.False(t, system.IsLogUnit("sshd"))
+
+	entry, err := system.ParseJournalEntry([]byte(`{"__REALTIME_TIMESTAMP":"1560000000123456",` +
+		`"_SYSTEMD_UNIT":"electrs.service","PRIORITY":"3","MESSAGE":"connection refused"}`))
+	require.NoError(t, err)
+	require.Equal(t, system.JournalEntry{
+		Time:     time.Unix(1560000000, 123456000),
+		Unit:     "electrs",
+		Priority: 3,
+		Message:  "connection refused",
+	}, entry)
+	// Messages that are not valid UTF-8 are encoded as bytes, entries without a priority are info.
+	entry, err = system.ParseJournalEntry([]byte(`{"__REALTIME_TIMESTAMP":"1560000000000000",` +
+		`"_SYSTEMD_UNIT":"tor.service","MESSAGE":[104,105,255]}`))
+	require.NoError(t, err)
+	require.Equal(t, 6, entry.Priority)
+	require.Equal(t, "hi�", entry.Message)
+	_, err = system.ParseJournalEntry([]byte(`{"MESSAGE":"no timestamp"}`))
+	require.Error(t, err)
+}
+
+func TestJournalLongLine(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bbb-journal")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	// A journalctl that follows forever after an entry longer than the scanner accepts.
+	script := "#!/bin/sh\nhead -c 2097152 /dev/zero | tr '\\0' a\necho\nexec sleep 600\n"
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "journalctl"), []byte(script), 0700))
+	path := os.Getenv("PATH")
+	require.NoError(t, os.Setenv("PATH", dir+":"+path))
+	defer os.Setenv("PATH", path)
+
+	result := make(chan error, 1)
+	go func() {
+		query := system.JournalQuery{Unit: "bitcoind", Follow: true, Priority: system.PriorityDebug}
+		result <- system.Journal(query, make(chan struct{}), func(system.JournalEntry) {})
+	}()
+	select {
+	case err := <-result:
+		require.Error(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("Journal did not return after an entry that is too long")
+	}
+}
+
+func TestLightningFiles(t *testing.T) {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		t.Skip("sqlite3 not available")
+	}
+	dir, err := ioutil.TempDir("", "bbb-lightning")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	databasePath := filepath.Join(dir, "lightningd.sqlite3")
+	output, err := exec.Command("sqlite3", databasePath,
+		"CREATE TABLE channels (id INTEGER); INSERT INTO channels VALUES (7);").CombinedOutput()
+	require.NoError(t, err, string(output))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "hsm_secret"), []byte("secret"), 0400))
+
+	database, hsmSecret, err := system.LightningFiles(dir)
+	require.NoError(t, err)
+	require.Equal(t, []byte("secret"), hsmSecret)
+	require.Equal(t, "SQLite format 3\x00", string(database[:16]))
+
+	// The restored database replaces the old one along with its write-ahead log.
+	require.NoError(t, ioutil.WriteFile(databasePath, []byte("old"), 0600))
+	require.NoError(t, ioutil.WriteFile(databasePath+"-wal", []byte("old"), 0600))
+	require.NoError(t, system.RestoreLightningFiles(dir, database, []byte("restored")))
+	output, err = exec.Command("sqlite3", databasePath, "SELECT id FROM channels;").CombinedOutput()
+	require.NoError(t, err, string(output))
+	require.Equal(t, "7\n", string(output))
+	restoredSecret, err := ioutil.ReadFile(filepath.Join(dir, "hsm_secret"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("restored"), restoredSecret)
+	_, err = os.Stat(databasePath + "-wal")
+	require.True(t, os.IsNotExist(err))
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	_, err = system.BackupDrive()
+	require.Error(t, err)
+}
+
+func TestConfigFiles(t *testing.T) {
+	root, err := ioutil.TempDir("", "bbb-config")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+	hiddenService := filepath.Join(root, "var/lib/tor/hidden_service_ssh")
+	require.NoError(t, os.MkdirAll(hiddenService, 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(hiddenService, "hostname"), []byte("abc.onion\n"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(hiddenService, "hs_ed25519_secret_key"), []byte("key"), 0600))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "etc/network"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "etc/network/interfaces"), []byte("auto lo\n"), 0644))
+
+	files, err := system.ConfigFiles(root)
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+	require.Equal(t, "etc/network/interfaces", files[0].Name)
+	require.Equal(t, os.FileMode(0644), files[0].Mode)
+
+	// The files are restored onto a freshly flashed Base, where the directories of the hidden services are missing.
+	restoreRoot, err := ioutil.TempDir("", "bbb-config")
+	require.NoError(t, err)
+	defer os.RemoveAll(restoreRoot)
+	require.NoError(t, system.RestoreConfigFiles(restoreRoot, files))
+	restored, err := system.ConfigFiles(restoreRoot)
+	require.NoError(t, err)
+	require.Equal(t, files, restored)
+	info, err := os.Stat(filepath.Join(restoreRoot, "var/lib/tor/hidden_service_ssh"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0700), info.Mode().Perm())
+
+	files[0].Name = "../etc/passwd"
+	require.Error(t, system.RestoreConfigFiles(restoreRoot, files))
+	require.Error(t, system.ValidateConfigFile(backup.File{Name: "etc/shadow"}))
+}
+
+func TestValidateConfigFile(t *testing.T) {
+	interfaces := func(data string) backup.File {
+		return backup.File{Name: "etc/network/interfaces", Data: []byte(data)}
+	}
+	require.NoError(t, system.ValidateConfigFile(interfaces(
+		"source /etc/network/interfaces.d/*\n# loopback\nauto lo\niface lo inet loopback\n\n"+
+			"allow-hotplug eth0\niface eth0 inet static\n  address 192.168.1.2\n  netmask 255.255.255.0\n"+
+			"  gateway 192.168.1.1\n  dns-nameservers 192.168.1.1\n")))
+	require.NoError(t, system.ValidateConfigFile(backup.File{
+		Name: "opt/shift/config/wifi/wlan0.conf",
+		Data: []byte("auto wlan0\niface wlan0 inet dhcp\n  wpa-ssid {wpa-ssid}\n  wpa-psk {wpa-psk}\n"),
+	}))
+
+	// Hooks run commands as root, so network settings with them are refused.
+	for _, hook := range []string{"pre-up", "up", "post-up", "pre-down", "down", "post-down"} {
+		require.EqualError(t, system.ValidateConfigFile(interfaces("iface eth0 inet dhcp\n  "+hook+" touch /x\n")),
+			"unsupported directive "+hook+" in etc/network/interfaces")
+	}
+	require.EqualError(t, system.ValidateConfigFile(backup.File{
+		Name: "opt/shift/config/wifi/wlan0.conf",
+		Data: []byte("iface wlan0 inet dhcp\n  wpa-action /tmp/x\n"),
+	}), "unsupported directive wpa-action in opt/shift/config/wifi/wlan0.conf")
+	require.Error(t, system.ValidateConfigFile(interfaces("source /tmp/evil\n")))
+	require.Error(t, system.ValidateConfigFile(interfaces("mapping eth0\n  script /tmp/x\n")))
+	require.EqualError(t, system.ValidateConfigFile(interfaces("iface eth0 inet dhcp\n  address 1.2.3.4 \\\n  up x\n")),
+		"continued lines are not supported in etc/network/interfaces")
+	require.NoError(t, system.ValidateConfigFile(backup.File{
+		Name: "var/lib/tor/hidden_service_ssh/hostname", Data: []byte("up\n"),
+	}))
+}
+
+func TestFactoryReset(t *testing.T) {
+	root, err := ioutil.TempDir("", "bbb-reset")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+	files := []string{
+		"opt/shift/sysconfig/TOR_SSH",
+		"opt/shift/config/wifi/wlan0.conf",
+		"var/lib/tor/hidden_service_ssh/hs_ed25519_secret_key",
+		"var/lib/tor/lightningd-service_v3/hostname",
+		"mnt/ssd/bitcoin/.bitcoin/testnet3/blocks/blk00000.dat",
+		"mnt/ssd/electrs/db/testnet/CURRENT",
+		"mnt/ssd/bitcoin/.lightning-testnet/hsm_secret",
+	}
+	for _, name := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0700))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(root, name), []byte("data"), 0600))
+	}
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(root, name))
+		return err == nil
+	}
+
+	// The data on the SSD is kept unless selected.
+	require.NoError(t, system.FactoryReset(root, system.ResetOptions{}))
+	for _, name := range files[:4] {
+		require.False(t, exists(name), name)
+	}
+	for _, name := range files[4:] {
+		require.True(t, exists(name), name)
+	}
+	require.True(t, exists("var/lib/tor/hidden_service_ssh"))
+	require.True(t, exists("opt/shift/sysconfig"))
+
+	require.NoError(t, system.FactoryReset(root, system.ResetOptions{Lightning: true}))
+	require.False(t, exists("mnt/ssd/bitcoin/.lightning-testnet/hsm_secret"))
+	require.True(t, exists("mnt/ssd/bitcoin/.lightning-testnet"))
+	require.True(t, exists("mnt/ssd/bitcoin/.bitcoin/testnet3"))
+	require.NoError(t, system.FactoryReset(root, system.ResetOptions{Blockchain: true}))
+	require.False(t, exists("mnt/ssd/bitcoin/.bitcoin/testnet3"))
+	require.False(t, exists("mnt/ssd/electrs/db/testnet"))
+
+	require.Empty(t, system.ResetServices(system.ResetOptions{}))
+	require.Equal(t, []string{"lightningd", "electrs", "bitcoind"},
+		system.ResetServices(system.ResetOptions{Blockchain: true, Lightning: true}))
 }
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+
+	"github.com/digitalbitbox/bitbox-base/middleware/src/system"
+)
+
+const (
+	// thermalInterval is the interval in which the temperature and the fan speed are sampled.
+	thermalInterval = time.Minute
+	// thermalHistorySize is the number of samples that are kept, a day's worth.
+	thermalHistorySize = 24 * 60
+)
+
+// thermalHistory keeps the last samples of the temperature and the fan speed. It is safe for concurrent use.
+type thermalHistory struct {
+	mu      sync.Mutex
+	samples []system.ThermalSample
+}
+
+// add records a sample, dropping the oldest one if the history is full.
+func (history *thermalHistory) add(sample system.ThermalSample) {
+	history.mu.Lock()
+	defer history.mu.Unlock()
+	history.samples = append(history.samples, sample)
+	if len(history.samples) > thermalHistorySize {
+		history.samples = history.samples[len(history.samples)-thermalHistorySize:]
+	}
+}
+
+// get returns the samples, oldest first.
+func (history *thermalHistory) get() []system.ThermalSample {
+	history.mu.Lock()
+	defer history.mu.Unlock()
+	return append([]system.ThermalSample{}, history.samples...)
+}
+
+// sampleThermal records the current temperature and fan speed.
+func (middleware *Middleware) sampleThermal() {
+	sample, err := middleware.backends.System.Thermal()
+	if err != nil {
+		middleware.logger.Debug("Failed to read the temperature", "error", err)
+		return
+	}
+	middleware.thermal.add(sample)
+}
+
+// thermalLoop samples the temperature and the fan speed.
+func (middleware *Middleware) thermalLoop() {
+	for {
+		middleware.sampleThermal()
+		time.Sleep(thermalInterval)
+	}
+}
+
+// ThermalHistory returns the temperature and fan speed samples of the last day, oldest first.
+func (middleware *Middleware) ThermalHistory() []system.ThermalSample {
+	return middleware.thermal.get()
+}
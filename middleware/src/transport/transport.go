@@ -0,0 +1,262 @@
+// Package transport abstracts the connections that the noise encrypted protobuf api is served over.
+// The noise handshake and the encrypted messages only need a connection that transmits whole messages,
+// so the same protocol can run over websockets, plain TCP and Unix sockets.
+//
+// All connections have read and write deadlines. The read deadline is extended by every received frame, including
+// keepalive pings and pongs, so that half-open connections, e.g. from a laptop that went to sleep, are detected.
+package transport
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// MaxFrameSize is the maximum size of a single frame. It is the maximum size of a noise message.
+	MaxFrameSize = 65535
+
+	// PingInterval is the interval in which the peers are expected to send keepalive pings.
+	PingInterval = 20 * time.Second
+	// ReadTimeout is the time after which a connection is considered dead if nothing was received on it.
+	ReadTimeout = 3 * PingInterval
+	// WriteTimeout is the time a single write may take before the connection is considered dead.
+	WriteTimeout = 10 * time.Second
+)
+
+// Close codes sent to the peer when closing a connection. They are the same as the websocket close codes.
+const (
+	// CloseNormal is sent if a connection is closed regularly.
+	CloseNormal = websocket.CloseNormalClosure
+	// CloseGoingAway is sent if the middleware shuts down.
+	CloseGoingAway = websocket.CloseGoingAway
+	// CloseRevoked is sent if the pairing of the client was revoked. The client should not reconnect.
+	CloseRevoked = 4001
+	// CloseRestored is sent if the configuration of the Base was restored from a backup, which replaces its static key
+	// and the paired clients. Clients that were paired when the backup was made can reconnect.
+	CloseRestored = 4002
+	// CloseReset is sent if the Base is reset to its factory state. The client has to pair again once it rebooted.
+	CloseReset = 4003
+)
+
+// CloseError is returned by ReadMessage if the peer closed the connection with a close code.
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (err *CloseError) Error() string {
+	return "connection closed by peer with code " + strconv.Itoa(err.Code) + " " + err.Reason
+}
+
+// Conn is a connection that sends and receives whole messages.
+type Conn interface {
+	// ReadMessage blocks until a full message is received. Keepalive pings are answered internally.
+	ReadMessage() ([]byte, error)
+	// WriteMessage sends a single message. Empty messages are not allowed.
+	WriteMessage(message []byte) error
+	// Ping sends a keepalive ping, which the peer answers with a pong.
+	Ping() error
+	// CloseWithReason tells the peer why the connection is closed and closes the underlying connection.
+	CloseWithReason(code int, reason string) error
+	// Close closes the connection with CloseNormal.
+	Close() error
+}
+
+// websocketConn implements Conn for a websocket connection. Every message is sent as a binary websocket message,
+// keepalives use websocket ping and pong control messages.
+type websocketConn struct {
+	ws *websocket.Conn
+	// writeMu serializes writes, since websocket connections support only one concurrent writer.
+	writeMu sync.Mutex
+}
+
+// NewWebsocketConn returns a Conn wrapping the given websocket connection. Incoming websocket messages larger than
+// MaxFrameSize close the connection.
+func NewWebsocketConn(ws *websocket.Conn) Conn {
+	conn := &websocketConn{ws: ws}
+	ws.SetReadLimit(MaxFrameSize)
+	_ = ws.SetReadDeadline(time.Now().Add(ReadTimeout))
+	ws.SetPingHandler(func(data string) error {
+		_ = ws.SetReadDeadline(time.Now().Add(ReadTimeout))
+		err := ws.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(WriteTimeout))
+		if err == websocket.ErrCloseSent {
+			return nil
+		}
+		return err
+	})
+	ws.SetPongHandler(func(string) error {
+		return ws.SetReadDeadline(time.Now().Add(ReadTimeout))
+	})
+	return conn
+}
+
+// ReadMessage implements Conn.
+func (conn *websocketConn) ReadMessage() ([]byte, error) {
+	_, message, err := conn.ws.ReadMessage()
+	if closeErr, ok := err.(*websocket.CloseError); ok {
+		return nil, &CloseError{Code: closeErr.Code, Reason: closeErr.Text}
+	}
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.ws.SetReadDeadline(time.Now().Add(ReadTimeout))
+	return message, nil
+}
+
+// WriteMessage implements Conn.
+func (conn *websocketConn) WriteMessage(message []byte) error {
+	if len(message) == 0 {
+		return errors.New("empty messages are not allowed")
+	}
+	conn.writeMu.Lock()
+	defer conn.writeMu.Unlock()
+	_ = conn.ws.SetWriteDeadline(time.Now().Add(WriteTimeout))
+	return conn.ws.WriteMessage(websocket.BinaryMessage, message)
+}
+
+// Ping implements Conn.
+func (conn *websocketConn) Ping() error {
+	return conn.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(WriteTimeout))
+}
+
+// CloseWithReason implements Conn.
+func (conn *websocketConn) CloseWithReason(code int, reason string) error {
+	_ = conn.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(WriteTimeout))
+	return conn.ws.Close()
+}
+
+// Close implements Conn.
+func (conn *websocketConn) Close() error {
+	return conn.CloseWithReason(CloseNormal, "")
+}
+
+// Control frame types of stream connections.
+const (
+	controlPing  byte = 1
+	controlPong  byte = 2
+	controlClose byte = 3
+)
+
+// streamConn implements Conn for stream oriented connections like TCP and Unix sockets.
+// Every message is prefixed with its length as a two byte big endian integer. A length of zero introduces a control
+// frame: a type byte, followed by the length of the control payload as a two byte big endian integer and the payload.
+// Close control frames carry the close code as a two byte big endian integer, followed by the reason.
+type streamConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	// writeMu makes sure that the length prefix and the message are not interleaved with other writes.
+	writeMu sync.Mutex
+}
+
+// NewStreamConn returns a Conn that frames messages with a length prefix on the given stream connection.
+func NewStreamConn(conn net.Conn) Conn {
+	return &streamConn{
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+	}
+}
+
+// ReadMessage implements Conn.
+func (conn *streamConn) ReadMessage() ([]byte, error) {
+	for {
+		_ = conn.conn.SetReadDeadline(time.Now().Add(ReadTimeout))
+		var length uint16
+		if err := binary.Read(conn.reader, binary.BigEndian, &length); err != nil {
+			return nil, err
+		}
+		if length > 0 {
+			message := make([]byte, length)
+			if _, err := io.ReadFull(conn.reader, message); err != nil {
+				return nil, err
+			}
+			return message, nil
+		}
+		if err := conn.readControl(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// readControl reads and handles a control frame.
+func (conn *streamConn) readControl() error {
+	var header struct {
+		Type   byte
+		Length uint16
+	}
+	if err := binary.Read(conn.reader, binary.BigEndian, &header); err != nil {
+		return err
+	}
+	payload := make([]byte, header.Length)
+	if _, err := io.ReadFull(conn.reader, payload); err != nil {
+		return err
+	}
+	switch header.Type {
+	case controlPing:
+		return conn.writeControl(controlPong, payload)
+	case controlClose:
+		if len(payload) < 2 {
+			return &CloseError{Code: websocket.CloseNoStatusReceived}
+		}
+		return &CloseError{Code: int(binary.BigEndian.Uint16(payload)), Reason: string(payload[2:])}
+	default:
+		// Pongs only extend the read deadline. Unknown control frames are ignored for forward compatibility.
+		return nil
+	}
+}
+
+func (conn *streamConn) writeControl(controlType byte, payload []byte) error {
+	frame := make([]byte, 5+len(payload))
+	frame[2] = controlType
+	binary.BigEndian.PutUint16(frame[3:], uint16(len(payload)))
+	copy(frame[5:], payload)
+	return conn.write(frame)
+}
+
+func (conn *streamConn) write(frame []byte) error {
+	conn.writeMu.Lock()
+	defer conn.writeMu.Unlock()
+	_ = conn.conn.SetWriteDeadline(time.Now().Add(WriteTimeout))
+	_, err := conn.conn.Write(frame)
+	return err
+}
+
+// WriteMessage implements Conn.
+func (conn *streamConn) WriteMessage(message []byte) error {
+	if len(message) == 0 {
+		return errors.New("empty messages are not allowed")
+	}
+	if len(message) > MaxFrameSize {
+		return errors.New("message exceeds the maximum frame size")
+	}
+	frame := make([]byte, 2+len(message))
+	binary.BigEndian.PutUint16(frame, uint16(len(message)))
+	copy(frame[2:], message)
+	return conn.write(frame)
+}
+
+// Ping implements Conn.
+func (conn *streamConn) Ping() error {
+	return conn.writeControl(controlPing, nil)
+}
+
+// CloseWithReason implements Conn.
+func (conn *streamConn) CloseWithReason(code int, reason string) error {
+	payload := make([]byte, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	copy(payload[2:], reason)
+	_ = conn.writeControl(controlClose, payload)
+	return conn.conn.Close()
+}
+
+// Close implements Conn.
+func (conn *streamConn) Close() error {
+	return conn.CloseWithReason(CloseNormal, "")
+}
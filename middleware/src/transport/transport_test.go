@@ -0,0 +1,62 @@
+package transport_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-base/middleware/src/transport"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamConn(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	server := transport.NewStreamConn(serverConn)
+	client := transport.NewStreamConn(clientConn)
+	// net.Pipe is unbuffered, so closing the raw connections avoids blocking on the close frames.
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	messages := [][]byte{[]byte("h"), {0}, make([]byte, transport.MaxFrameSize)}
+	go func() {
+		for _, message := range messages {
+			require.NoError(t, client.WriteMessage(message))
+		}
+	}()
+	for _, message := range messages {
+		received, err := server.ReadMessage()
+		require.NoError(t, err)
+		require.Equal(t, message, received)
+	}
+
+	err := client.WriteMessage(make([]byte, transport.MaxFrameSize+1))
+	require.Error(t, err)
+	// Empty frames are reserved for control frames.
+	err = client.WriteMessage([]byte{})
+	require.Error(t, err)
+}
+
+func TestStreamConnControl(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	server := transport.NewStreamConn(serverConn)
+	client := transport.NewStreamConn(clientConn)
+
+	// Pings are answered while reading, without being returned as messages.
+	go func() {
+		require.NoError(t, client.Ping())
+		require.NoError(t, client.WriteMessage([]byte("after ping")))
+	}()
+	clientErr := make(chan error)
+	go func() {
+		_, err := client.ReadMessage()
+		clientErr <- err
+	}()
+	received, err := server.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, "after ping", string(received))
+
+	// The close code and reason are passed to the peer.
+	require.NoError(t, server.CloseWithReason(transport.CloseRevoked, "pairing revoked"))
+	err = <-clientErr
+	require.Equal(t, &transport.CloseError{Code: transport.CloseRevoked, Reason: "pairing revoked"}, err)
+	require.NoError(t, client.Close())
+}
@@ -0,0 +1,222 @@
+// Package zmq implements a minimal ZeroMQ SUB socket, enough to receive the notifications bitcoind publishes with
+// -zmqpubhashblock and -zmqpubrawtx. It speaks ZMTP 3.0 with the NULL security mechanism over TCP.
+package zmq
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	greetingSize = 64
+	// maxFrameSize limits the size of incoming frames. Raw transactions and hashes are far smaller.
+	maxFrameSize = 4 << 20
+	dialTimeout  = 5 * time.Second
+	// keepAlivePeriod makes the connection fail if the publisher is gone without closing it.
+	keepAlivePeriod = 30 * time.Second
+
+	flagMore    = 0x01
+	flagLong    = 0x02
+	flagCommand = 0x04
+)
+
+// Message is a notification received from a publisher.
+type Message struct {
+	Topic string
+	Body  []byte
+	// Sequence is the sequence number bitcoind appends to every notification, or 0 if the publisher did not send one.
+	Sequence uint32
+}
+
+// Subscriber receives the messages of a publisher for the subscribed topics.
+type Subscriber struct {
+	conn      net.Conn
+	reader    *bufio.Reader
+	closeOnce sync.Once
+}
+
+// Dial connects to the publisher at address, like tcp://127.0.0.1:28332, and subscribes to the given topics.
+func Dial(address string, topics ...string) (*Subscriber, error) {
+	if !strings.HasPrefix(address, "tcp://") {
+		return nil, errors.New("unsupported zmq address " + address + ", only tcp:// is supported")
+	}
+	dialer := net.Dialer{Timeout: dialTimeout, KeepAlive: keepAlivePeriod}
+	conn, err := dialer.Dial("tcp", strings.TrimPrefix(address, "tcp://"))
+	if err != nil {
+		return nil, err
+	}
+	subscriber := &Subscriber{conn: conn, reader: bufio.NewReader(conn)}
+	_ = conn.SetDeadline(time.Now().Add(dialTimeout))
+	if err := Handshake(conn, subscriber.reader, "SUB"); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	for _, topic := range topics {
+		// ZMTP 3.0 subscriptions are messages starting with 1, followed by the topic prefix.
+		if err := writeFrame(conn, 0, append([]byte{1}, topic...)); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return subscriber, nil
+}
+
+// Receive blocks until the next message arrives. If no message arrives within timeout, it fails with a net.Error
+// whose Timeout method returns true. A zero timeout waits forever.
+func (subscriber *Subscriber) Receive(timeout time.Duration) (*Message, error) {
+	deadline := time.Time{}
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	if err := subscriber.conn.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+	parts, err := ReadMessage(subscriber.reader)
+	if err != nil {
+		return nil, err
+	}
+	message := &Message{Topic: string(parts[0])}
+	if len(parts) > 1 {
+		message.Body = parts[1]
+	}
+	if len(parts) > 2 && len(parts[2]) == 4 {
+		message.Sequence = binary.LittleEndian.Uint32(parts[2])
+	}
+	return message, nil
+}
+
+// Close closes the connection, which makes a blocked Receive return.
+func (subscriber *Subscriber) Close() error {
+	var err error
+	subscriber.closeOnce.Do(func() {
+		err = subscriber.conn.Close()
+	})
+	return err
+}
+
+// Handshake exchanges the ZMTP greeting and the READY command announcing socketType. It is exported for the fake
+// publisher in the zmqtest package.
+func Handshake(writer io.Writer, reader io.Reader, socketType string) error {
+	greeting := make([]byte, greetingSize)
+	greeting[0] = 0xff
+	greeting[9] = 0x7f
+	greeting[10] = 3
+	greeting[11] = 0
+	copy(greeting[12:32], "NULL")
+	if _, err := writer.Write(greeting); err != nil {
+		return err
+	}
+	peerGreeting := make([]byte, greetingSize)
+	if _, err := io.ReadFull(reader, peerGreeting); err != nil {
+		return err
+	}
+	if peerGreeting[0] != 0xff || peerGreeting[9] != 0x7f || peerGreeting[10] < 3 {
+		return errors.New("peer does not speak ZMTP 3")
+	}
+	if strings.TrimRight(string(peerGreeting[12:32]), "\x00") != "NULL" {
+		return errors.New("unsupported zmq security mechanism")
+	}
+
+	ready := append([]byte{5}, "READY"...)
+	ready = append(ready, byte(len("Socket-Type")))
+	ready = append(ready, "Socket-Type"...)
+	length := make([]byte, 4)
+	binary.BigEndian.PutUint32(length, uint32(len(socketType)))
+	ready = append(ready, length...)
+	ready = append(ready, socketType...)
+	if err := writeFrame(writer, flagCommand, ready); err != nil {
+		return err
+	}
+	flags, body, err := readFrame(reader)
+	if err != nil {
+		return err
+	}
+	if flags&flagCommand == 0 || len(body) < 6 || string(body[1:6]) != "READY" {
+		return errors.New("expected zmq READY command")
+	}
+	return nil
+}
+
+// writeFrame writes a single ZMTP frame with the given flags. The long flag is set as needed.
+func writeFrame(writer io.Writer, flags byte, body []byte) error {
+	var header []byte
+	if len(body) > 255 {
+		header = make([]byte, 9)
+		header[0] = flags | flagLong
+		binary.BigEndian.PutUint64(header[1:], uint64(len(body)))
+	} else {
+		header = []byte{flags, byte(len(body))}
+	}
+	_, err := writer.Write(append(header, body...))
+	return err
+}
+
+// WriteMessage writes a message consisting of the given parts.
+func WriteMessage(writer io.Writer, parts [][]byte) error {
+	for i, part := range parts {
+		var flags byte
+		if i < len(parts)-1 {
+			flags = flagMore
+		}
+		if err := writeFrame(writer, flags, part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readFrame reads a single ZMTP frame.
+func readFrame(reader io.Reader) (byte, []byte, error) {
+	header := make([]byte, 1)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return 0, nil, err
+	}
+	flags := header[0]
+	var size uint64
+	if flags&flagLong != 0 {
+		sizeBytes := make([]byte, 8)
+		if _, err := io.ReadFull(reader, sizeBytes); err != nil {
+			return 0, nil, err
+		}
+		size = binary.BigEndian.Uint64(sizeBytes)
+	} else {
+		sizeBytes := make([]byte, 1)
+		if _, err := io.ReadFull(reader, sizeBytes); err != nil {
+			return 0, nil, err
+		}
+		size = uint64(sizeBytes[0])
+	}
+	if size > maxFrameSize {
+		return 0, nil, errors.New("zmq frame too large")
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return 0, nil, err
+	}
+	return flags, body, nil
+}
+
+// ReadMessage reads the frames of the next message, skipping commands.
+func ReadMessage(reader io.Reader) ([][]byte, error) {
+	parts := [][]byte{}
+	for {
+		flags, body, err := readFrame(reader)
+		if err != nil {
+			return nil, err
+		}
+		if flags&flagCommand != 0 {
+			continue
+		}
+		parts = append(parts, body)
+		if flags&flagMore == 0 {
+			return parts, nil
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package zmq_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/digitalbitbox/bitbox-base/middleware/src/zmq"
+	"github.com/digitalbitbox/bitbox-base/middleware/src/zmq/zmqtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriber(t *testing.T) {
+	publisher, err := zmqtest.NewPublisher()
+	require.NoError(t, err)
+	defer publisher.Close()
+
+	subscriber, err := zmq.Dial(publisher.Address(), "hashblock")
+	require.NoError(t, err)
+	defer subscriber.Close()
+	require.True(t, publisher.WaitSubscribed("hashblock", time.Second))
+
+	// Only subscribed topics are received, large bodies use long frames.
+	publisher.Publish("rawtx", []byte{1, 2, 3})
+	body := make([]byte, 1000)
+	body[999] = 7
+	publisher.Publish("hashblock", body)
+	message, err := subscriber.Receive(0)
+	require.NoError(t, err)
+	require.Equal(t, "hashblock", message.Topic)
+	require.Equal(t, body, message.Body)
+	require.Equal(t, uint32(1), message.Sequence)
+
+	// A silent publisher makes Receive time out.
+	_, err = subscriber.Receive(10 * time.Millisecond)
+	netErr, ok := err.(net.Error)
+	require.True(t, ok && netErr.Timeout())
+
+	// A disconnecting publisher makes Receive fail.
+	publisher.DisconnectAll()
+	_, err = subscriber.Receive(0)
+	require.Error(t, err)
+}
+
+func TestDialInvalidAddress(t *testing.T) {
+	_, err := zmq.Dial("ipc:///tmp/bitcoind.sock", "hashblock")
+	require.Error(t, err)
+}
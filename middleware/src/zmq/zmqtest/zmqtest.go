@@ -0,0 +1,147 @@
+// Package zmqtest provides a fake ZeroMQ publisher, standing in for bitcoind in tests.
+package zmqtest
+
+import (
+	"bufio"
+	"encoding/binary"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/digitalbitbox/bitbox-base/middleware/src/zmq"
+)
+
+// subscription is a connected subscriber and the topics it subscribed to.
+type subscription struct {
+	conn   net.Conn
+	topics []string
+}
+
+// Publisher is a fake ZeroMQ PUB socket listening on a random local TCP port.
+type Publisher struct {
+	listener      net.Listener
+	mu            sync.Mutex
+	subscriptions []*subscription
+	sequence      uint32
+	// subscribed is signaled whenever a subscription arrived.
+	subscribed chan struct{}
+}
+
+// NewPublisher starts a publisher. It must be closed after use.
+func NewPublisher() (*Publisher, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	publisher := &Publisher{listener: listener, subscribed: make(chan struct{}, 1)}
+	go publisher.accept()
+	return publisher, nil
+}
+
+// Address returns the address to connect to, like tcp://127.0.0.1:38145.
+func (publisher *Publisher) Address() string {
+	return "tcp://" + publisher.listener.Addr().String()
+}
+
+func (publisher *Publisher) accept() {
+	for {
+		conn, err := publisher.listener.Accept()
+		if err != nil {
+			return
+		}
+		go publisher.serve(conn)
+	}
+}
+
+// serve performs the handshake and collects the subscriptions of a subscriber.
+func (publisher *Publisher) serve(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	if err := zmq.Handshake(conn, reader, "PUB"); err != nil {
+		_ = conn.Close()
+		return
+	}
+	subscription := &subscription{conn: conn}
+	publisher.mu.Lock()
+	publisher.subscriptions = append(publisher.subscriptions, subscription)
+	publisher.mu.Unlock()
+	for {
+		parts, err := zmq.ReadMessage(reader)
+		if err != nil {
+			_ = conn.Close()
+			return
+		}
+		if len(parts[0]) > 0 && parts[0][0] == 1 {
+			publisher.mu.Lock()
+			subscription.topics = append(subscription.topics, string(parts[0][1:]))
+			publisher.mu.Unlock()
+			select {
+			case publisher.subscribed <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// WaitSubscribed waits until at least one subscriber subscribed to topic. It returns false on timeout.
+func (publisher *Publisher) WaitSubscribed(topic string, timeout time.Duration) bool {
+	deadline := time.After(timeout)
+	for {
+		publisher.mu.Lock()
+		for _, subscription := range publisher.subscriptions {
+			for _, subscribed := range subscription.topics {
+				if strings.HasPrefix(topic, subscribed) {
+					publisher.mu.Unlock()
+					return true
+				}
+			}
+		}
+		publisher.mu.Unlock()
+		select {
+		case <-publisher.subscribed:
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			return false
+		}
+	}
+}
+
+// Publish sends a notification like bitcoind does: topic, body and a little endian sequence number.
+func (publisher *Publisher) Publish(topic string, body []byte) {
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+	sequence := make([]byte, 4)
+	binary.LittleEndian.PutUint32(sequence, publisher.sequence)
+	publisher.sequence++
+	for _, subscription := range publisher.subscriptions {
+		for _, subscribed := range subscription.topics {
+			if strings.HasPrefix(topic, subscribed) {
+				_ = zmq.WriteMessage(subscription.conn, [][]byte{[]byte(topic), body, sequence})
+				break
+			}
+		}
+	}
+}
+
+// DisconnectAll closes the connections to all subscribers, like a restarting bitcoind.
+func (publisher *Publisher) DisconnectAll() {
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+	for _, subscription := range publisher.subscriptions {
+		_ = subscription.conn.Close()
+	}
+	publisher.subscriptions = nil
+}
+
+// Forget drops all subscribers without closing their connections, like a connection that died silently.
+func (publisher *Publisher) Forget() {
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+	publisher.subscriptions = nil
+}
+
+// Close stops the publisher and disconnects all subscribers.
+func (publisher *Publisher) Close() error {
+	publisher.DisconnectAll()
+	return publisher.listener.Close()
+}
@@ -18,27 +18,30 @@ import (
 	"flag"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"os"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/digitalbitbox/bitbox-base/middleware/src/logging"
 )
 
-func readValueFile(filepath string) (value string, err error) {
+func readValueFile(logger *logging.Logger, filepath string) (value string, err error) {
 	data, err := ioutil.ReadFile(filepath)
 	if err != nil {
-		log.Fatal(err)
+		logger.Error("failed to read value file", "path", filepath, "error", err)
+		os.Exit(1)
 	}
 	value = string(data)
 	return value, err
 }
 
-func writeValueFile(filepath string, value string) (err error) {
+func writeValueFile(logger *logging.Logger, filepath string, value string) (err error) {
 	output := []byte(value)
 	err = ioutil.WriteFile(filepath, output, 0644)
 	if err != nil {
-		log.Fatal(err)
+		logger.Error("failed to write value file", "path", filepath, "error", err)
+		os.Exit(1)
 	}
 	return err
 }
@@ -57,7 +60,8 @@ func main() {
 	fanMax := flag.Int("fmax", 255, "maximum value for fan control")
 	fanKickstart := flag.Int("kickstart", 0, "seconds to kickstart fan with full power (default 0 = off)")
 	cycle := flag.Int("cycle", 10, "length of sleep cycle in seconds after each temperature check")
-	verbose := flag.Bool("v", false, "verbose, log internal data to stdout")
+	verbose := flag.Bool("v", false, "verbose, log internal data, same as -loglevel debug")
+	logLevel := flag.String("loglevel", "info", "minimum level of logged lines: debug, info, warning or error. SIGUSR1 switches to debug until SIGUSR2")
 	version := flag.Bool("version", false, "return program version")
 	flag.Parse()
 
@@ -66,34 +70,37 @@ func main() {
 		os.Exit(0)
 	}
 
+	level, err := logging.ParseLevel(*logLevel)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if *verbose {
+		level = logging.LevelDebug
+	}
+	logger := logging.NewDefault(level)
+	defer logger.HandleSignals()()
+
 	// sanity check for arguments
 	if *tempCooldown > *tempMin || *tempMin > *tempMax {
-		fmt.Printf("ERROR: inconsistent temperature range supplied.\n")
-		fmt.Printf("       cooldown (%v) must be <= tmin (%v) must be < tmax (%v)\n\n", *tempCooldown, *tempMin, *tempMax)
+		logger.Error("inconsistent temperature range supplied, cooldown must be <= tmin must be < tmax",
+			"cooldown", *tempCooldown, "tmin", *tempMin, "tmax", *tempMax)
 		os.Exit(1)
 	}
 
-	fmt.Println("BitBox Base fan control, version", versionNum)
-	if *verbose {
-		fmt.Println("temp:     ", *tempFile)
-		fmt.Println("tmin:     ", *tempMin)
-		fmt.Println("tmax:     ", *tempMax)
-		fmt.Println("cooldown: ", *tempCooldown)
-		fmt.Println("fan:      ", *fanFile)
-		fmt.Println("fmin:     ", *fanMin)
-		fmt.Println("fmax:     ", *fanMax)
-		fmt.Println("kickstart:", *fanKickstart)
-		fmt.Println("cycle:    ", *cycle)
-	}
+	logger.Info("BitBox Base fan control started", "version", versionNum)
+	logger.Debug("configuration", "temp", *tempFile, "tmin", *tempMin, "tmax", *tempMax, "cooldown", *tempCooldown,
+		"fan", *fanFile, "fmin", *fanMin, "fmax", *fanMax, "kickstart", *fanKickstart, "cycle", *cycle)
 
 	cooldown := false
 	for {
 		// read current temperature
-		tempStr, _ := readValueFile(*tempFile)
+		tempStr, _ := readValueFile(logger, *tempFile)
 		tempCur, err := strconv.Atoi(strings.TrimSpace(tempStr))
 		tempCur = tempCur / 1000
 		if err != nil {
-			log.Fatal(err)
+			logger.Error("invalid temperature", "path", *tempFile, "error", err)
+			os.Exit(1)
 		}
 
 		// linear PWM increase beteween tempMin and tempMax, from fanMin to fanMax
@@ -114,30 +121,26 @@ func main() {
 			if tempCur < *tempCooldown {
 				fanPWM = 0
 				cooldown = false
-				fmt.Println("Fan turned OFF.")
+				logger.Info("Fan turned OFF", "temperature", tempCur)
 			}
 
 		} else if tempCur >= *tempMin {
 			// tempMin exeeded, start fan
 			cooldown = true
-			fmt.Println("Fan turned ON.")
+			logger.Info("Fan turned ON", "temperature", tempCur)
 
 			if *fanKickstart > 0 {
-				if *verbose {
-					fmt.Printf("Kickstart for %v seconds!\n", *fanKickstart)
-				}
-				writeValueFile(*fanFile, strconv.Itoa(*fanMax))
+				logger.Debug("kickstart", "seconds", *fanKickstart)
+				writeValueFile(logger, *fanFile, strconv.Itoa(*fanMax))
 				time.Sleep(time.Duration(*fanKickstart) * time.Second)
 			}
 		} else {
 			fanPWM = 0
 		}
 		// adjust fan speed
-		writeValueFile(*fanFile, strconv.Itoa(fanPWM))
+		writeValueFile(logger, *fanFile, strconv.Itoa(fanPWM))
 
-		if *verbose {
-			fmt.Printf("temperature: %v / fan set to: %v / kickstart: %v / cooldown: %v\n", tempCur, fanPWM, *fanKickstart, cooldown)
-		}
+		logger.Debug("fan adjusted", "temperature", tempCur, "fan", fanPWM, "kickstart", *fanKickstart, "cooldown", cooldown)
 
 		time.Sleep(time.Duration(*cycle) * time.Second)
 	}
@@ -46,6 +46,7 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"net/http"
@@ -53,6 +54,8 @@ import (
 	"os/exec"
 	"strings"
 	"time"
+
+	"github.com/digitalbitbox/bitbox-base/middleware/src/logging"
 )
 
 type (
@@ -66,8 +69,9 @@ type (
 	logFollower struct {
 		unit string // systemd unit to follow, e.g 'bitcoind.service'
 		// TODO(hkjn): change to more structured type for the chan below for relevant log events the supervisor cares about
-		logs chan string // lines of log output are sent through this chan
-		errs chan error  // lines of stderr output are sent through this chan
+		logs   chan string // lines of log output are sent through this chan
+		errs   chan error  // lines of stderr output are sent through this chan
+		logger *logging.Logger
 	}
 
 	// prometheusFollower follows metrics exposed by a Prometheus server.
@@ -79,7 +83,8 @@ type (
 		// lines of log output are sent through this chan
 		logs chan string
 		// lines of stderr output are sent through this chan
-		errs chan error
+		errs   chan error
+		logger *logging.Logger
 	}
 	// followers represents several follower objects.
 	followers []follower
@@ -97,7 +102,7 @@ func (w chanStringWriter) Write(p []byte) (int, error) {
 
 // Write implements the io.Writer interface by sending the content as error through the wrapped channel.
 func (w chanErrWriter) Write(p []byte) (int, error) {
-	w.errs <- fmt.Errorf(string(p))
+	w.errs <- errors.New(string(p))
 	return len(p), nil
 }
 
@@ -119,7 +124,7 @@ func (lf logFollower) follow() {
 	errWriter := chanErrWriter{lf.errs}
 	cmd.Stdout = chanStringWriter{lf.logs}
 	cmd.Stderr = errWriter
-	fmt.Printf("log follower running %q\n", fullCmd)
+	lf.logger.Info("log follower running", "command", fullCmd)
 	if err := cmd.Run(); err != nil {
 		errWriter.Write([]byte(fmt.Sprintf("failed to start cmd: %v", err)))
 	}
@@ -129,7 +134,7 @@ func (lf logFollower) follow() {
 // follow implements follower interface by following Prometheus server and querying for values forever.
 func (pf prometheusFollower) follow() {
 	for {
-		fmt.Printf("prometheusFollower querying for %q\n", pf.expression)
+		pf.logger.Debug("prometheusFollower querying", "expression", pf.expression)
 		pf.query()
 		time.Sleep(30 * time.Second)
 	}
@@ -158,7 +163,7 @@ func (pf prometheusFollower) query() {
 		pf.errs <- fmt.Errorf("failed to read response body from prometheus request for %q: %v", pf.expression, err)
 		return
 	}
-	fmt.Printf("xx: decoded json: %+v\n", resp)
+	pf.logger.Debug("decoded prometheus response", "expression", pf.expression, "response", fmt.Sprintf("%+v", resp))
 	if resp.Status != "success" {
 		pf.errs <- fmt.Errorf("prometheus request for %q returned non-success: %v", pf.expression, resp)
 		return
@@ -173,7 +178,7 @@ func (pf prometheusFollower) query() {
 	}
 	timestamp := firstResult.Value[0]
 	value := firstResult.Value[1]
-	fmt.Printf("xx: parsed value %+v (%T) at time %v (%T)\n", value, value, timestamp, timestamp)
+	pf.logger.Debug("parsed prometheus value", "expression", pf.expression, "value", value, "timestamp", timestamp)
 	switch v := value.(type) {
 	case string:
 		pf.logs <- fmt.Sprintf("value of %q: %+v", pf.expression, v)
@@ -188,12 +193,21 @@ func main() {
 
 	// parse command line arguments
 	version := flag.Bool("version", false, "return program version")
+	logLevel := flag.String("loglevel", "info", "minimum level of logged lines: debug, info, warning or error. SIGUSR1 switches to debug until SIGUSR2")
 	flag.Parse()
 
-	fmt.Printf("bbbsupervisor version %v\n", versionNum)
 	if *version {
+		fmt.Printf("bbbsupervisor version %v\n", versionNum)
 		os.Exit(0)
 	}
+	level, err := logging.ParseLevel(*logLevel)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	logger := logging.NewDefault(level)
+	defer logger.HandleSignals()()
+	logger.Info("bbbsupervisor started", "version", versionNum)
 
 	// channel to process log output from systemd followers
 	logs := make(chan string)
@@ -201,12 +215,12 @@ func main() {
 	errs := make(chan error)
 
 	// start following systemd services in separate goroutines
-	fmt.Println("starting log followers..")
+	logger.Info("starting log followers")
 	followers := followers{
-		logFollower{unit: "NetworkManager.service", logs: logs, errs: errs},
-		logFollower{unit: "bitcoind.service", logs: logs, errs: errs},
-		logFollower{unit: "electrs.service", logs: logs, errs: errs},
-		prometheusFollower{expression: "lightning_funds_output", server: "http://localhost:9090", logs: logs, errs: errs},
+		logFollower{unit: "NetworkManager.service", logs: logs, errs: errs, logger: logger},
+		logFollower{unit: "bitcoind.service", logs: logs, errs: errs, logger: logger},
+		logFollower{unit: "electrs.service", logs: logs, errs: errs, logger: logger},
+		prometheusFollower{expression: "lightning_funds_output", server: "http://localhost:9090", logs: logs, errs: errs, logger: logger},
 	}
 	for _, lf := range followers {
 		go lf.follow()
@@ -216,10 +230,10 @@ func main() {
 		select {
 		// journald log messages
 		case message := <-logs:
-			fmt.Printf("follower passed on output: %q\n", message)
+			logger.Debug("follower passed on output", "output", message)
 
 		case err := <-errs:
-			fmt.Printf("fatal: error from follower: %v\n", err)
+			logger.Error("fatal error from follower", "error", err)
 			os.Exit(1)
 			// logfile messages
 			// TODO(Stadicus): tail logfiles on filesystem (if necessary)